@@ -91,6 +91,15 @@ var (
 	// ErrNotAllowed is thrown when checking user permissions for a command if
 	// the user does not have the appropriate permissions to use the command.
 	ErrNotAllowed = errors.New("user not allowed to use command")
+
+	// ErrRedirectNotAllowed is returned when a user attempts to redirect
+	// command output to a channel that they aren't a member of without the
+	// gort:redirect_any permission.
+	ErrRedirectNotAllowed = errors.New("user not allowed to redirect to channel")
+
+	// ErrTriggerCoolingDown is recorded against a request that was found by
+	// a trigger whose cooldown hasn't yet expired in the originating channel.
+	ErrTriggerCoolingDown = errors.New("trigger cooling down")
 )
 
 // Adapter represents a connection to a chat provider.
@@ -196,15 +205,16 @@ func GetCommandEntry(ctx context.Context, bundleName, commandName string) (data.
 }
 
 // GetCommandEntryByTrigger accepts a tokenized parameter slice and returns any
-// associated data.CommandEntry instances. If the number of matching
+// associated data.CommandEntry instances. Triggers whose conditions don't
+// permit the requestor to fire them are ignored. If the number of matching
 // commands is > 1, an error is returned.
-func GetCommandEntryByTrigger(ctx context.Context, tokens []string) (data.CommandEntry, error) {
+func GetCommandEntryByTrigger(ctx context.Context, id RequestorIdentity, tokens []string) (data.CommandEntry, error) {
 	finders, err := allCommandEntryFinders()
 	if err != nil {
 		return data.CommandEntry{}, err
 	}
 
-	entries, err := findAllEntriesByTrigger(ctx, tokens, finders...)
+	entries, err := findAllEntriesByTrigger(ctx, id, tokens, finders...)
 	if err != nil {
 		return data.CommandEntry{}, err
 	}
@@ -261,21 +271,42 @@ func OnConnected(ctx context.Context, event *ProviderEvent, data *ConnectedEvent
 // OnChannelMessage handles ChannelMessageEvent events.
 // If a command is found in the text, it will emit a data.CommandRequest
 // instance to the commands channel.
-// TODO Support direct in-channel mentions.
+//
+// A message is addressed to Gort if it mentions Gort, or if it starts with
+// the provider's command prefix and enable_spoken_commands is set; the
+// command in it is then found by name. Any other message may fire a trigger.
 func OnChannelMessage(ctx context.Context, event *ProviderEvent, data *ChannelMessageEvent) (*data.CommandRequest, error) {
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	ctx, sp := tr.Start(ctx, "adapter.OnChannelMessage")
 	defer sp.End()
 
 	rawCommandText := data.Text
+	addressed := data.IsMention
 
 	// Ignore empty messages
-	if len(rawCommandText) <= 1 {
+	if len(rawCommandText) <= 1 && !addressed {
+		return nil, nil
+	}
+
+	stripped, hasPrefix := stripCommandPrefix(rawCommandText, event.Info.Provider.GetCommandPrefix())
+
+	// If this starts with the command prefix but enable_spoken_commands is
+	// false, ignore it unless Gort was mentioned.
+	if hasPrefix && !addressed && !config.GetGortServerConfigs().EnableSpokenCommands {
+		return nil, nil
+	}
+
+	if hasPrefix {
+		rawCommandText = stripped
+		addressed = true
+	}
+
+	if addressed && rawCommandText == "" {
 		return nil, nil
 	}
 
-	// If this starts with a trigger character but enable_spoken_commands is false, ignore.
-	if rawCommandText[0] == '!' && !config.GetGortServerConfigs().EnableSpokenCommands {
+	// Ignore our own messages, and those of other bots unless permitted.
+	if !botMessagePermitted(data.IsBot, data.IsSelf, addressed) {
 		return nil, nil
 	}
 
@@ -286,19 +317,23 @@ func OnChannelMessage(ctx context.Context, event *ProviderEvent, data *ChannelMe
 		return nil, err
 	}
 
+	if data.IsBot {
+		markBot(&id)
+	}
+
 	adapterLogEntry(ctx, nil, event, id).
 		WithField("command.raw", rawCommandText).
 		Debug("Got message")
 	addSpanAttributes(ctx, sp, event, attribute.String("command.raw", rawCommandText))
 
-	// Find command by Name if the message starts with '!'
-	if rawCommandText[0] == '!' {
-		rawCommandText = rawCommandText[1:]
+	// Find command by name if the message is addressed to Gort.
+	if addressed {
 		return GetCommandRequest(ctx, rawCommandText, id, commandFromTokensByName)
 	}
 
-	// Otherwise attempt to find command by trigger
-	return GetCommandRequest(ctx, rawCommandText, id, commandFromTokensByTrigger)
+	// Otherwise attempt to find command by trigger. Aliases are only
+	// expanded in messages that are addressed to Gort.
+	return getCommandRequest(ctx, rawCommandText, id, commandFromTokensByTrigger, false)
 }
 
 // OnDirectMessage handles DirectMessageEvent events.
@@ -309,6 +344,12 @@ func OnDirectMessage(ctx context.Context, event *ProviderEvent, data *DirectMess
 
 	rawCommandText := data.Text
 
+	// A direct message is always addressed to Gort, so the bot policy for
+	// commands applies even if it's matched by a trigger.
+	if !botMessagePermitted(data.IsBot, data.IsSelf, true) {
+		return nil, nil
+	}
+
 	id, err := buildRequestorIdentity(ctx, event.Adapter, data.ChannelID, data.UserID)
 	if err != nil {
 		telemetry.Errors().WithError(err).Commit(ctx)
@@ -316,18 +357,50 @@ func OnDirectMessage(ctx context.Context, event *ProviderEvent, data *DirectMess
 		return nil, err
 	}
 
+	if data.IsBot {
+		markBot(&id)
+	}
+
 	adapterLogEntry(ctx, nil, event, id).
 		WithField("command.raw", rawCommandText).
 		Debug("Got direct message")
 	addSpanAttributes(ctx, sp, event, attribute.String("command.raw", rawCommandText))
 
-	if rawCommandText[0] == '!' {
-		rawCommandText = rawCommandText[1:]
-		return GetCommandRequest(ctx, rawCommandText, id, commandFromTokensByName)
+	if stripped, ok := stripCommandPrefix(rawCommandText, event.Info.Provider.GetCommandPrefix()); ok {
+		return GetCommandRequest(ctx, stripped, id, commandFromTokensByName)
 	}
 	return GetCommandRequest(ctx, rawCommandText, id, commandFromTokensByNameOrTrigger)
 }
 
+// botMessagePermitted returns true unless the message was sent by Gort
+// itself, or by another bot when the allow_bot_commands setting (for a
+// message addressed to Gort) or allow_bot_triggers setting (for any other
+// message) doesn't permit it.
+func botMessagePermitted(isBot, isSelf, addressed bool) bool {
+	switch {
+	case isSelf:
+		return false
+	case !isBot:
+		return true
+	case addressed:
+		return config.GetGortServerConfigs().AllowBotCommands
+	default:
+		return config.GetGortServerConfigs().AllowBotTriggers
+	}
+}
+
+// markBot flags the requestor as a bot, so that triggers that don't allow
+// bots won't fire.
+func markBot(id *RequestorIdentity) {
+	if id.ChatUser == nil {
+		return
+	}
+
+	u := *id.ChatUser
+	u.IsBot = true
+	id.ChatUser = &u
+}
+
 // SendErrorMessage sends an error message to a specified channel.
 func SendErrorMessage(ctx context.Context, a Adapter, channelID string, title, text string) error {
 	e := data.NewCommandResponseEnvelope(data.CommandRequest{}, data.WithError(title, fmt.Errorf(text), 1))
@@ -396,6 +469,7 @@ func SendEnvelope(ctx context.Context, a Adapter, channelID string, envelope dat
 
 // StartListening instructs all relays to establish connections, receives all
 // events from all relays, and forwards them to the various On* handler functions.
+// Schedules are also converted into command requests as they become due.
 func StartListening(ctx context.Context) (<-chan data.CommandRequest, chan<- data.CommandResponseEnvelope, <-chan error) {
 	log.Debug("Instructing relays to establish connections")
 
@@ -407,6 +481,9 @@ func StartListening(ctx context.Context) (<-chan data.CommandRequest, chan<- dat
 	// Start listening for events coming from the chat provider
 	go startProviderEventListening(commandRequests, allEvents, adapterErrors)
 
+	// Start converting due schedules into command requests
+	go startScheduleListening(ctx, commandRequests, adapterErrors)
+
 	// Start listening for responses coming back from the relay
 	go startRelayResponseListening(commandResponses, allEvents, adapterErrors)
 
@@ -452,11 +529,11 @@ func (r *requestLog) Error(
 // commandFromTokens defines a function that attempts to identify a command from a slice of tokens.
 // It returns both a data.CommandEntry defining the command, and a command.Command that re-defines the input
 // as appropriate to the command that was found.
-type commandFromTokens func(ctx context.Context, tokens []string) (*data.CommandEntry, command.Command, error)
+type commandFromTokens func(ctx context.Context, id RequestorIdentity, tokens []string) (*data.CommandEntry, command.Command, error)
 
 // commandFromTokensByTrigger implements commandFromTokens.
 // It checks if a command can be identified from the given tokens by the command name.
-func commandFromTokensByName(ctx context.Context, tokens []string) (*data.CommandEntry, command.Command, error) {
+func commandFromTokensByName(ctx context.Context, id RequestorIdentity, tokens []string) (*data.CommandEntry, command.Command, error) {
 	// Build a temporary Command value using default tokenization rules. We'll
 	// use this to load the CommandEntry for the relevant command (as defined
 	// in a command bundle), which contains the command's parsing rules that
@@ -471,11 +548,11 @@ func commandFromTokensByName(ctx context.Context, tokens []string) (*data.Comman
 		return nil, command.Command{}, err
 	}
 
-	// Now that we have a command entry, we can re-create the complete Command value.
+	// Now that we have a command entry, we can re-create the complete Command
+	// value according to the command's option and argument schema.
 	tokens[0] = cmdEntry.Bundle.Name + ":" + cmdEntry.Command.Name
 
-	// TODO Set parse options based on the CommandEntry settings.
-	cmdInput, err = command.Parse(tokens)
+	cmdInput, err = cmdEntry.Parse(tokens)
 	if err != nil {
 		return nil, command.Command{}, err
 	}
@@ -485,8 +562,8 @@ func commandFromTokensByName(ctx context.Context, tokens []string) (*data.Comman
 
 // commandFromTokensByTrigger implements commandFromTokens.
 // It checks if a command can be identified from the given tokens by a trigger pattern.
-func commandFromTokensByTrigger(ctx context.Context, tokens []string) (*data.CommandEntry, command.Command, error) {
-	cmdEntry, err := GetCommandEntryByTrigger(ctx, tokens)
+func commandFromTokensByTrigger(ctx context.Context, id RequestorIdentity, tokens []string) (*data.CommandEntry, command.Command, error) {
+	cmdEntry, err := GetCommandEntryByTrigger(ctx, id, tokens)
 	if err != nil && gerrs.Is(err, ErrNoSuchCommand) {
 		return nil, command.Command{}, nil
 	}
@@ -494,11 +571,17 @@ func commandFromTokensByTrigger(ctx context.Context, tokens []string) (*data.Com
 		return nil, command.Command{}, err
 	}
 
-	// TODO Set parse options based on the CommandEntry settings.
-	cmdInput, err := command.Parse(
+	// If the matching trigger has named groups, the captured values are the
+	// command's parameters; otherwise the whole message is passed along.
+	params := tokens
+	if len(cmdEntry.Captures) > 0 {
+		params = cmdEntry.Captures.Values()
+	}
+
+	cmdInput, err := cmdEntry.Parse(
 		append(
 			[]string{cmdEntry.Bundle.Name + ":" + cmdEntry.Command.Name},
-			tokens...,
+			params...,
 		),
 	)
 	if err != nil {
@@ -511,36 +594,49 @@ func commandFromTokensByTrigger(ctx context.Context, tokens []string) (*data.Com
 // It first checks if a command can be identified from the given tokens by name,
 // if this is unsuccessful because the command does not exist, it will attempt to
 // identify the command from a trigger.
-func commandFromTokensByNameOrTrigger(ctx context.Context, tokens []string) (*data.CommandEntry, command.Command, error) {
-	cmdEntry, cmdInput, err := commandFromTokensByName(ctx, tokens)
+func commandFromTokensByNameOrTrigger(ctx context.Context, id RequestorIdentity, tokens []string) (*data.CommandEntry, command.Command, error) {
+	cmdEntry, cmdInput, err := commandFromTokensByName(ctx, id, tokens)
 	if err == nil {
 		return cmdEntry, cmdInput, nil
 	}
 	if err != nil && !gerrs.Is(err, ErrNoSuchCommand) {
 		return nil, command.Command{}, err
 	}
-	return commandFromTokensByTrigger(ctx, tokens)
-}
-
-// parametersFromCommand converts parameters from a command.Command into
-// a string slice.
-func parametersFromCommand(cmd command.Command) []string {
-	var out []string
-	for _, p := range cmd.Parameters {
-		out = append(out, p.String())
-	}
-	return out
+	return commandFromTokensByTrigger(ctx, id, tokens)
 }
 
 // GetCommandRequest builds a CommandRequest object based on the provided message content and user id.
 // Both user existence and authorization are verified.
 // A lookup function for identifying a command based on tokens must be provided as a parameter.
 // Telemetry for the lookup operation are handled inside this function.
+//
+// If the message is a pipeline (commands separated by "|"), the first
+// command is found using the lookup function, and all subsequent commands by
+// name. The user must be permitted to execute every command in the pipeline.
+// The returned request is the first stage; each further stage is linked via
+// its Next field, and all stages share the same request ID.
+//
+// If the message starts with the name of one of the user's aliases, or a
+// site alias, it's expanded before the command is looked up.
 func GetCommandRequest(
 	ctx context.Context,
 	rawCommand string,
 	id RequestorIdentity,
 	fCommandFromTokens commandFromTokens,
+) (*data.CommandRequest, error) {
+	return getCommandRequest(ctx, rawCommand, id, fCommandFromTokens, true)
+}
+
+// getCommandRequest does the actual work for GetCommandRequest. Messages that
+// weren't addressed to Gort, and so can only match triggers, are matched as
+// they are: aliases aren't expanded, and redirects and pipelines aren't
+// parsed, so that ordinary chat containing ">" or "|" isn't misread.
+func getCommandRequest(
+	ctx context.Context,
+	rawCommand string,
+	id RequestorIdentity,
+	fCommandFromTokens commandFromTokens,
+	addressed bool,
 ) (*data.CommandRequest, error) {
 	// Start trace span
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
@@ -558,7 +654,32 @@ func GetCommandRequest(
 		return nil, fmt.Errorf("command tokenziation error")
 	}
 
-	cmdEntry, cmdInput, commandLookupErr := fCommandFromTokens(ctx, tokens)
+	var targets []string
+	var redirectErr, pipelineErr error
+	stages := [][]string{tokens}
+
+	if addressed {
+		if tokens, err = expandAlias(ctx, id, tokens); err != nil {
+			return nil, err
+		}
+
+		// Separate out any output redirect, and split what's left into
+		// pipeline stages. Errors are reported once we know that the first
+		// stage is actually a command.
+		tokens, targets, redirectErr = command.SplitRedirect(tokens)
+		stages, pipelineErr = command.SplitPipeline(tokens)
+		if pipelineErr != nil {
+			stages = [][]string{tokens}
+			for i, t := range tokens {
+				if t == command.PipeToken {
+					stages[0] = tokens[:i]
+					break
+				}
+			}
+		}
+	}
+
+	cmdEntry, cmdInput, commandLookupErr := fCommandFromTokens(ctx, id, stages[0])
 	if commandLookupErr == nil && cmdEntry == nil {
 		return nil, nil
 	}
@@ -573,35 +694,25 @@ func GetCommandRequest(
 		return nil, rl.Error(ctx, err, "command had no tokens", logUserMessage("Empty Command", msg))
 	}
 
-	if commandLookupErr != nil {
-		err := commandLookupErr
-		switch {
-		case gerrs.Is(err, ErrNoSuchCommand):
-			msg := fmt.Sprintf("No such bundle is currently installed: %s.\n"+
-				"If this is not expected, you should contact a Gort administrator.",
-				tokens[0])
-			return nil, rl.Error(ctx, err, "command lookup error", logUserMessage("No Such Command", msg))
-		case gerrs.Is(err, ErrMultipleCommands):
-			msg := fmt.Sprintf("The command %s matches multiple bundles.\n"+
-				"Please namespace your command using the bundle name: `bundle:command`.",
-				tokens[0])
-			return nil, rl.Error(ctx, err, "command lookup error", logUserMessage("No Such Command", msg))
-		default:
-			return nil, rl.Error(ctx, err, "command lookup error", logUserMessage("Error", err.Error()))
-		}
+	if pipelineErr != nil {
+		msg := "Every stage of a command pipeline must contain a command."
+		return nil, rl.Error(ctx, pipelineErr, "invalid pipeline", logUserMessage("Invalid Pipeline", msg))
 	}
 
-	rl.le = rl.le.WithField("command.name", cmdEntry.Command.Name).
-		WithField("command.params", cmdInput.Parameters.String())
-	request.Parameters = parametersFromCommand(cmdInput)
-	da.RequestUpdate(ctx, request)
+	if redirectErr != nil {
+		msg := fmt.Sprintf("Invalid output redirect: %s.\n"+
+			"Use `> destination` for one destination, or `*> destination...` for several.",
+			redirectErr.Error())
+		return nil, rl.Error(ctx, redirectErr, "invalid redirect", logUserMessage("Invalid Redirect", msg))
+	}
 
-	cmdFoundMessage := fmt.Sprintf("Executing command: %s", cmdEntry.Command.Name)
-	err = SendMessage(ctx, id.Adapter, id.ChatChannel.ID, cmdFoundMessage)
-	if err != nil {
-		rl.Error(ctx, err, "failed to send command acknowledgement")
+	if commandLookupErr != nil {
+		return nil, commandLookupError(ctx, rl, commandLookupErr, stages[0])
 	}
 
+	rl.le = rl.le.WithField("command.name", cmdEntry.Command.Name).
+		WithField("command.params", cmdInput.Parameters.String())
+	request.Parameters = cmdEntry.Parameters(cmdInput)
 	request.CommandEntry = *cmdEntry
 	da.RequestUpdate(ctx, request)
 
@@ -610,22 +721,65 @@ func GetCommandRequest(
 	rl.le.Debug("Found matching command+bundle")
 	addSpanAttributes(ctx, sp, *cmdEntry)
 
-	err = checkPermissions(ctx, id, cmdInput, *cmdEntry)
-	if err != nil {
-		switch {
-		case gerrs.Is(err, auth.ErrRuleLoadError):
-			return nil, rl.Error(ctx, err, "rule load error", logUserMessage("Error", unexpectedError))
-		case gerrs.Is(err, auth.ErrNoRulesDefined):
-			msg := fmt.Sprintf("The command %s:%s doesn't have any associated rules.\n"+
-				"For a command to be executable, it must have at least one rule.",
-				cmdEntry.Bundle.Name, cmdEntry.Command.Name)
-			return nil, rl.Error(ctx, err, "no rules defined", logUserMessage("No Rules Defined", msg))
-		case gerrs.Is(err, ErrNotAllowed):
-			msg := fmt.Sprintf("You do not have the permissions to execute %s:%s.", cmdEntry.Bundle.Name, cmdEntry.Command.Name)
-			return nil, rl.Error(ctx, err, "permission denied", logUserMessage("Permission Denied", msg))
-		default:
-			return nil, rl.Error(ctx, err, "permission check failure", logUserMessage("Error", unexpectedError))
+	if err := checkPermissions(ctx, id, cmdInput, *cmdEntry); err != nil {
+		return nil, permissionError(ctx, rl, err, *cmdEntry)
+	}
+
+	// Resolve any redirect targets. This is done before any further pipeline
+	// stages are built so that every stage carries the same redirects.
+	if len(targets) > 0 {
+		request.Redirects, err = resolveRedirects(ctx, id, targets)
+		if err != nil {
+			return nil, redirectError(ctx, rl, err)
+		}
+
+		rl.le = rl.le.WithField("command.redirects", strings.Join(request.Redirects, ","))
+		da.RequestUpdate(ctx, request)
+	}
+
+	// Look up, and check permissions for, any further pipeline stages.
+	names := []string{cmdEntry.Command.Name}
+	last := &request
+
+	for _, stage := range stages[1:] {
+		cmdEntry, cmdInput, err := commandFromTokensByName(ctx, id, stage)
+		if err != nil {
+			return nil, commandLookupError(ctx, rl, err, stage)
 		}
+
+		if err := checkPermissions(ctx, id, cmdInput, *cmdEntry); err != nil {
+			return nil, permissionError(ctx, rl, err, *cmdEntry)
+		}
+
+		next := request
+		next.CommandEntry = *cmdEntry
+		next.Parameters = cmdEntry.Parameters(cmdInput)
+		next.Next = nil
+
+		last.Next = &next
+		last = &next
+		names = append(names, cmdEntry.Command.Name)
+	}
+
+	if len(stages) > 1 {
+		rl.le = rl.le.WithField("command.pipeline", request.Pipeline())
+		da.RequestUpdate(ctx, request)
+	}
+
+	// A trigger's cooldown only starts once the requestor is known to be
+	// allowed to run the command. If it's already running, the message is
+	// ignored just as if the trigger hadn't matched.
+	if !startTriggerCooldown(id, *cmdEntry) {
+		da.RequestError(ctx, request, ErrTriggerCoolingDown)
+		rl.le.Debug("Trigger cooling down")
+		return nil, nil
+	}
+
+	// The request ID is included so that the request can be cancelled.
+	cmdFoundMessage := fmt.Sprintf("Executing command: %s (request %d)", strings.Join(names, " | "), request.RequestID)
+	err = SendMessage(ctx, id.Adapter, id.ChatChannel.ID, cmdFoundMessage)
+	if err != nil {
+		rl.Error(ctx, err, "failed to send command acknowledgement")
 	}
 
 	// Update log entry with command info
@@ -634,6 +788,72 @@ func GetCommandRequest(
 	return &request, nil
 }
 
+// commandLookupError logs a failure to find the command described by tokens,
+// and informs the user.
+func commandLookupError(ctx context.Context, rl requestLog, err error, tokens []string) error {
+	switch {
+	case gerrs.Is(err, ErrNoSuchCommand):
+		msg := fmt.Sprintf("No such bundle is currently installed: %s.\n"+
+			"If this is not expected, you should contact a Gort administrator.",
+			tokens[0])
+		return rl.Error(ctx, err, "command lookup error", logUserMessage("No Such Command", msg))
+	case gerrs.Is(err, ErrMultipleCommands):
+		msg := fmt.Sprintf("The command %s matches multiple bundles.\n"+
+			"Please namespace your command using the bundle name: `bundle:command`.",
+			tokens[0])
+		return rl.Error(ctx, err, "command lookup error", logUserMessage("No Such Command", msg))
+	case gerrs.Is(err, data.ErrInvalidInvocation):
+		// The nested error describes the problem and the command's usage.
+		msg := err.Error()
+		if ne, ok := err.(gerrs.NestedError); ok {
+			msg = ne.Err.Error()
+		}
+		return rl.Error(ctx, err, "invalid command invocation", logUserMessage("Invalid Command Usage", msg))
+	default:
+		return rl.Error(ctx, err, "command lookup error", logUserMessage("Error", err.Error()))
+	}
+}
+
+// permissionError logs a failed permission check for cmdEntry, and informs
+// the user.
+func permissionError(ctx context.Context, rl requestLog, err error, cmdEntry data.CommandEntry) error {
+	switch {
+	case gerrs.Is(err, auth.ErrRuleLoadError):
+		return rl.Error(ctx, err, "rule load error", logUserMessage("Error", unexpectedError))
+	case gerrs.Is(err, auth.ErrNoRulesDefined):
+		msg := fmt.Sprintf("The command %s:%s doesn't have any associated rules.\n"+
+			"For a command to be executable, it must have at least one rule.",
+			cmdEntry.Bundle.Name, cmdEntry.Command.Name)
+		return rl.Error(ctx, err, "no rules defined", logUserMessage("No Rules Defined", msg))
+	case gerrs.Is(err, ErrNotAllowed):
+		msg := fmt.Sprintf("You do not have the permissions to execute %s:%s.", cmdEntry.Bundle.Name, cmdEntry.Command.Name)
+		return rl.Error(ctx, err, "permission denied", logUserMessage("Permission Denied", msg))
+	default:
+		return rl.Error(ctx, err, "permission check failure", logUserMessage("Error", unexpectedError))
+	}
+}
+
+// redirectError logs a failure to resolve a redirect target, and informs
+// the user.
+func redirectError(ctx context.Context, rl requestLog, err error) error {
+	// resolveRedirects wraps the offending target in its errors.
+	target := "that channel"
+	if ne, ok := err.(gerrs.NestedError); ok {
+		target = ne.Err.Error()
+	}
+
+	switch {
+	case gerrs.Is(err, ErrChannelNotFound):
+		msg := fmt.Sprintf("I can't find the channel %s, or I'm not a member of it.", target)
+		return rl.Error(ctx, err, "redirect target not found", logUserMessage("No Such Channel", msg))
+	case gerrs.Is(err, ErrRedirectNotAllowed):
+		msg := fmt.Sprintf("You can't redirect output to %s because you aren't a member of it.", target)
+		return rl.Error(ctx, err, "redirect permission denied", logUserMessage("Permission Denied", msg))
+	default:
+		return rl.Error(ctx, err, "redirect failure", logUserMessage("Error", unexpectedError))
+	}
+}
+
 func checkPermissions(ctx context.Context, id RequestorIdentity, cmdInput command.Command, cmdEntry data.CommandEntry) error {
 	da, err := dataaccess.Get()
 	if err != nil {
@@ -1013,7 +1233,6 @@ func findAllEntries(ctx context.Context, bundleName, commandName string, finder
 	entries := make([]data.CommandEntry, 0)
 
 	for _, f := range finder {
-		fmt.Println(bundleName, commandName)
 		e, err := f.FindCommandEntry(ctx, bundleName, commandName)
 		if err != nil {
 			return nil, err
@@ -1025,8 +1244,12 @@ func findAllEntries(ctx context.Context, bundleName, commandName string, finder
 	return entries, nil
 }
 
-func findAllEntriesByTrigger(ctx context.Context, tokens []string, finder ...bundles.CommandEntryFinder) ([]data.CommandEntry, error) {
+// findAllEntriesByTrigger returns the commands with a trigger that matches
+// tokens and whose conditions permit it to be fired by the requestor. If
+// several of a command's triggers qualify, the first is used.
+func findAllEntriesByTrigger(ctx context.Context, id RequestorIdentity, tokens []string, finder ...bundles.CommandEntryFinder) ([]data.CommandEntry, error) {
 	entries := make([]data.CommandEntry, 0)
+	found := map[string]bool{}
 
 	for _, f := range finder {
 		e, err := f.FindCommandEntryByTrigger(ctx, tokens)
@@ -1034,7 +1257,21 @@ func findAllEntriesByTrigger(ctx context.Context, tokens []string, finder ...bun
 			return nil, err
 		}
 
-		entries = append(entries, e...)
+		for _, entry := range e {
+			key := entry.Bundle.Name + ":" + entry.Command.Name
+			if found[key] {
+				continue
+			}
+
+			ok, err := triggerConditionsMet(ctx, id, entry)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				entries = append(entries, entry)
+				found[key] = true
+			}
+		}
 	}
 
 	return entries, nil
@@ -1171,6 +1408,8 @@ func startProviderEventListening(requests chan<- data.CommandRequest,
 func startRelayResponseListening(responses <-chan data.CommandResponseEnvelope,
 	allEvents <-chan *ProviderEvent, adapterErrors chan<- error) {
 
+	streams := newStreamTracker()
+
 	for envelope := range responses {
 		adapter, err := GetAdapter(envelope.Request.Adapter)
 		if err != nil {
@@ -1178,15 +1417,36 @@ func startRelayResponseListening(responses <-chan data.CommandResponseEnvelope,
 			continue
 		}
 
+		ctx := context.Background()
+
+		if envelope.Data.Partial {
+			if err := streams.partial(ctx, adapter, envelope); err != nil {
+				adapterErrors <- err
+			}
+			continue
+		}
+
 		tt := data.Command
 		if envelope.Data.ExitCode != 0 {
 			tt = data.CommandError
+		} else if envelope.Data.Template != "" {
+			tt = envelope.Data.Template
 		}
 
-		ctx := context.Background()
-		channelID := envelope.Request.ChannelID
-		if err := SendEnvelope(ctx, adapter, channelID, envelope, tt); err != nil {
+		if err := streams.final(ctx, adapter, envelope, tt); err != nil {
 			adapterErrors <- err
 		}
 	}
 }
+
+// responseChannels returns the IDs of the channels that a response should be
+// sent to. Successful output goes to the request's redirect targets if it has
+// any; errors always go back to the channel the request came from, where the
+// requestor will see them.
+func responseChannels(envelope data.CommandResponseEnvelope) []string {
+	if len(envelope.Request.Redirects) == 0 || envelope.Data.ExitCode != 0 {
+		return []string{envelope.Request.ChannelID}
+	}
+
+	return envelope.Request.Redirects
+}
@@ -29,6 +29,10 @@ func TestChannelMessage(t *testing.T) {
 		expected        string
 		expectNoRequest bool
 		err             bool
+		isBot           bool
+		isSelf          bool
+		isMention       bool
+		prefix          string
 	}{
 		{
 			name:     "can execute command by name with bang",
@@ -55,6 +59,91 @@ func TestChannelMessage(t *testing.T) {
 			message:         "nothing to match",
 			expectNoRequest: true,
 		},
+		{
+			name:     "can execute pipeline by name with bang",
+			message:  "!test:cmd arg1 | test:cmd arg2",
+			expected: "test:cmd arg1 | test:cmd arg2",
+		},
+		{
+			name:    "error on pipeline with empty stage",
+			message: "!test:cmd arg1 |",
+			err:     true,
+		},
+		{
+			name:    "error on pipeline with unknown command",
+			message: "!test:cmd arg1 | missing:cmd",
+			err:     true,
+		},
+		{
+			name:    "error on pipeline with forbidden command",
+			message: "!test:cmd arg1 | test:secret",
+			err:     true,
+		},
+		{
+			name:            "no request on untriggered pipeline without bang",
+			message:         "nothing | to match",
+			expectNoRequest: true,
+		},
+		{
+			name:            "no request on own message",
+			message:         "!test:cmd arg1 arg2",
+			expectNoRequest: true,
+			isSelf:          true,
+		},
+		{
+			name:            "no request on bot command by default",
+			message:         "!test:cmd arg1 arg2",
+			expectNoRequest: true,
+			isBot:           true,
+		},
+		{
+			name:            "no request on bot trigger by default",
+			message:         "run this command",
+			expectNoRequest: true,
+			isBot:           true,
+		},
+		{
+			name:      "can execute command by name with mention",
+			message:   "test:cmd arg1 arg2",
+			expected:  "test:cmd arg1 arg2",
+			isMention: true,
+		},
+		{
+			name:      "can execute command by name with mention and bang",
+			message:   "!test:cmd arg1 arg2",
+			expected:  "test:cmd arg1 arg2",
+			isMention: true,
+		},
+		{
+			name:            "no request on empty mention",
+			message:         "",
+			expectNoRequest: true,
+			isMention:       true,
+		},
+		{
+			name:     "can execute command by name with custom prefix",
+			message:  ".test:cmd arg1 arg2",
+			expected: "test:cmd arg1 arg2",
+			prefix:   ".",
+		},
+		{
+			name:     "can execute command by name with word prefix",
+			message:  "/gort test:cmd arg1 arg2",
+			expected: "test:cmd arg1 arg2",
+			prefix:   "/gort",
+		},
+		{
+			name:            "no request on word prefix without a space",
+			message:         "/gortify test:cmd arg1 arg2",
+			expectNoRequest: true,
+			prefix:          "/gort",
+		},
+		{
+			name:            "no request on bang with custom prefix",
+			message:         "!test:cmd arg1 arg2",
+			expectNoRequest: true,
+			prefix:          ".",
+		},
 	}
 
 	for _, test := range tests {
@@ -66,8 +155,9 @@ func TestChannelMessage(t *testing.T) {
 					Data:      nil,
 					Info: &Info{
 						Provider: &ProviderInfo{
-							Type: "test",
-							Name: "provider",
+							Type:          "test",
+							Name:          "provider",
+							CommandPrefix: test.prefix,
 						},
 					},
 					Adapter: &testAdapter{},
@@ -76,6 +166,9 @@ func TestChannelMessage(t *testing.T) {
 					ChannelID: "mychannel",
 					Text:      test.message,
 					UserID:    "user",
+					IsBot:     test.isBot,
+					IsSelf:    test.isSelf,
+					IsMention: test.isMention,
 				},
 			)
 			if err != nil {
@@ -98,7 +191,7 @@ func TestChannelMessage(t *testing.T) {
 			if test.expectNoRequest {
 				t.Errorf("expected nil, got %q", result)
 			}
-			if result.String() != test.expected {
+			if result.Pipeline() != test.expected {
 				t.Errorf("expected %q, got %q", test.expected, result)
 			}
 		})
@@ -182,7 +275,7 @@ func TestDirectMessage(t *testing.T) {
 			if test.expectNoRequest {
 				t.Errorf("expected nil, got %q", result)
 			}
-			if result.String() != test.expected {
+			if result.Pipeline() != test.expected {
 				t.Errorf("expected %q, got %q", test.expected, result)
 			}
 		})
@@ -232,6 +325,10 @@ var testBundle = data.Bundle{
 			},
 			Rules: []string{"allow"},
 		},
+		"secret": {
+			Name:  "secret",
+			Rules: []string{"must have test:secret"},
+		},
 	},
 }
 
@@ -256,7 +353,11 @@ func (t *testAdapter) GetName() string {
 
 // GetPresentChannels returns a slice of channels that the adapter is present in.
 func (t *testAdapter) GetPresentChannels() ([]*ChannelInfo, error) {
-	panic("not implemented") // TODO: Implement
+	return []*ChannelInfo{
+		{ID: "mychannel", Name: "mychannel", Members: []string{"user"}},
+		{ID: "C0123", Name: "ops-alerts", Members: []string{"user", "other"}},
+		{ID: "C0456", Name: "secret", Members: []string{"other"}},
+	}, nil
 }
 
 // GetUserInfo provides info on a specific provider user accessible
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package adaptertest provides utilities for testing chat adapters.
+package adaptertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getgort/gort/adapter"
+)
+
+// EventTimeout is how long NextEvent waits for an event.
+const EventTimeout = 5 * time.Second
+
+// NextEvent returns the next event sent by an adapter, failing the test if
+// none is sent within EventTimeout.
+func NextEvent(t *testing.T, events <-chan *adapter.ProviderEvent) *adapter.ProviderEvent {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(EventTimeout):
+		t.Fatal("timed out waiting for event")
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/getgort/gort/command"
+	"github.com/getgort/gort/dataaccess"
+	"github.com/getgort/gort/dataaccess/errs"
+	gerrs "github.com/getgort/gort/errors"
+)
+
+// expandAlias replaces an alias at the start of a tokenized command line
+// with the tokens of the command line that it stands for; any further tokens
+// are kept, and so become arguments to (the last stage of) that command
+// line. The requesting user's own aliases take precedence over site aliases,
+// and an installed command with the same name takes precedence over both.
+// Aliases aren't expanded recursively. If the first token isn't an alias,
+// tokens is returned unchanged.
+func expandAlias(ctx context.Context, id RequestorIdentity, tokens []string) ([]string, error) {
+	if len(tokens) == 0 || strings.ContainsRune(tokens[0], ':') {
+		return tokens, nil
+	}
+
+	da, err := dataaccess.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	if entries, err := da.FindCommandEntry(ctx, "", tokens[0]); err != nil {
+		return nil, err
+	} else if len(entries) > 0 {
+		return tokens, nil
+	}
+
+	owners := []string{""}
+	if id.GortUser != nil {
+		owners = []string{id.GortUser.Username, ""}
+	}
+
+	for _, owner := range owners {
+		alias, err := da.AliasGet(ctx, owner, tokens[0])
+		switch {
+		case gerrs.Is(err, errs.ErrNoSuchAlias):
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		expanded, err := command.Tokenize(strings.TrimPrefix(alias.Command, "!"))
+		if err != nil {
+			return nil, err
+		}
+
+		return append(expanded, tokens[1:]...), nil
+	}
+
+	return tokens, nil
+}
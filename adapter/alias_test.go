@@ -0,0 +1,99 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+)
+
+func TestExpandAlias(t *testing.T) {
+	ctx := context.Background()
+
+	da, err := dataaccess.Get()
+	require.NoError(t, err)
+
+	aliases := []rest.Alias{
+		{Name: "both", Command: "test:cmd site"},
+		{Name: "both", Command: "test:cmd mine | test:cmd next", Owner: "user"},
+		{Name: "site-only", Command: "!test:cmd site"},
+		{Name: "others", Command: "test:cmd other", Owner: "other"},
+		{Name: "cmd", Command: "test:secret"},
+	}
+	for _, a := range aliases {
+		require.NoError(t, da.AliasCreate(ctx, a))
+		defer da.AliasDelete(ctx, a.Owner, a.Name)
+	}
+
+	user := rest.User{Username: "user"}
+	id := RequestorIdentity{GortUser: &user}
+
+	var tests = []struct {
+		tokens   []string
+		expected []string
+	}{
+		{[]string{"both", "x"}, []string{"test:cmd", "mine", "|", "test:cmd", "next", "x"}},
+		{[]string{"site-only"}, []string{"test:cmd", "site"}},
+		{[]string{"others"}, []string{"others"}},
+		{[]string{"cmd", "x"}, []string{"cmd", "x"}},
+		{[]string{"test:both"}, []string{"test:both"}},
+	}
+
+	for _, test := range tests {
+		expanded, err := expandAlias(ctx, id, test.tokens)
+		require.NoError(t, err, test.tokens)
+		assert.Equal(t, test.expected, expanded, test.tokens)
+	}
+
+	// Without a Gort user, only site aliases apply.
+	expanded, err := expandAlias(ctx, RequestorIdentity{}, []string{"both"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test:cmd", "site"}, expanded)
+
+	event := &ProviderEvent{
+		EventType: EventChannelMessage,
+		Info:      &Info{Provider: &ProviderInfo{Type: "test", Name: "provider"}},
+		Adapter:   &testAdapter{},
+	}
+
+	// Aliases are expanded in messages addressed to Gort...
+	request, err := OnChannelMessage(ctx, event, &ChannelMessageEvent{
+		ChannelID: "mychannel",
+		Text:      "!site-only extra",
+		UserID:    "user",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, request)
+	assert.Equal(t, "cmd", request.Command.Name)
+	assert.Equal(t, data.CommandParameters{"site", "extra"}, request.Parameters)
+
+	// ...but not otherwise.
+	request, err = OnChannelMessage(ctx, event, &ChannelMessageEvent{
+		ChannelID: "mychannel",
+		Text:      "site-only extra",
+		UserID:    "user",
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, request)
+}
@@ -19,6 +19,7 @@ package discord
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +33,10 @@ import (
 
 const ZeroWidthSpace = "\u200b"
 
+// channelMentionRegex matches a channel mention, which Discord writes as
+// "<#id>".
+var channelMentionRegex = regexp.MustCompile(`<#([0-9]+)>`)
+
 // NewAdapter will construct a DiscordAdapter instance for a given provider configuration.
 func NewAdapter(provider data.DiscordProvider) (adapter.Adapter, error) {
 	// Create a new Discord session using the provided bot token.
@@ -47,6 +52,8 @@ func NewAdapter(provider data.DiscordProvider) (adapter.Adapter, error) {
 }
 
 var _ adapter.Adapter = &Adapter{}
+var _ adapter.DirectMessenger = &Adapter{}
+var _ adapter.ChannelMemberChecker = &Adapter{}
 
 // Adapter is the Discord provider implementation of a relay, which knows how
 // to receive events from the Discord API, translate them into Gort events, and
@@ -67,26 +74,62 @@ func (s *Adapter) GetChannelInfo(channelID string) (*adapter.ChannelInfo, error)
 	return newChannelInfoFromDiscordChannel(channel), nil
 }
 
+// GetDirectMessageChannel returns the ID of the private channel between
+// the bot and the specified user, creating it if necessary.
+func (s *Adapter) GetDirectMessageChannel(userID string) (string, error) {
+	ch, err := s.session.UserChannelCreate(userID)
+	if err != nil {
+		return "", err
+	}
+	return ch.ID, nil
+}
+
 // GetName provides the name of this adapter as per the configuration.
 func (s *Adapter) GetName() string {
 	return s.provider.Name
 }
 
-// GetPresentChannels returns a slice of channels that a user is present in.
+// GetPresentChannels returns the text channels, of the guilds that the bot
+// is in, that it's able to post in, as known to the session's state.
 func (s *Adapter) GetPresentChannels() ([]*adapter.ChannelInfo, error) {
-	allChannels, err := s.session.UserChannels()
-	if err != nil {
-		return nil, err
+	var guildChannels []*discordgo.Channel
+
+	s.session.State.RLock()
+	for _, g := range s.session.State.Guilds {
+		for _, ch := range g.Channels {
+			switch ch.Type {
+			case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews:
+				guildChannels = append(guildChannels, ch)
+			}
+		}
 	}
+	s.session.State.RUnlock()
+
+	const canPost = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages
 
 	channels := make([]*adapter.ChannelInfo, 0)
-	for _, ch := range allChannels {
-		channels = append(channels, newChannelInfoFromDiscordChannel(ch))
+	for _, ch := range guildChannels {
+		perms, err := s.session.State.UserChannelPermissions(s.session.State.User.ID, ch.ID)
+		if err == nil && perms&canPost == canPost {
+			channels = append(channels, newChannelInfoFromDiscordChannel(ch))
+		}
 	}
 
 	return channels, nil
 }
 
+// IsChannelMember returns true if the user can view the channel. Guild
+// channels don't have members of their own: anyone permitted to see one can
+// read and post in it.
+func (s *Adapter) IsChannelMember(channelID, userID string) (bool, error) {
+	perms, err := s.session.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		return false, err
+	}
+
+	return perms&discordgo.PermissionViewChannel != 0, nil
+}
+
 // GetUserInfo provides info on a specific provider user accessible
 // to the adapter.
 func (s *Adapter) GetUserInfo(userID string) (*adapter.UserInfo, error) {
@@ -246,6 +289,29 @@ func (s *Adapter) SendText(ctx context.Context, channelID string, message string
 	return err
 }
 
+// SendTextEditable sends a simple text message to the specified channel,
+// returning an ID of the form "channel/message" that can be passed to
+// EditText.
+func (s *Adapter) SendTextEditable(ctx context.Context, channelID string, message string) (string, error) {
+	m, err := s.session.ChannelMessageSend(channelID, message)
+	if err != nil {
+		return "", err
+	}
+
+	return m.ChannelID + "/" + m.ID, nil
+}
+
+// EditText replaces the text of a message sent by SendTextEditable.
+func (s *Adapter) EditText(ctx context.Context, messageID string, message string) error {
+	parts := strings.SplitN(messageID, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid Discord message ID: %q", messageID)
+	}
+
+	_, err := s.session.ChannelMessageEdit(parts[0], parts[1], message)
+	return err
+}
+
 // SendError is a break-glass error message function that's used when the
 // templating function fails somehow. Obviously, it does not utilize the
 // templating engine.
@@ -269,10 +335,10 @@ func (s *Adapter) SendError(ctx context.Context, channelID string, title string,
 // This function will be called (due to AddHandler above) every time a new
 // message is created on any channel that the authenticated bot has access to.
 func (s *Adapter) messageCreate(sess *discordgo.Session, m *discordgo.MessageCreate) {
-	// Ignore all messages created by the bot itself
-	if m.Author.ID == sess.State.User.ID {
-		return
-	}
+	// Messages created by the bot itself, or by other bots, are flagged so
+	// that they can be ignored.
+	isSelf := m.Author.ID == sess.State.User.ID
+
 	channel, err := sess.Channel(m.ChannelID)
 	if err != nil {
 		panic(err)
@@ -282,22 +348,38 @@ func (s *Adapter) messageCreate(sess *discordgo.Session, m *discordgo.MessageCre
 			adapter.EventChannelMessage,
 			&adapter.DirectMessageEvent{
 				ChannelID: m.ChannelID,
-				Text:      m.Content,
+				Text:      scrubChannelMentions(m.Content),
 				UserID:    m.Author.ID,
+				IsBot:     m.Author.Bot,
+				IsSelf:    isSelf,
 			},
 		)
 	} else {
+		// Discord writes a mention of the bot as "<@id>", or "<@!id>" if
+		// the bot has a nickname.
+		selfID := sess.State.User.ID
+		text, mentioned := adapter.StripMention(m.Content, "<@"+selfID+">", "<@!"+selfID+">")
+
 		s.events <- s.wrapEvent(
 			adapter.EventChannelMessage,
 			&adapter.ChannelMessageEvent{
 				ChannelID: m.ChannelID,
-				Text:      m.Content,
+				Text:      scrubChannelMentions(text),
 				UserID:    m.Author.ID,
+				IsBot:     m.Author.Bot,
+				IsSelf:    isSelf,
+				IsMention: mentioned,
 			},
 		)
 	}
 }
 
+// scrubChannelMentions rewrites channel mentions as "#id", so that they can
+// be used as redirect targets.
+func scrubChannelMentions(text string) string {
+	return channelMentionRegex.ReplaceAllString(text, "#$1")
+}
+
 // onConnected is called when the Slack API emits a ConnectedEvent.
 func (s *Adapter) onConnected(sess *discordgo.Session, m *discordgo.Connect) *adapter.ProviderEvent {
 	return s.wrapEvent(
@@ -363,5 +445,6 @@ func newUserInfoFromDiscordUser(user *discordgo.User) *adapter.UserInfo {
 	u.DisplayName = user.Avatar
 	u.DisplayNameNormalized = user.Avatar
 	u.Email = user.Email
+	u.IsBot = user.Bot
 	return u
 }
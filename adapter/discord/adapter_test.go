@@ -0,0 +1,36 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package discord
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrubChannelMentions(t *testing.T) {
+	tests := map[string]string{
+		"echo foo":                "echo foo",
+		"echo foo > <#123456789>": "echo foo > #123456789",
+		"echo foo *> <#123456789> <#987654321> me": "echo foo *> #123456789 #987654321 me",
+		"<@123456789> echo foo > <#123456789>":     "<@123456789> echo foo > #123456789",
+	}
+
+	for test, expected := range tests {
+		assert.Equal(t, expected, scrubChannelMentions(test))
+	}
+}
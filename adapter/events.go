@@ -61,11 +61,17 @@ type AuthenticationErrorEvent struct {
 }
 
 // ChannelMessageEvent indicates received a message via a public or private
-// channel (message.channels)
+// channel (message.channels). IsBot is set if the message was sent by a bot,
+// and IsSelf if it was sent by Gort itself. IsMention is set if the message
+// was addressed to Gort by mentioning it, in which case the mention has been
+// removed from Text.
 type ChannelMessageEvent struct {
 	ChannelID string
 	Text      string
 	UserID    string
+	IsBot     bool
+	IsSelf    bool
+	IsMention bool
 }
 
 // ConnectedEvent indicates the client has successfully connected to
@@ -85,11 +91,13 @@ type ChannelJoinedEvent struct {
 }
 
 // DirectMessageEvent indicates the bot has received a direct message from a
-// user (message.im)
+// user (message.im). IsBot and IsSelf are as for ChannelMessageEvent.
 type DirectMessageEvent struct {
 	ChannelID string
 	Text      string
 	UserID    string
+	IsBot     bool
+	IsSelf    bool
 }
 
 // ErrorEvent indicates an error reported by the provider. The occurs before a
@@ -0,0 +1,403 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/templates"
+)
+
+const (
+	// defaultCallbackTimeout is how long to wait for the callback to
+	// respond if the provider doesn't say.
+	defaultCallbackTimeout = 10 * time.Second
+
+	// maxMessageBodySize is the largest inbound message that's accepted.
+	maxMessageBodySize = 1 << 20
+)
+
+// NewAdapter will construct an HTTP Adapter instance for a given provider
+// configuration.
+func NewAdapter(provider data.HTTPProvider) (adapter.Adapter, error) {
+	if provider.Secret == "" {
+		return nil, fmt.Errorf("http provider %q has no secret", provider.Name)
+	}
+	if provider.CallbackURL == "" {
+		return nil, fmt.Errorf("http provider %q has no callback_url", provider.Name)
+	}
+
+	timeout := provider.CallbackTimeout
+	if timeout <= 0 {
+		timeout = defaultCallbackTimeout
+	}
+
+	return &Adapter{
+		provider: provider,
+		client:   &http.Client{Timeout: timeout},
+		users:    map[string]*adapter.UserInfo{},
+		channels: map[string]*adapter.ChannelInfo{},
+	}, nil
+}
+
+var _ adapter.Adapter = &Adapter{}
+var _ http.Handler = &Adapter{}
+
+// Adapter is a generic adapter for front-ends that talk to Gort over HTTP.
+// Inbound messages are POSTed to it, as JSON, through its ServeHTTP method;
+// outbound messages are POSTed, as JSON, to the configured callback URL.
+// Both directions are signed with the provider's shared secret.
+type Adapter struct {
+	provider data.HTTPProvider
+	client   *http.Client
+
+	// mu guards the fields below. Users and channels aren't known until
+	// they're seen in a message, so they're remembered as they arrive.
+	mu       sync.Mutex
+	events   chan *adapter.ProviderEvent
+	users    map[string]*adapter.UserInfo
+	channels map[string]*adapter.ChannelInfo
+}
+
+// GetChannelInfo provides info on a specific channel that a message has
+// been received from.
+func (s *Adapter) GetChannelInfo(channelID string) (*adapter.ChannelInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info, ok := s.channels[channelID]; ok {
+		c := *info
+		c.Members = append([]string(nil), info.Members...)
+		return &c, nil
+	}
+
+	return &adapter.ChannelInfo{ID: channelID, Name: channelID}, nil
+}
+
+// GetName provides the name of this adapter as per the configuration.
+func (s *Adapter) GetName() string {
+	return s.provider.Name
+}
+
+// GetPresentChannels returns a slice of the channels that messages have
+// been received from.
+func (s *Adapter) GetPresentChannels() ([]*adapter.ChannelInfo, error) {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.channels))
+	for id := range s.channels {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+
+	sort.Strings(ids)
+
+	channels := make([]*adapter.ChannelInfo, 0, len(ids))
+	for _, id := range ids {
+		info, _ := s.GetChannelInfo(id)
+		channels = append(channels, info)
+	}
+
+	return channels, nil
+}
+
+// GetUserInfo provides info on a specific user. Anything that's known about
+// the user comes from the messages they've sent; if they haven't sent any
+// only their ID is known.
+func (s *Adapter) GetUserInfo(userID string) (*adapter.UserInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info, ok := s.users[userID]; ok {
+		u := *info
+		return &u, nil
+	}
+
+	return &adapter.UserInfo{ID: userID, Name: userID}, nil
+}
+
+// Listen begins relaying inbound messages via the returned channel until
+// ctx is done. There is no connection to establish, so it's immediately
+// connected.
+func (s *Adapter) Listen(ctx context.Context) <-chan *adapter.ProviderEvent {
+	events := make(chan *adapter.ProviderEvent, 100)
+	events <- s.wrapEvent(adapter.EventConnected, &adapter.ConnectedEvent{})
+
+	s.mu.Lock()
+	s.events = events
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mu.Lock()
+		s.events = nil
+		s.mu.Unlock()
+	}()
+
+	return events
+}
+
+// Send the contents of a response envelope to a specified channel. If
+// channelID is empty the value of envelope.Request.ChannelID will be used.
+func (s *Adapter) Send(ctx context.Context, channelID string, elements templates.OutputElements) error {
+	encoded, err := encodeElements(elements.Elements)
+	if err != nil {
+		return err
+	}
+
+	return s.post(ctx, outboundMessage{
+		ChannelID: channelID,
+		Type:      outboundTypeMessage,
+		Title:     elements.Title,
+		Color:     elements.Color,
+		Text:      strings.TrimSpace(elements.Alt()),
+		Elements:  encoded,
+	})
+}
+
+// SendText sends a simple text message to the specified channel.
+func (s *Adapter) SendText(ctx context.Context, channelID string, message string) error {
+	return s.post(ctx, outboundMessage{
+		ChannelID: channelID,
+		Type:      outboundTypeText,
+		Text:      message,
+	})
+}
+
+// SendError is a break-glass error message function that's used when the
+// templating function fails somehow. Obviously, it does not utilize the
+// templating engine.
+func (s *Adapter) SendError(ctx context.Context, channelID string, title string, err error) error {
+	if title == "" {
+		title = "Unhandled Error"
+	}
+
+	return s.post(ctx, outboundMessage{
+		ChannelID: channelID,
+		Type:      outboundTypeError,
+		Title:     title,
+		Text:      err.Error(),
+	})
+}
+
+// ServeHTTP accepts an inbound message. The request must be signed with the
+// shared secret (see data.WebhookSignatureHeader) or carry it as a token
+// (see data.WebhookTokenHeader).
+func (s *Adapter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	le := log.WithField("adapter", s.GetName())
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.authenticate(r, body); err != nil {
+		le.WithError(err).Warn("Rejected inbound HTTP message")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var msg inboundMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		http.Error(w, "invalid message: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if msg.Direct && msg.ChannelID == "" {
+		msg.ChannelID = msg.UserID
+	}
+
+	switch {
+	case msg.UserID == "":
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	case msg.ChannelID == "":
+		http.Error(w, "channel_id is required", http.StatusBadRequest)
+		return
+	case strings.TrimSpace(msg.Text) == "":
+		http.Error(w, "text is required", http.StatusBadRequest)
+		return
+	}
+
+	events := s.remember(msg)
+	if events == nil {
+		http.Error(w, "adapter is not listening", http.StatusServiceUnavailable)
+		return
+	}
+
+	var pe *adapter.ProviderEvent
+	if msg.Direct {
+		pe = s.wrapEvent(adapter.EventDirectMessage, &adapter.DirectMessageEvent{
+			ChannelID: msg.ChannelID,
+			Text:      msg.Text,
+			UserID:    msg.UserID,
+			IsBot:     msg.IsBot,
+		})
+	} else {
+		pe = s.wrapEvent(adapter.EventChannelMessage, &adapter.ChannelMessageEvent{
+			ChannelID: msg.ChannelID,
+			Text:      msg.Text,
+			UserID:    msg.UserID,
+			IsBot:     msg.IsBot,
+			IsMention: msg.Mention,
+		})
+	}
+
+	select {
+	case events <- pe:
+		w.WriteHeader(http.StatusAccepted)
+	case <-r.Context().Done():
+		http.Error(w, "timed out", http.StatusServiceUnavailable)
+	}
+}
+
+// authenticate checks an inbound request's signature or token against the
+// shared secret.
+func (s *Adapter) authenticate(r *http.Request, body []byte) error {
+	if signature := r.Header.Get(data.WebhookSignatureHeader); signature != "" {
+		got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+		if err != nil || !hmac.Equal(got, s.sign(body)) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+
+	if token := r.Header.Get(data.WebhookTokenHeader); token != "" {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.provider.Secret)) != 1 {
+			return fmt.Errorf("invalid token")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("missing signature or token")
+}
+
+// remember records what an inbound message says about its sender and
+// channel, and returns the events channel (which is nil if the adapter
+// isn't listening).
+func (s *Adapter) remember(msg inboundMessage) chan *adapter.ProviderEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[msg.UserID]
+	if !ok {
+		u = &adapter.UserInfo{ID: msg.UserID, Name: msg.UserID}
+		s.users[msg.UserID] = u
+	}
+	if msg.UserName != "" {
+		u.Name = msg.UserName
+	}
+	if msg.Email != "" {
+		u.Email = msg.Email
+	}
+	if msg.DisplayName != "" {
+		u.DisplayName = msg.DisplayName
+		u.DisplayNameNormalized = msg.DisplayName
+		u.RealName = msg.DisplayName
+		u.RealNameNormalized = msg.DisplayName
+	}
+	u.IsBot = msg.IsBot
+
+	c, ok := s.channels[msg.ChannelID]
+	if !ok {
+		c = &adapter.ChannelInfo{ID: msg.ChannelID, Name: msg.ChannelID}
+		s.channels[msg.ChannelID] = c
+	}
+	if msg.ChannelName != "" {
+		c.Name = msg.ChannelName
+	}
+
+	i := sort.SearchStrings(c.Members, msg.UserID)
+	if i == len(c.Members) || c.Members[i] != msg.UserID {
+		c.Members = append(c.Members, "")
+		copy(c.Members[i+1:], c.Members[i:])
+		c.Members[i] = msg.UserID
+	}
+
+	return s.events
+}
+
+// post delivers an outbound message to the callback URL.
+func (s *Adapter) post(ctx context.Context, msg outboundMessage) error {
+	msg.Adapter = s.GetName()
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.provider.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(data.WebhookSignatureHeader, "sha256="+hex.EncodeToString(s.sign(body)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+// sign returns the HMAC-SHA256 of body, keyed with the shared secret.
+func (s *Adapter) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(s.provider.Secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// wrapEvent creates a new ProviderEvent instance with metadata and the Event data attached.
+func (s *Adapter) wrapEvent(eventType adapter.EventType, data interface{}) *adapter.ProviderEvent {
+	return &adapter.ProviderEvent{
+		EventType: eventType,
+		Data:      data,
+		Info: &adapter.Info{
+			Provider: adapter.NewProviderInfoFromConfig(s.provider),
+		},
+		Adapter: s,
+	}
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/templates"
+)
+
+const secret = "s3cr3t"
+
+func sign(body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTestAdapter(t *testing.T, callbackURL string) *Adapter {
+	a, err := NewAdapter(data.HTTPProvider{
+		AbstractProvider: data.AbstractProvider{Name: "portal"},
+		Secret:           secret,
+		CallbackURL:      callbackURL,
+	})
+	require.NoError(t, err)
+	return a.(*Adapter)
+}
+
+func post(a *Adapter, body string, headers map[string]string) *http.Response {
+	req := httptest.NewRequest("POST", "http://example.com/v2/adapters/portal/messages", strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+	return w.Result()
+}
+
+func TestNewAdapter(t *testing.T) {
+	_, err := NewAdapter(data.HTTPProvider{CallbackURL: "http://localhost"})
+	assert.Error(t, err)
+
+	_, err = NewAdapter(data.HTTPProvider{Secret: secret})
+	assert.Error(t, err)
+}
+
+func TestServeHTTP(t *testing.T) {
+	a := newTestAdapter(t, "http://localhost")
+
+	body := `{"user_id": "U1", "user_name": "alice", "email": "alice@example.com", "display_name": "Alice",
+		"channel_id": "C1", "channel_name": "ops", "text": "!echo foo", "mention": true}`
+
+	// Not listening yet.
+	resp := post(a, body, map[string]string{data.WebhookTokenHeader: secret})
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := a.Listen(ctx)
+	e := <-events
+	require.Equal(t, adapter.EventConnected, e.EventType)
+	assert.Equal(t, "http", e.Info.Provider.Type)
+
+	// Unauthenticated
+	resp = post(a, body, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = post(a, body, map[string]string{data.WebhookTokenHeader: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = post(a, body, map[string]string{data.WebhookSignatureHeader: sign(body + " ")})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Invalid
+	resp = post(a, `{"user_id": "U1", "channel_id": "C1"}`, map[string]string{data.WebhookTokenHeader: secret})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = post(a, `{"user_id": "U1", "text": "hi"}`, map[string]string{data.WebhookTokenHeader: secret})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = post(a, `{`, map[string]string{data.WebhookTokenHeader: secret})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// A signed channel message
+	resp = post(a, body, map[string]string{data.WebhookSignatureHeader: sign(body)})
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	e = <-events
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "C1",
+		Text:      "!echo foo",
+		UserID:    "U1",
+		IsMention: true,
+	}, e.Data)
+
+	// A direct message with a token, without a channel
+	resp = post(a, `{"user_id": "U2", "text": "whoami", "direct": true}`, map[string]string{data.WebhookTokenHeader: secret})
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	e = <-events
+	require.Equal(t, adapter.EventDirectMessage, e.EventType)
+	assert.Equal(t, &adapter.DirectMessageEvent{
+		ChannelID: "U2",
+		Text:      "whoami",
+		UserID:    "U2",
+	}, e.Data)
+
+	// What the messages said about their senders and channels is remembered.
+	u, err := a.GetUserInfo("U1")
+	require.NoError(t, err)
+	assert.Equal(t, &adapter.UserInfo{
+		ID:                    "U1",
+		Name:                  "alice",
+		Email:                 "alice@example.com",
+		DisplayName:           "Alice",
+		DisplayNameNormalized: "Alice",
+		RealName:              "Alice",
+		RealNameNormalized:    "Alice",
+	}, u)
+
+	u, err = a.GetUserInfo("U3")
+	require.NoError(t, err)
+	assert.Equal(t, &adapter.UserInfo{ID: "U3", Name: "U3"}, u)
+
+	channels, err := a.GetPresentChannels()
+	require.NoError(t, err)
+	assert.Equal(t, []*adapter.ChannelInfo{
+		{ID: "C1", Name: "ops", Members: []string{"U1"}},
+		{ID: "U2", Name: "U2", Members: []string{"U2"}},
+	}, channels)
+
+	// Stop listening.
+	cancel()
+	assert.Eventually(t, func() bool {
+		return post(a, body, map[string]string{data.WebhookTokenHeader: secret}).StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSend(t *testing.T) {
+	var received []outboundMessage
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if r.Header.Get(data.WebhookSignatureHeader) != sign(string(b)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var msg outboundMessage
+		require.NoError(t, json.Unmarshal(b, &msg))
+		received = append(received, msg)
+	}))
+	defer server.Close()
+
+	a := newTestAdapter(t, server.URL)
+	ctx := context.Background()
+
+	require.NoError(t, a.SendText(ctx, "C1", "hello"))
+	require.NoError(t, a.SendError(ctx, "C1", "", assert.AnError))
+	require.NoError(t, a.Send(ctx, "C1", templates.OutputElements{
+		Title: "Status",
+		Elements: []templates.OutputElement{
+			&templates.Section{
+				Fields: []templates.OutputElement{
+					&templates.Text{Title: "CPU", Text: "12%", Inline: true},
+				},
+			},
+			&templates.Image{URL: "https://example.com/a.png"},
+		},
+	}))
+
+	require.Len(t, received, 3)
+
+	assert.Equal(t, outboundMessage{Adapter: "portal", ChannelID: "C1", Type: "text", Text: "hello"}, received[0])
+	assert.Equal(t, outboundMessage{Adapter: "portal", ChannelID: "C1", Type: "error", Title: "Unhandled Error", Text: assert.AnError.Error()}, received[1])
+
+	assert.Equal(t, "message", received[2].Type)
+	assert.Equal(t, "Status", received[2].Title)
+	assert.Equal(t, "Status\n\n\n\n12%\n\nhttps://example.com/a.png", received[2].Text)
+	assert.Equal(t, []map[string]interface{}{
+		{"Type": "Section", "Fields": []interface{}{
+			map[string]interface{}{"Type": "Text", "Title": "CPU", "Text": "12%", "Inline": true},
+		}},
+		{"Type": "Image", "URL": "https://example.com/a.png"},
+	}, received[2].Elements)
+
+	// The callback's failures are reported.
+	a.provider.Secret = "wrong"
+	assert.Error(t, a.SendText(ctx, "C1", "hello"))
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/getgort/gort/templates"
+)
+
+// inboundMessage is the body of a message POSTed to Gort by the front-end.
+type inboundMessage struct {
+	// The sender's ID, which Gort users are mapped to. Required.
+	UserID string `json:"user_id"`
+
+	// Optional details about the sender. They're used if a Gort user is
+	// created for them.
+	UserName    string `json:"user_name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+
+	// The channel the message was sent in. Required unless Direct is set,
+	// in which case it defaults to the user ID.
+	ChannelID   string `json:"channel_id,omitempty"`
+	ChannelName string `json:"channel_name,omitempty"`
+
+	// The message text. Required.
+	Text string `json:"text"`
+
+	// Direct is set for a message sent directly to Gort, which is always
+	// treated as a command. Mention is set if a channel message addresses
+	// Gort. IsBot is set if the sender is a bot.
+	Direct  bool `json:"direct,omitempty"`
+	Mention bool `json:"mention,omitempty"`
+	IsBot   bool `json:"is_bot,omitempty"`
+}
+
+// Types of outbound message.
+const (
+	outboundTypeMessage = "message"
+	outboundTypeText    = "text"
+	outboundTypeError   = "error"
+)
+
+// outboundMessage is the body of a request to the callback URL.
+type outboundMessage struct {
+	Adapter   string `json:"adapter"`
+	ChannelID string `json:"channel_id"`
+
+	// Type is "message" for templated output, "text" for a plain message,
+	// and "error" for an error.
+	Type string `json:"type"`
+
+	// Title and Color are the message's title and color, if any. For an
+	// error the title describes it.
+	Title string `json:"title,omitempty"`
+	Color string `json:"color,omitempty"`
+
+	// Text is the plain-text form of the message.
+	Text string `json:"text"`
+
+	// Elements are the template output elements of a "message", each with
+	// a "Type" field naming the element ("Header", "Text", and so on).
+	Elements []map[string]interface{} `json:"elements,omitempty"`
+}
+
+// encodeElements converts output elements into a form that can be encoded
+// as JSON, adding each element's type.
+func encodeElements(elements []templates.OutputElement) ([]map[string]interface{}, error) {
+	encoded := make([]map[string]interface{}, 0, len(elements))
+
+	for _, e := range elements {
+		m, err := encodeElement(e)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, m)
+	}
+
+	return encoded, nil
+}
+
+func encodeElement(e templates.OutputElement) (map[string]interface{}, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	m["Type"] = reflect.Indirect(reflect.ValueOf(e)).Type().Name()
+
+	// A section's elements are interfaces, so they're encoded separately
+	// to get their types.
+	if section, ok := e.(*templates.Section); ok {
+		if section.Text != nil {
+			if m["Text"], err = encodeElement(section.Text); err != nil {
+				return nil, err
+			}
+		}
+		if len(section.Fields) > 0 {
+			if m["Fields"], err = encodeElements(section.Fields); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,769 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package irc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/templates"
+)
+
+var (
+	// reconnectDelay is how long the adapter waits before reconnecting
+	// after its connection is lost.
+	reconnectDelay = 5 * time.Second
+
+	// readTimeout is how long the connection may be silent before it's
+	// considered dead. Servers send a PING every few minutes.
+	readTimeout = 10 * time.Minute
+
+	// errNotConnected is returned when sending while disconnected.
+	errNotConnected = errors.New("not connected to irc server")
+)
+
+// The longest line a server will accept is 512 bytes, including the
+// trailing CR-LF and the prefix that the server adds when relaying a message
+// to other clients. The prefix length isn't known in advance, so some room
+// is left for it.
+const (
+	maxLineLength = 510
+	prefixReserve = 100
+)
+
+// NewAdapter will construct an IRC Adapter instance for a given provider
+// configuration.
+func NewAdapter(provider data.IRCProvider) (adapter.Adapter, error) {
+	if provider.Server == "" {
+		return nil, fmt.Errorf("irc provider %q has no server", provider.Name)
+	}
+
+	if provider.Nick == "" {
+		provider.Nick = provider.BotName
+	}
+	if provider.Nick == "" {
+		provider.Nick = "gort"
+	}
+
+	return &Adapter{
+		provider: provider,
+		flood:    newFloodLimiter(provider.FloodBurst, provider.FloodDelay),
+		channels: map[string]map[string]bool{},
+	}, nil
+}
+
+var _ adapter.Adapter = &Adapter{}
+var _ adapter.DirectMessenger = &Adapter{}
+
+// Adapter is the IRC provider implementation of a relay, which knows how to
+// receive messages from an IRC server, translate them into Gort events, and
+// forward them along.
+type Adapter struct {
+	provider data.IRCProvider
+	events   chan *adapter.ProviderEvent
+
+	// mu guards the connection and session state below. caps holds the
+	// capabilities that have been requested but not yet settled, and
+	// accounts maps the nick of each user seen with the account tag to
+	// their account name.
+	mu         sync.Mutex
+	conn       net.Conn
+	nick       string
+	pending    map[string]bool
+	channels   map[string]map[string]bool
+	caps       map[string]bool
+	accountTag bool
+	accounts   map[string]string
+
+	// writeMu serializes writes to the connection; sendMu serializes
+	// (rate-limited) messages, so that multi-line output isn't interleaved.
+	writeMu sync.Mutex
+	sendMu  sync.Mutex
+	flood   *floodLimiter
+}
+
+// GetChannelInfo provides info on a specific provider channel accessible
+// to the adapter. Direct messages are addressed to a nick rather than a
+// channel, so for those the "channel" consists of the user and the bot.
+// Members are listed by user ID; when users are identified by account, only
+// the members whose account is known are listed.
+func (s *Adapter) GetChannelInfo(channelID string) (*adapter.ChannelInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var nicks []string
+
+	if !isChannel(channelID) {
+		nicks = []string{channelID}
+	} else {
+		members, ok := s.channels[strings.ToLower(channelID)]
+		if !ok {
+			return nil, adapter.ErrChannelNotFound
+		}
+		for nick := range members {
+			if !strings.EqualFold(nick, s.nick) {
+				nicks = append(nicks, nick)
+			}
+		}
+	}
+
+	info := &adapter.ChannelInfo{ID: channelID, Name: channelID, Members: []string{s.nick}}
+	for _, nick := range nicks {
+		if !s.accountTag {
+			info.Members = append(info.Members, nick)
+		} else if account, ok := s.accounts[nick]; ok {
+			info.Members = append(info.Members, account)
+		}
+	}
+	sort.Strings(info.Members)
+
+	return info, nil
+}
+
+// GetDirectMessageChannel returns the nick that direct messages to the
+// specified user should be sent to. When users are identified by account
+// that's the nick they were last seen using.
+func (s *Adapter) GetDirectMessageChannel(userID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accountTag {
+		for nick, account := range s.accounts {
+			if account == userID {
+				return nick, nil
+			}
+		}
+	}
+
+	return userID, nil
+}
+
+// GetName provides the name of this adapter as per the configuration.
+func (s *Adapter) GetName() string {
+	return s.provider.Name
+}
+
+// GetPresentChannels returns a slice of channels that the bot has joined.
+func (s *Adapter) GetPresentChannels() ([]*adapter.ChannelInfo, error) {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.channels))
+	for name := range s.channels {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+
+	sort.Strings(names)
+
+	channels := make([]*adapter.ChannelInfo, 0, len(names))
+	for _, name := range names {
+		info, err := s.GetChannelInfo(name)
+		if err != nil {
+			continue
+		}
+		channels = append(channels, info)
+	}
+
+	return channels, nil
+}
+
+// GetUserInfo provides info on a specific provider user accessible to the
+// adapter. IRC users are identified by their account name or, if nick
+// identity is allowed and the server doesn't support accounts, their nick,
+// which is all that is known about them.
+func (s *Adapter) GetUserInfo(userID string) (*adapter.UserInfo, error) {
+	return &adapter.UserInfo{
+		ID:                    userID,
+		Name:                  userID,
+		DisplayName:           userID,
+		DisplayNameNormalized: userID,
+	}, nil
+}
+
+// Listen causes the Adapter to initiate a connection to its provider and
+// begin relaying back events (including errors) via the returned channel.
+// If the connection is lost it's re-established until ctx is done.
+func (s *Adapter) Listen(ctx context.Context) <-chan *adapter.ProviderEvent {
+	s.events = make(chan *adapter.ProviderEvent, 100)
+
+	go func() {
+		le := log.WithField("adapter", s.GetName())
+
+		for {
+			le.WithField("server", s.provider.Server).Info("Connecting to IRC server")
+
+			conn, err := s.dial(ctx)
+			if err != nil {
+				s.events <- s.wrapEvent(adapter.EventConnectionError, &adapter.ErrorEvent{Msg: err.Error()})
+			} else {
+				fatal := s.session(ctx, conn)
+				s.events <- s.wrapEvent(adapter.EventDisconnected, &adapter.DisconnectedEvent{Intentional: ctx.Err() != nil})
+				if fatal {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reconnectDelay):
+			}
+		}
+	}()
+
+	return s.events
+}
+
+// Send the contents of a response envelope to a specified channel. If
+// channelID is empty the value of envelope.Request.ChannelID will be used.
+func (s *Adapter) Send(ctx context.Context, channelID string, elements templates.OutputElements) error {
+	return s.sendLines(ctx, channelID, render(elements))
+}
+
+// SendText sends a simple text message to the specified channel.
+func (s *Adapter) SendText(ctx context.Context, channelID string, message string) error {
+	return s.sendLines(ctx, channelID, textLines(message, ""))
+}
+
+// SendError is a break-glass error message function that's used when the
+// templating function fails somehow. Obviously, it does not utilize the
+// templating engine.
+func (s *Adapter) SendError(ctx context.Context, channelID string, title string, err error) error {
+	if title == "" {
+		title = "Unhandled Error"
+	}
+
+	lines := textLines(title, codeBold+colorRed)
+	lines = append(lines, textLines(err.Error(), "")...)
+
+	return s.sendLines(ctx, channelID, lines)
+}
+
+// dial opens a connection to the configured server.
+func (s *Adapter) dial(ctx context.Context) (net.Conn, error) {
+	d := &net.Dialer{Timeout: 30 * time.Second}
+
+	if !s.provider.TLS {
+		return d.DialContext(ctx, "tcp", s.provider.Server)
+	}
+
+	host, _, err := net.SplitHostPort(s.provider.Server)
+	if err != nil {
+		return nil, err
+	}
+
+	td := &tls.Dialer{
+		NetDialer: d,
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: s.provider.TLSSkipVerify,
+		},
+	}
+
+	return td.DialContext(ctx, "tcp", s.provider.Server)
+}
+
+// session registers with the server over conn and relays events until the
+// connection is closed. It returns true if the failure was one that
+// reconnecting won't fix, such as bad credentials.
+func (s *Adapter) session(ctx context.Context, conn net.Conn) bool {
+	le := log.WithField("adapter", s.GetName())
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.write(formatMessage("QUIT", "Shutting down"))
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	s.mu.Lock()
+	s.conn = conn
+	s.nick = s.provider.Nick
+	s.pending = nil
+	s.channels = map[string]map[string]bool{}
+	s.caps = nil
+	s.accountTag = false
+	s.accounts = map[string]string{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	if err := s.register(); err != nil {
+		le.WithError(err).Error("IRC registration failed")
+		return false
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		if !scanner.Scan() {
+			break
+		}
+
+		m, err := parseMessage(scanner.Text())
+		if err != nil {
+			le.WithError(err).Debug("IRC event: unparseable message")
+			continue
+		}
+
+		pe, fatal := s.onMessage(m)
+		if pe != nil {
+			s.events <- pe
+		}
+		if fatal {
+			return true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		le.WithError(err).Debug("IRC event: read failed")
+	}
+
+	return false
+}
+
+// register begins the connection registration sequence. The account-tag
+// capability, and SASL if configured, are requested separately so that the
+// server refusing one doesn't refuse both, and are negotiated as the server
+// responds.
+func (s *Adapter) register() error {
+	caps := []string{"account-tag"}
+	if s.provider.SASLUser != "" {
+		caps = append(caps, "sasl")
+	}
+
+	s.mu.Lock()
+	s.caps = map[string]bool{}
+	for _, c := range caps {
+		s.caps[c] = true
+	}
+	s.mu.Unlock()
+
+	var lines []string
+
+	for _, c := range caps {
+		lines = append(lines, formatMessage("CAP", "REQ", c))
+	}
+	if s.provider.Password != "" {
+		lines = append(lines, formatMessage("PASS", s.provider.Password))
+	}
+
+	lines = append(lines,
+		formatMessage("NICK", s.provider.Nick),
+		formatMessage("USER", s.provider.Nick, "0", "*", "Gort"),
+	)
+
+	for _, l := range lines {
+		if err := s.write(l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// onMessage handles a message received from the server, returning the
+// resulting event (if any), and whether the session should be ended
+// without reconnecting.
+func (s *Adapter) onMessage(m *message) (*adapter.ProviderEvent, bool) {
+	switch m.Command {
+	case "PING":
+		s.write(formatMessage("PONG", m.Params...))
+
+	case "CAP":
+		if sub := m.Param(1); sub == "ACK" || sub == "NAK" {
+			return s.onCapReply(m)
+		}
+
+	case "AUTHENTICATE":
+		if m.Param(0) == "+" {
+			s.authenticate()
+		}
+
+	case "903": // RPL_SASLSUCCESS
+		s.capSettled("sasl")
+
+	case "902", "904", "905", "906": // SASL failures
+		return s.onAuthenticationError("SASL authentication failed"), true
+
+	case "464": // ERR_PASSWDMISMATCH
+		return s.onAuthenticationError("invalid server password"), true
+
+	case "433": // ERR_NICKNAMEINUSE
+		s.mu.Lock()
+		s.nick += "_"
+		nick := s.nick
+		s.mu.Unlock()
+		s.write(formatMessage("NICK", nick))
+
+	case "001": // RPL_WELCOME
+		return s.onWelcome(m)
+
+	case "353": // RPL_NAMREPLY
+		s.mu.Lock()
+		if members, ok := s.channels[strings.ToLower(m.Param(2))]; ok {
+			for _, nick := range strings.Fields(m.Param(3)) {
+				members[strings.TrimLeft(nick, "~&@%+")] = true
+			}
+		}
+		s.mu.Unlock()
+
+	case "403", "405", "471", "473", "474", "475", "477": // Join failures
+		log.WithField("adapter", s.GetName()).
+			WithField("channel", m.Param(1)).
+			WithField("reason", m.Param(2)).
+			Warn("Failed to join IRC channel")
+		return s.onJoined(m.Param(1)), false
+
+	case "JOIN":
+		return s.onJoin(m), false
+
+	case "PART", "KICK":
+		nick := m.Nick()
+		if m.Command == "KICK" {
+			nick = m.Param(1)
+		}
+
+		s.mu.Lock()
+		if strings.EqualFold(nick, s.nick) {
+			delete(s.channels, strings.ToLower(m.Param(0)))
+		} else if members, ok := s.channels[strings.ToLower(m.Param(0))]; ok {
+			delete(members, nick)
+		}
+		s.mu.Unlock()
+
+	case "QUIT":
+		s.mu.Lock()
+		for _, members := range s.channels {
+			delete(members, m.Nick())
+		}
+		delete(s.accounts, m.Nick())
+		s.mu.Unlock()
+
+	case "NICK":
+		s.mu.Lock()
+		if strings.EqualFold(m.Nick(), s.nick) {
+			s.nick = m.Param(0)
+		}
+		for _, members := range s.channels {
+			if members[m.Nick()] {
+				delete(members, m.Nick())
+				members[m.Param(0)] = true
+			}
+		}
+		if account, ok := s.accounts[m.Nick()]; ok {
+			delete(s.accounts, m.Nick())
+			s.accounts[m.Param(0)] = account
+		}
+		s.mu.Unlock()
+
+	case "PRIVMSG":
+		return s.onPrivmsg(m), false
+	}
+
+	return nil, false
+}
+
+// onCapReply handles the server's acknowledgement or refusal of requested
+// capabilities. SASL is required if it was requested, and isn't settled
+// until authentication succeeds.
+func (s *Adapter) onCapReply(m *message) (*adapter.ProviderEvent, bool) {
+	ack := m.Param(1) == "ACK"
+
+	for _, c := range strings.Fields(m.Param(2)) {
+		switch c {
+		case "sasl":
+			if !ack {
+				return s.onAuthenticationError("server does not support SASL"), true
+			}
+			s.write(formatMessage("AUTHENTICATE", "PLAIN"))
+			continue
+
+		case "account-tag":
+			s.mu.Lock()
+			s.accountTag = ack
+			s.mu.Unlock()
+		}
+
+		s.capSettled(c)
+	}
+
+	return nil, false
+}
+
+// capSettled marks a requested capability as settled, and ends capability
+// negotiation once none remain.
+func (s *Adapter) capSettled(c string) {
+	s.mu.Lock()
+	delete(s.caps, c)
+	end := s.caps != nil && len(s.caps) == 0
+	if end {
+		s.caps = nil
+	}
+	s.mu.Unlock()
+
+	if end {
+		s.write(formatMessage("CAP", "END"))
+	}
+}
+
+// authenticate sends the SASL PLAIN credentials, split into 400 byte
+// chunks as the protocol requires.
+func (s *Adapter) authenticate() {
+	p := s.provider
+	creds := base64.StdEncoding.EncodeToString([]byte(p.SASLUser + "\x00" + p.SASLUser + "\x00" + p.SASLPassword))
+
+	for len(creds) >= 400 {
+		s.write(formatMessage("AUTHENTICATE", creds[:400]))
+		creds = creds[400:]
+	}
+	if creds == "" {
+		creds = "+"
+	}
+
+	s.write(formatMessage("AUTHENTICATE", creds))
+}
+
+// onWelcome is called when registration is complete. Users can't be
+// identified unless the server supports the account tag or nick identity is
+// allowed, so without either the session is ended. Otherwise the configured
+// channels are joined; the connected event is sent once they all have been.
+func (s *Adapter) onWelcome(m *message) (*adapter.ProviderEvent, bool) {
+	s.mu.Lock()
+	s.nick = m.Param(0)
+	s.caps = nil
+	accountTag := s.accountTag
+	s.pending = map[string]bool{}
+	for _, ch := range s.provider.Channels {
+		s.pending[strings.ToLower(ch)] = true
+	}
+	s.mu.Unlock()
+
+	if !accountTag && !s.provider.AllowNickIdentity {
+		return s.onAuthenticationError("server does not support the account-tag capability " +
+			"needed to identify users; set allow_nick_identity to identify them by nick"), true
+	}
+
+	if len(s.provider.Channels) == 0 {
+		return s.wrapEvent(adapter.EventConnected, &adapter.ConnectedEvent{}), false
+	}
+
+	for _, ch := range s.provider.Channels {
+		s.write(formatMessage("JOIN", ch))
+	}
+
+	return nil, false
+}
+
+// onJoin is called when anyone, including the bot, joins a channel.
+func (s *Adapter) onJoin(m *message) *adapter.ProviderEvent {
+	channel := m.Param(0)
+
+	s.mu.Lock()
+	self := strings.EqualFold(m.Nick(), s.nick)
+	if self {
+		s.channels[strings.ToLower(channel)] = map[string]bool{s.nick: true}
+	} else if members, ok := s.channels[strings.ToLower(channel)]; ok {
+		members[m.Nick()] = true
+	}
+	s.mu.Unlock()
+
+	if !self {
+		return nil
+	}
+
+	return s.onJoined(channel)
+}
+
+// onJoined is called when an attempt to join a channel succeeds or fails.
+// Once the last of the configured channels has been attempted a connected
+// event is returned.
+func (s *Adapter) onJoined(channel string) *adapter.ProviderEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.pending[strings.ToLower(channel)] {
+		return nil
+	}
+
+	delete(s.pending, strings.ToLower(channel))
+	if len(s.pending) > 0 {
+		return nil
+	}
+
+	return s.wrapEvent(adapter.EventConnected, &adapter.ConnectedEvent{})
+}
+
+// onPrivmsg is called when a message is sent to a channel the bot is in,
+// or directly to the bot.
+func (s *Adapter) onPrivmsg(m *message) *adapter.ProviderEvent {
+	target, text := m.Param(0), m.Param(1)
+
+	// Ignore CTCP requests, including actions.
+	if strings.HasPrefix(text, "\x01") {
+		return nil
+	}
+
+	text = stripFormatting(text)
+
+	s.mu.Lock()
+	nick := s.nick
+	s.mu.Unlock()
+
+	isSelf := strings.EqualFold(m.Nick(), nick)
+	_, isBot := m.Tags["bot"]
+
+	userID, ok := s.userID(m)
+	if !ok {
+		log.WithField("adapter", s.GetName()).
+			WithField("nick", m.Nick()).
+			Debug("Ignoring message from user who isn't logged in")
+		return nil
+	}
+
+	if !isChannel(target) {
+		return s.wrapEvent(
+			adapter.EventDirectMessage,
+			&adapter.DirectMessageEvent{
+				ChannelID: m.Nick(),
+				Text:      text,
+				UserID:    userID,
+				IsBot:     isBot,
+				IsSelf:    isSelf,
+			},
+		)
+	}
+
+	text, mentioned := adapter.StripMention(text, nick)
+
+	return s.wrapEvent(
+		adapter.EventChannelMessage,
+		&adapter.ChannelMessageEvent{
+			ChannelID: target,
+			Text:      text,
+			UserID:    userID,
+			IsBot:     isBot,
+			IsSelf:    isSelf,
+			IsMention: mentioned,
+		},
+	)
+}
+
+// userID returns the ID of the user who sent m: their account name if the
+// server supports the account tag, or their nick otherwise. It returns false
+// if the server supports the account tag but the user isn't logged in.
+func (s *Adapter) userID(m *message) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.accountTag {
+		return m.Nick(), true
+	}
+
+	account := m.Tags["account"]
+	if account == "" {
+		delete(s.accounts, m.Nick())
+		return "", false
+	}
+
+	s.accounts[m.Nick()] = account
+	return account, true
+}
+
+// onAuthenticationError is called when the server rejects the bot's
+// credentials.
+func (s *Adapter) onAuthenticationError(reason string) *adapter.ProviderEvent {
+	return s.wrapEvent(
+		adapter.EventAuthenticationError,
+		&adapter.AuthenticationErrorEvent{
+			Msg: fmt.Sprintf("Connection failed to %s: %s", s.provider.Name, reason),
+		},
+	)
+}
+
+// sendLines sends each line to target as a PRIVMSG, splitting lines that
+// are too long and pacing them to avoid being disconnected for flooding.
+func (s *Adapter) sendLines(ctx context.Context, target string, lines []line) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	max := maxLineLength - prefixReserve - len(formatMessage("PRIVMSG", target, ""))
+
+	for _, l := range lines {
+		for _, piece := range l.split(max) {
+			if err := s.flood.wait(ctx); err != nil {
+				return err
+			}
+			if err := s.write(formatMessage("PRIVMSG", target, piece)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// write sends a single protocol line to the server.
+func (s *Adapter) write(line string) error {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil {
+		return errNotConnected
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	_, err := conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+// wrapEvent creates a new ProviderEvent instance with metadata and the Event data attached.
+func (s *Adapter) wrapEvent(eventType adapter.EventType, data interface{}) *adapter.ProviderEvent {
+	return &adapter.ProviderEvent{
+		EventType: eventType,
+		Data:      data,
+		Info: &adapter.Info{
+			Provider: adapter.NewProviderInfoFromConfig(s.provider),
+		},
+		Adapter: s,
+	}
+}
@@ -0,0 +1,384 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package irc
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/adapter/adaptertest"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/templates"
+)
+
+// mockServer is a stand-in for an IRC server that accepts a single client.
+type mockServer struct {
+	t        *testing.T
+	listener net.Listener
+	conn     net.Conn
+	reader   *bufio.Reader
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	return &mockServer{t: t, listener: l}
+}
+
+func (m *mockServer) accept() {
+	conn, err := m.listener.Accept()
+	require.NoError(m.t, err)
+	m.t.Cleanup(func() { conn.Close() })
+
+	m.conn = conn
+	m.reader = bufio.NewReader(conn)
+}
+
+// expect reads the next line from the client and checks it.
+func (m *mockServer) expect(line string) {
+	m.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	got, err := m.reader.ReadString('\n')
+	require.NoError(m.t, err)
+	require.Equal(m.t, line, strings.TrimRight(got, "\r\n"))
+}
+
+func (m *mockServer) send(lines ...string) {
+	for _, l := range lines {
+		_, err := m.conn.Write([]byte(l + "\r\n"))
+		require.NoError(m.t, err)
+	}
+}
+
+func newTestAdapter(t *testing.T, m *mockServer, provider data.IRCProvider) *Adapter {
+	provider.Name = "irc"
+	provider.Server = m.listener.Addr().String()
+	provider.FloodDelay = time.Millisecond
+
+	a, err := NewAdapter(provider)
+	require.NoError(t, err)
+	return a.(*Adapter)
+}
+
+func TestNewAdapter(t *testing.T) {
+	_, err := NewAdapter(data.IRCProvider{})
+	assert.Error(t, err)
+
+	a, err := NewAdapter(data.IRCProvider{Server: "localhost:6697"})
+	require.NoError(t, err)
+	assert.Equal(t, "gort", a.(*Adapter).provider.Nick)
+
+	a, err = NewAdapter(data.IRCProvider{Server: "localhost:6697", AbstractProvider: data.AbstractProvider{BotName: "robbie"}})
+	require.NoError(t, err)
+	assert.Equal(t, "robbie", a.(*Adapter).provider.Nick)
+}
+
+func TestListen(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m, data.IRCProvider{
+		Nick:              "gort",
+		SASLUser:          "gortbot",
+		SASLPassword:      "hunter2",
+		Channels:          []string{"#ops", "#secret"},
+		AllowNickIdentity: true,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := a.Listen(ctx)
+	m.accept()
+
+	m.expect("CAP REQ account-tag")
+	m.expect("CAP REQ sasl")
+	m.expect("NICK gort")
+	m.expect("USER gort 0 * Gort")
+
+	m.send(
+		":irc.example.com CAP * NAK :account-tag",
+		":irc.example.com CAP * ACK :sasl",
+	)
+	m.expect("AUTHENTICATE PLAIN")
+	m.send("AUTHENTICATE +")
+	m.expect("AUTHENTICATE " + base64.StdEncoding.EncodeToString([]byte("gortbot\x00gortbot\x00hunter2")))
+	m.send(":irc.example.com 903 gort :SASL authentication successful")
+	m.expect("CAP END")
+
+	m.send(":irc.example.com 433 * gort :Nickname is already in use")
+	m.expect("NICK gort_")
+
+	m.send(
+		":irc.example.com 001 gort_ :Welcome to the network",
+		"PING :irc.example.com",
+	)
+	m.expect("JOIN #ops")
+	m.expect("JOIN #secret")
+	m.expect("PONG irc.example.com")
+
+	m.send(
+		":gort_!gort@gort.example.com JOIN #ops",
+		":irc.example.com 353 gort_ = #ops :gort_ @alice +bob",
+		":irc.example.com 473 gort_ #secret :Cannot join channel (+i)",
+	)
+
+	e := adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventConnected, e.EventType)
+	assert.Equal(t, "irc", e.Info.Provider.Type)
+
+	channels, err := a.GetPresentChannels()
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, "#ops", channels[0].ID)
+	assert.Equal(t, []string{"alice", "bob", "gort_"}, channels[0].Members)
+
+	_, err = a.GetChannelInfo("#secret")
+	assert.ErrorIs(t, err, adapter.ErrChannelNotFound)
+
+	m.send(
+		":alice!alice@example.com PRIVMSG #ops :gort_: \x02echo\x02 foo",
+		":alice!alice@example.com PRIVMSG #ops :\x01ACTION waves\x01",
+		"@bot :robot!robot@example.com PRIVMSG #ops :!deploy",
+		":alice!alice@example.com PRIVMSG gort_ :whoami",
+	)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "#ops",
+		Text:      "echo foo",
+		UserID:    "alice",
+		IsMention: true,
+	}, e.Data)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "#ops",
+		Text:      "!deploy",
+		UserID:    "robot",
+		IsBot:     true,
+	}, e.Data)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventDirectMessage, e.EventType)
+	assert.Equal(t, &adapter.DirectMessageEvent{
+		ChannelID: "alice",
+		Text:      "whoami",
+		UserID:    "alice",
+	}, e.Data)
+
+	info, err := a.GetChannelInfo("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "gort_"}, info.Members)
+
+	require.NoError(t, a.SendText(ctx, "#ops", "hello\nworld"))
+	m.expect("PRIVMSG #ops hello")
+	m.expect("PRIVMSG #ops world")
+
+	require.NoError(t, a.Send(ctx, "#ops", templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Header{Title: "Results"},
+			&templates.Text{Text: "uptime 3d", Monospace: true},
+		},
+	}))
+	m.expect("PRIVMSG #ops \x02Results\x0f")
+	m.expect("PRIVMSG #ops :\x11uptime 3d\x0f")
+
+	m.send("ERROR :Closing link")
+	m.conn.Close()
+
+	e = adaptertest.NextEvent(t, events)
+	assert.Equal(t, adapter.EventDisconnected, e.EventType)
+}
+
+func TestListenAccountTag(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m, data.IRCProvider{Nick: "gort", Channels: []string{"#ops"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := a.Listen(ctx)
+	m.accept()
+
+	m.expect("CAP REQ account-tag")
+	m.expect("NICK gort")
+	m.expect("USER gort 0 * Gort")
+
+	m.send(":irc.example.com CAP * ACK :account-tag")
+	m.expect("CAP END")
+
+	m.send(":irc.example.com 001 gort :Welcome to the network")
+	m.expect("JOIN #ops")
+
+	m.send(
+		":gort!gort@gort.example.com JOIN #ops",
+		":irc.example.com 353 gort = #ops :gort @ally mallory",
+	)
+
+	e := adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventConnected, e.EventType)
+
+	// Users are identified by account, and those who aren't logged in are
+	// ignored, whatever their nick.
+	m.send(
+		"@account=alice :ally!ally@example.com PRIVMSG #ops :!whoami",
+		":mallory!mallory@example.com PRIVMSG #ops :!whoami",
+		"@account=alice :ally!ally@example.com PRIVMSG gort :whoami",
+	)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "#ops",
+		Text:      "!whoami",
+		UserID:    "alice",
+	}, e.Data)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventDirectMessage, e.EventType)
+	assert.Equal(t, &adapter.DirectMessageEvent{
+		ChannelID: "ally",
+		Text:      "whoami",
+		UserID:    "alice",
+	}, e.Data)
+
+	info, err := a.GetChannelInfo("#ops")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "gort"}, info.Members)
+
+	info, err = a.GetChannelInfo("ally")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "gort"}, info.Members)
+
+	// Direct messages are sent to the account's current nick.
+	m.send(":ally!ally@example.com NICK :alice_")
+	require.Eventually(t, func() bool {
+		nick, err := a.GetDirectMessageChannel("alice")
+		return err == nil && nick == "alice_"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestListenNoAccountTag(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m, data.IRCProvider{Nick: "gort"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := a.Listen(ctx)
+	m.accept()
+
+	m.expect("CAP REQ account-tag")
+	m.send(":irc.example.com CAP * NAK :account-tag")
+	m.expect("NICK gort")
+	m.expect("USER gort 0 * Gort")
+	m.expect("CAP END")
+
+	// Without the account tag users can't be identified unless nick
+	// identity is allowed.
+	m.send(":irc.example.com 001 gort :Welcome to the network")
+
+	e := adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventAuthenticationError, e.EventType)
+	assert.Contains(t, e.Data.(*adapter.AuthenticationErrorEvent).Msg, "allow_nick_identity")
+
+	e = adaptertest.NextEvent(t, events)
+	assert.Equal(t, adapter.EventDisconnected, e.EventType)
+}
+
+func TestListenSASLFailure(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m, data.IRCProvider{
+		SASLUser:     "gortbot",
+		SASLPassword: "wrong",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := a.Listen(ctx)
+	m.accept()
+
+	m.send(
+		":irc.example.com CAP * ACK :sasl",
+		":irc.example.com 904 * :SASL authentication failed",
+	)
+
+	e := adaptertest.NextEvent(t, events)
+	assert.Equal(t, adapter.EventAuthenticationError, e.EventType)
+
+	e = adaptertest.NextEvent(t, events)
+	assert.Equal(t, adapter.EventDisconnected, e.EventType)
+}
+
+func TestParseMessage(t *testing.T) {
+	m, err := parseMessage("@time=2021-01-01T00:00:00Z;bot :nick!user@host PRIVMSG #chan :hello there\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"time": "2021-01-01T00:00:00Z", "bot": ""}, m.Tags)
+	assert.Equal(t, "nick!user@host", m.Prefix)
+	assert.Equal(t, "nick", m.Nick())
+	assert.Equal(t, "PRIVMSG", m.Command)
+	assert.Equal(t, []string{"#chan", "hello there"}, m.Params)
+
+	m, err = parseMessage("ping irc.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "PING", m.Command)
+	assert.Equal(t, "irc.example.com", m.Param(0))
+	assert.Equal(t, "", m.Param(1))
+
+	_, err = parseMessage(":prefix-only")
+	assert.Error(t, err)
+
+	assert.Equal(t, "PRIVMSG #chan :hello there", formatMessage("PRIVMSG", "#chan", "hello there"))
+	assert.Equal(t, "PRIVMSG #chan :", formatMessage("PRIVMSG", "#chan", ""))
+	assert.Equal(t, "PRIVMSG #chan ::)", formatMessage("PRIVMSG", "#chan", ":)"))
+	assert.Equal(t, "JOIN #chan", formatMessage("JOIN", "#chan"))
+	assert.Equal(t, "QUIT", formatMessage("QUIT"))
+}
+
+func TestFloodLimiter(t *testing.T) {
+	l := newFloodLimiter(2, 20*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, l.wait(ctx))
+	}
+	assert.Less(t, int64(time.Since(start)), int64(20*time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.wait(ctx))
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(60*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	l = newFloodLimiter(1, time.Hour)
+	require.NoError(t, l.wait(ctx))
+	assert.Error(t, l.wait(ctx))
+}
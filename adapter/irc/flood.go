@@ -0,0 +1,74 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package irc
+
+import (
+	"context"
+	"time"
+)
+
+// Flood protection defaults. Most servers will disconnect a client that
+// sends more than a handful of messages in quick succession.
+const (
+	defaultFloodBurst = 4
+	defaultFloodDelay = time.Second
+)
+
+// floodLimiter paces outgoing messages. Up to burst messages are sent
+// immediately, after which messages are sent no faster than one per delay.
+type floodLimiter struct {
+	burst int
+	delay time.Duration
+	next  time.Time
+}
+
+func newFloodLimiter(burst int, delay time.Duration) *floodLimiter {
+	if burst <= 0 {
+		burst = defaultFloodBurst
+	}
+	if delay <= 0 {
+		delay = defaultFloodDelay
+	}
+
+	return &floodLimiter{burst: burst, delay: delay}
+}
+
+// wait blocks until another message may be sent, or until ctx is done.
+// It's not safe for concurrent use.
+func (l *floodLimiter) wait(ctx context.Context) error {
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(l.delay)
+
+	d := l.next.Sub(now) - time.Duration(l.burst)*l.delay
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.next = l.next.Add(-l.delay)
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
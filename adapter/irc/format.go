@@ -0,0 +1,218 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package irc
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/getgort/gort/templates"
+)
+
+// IRC formatting control codes.
+const (
+	codeBold      = "\x02"
+	codeColor     = "\x03"
+	codeMonospace = "\x11"
+	codeReset     = "\x0f"
+
+	colorRed = codeColor + "04"
+)
+
+// line is a single line of output, along with the formatting codes that
+// should be applied to it. The style is re-applied to every piece when a
+// long line is split.
+type line struct {
+	Text  string
+	Style string
+}
+
+// render converts output elements into lines of IRC text. IRC has no
+// structured messages, so each element is rendered via its alt text,
+// with titles set in bold and monospace text marked as such. If the
+// template provides an explicit alt element it's used as-is.
+func render(elements templates.OutputElements) []line {
+	for _, e := range elements.Elements {
+		if alt, ok := e.(*templates.Alt); ok {
+			return textLines(alt.Text, "")
+		}
+	}
+
+	var lines []line
+
+	if elements.Title != "" {
+		lines = append(lines, textLines(elements.Title, codeBold)...)
+	}
+
+	for _, e := range flatten(elements.Elements) {
+		switch t := e.(type) {
+		case *templates.Header:
+			lines = append(lines, textLines(t.Title, codeBold)...)
+
+		case *templates.Text:
+			lines = append(lines, textLines(t.Title, codeBold)...)
+			if t.Monospace {
+				lines = append(lines, textLines(t.Text, codeMonospace)...)
+			} else {
+				lines = append(lines, textLines(t.Text, "")...)
+			}
+
+		case templates.WithAlt:
+			lines = append(lines, textLines(t.Alt(), "")...)
+		}
+	}
+
+	return lines
+}
+
+// flatten replaces each section with its contents.
+func flatten(elements []templates.OutputElement) []templates.OutputElement {
+	var flattened []templates.OutputElement
+
+	for _, e := range elements {
+		if section, ok := e.(*templates.Section); ok {
+			if section.Text != nil {
+				flattened = append(flattened, section.Text)
+			}
+			flattened = append(flattened, section.Fields...)
+		} else {
+			flattened = append(flattened, e)
+		}
+	}
+
+	return flattened
+}
+
+// textLines splits text into lines with the given style. Either a carriage
+// return or a line feed ends a line, since both end an IRC message. Any
+// formatting codes in the text are removed, as are NULs and other control
+// characters apart from tabs, so that output can't inject protocol commands
+// or CTCP requests. Blank lines are dropped, since IRC can't send an empty
+// message.
+func textLines(text, style string) []line {
+	var lines []line
+
+	isLineEnd := func(r rune) bool { return r == '\n' || r == '\r' }
+
+	for _, s := range strings.FieldsFunc(text, isLineEnd) {
+		s = strings.Map(dropControl, stripFormatting(s))
+		s = strings.TrimRight(s, " \t")
+		if s != "" {
+			lines = append(lines, line{Text: s, Style: style})
+		}
+	}
+
+	return lines
+}
+
+// dropControl is a strings.Map function that removes control characters
+// other than tabs.
+func dropControl(r rune) rune {
+	if r != '\t' && unicode.IsControl(r) {
+		return -1
+	}
+	return r
+}
+
+// split breaks a line into pieces that, including formatting codes, are no
+// longer than max bytes. Where possible it breaks at a space.
+func (l line) split(max int) []string {
+	if l.Style != "" {
+		max -= len(l.Style) + len(codeReset)
+	}
+
+	var pieces []string
+	text := l.Text
+
+	for len(text) > max {
+		i := max
+		for i > 0 && !utf8.RuneStart(text[i]) {
+			i--
+		}
+		if sp := strings.LastIndexByte(text[:i], ' '); sp > 0 {
+			i = sp
+		}
+		if i == 0 {
+			// A single rune that's larger than max; send it anyway.
+			_, i = utf8.DecodeRuneInString(text)
+		}
+
+		pieces = append(pieces, text[:i])
+		text = strings.TrimLeft(text[i:], " ")
+	}
+
+	if text != "" {
+		pieces = append(pieces, text)
+	}
+
+	if l.Style != "" {
+		for i := range pieces {
+			pieces[i] = l.Style + pieces[i] + codeReset
+		}
+	}
+
+	return pieces
+}
+
+// stripFormatting removes IRC formatting control codes from text.
+func stripFormatting(text string) string {
+	if !strings.ContainsAny(text, "\x02\x03\x04\x0f\x11\x16\x1d\x1e\x1f") {
+		return text
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '\x03':
+			// Color: up to two digits, optionally followed by a comma and
+			// up to two more.
+			i += skipColor(text[i+1:], "0123456789", 2)
+		case '\x04':
+			// Hex color: six hex digits, optionally followed by a comma
+			// and six more.
+			i += skipColor(text[i+1:], "0123456789abcdefABCDEF", 6)
+		case '\x02', '\x0f', '\x11', '\x16', '\x1d', '\x1e', '\x1f':
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// skipColor returns the number of bytes taken up by the parameters of a
+// color code at the start of s.
+func skipColor(s, digits string, max int) int {
+	span := func(s string) int {
+		n := 0
+		for n < len(s) && n < max && strings.IndexByte(digits, s[n]) >= 0 {
+			n++
+		}
+		return n
+	}
+
+	n := span(s)
+	if n > 0 && n < len(s) && s[n] == ',' {
+		if m := span(s[n+1:]); m > 0 {
+			n += 1 + m
+		}
+	}
+
+	return n
+}
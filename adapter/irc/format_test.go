@@ -0,0 +1,117 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package irc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getgort/gort/templates"
+)
+
+func TestRender(t *testing.T) {
+	lines := render(templates.OutputElements{
+		Title: "Status",
+		Elements: []templates.OutputElement{
+			&templates.Section{
+				Text: &templates.Text{Text: "All systems go"},
+				Fields: []templates.OutputElement{
+					&templates.Text{Title: "CPU", Text: "12%"},
+				},
+			},
+			&templates.Divider{},
+			&templates.Text{Text: "line one\n\nline two", Monospace: true},
+			&templates.Image{URL: "https://example.com/graph.png"},
+		},
+	})
+
+	assert.Equal(t, []line{
+		{Text: "Status", Style: codeBold},
+		{Text: "All systems go"},
+		{Text: "CPU", Style: codeBold},
+		{Text: "12%"},
+		{Text: "==="},
+		{Text: "line one", Style: codeMonospace},
+		{Text: "line two", Style: codeMonospace},
+		{Text: "https://example.com/graph.png"},
+	}, lines)
+
+	// An explicit alt element takes precedence.
+	lines = render(templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Header{Title: "Status"},
+			&templates.Alt{Text: "all good"},
+		},
+	})
+	assert.Equal(t, []line{{Text: "all good"}}, lines)
+}
+
+func TestTextLines(t *testing.T) {
+	tests := map[string][]line{
+		"one\ntwo":                         {{Text: "one"}, {Text: "two"}},
+		"one\r\ntwo\r\n":                   {{Text: "one"}, {Text: "two"}},
+		"one\rPRIVMSG #other :injected":    {{Text: "one"}, {Text: "PRIVMSG #other :injected"}},
+		"nul\x00byte":                      {{Text: "nulbyte"}},
+		"\x01ACTION dances\x01":            {{Text: "ACTION dances"}},
+		"\x0304red\x0f and\tplain  ":       {{Text: "red and\tplain"}},
+		"\n\r\n  \n\x00\n":                 nil,
+		"del\x7f and c1 \u0085 characters": {{Text: "del and c1  characters"}},
+	}
+
+	for text, expected := range tests {
+		assert.Equal(t, expected, textLines(text, ""), "%q", text)
+	}
+}
+
+func TestLineSplit(t *testing.T) {
+	assert.Equal(t, []string{"short"}, line{Text: "short"}.split(10))
+
+	assert.Equal(t,
+		[]string{"the quick", "brown fox", "jumps"},
+		line{Text: "the quick brown fox jumps"}.split(10))
+
+	assert.Equal(t,
+		[]string{"\x11the\x0f", "\x11quick\x0f"},
+		line{Text: "the quick", Style: codeMonospace}.split(7))
+
+	// Multi-byte runes are never split.
+	pieces := line{Text: strings.Repeat("é", 10)}.split(5)
+	assert.Equal(t, []string{"éé", "éé", "éé", "éé", "éé"}, pieces)
+
+	long := line{Text: strings.Repeat("x", 1000)}.split(400)
+	assert.Len(t, long, 3)
+	for _, p := range long {
+		assert.LessOrEqual(t, len(p), 400)
+	}
+}
+
+func TestStripFormatting(t *testing.T) {
+	tests := map[string]string{
+		"plain":                        "plain",
+		"\x02bold\x02 text":            "bold text",
+		"\x0304red\x03 and \x0312,04x": "red and x",
+		"\x03,5comma":                  ",5comma",
+		"\x04ff0000hex\x0f":            "hex",
+		"\x1ditalic\x1d\x1funder\x1f":  "italicunder",
+	}
+
+	for test, expected := range tests {
+		assert.Equal(t, expected, stripFormatting(test), "%q", test)
+	}
+}
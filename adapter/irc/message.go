@@ -0,0 +1,130 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package irc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// message is a single IRC protocol message, as described by RFC 1459 and
+// extended by IRCv3 message tags.
+type message struct {
+	Tags    map[string]string
+	Prefix  string
+	Command string
+	Params  []string
+}
+
+// parseMessage parses a single line received from the server. The trailing
+// CR-LF, if any, is ignored.
+func parseMessage(line string) (*message, error) {
+	line = strings.TrimRight(line, "\r\n")
+	m := &message{}
+
+	if strings.HasPrefix(line, "@") {
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			return nil, fmt.Errorf("malformed message: %q", line)
+		}
+
+		m.Tags = map[string]string{}
+		for _, tag := range strings.Split(line[1:i], ";") {
+			kv := strings.SplitN(tag, "=", 2)
+			if len(kv) == 2 {
+				m.Tags[kv[0]] = kv[1]
+			} else {
+				m.Tags[kv[0]] = ""
+			}
+		}
+
+		line = strings.TrimLeft(line[i:], " ")
+	}
+
+	if strings.HasPrefix(line, ":") {
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			return nil, fmt.Errorf("malformed message: %q", line)
+		}
+
+		m.Prefix = line[1:i]
+		line = strings.TrimLeft(line[i:], " ")
+	}
+
+	for line != "" {
+		if strings.HasPrefix(line, ":") {
+			m.Params = append(m.Params, line[1:])
+			break
+		}
+
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			i = len(line)
+		}
+
+		if m.Command == "" {
+			m.Command = strings.ToUpper(line[:i])
+		} else {
+			m.Params = append(m.Params, line[:i])
+		}
+
+		line = strings.TrimLeft(line[i:], " ")
+	}
+
+	if m.Command == "" {
+		return nil, fmt.Errorf("malformed message: %q", line)
+	}
+
+	return m, nil
+}
+
+// Nick returns the nickname portion of the message prefix.
+func (m *message) Nick() string {
+	if i := strings.IndexAny(m.Prefix, "!@"); i >= 0 {
+		return m.Prefix[:i]
+	}
+	return m.Prefix
+}
+
+// Param returns the i'th parameter, or an empty string if there isn't one.
+func (m *message) Param(i int) string {
+	if i < len(m.Params) {
+		return m.Params[i]
+	}
+	return ""
+}
+
+// formatMessage builds a protocol line (without the trailing CR-LF). The
+// last parameter is sent as a trailing parameter if it needs to be.
+func formatMessage(command string, params ...string) string {
+	if len(params) == 0 {
+		return command
+	}
+
+	last := len(params) - 1
+	trailing := params[last]
+	if trailing == "" || strings.HasPrefix(trailing, ":") || strings.ContainsRune(trailing, ' ') {
+		trailing = ":" + trailing
+	}
+
+	return command + " " + strings.Join(append(params[:last:last], trailing), " ")
+}
+
+// isChannel returns true if target names a channel rather than a user.
+func isChannel(target string) bool {
+	return target != "" && strings.ContainsAny(target[:1], "#&+!")
+}
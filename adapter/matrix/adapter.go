@@ -0,0 +1,561 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+	"github.com/getgort/gort/templates"
+)
+
+var (
+	// retryDelay is how long the adapter waits before retrying a failed
+	// request to the homeserver.
+	retryDelay = 5 * time.Second
+
+	// syncTimeout is how long each /sync request waits for new events.
+	syncTimeout = 30 * time.Second
+)
+
+// initialSyncFilter is used for the first sync, which only serves to get
+// pending invites and a starting point: messages sent while Gort was
+// offline aren't replayed.
+const initialSyncFilter = `{"room":{"timeline":{"limit":0}}}`
+
+// NewAdapter will construct a Matrix Adapter instance for a given provider
+// configuration.
+func NewAdapter(provider data.MatrixProvider) (adapter.Adapter, error) {
+	if provider.Homeserver == "" {
+		return nil, fmt.Errorf("matrix provider %q has no homeserver", provider.Name)
+	}
+	if provider.AccessToken == "" {
+		return nil, fmt.Errorf("matrix provider %q has no access_token", provider.Name)
+	}
+
+	return &Adapter{
+		client:   newClient(provider.Homeserver, provider.AccessToken),
+		provider: provider,
+		members:  map[string][]string{},
+		dms:      map[string]string{},
+	}, nil
+}
+
+var _ adapter.Adapter = &Adapter{}
+var _ adapter.MessageEditor = &Adapter{}
+var _ adapter.DirectMessenger = &Adapter{}
+
+// Adapter is the Matrix provider implementation of a relay, which knows how
+// to receive events from a Matrix homeserver, translate them into Gort
+// events, and forward them along.
+type Adapter struct {
+	client   *client
+	provider data.MatrixProvider
+	events   chan *adapter.ProviderEvent
+
+	// mu guards the fields below. members caches the user IDs of the
+	// members of each room; an entry is dropped when membership changes.
+	// dms caches the direct message room of each user.
+	mu          sync.Mutex
+	self        string
+	displayName string
+	members     map[string][]string
+	dms         map[string]string
+}
+
+// GetChannelInfo provides info on a specific room accessible to the
+// adapter.
+func (s *Adapter) GetChannelInfo(channelID string) (*adapter.ChannelInfo, error) {
+	ctx := context.Background()
+
+	members, err := s.roomMembers(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := s.client.roomName(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = channelID
+	}
+
+	return &adapter.ChannelInfo{
+		ID:      channelID,
+		Name:    name,
+		Members: members,
+	}, nil
+}
+
+// GetDirectMessageChannel returns the ID of a direct message room shared
+// by the bot and the specified user. If there isn't one a new room is
+// created and the user is invited to it.
+func (s *Adapter) GetDirectMessageChannel(userID string) (string, error) {
+	ctx := context.Background()
+
+	s.mu.Lock()
+	roomID, ok := s.dms[userID]
+	s.mu.Unlock()
+
+	if ok {
+		return roomID, nil
+	}
+
+	rooms, err := s.client.joinedRooms(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, id := range rooms {
+		members, err := s.roomMembers(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if len(members) == 2 && (members[0] == userID || members[1] == userID) {
+			roomID = id
+			break
+		}
+	}
+
+	if roomID == "" {
+		if roomID, err = s.client.createDirectRoom(ctx, userID); err != nil {
+			return "", err
+		}
+	}
+
+	s.mu.Lock()
+	s.dms[userID] = roomID
+	s.mu.Unlock()
+
+	return roomID, nil
+}
+
+// GetName provides the name of this adapter as per the configuration.
+func (s *Adapter) GetName() string {
+	return s.provider.Name
+}
+
+// GetPresentChannels returns a slice of the rooms that the bot has joined,
+// excluding direct message rooms.
+func (s *Adapter) GetPresentChannels() ([]*adapter.ChannelInfo, error) {
+	rooms, err := s.client.joinedRooms(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]*adapter.ChannelInfo, 0, len(rooms))
+
+	for _, id := range rooms {
+		info, err := s.GetChannelInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		if len(info.Members) > 2 {
+			channels = append(channels, info)
+		}
+	}
+
+	return channels, nil
+}
+
+// GetUserInfo provides info on a specific Matrix user, identified by their
+// full user ID ("@alice:example.com").
+func (s *Adapter) GetUserInfo(userID string) (*adapter.UserInfo, error) {
+	p, err := s.client.getProfile(context.Background(), userID)
+	if isStatus(err, http.StatusNotFound) {
+		return nil, errs.ErrNoSuchUser
+	} else if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	self := s.self
+	s.mu.Unlock()
+
+	return newUserInfoFromMatrixUser(userID, p, serverName(self)), nil
+}
+
+// Listen causes the Adapter to initiate a connection to its provider and
+// begin relaying back events (including errors) via the returned channel.
+func (s *Adapter) Listen(ctx context.Context) <-chan *adapter.ProviderEvent {
+	s.events = make(chan *adapter.ProviderEvent, 100)
+
+	go func() {
+		le := log.WithField("adapter", s.GetName())
+		le.WithField("homeserver", s.provider.Homeserver).Info("Connecting to Matrix homeserver")
+
+		since, ok := s.connect(ctx)
+		if !ok {
+			return
+		}
+
+		s.events <- s.wrapEvent(adapter.EventConnected, &adapter.ConnectedEvent{})
+
+		for ctx.Err() == nil {
+			resp, err := s.client.sync(ctx, since, syncTimeout, "")
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+
+				le.WithError(err).Warn("Matrix sync failed")
+				s.events <- s.wrapEvent(adapter.EventConnectionError, &adapter.ErrorEvent{Msg: err.Error()})
+				s.sleep(ctx, retryDelay)
+				continue
+			}
+
+			since = resp.NextBatch
+			s.onSync(ctx, resp)
+		}
+
+		s.events <- s.wrapEvent(adapter.EventDisconnected, &adapter.DisconnectedEvent{Intentional: true})
+	}()
+
+	return s.events
+}
+
+// Send the contents of a response envelope to a specified channel. If
+// channelID is empty the value of envelope.Request.ChannelID will be used.
+func (s *Adapter) Send(ctx context.Context, channelID string, elements templates.OutputElements) error {
+	content, err := buildContent(elements)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.sendMessage(ctx, channelID, content)
+	return err
+}
+
+// SendText sends a simple text message to the specified channel.
+func (s *Adapter) SendText(ctx context.Context, channelID string, message string) error {
+	_, err := s.SendTextEditable(ctx, channelID, message)
+	return err
+}
+
+// SendTextEditable sends a text message to a specified room, and returns
+// an ID of the form "room/event" that can be passed to EditText.
+func (s *Adapter) SendTextEditable(ctx context.Context, channelID string, message string) (string, error) {
+	eventID, err := s.client.sendMessage(ctx, channelID, messageContent{
+		MsgType: msgTypeNotice,
+		Body:    message,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return channelID + "/" + eventID, nil
+}
+
+// EditText replaces the text of a message sent by SendTextEditable.
+func (s *Adapter) EditText(ctx context.Context, messageID string, message string) error {
+	parts := strings.SplitN(messageID, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid message id: %q", messageID)
+	}
+
+	_, err := s.client.sendMessage(ctx, parts[0], messageContent{
+		MsgType:    msgTypeNotice,
+		Body:       "* " + message,
+		NewContent: &messageContent{MsgType: msgTypeNotice, Body: message},
+		RelatesTo:  &relatesTo{RelType: "m.replace", EventID: parts[1]},
+	})
+	return err
+}
+
+// SendError is a break-glass error message function that's used when the
+// templating function fails somehow. Obviously, it does not utilize the
+// templating engine.
+func (s *Adapter) SendError(ctx context.Context, channelID string, title string, err error) error {
+	if title == "" {
+		title = "Unhandled Error"
+	}
+
+	_, err = s.client.sendMessage(ctx, channelID, messageContent{
+		MsgType: msgTypeNotice,
+		Body:    title + ": " + err.Error(),
+		Format:  formatHTML,
+		FormattedBody: fmt.Sprintf(`<h4><font color="#FF0000">%s</font></h4><pre><code>%s</code></pre>`,
+			html.EscapeString(title), html.EscapeString(err.Error())),
+	})
+	return err
+}
+
+// connect identifies the bot's account and performs the initial sync,
+// retrying until it succeeds or ctx is done. It returns the token to sync
+// from, and false if the adapter should give up.
+func (s *Adapter) connect(ctx context.Context) (string, bool) {
+	for {
+		since, err := s.initialSync(ctx)
+		if err == nil {
+			return since, true
+		}
+		if ctx.Err() != nil {
+			return "", false
+		}
+
+		if isStatus(err, http.StatusUnauthorized) {
+			s.events <- s.wrapEvent(
+				adapter.EventAuthenticationError,
+				&adapter.AuthenticationErrorEvent{
+					Msg: fmt.Sprintf("Connection failed to %s: invalid access token", s.provider.Name),
+				},
+			)
+			return "", false
+		}
+
+		s.events <- s.wrapEvent(adapter.EventConnectionError, &adapter.ErrorEvent{Msg: err.Error()})
+		s.sleep(ctx, retryDelay)
+	}
+}
+
+func (s *Adapter) initialSync(ctx context.Context) (string, error) {
+	self, err := s.client.whoami(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var displayName string
+	if p, err := s.client.getProfile(ctx, self); err == nil {
+		displayName = p.DisplayName
+	}
+
+	s.mu.Lock()
+	s.self = self
+	s.displayName = displayName
+	s.mu.Unlock()
+
+	resp, err := s.client.sync(ctx, "", 0, initialSyncFilter)
+	if err != nil {
+		return "", err
+	}
+
+	s.onSync(ctx, resp)
+
+	return resp.NextBatch, nil
+}
+
+// onSync handles the events in a sync response.
+func (s *Adapter) onSync(ctx context.Context, resp *syncResponse) {
+	for roomID := range resp.Rooms.Invite {
+		s.onInvite(ctx, roomID)
+	}
+
+	for roomID, room := range resp.Rooms.Join {
+		for _, ev := range room.Timeline.Events {
+			switch ev.Type {
+			case "m.room.member":
+				s.mu.Lock()
+				delete(s.members, roomID)
+				s.mu.Unlock()
+
+			case "m.room.message":
+				if pe := s.onMessage(ctx, roomID, ev); pe != nil {
+					s.events <- pe
+				}
+			}
+		}
+	}
+}
+
+// onInvite is called when the bot is invited to a room, which it joins.
+func (s *Adapter) onInvite(ctx context.Context, roomID string) {
+	le := log.WithField("adapter", s.GetName()).WithField("room", roomID)
+
+	if err := s.client.joinRoom(ctx, roomID); err != nil {
+		le.WithError(err).Error("Failed to join Matrix room")
+		return
+	}
+
+	le.Info("Joined Matrix room")
+}
+
+// onMessage is called when a message is sent to a room that the bot is in.
+func (s *Adapter) onMessage(ctx context.Context, roomID string, ev event) *adapter.ProviderEvent {
+	var content messageContent
+	if err := json.Unmarshal(ev.Content, &content); err != nil {
+		return nil
+	}
+
+	// Only plain text is of interest, and edits are not re-executed.
+	if content.MsgType != msgTypeText && content.MsgType != msgTypeNotice {
+		return nil
+	}
+	if content.RelatesTo != nil && content.RelatesTo.RelType == "m.replace" {
+		return nil
+	}
+
+	s.mu.Lock()
+	self, displayName := s.self, s.displayName
+	s.mu.Unlock()
+
+	text := content.Body
+	if content.RelatesTo != nil && content.RelatesTo.InReplyTo != nil {
+		text = stripReplyFallback(text)
+	}
+
+	isSelf := ev.Sender == self
+	isBot := content.MsgType == msgTypeNotice
+
+	members, err := s.roomMembers(ctx, roomID)
+	if err != nil {
+		log.WithError(err).WithField("adapter", s.GetName()).
+			WithField("room", roomID).Warn("Failed to get Matrix room members")
+	}
+
+	if len(members) == 2 {
+		return s.wrapEvent(
+			adapter.EventDirectMessage,
+			&adapter.DirectMessageEvent{
+				ChannelID: roomID,
+				Text:      text,
+				UserID:    ev.Sender,
+				IsBot:     isBot,
+				IsSelf:    isSelf,
+			},
+		)
+	}
+
+	text, mentioned := adapter.StripMention(text, self, displayName, localpart(self))
+	if content.Mentions != nil {
+		for _, id := range content.Mentions.UserIDs {
+			mentioned = mentioned || id == self
+		}
+	}
+
+	return s.wrapEvent(
+		adapter.EventChannelMessage,
+		&adapter.ChannelMessageEvent{
+			ChannelID: roomID,
+			Text:      text,
+			UserID:    ev.Sender,
+			IsBot:     isBot,
+			IsSelf:    isSelf,
+			IsMention: mentioned,
+		},
+	)
+}
+
+// roomMembers returns the sorted user IDs of the members of a room. A room
+// that the bot isn't in is reported as not found.
+func (s *Adapter) roomMembers(ctx context.Context, roomID string) ([]string, error) {
+	s.mu.Lock()
+	members, ok := s.members[roomID]
+	s.mu.Unlock()
+
+	if ok {
+		return members, nil
+	}
+
+	joined, err := s.client.joinedMembers(ctx, roomID)
+	if isStatus(err, http.StatusForbidden) || isStatus(err, http.StatusNotFound) {
+		return nil, adapter.ErrChannelNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	members = make([]string, 0, len(joined))
+	for id := range joined {
+		members = append(members, id)
+	}
+	sort.Strings(members)
+
+	s.mu.Lock()
+	s.members[roomID] = members
+	s.mu.Unlock()
+
+	return members, nil
+}
+
+func (s *Adapter) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// wrapEvent creates a new ProviderEvent instance with metadata and the Event data attached.
+func (s *Adapter) wrapEvent(eventType adapter.EventType, data interface{}) *adapter.ProviderEvent {
+	return &adapter.ProviderEvent{
+		EventType: eventType,
+		Data:      data,
+		Info: &adapter.Info{
+			Provider: adapter.NewProviderInfoFromConfig(s.provider),
+		},
+		Adapter: s,
+	}
+}
+
+func isStatus(err error, status int) bool {
+	var e *apiError
+	return errors.As(err, &e) && e.StatusCode == status
+}
+
+// localpart returns the "alice" in "@alice:example.com".
+func localpart(userID string) string {
+	id := strings.TrimPrefix(userID, "@")
+	if i := strings.IndexByte(id, ':'); i >= 0 {
+		return id[:i]
+	}
+	return id
+}
+
+// serverName returns the "example.com" in "@alice:example.com".
+func serverName(userID string) string {
+	if i := strings.IndexByte(userID, ':'); i >= 0 {
+		return userID[i+1:]
+	}
+	return ""
+}
+
+// newUserInfoFromMatrixUser builds a UserInfo for a Matrix user. Users on
+// the bot's own homeserver are named by their localpart; users from other
+// homeservers keep their server name, so that "@alice:example.com" and
+// "@alice:example.org" don't collide when Gort users are created for them.
+func newUserInfoFromMatrixUser(userID string, p *profile, homeserver string) *adapter.UserInfo {
+	name := strings.TrimPrefix(userID, "@")
+	if serverName(userID) == homeserver {
+		name = localpart(userID)
+	}
+
+	displayName := p.DisplayName
+	if displayName == "" {
+		displayName = localpart(userID)
+	}
+
+	return &adapter.UserInfo{
+		ID:                    userID,
+		Name:                  name,
+		DisplayName:           displayName,
+		DisplayNameNormalized: displayName,
+		RealName:              displayName,
+		RealNameNormalized:    displayName,
+	}
+}
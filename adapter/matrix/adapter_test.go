@@ -0,0 +1,446 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/adapter/adaptertest"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+	"github.com/getgort/gort/templates"
+)
+
+// sent is a message received by the mock homeserver.
+type sent struct {
+	RoomID  string
+	Content messageContent
+}
+
+// mockServer is a stand-in for the small part of the Matrix client-server
+// API that the adapter uses.
+type mockServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	joined  []string
+	created []string
+	sent    []sent
+}
+
+var mockRooms = map[string][]string{
+	"!ops:example.com": {"@gort:example.com", "@alice:example.com", "@bob:example.com"},
+	"!dm:example.com":  {"@gort:example.com", "@alice:example.com"},
+}
+
+// mockSyncs are the timeline events returned by successive syncs, keyed by
+// the since token.
+var mockSyncs = map[string]string{
+	"": `{"next_batch": "s1", "rooms": {"invite": {"!new:example.com": {"invite_state": {"events": []}}}}}`,
+
+	"s1": `{"next_batch": "s2", "rooms": {"join": {"!ops:example.com": {"timeline": {"events": [
+		{"type": "m.room.message", "sender": "@alice:example.com", "event_id": "$1",
+			"content": {"msgtype": "m.text", "body": "Gort: echo foo"}},
+		{"type": "m.room.message", "sender": "@alice:example.com", "event_id": "$2",
+			"content": {"msgtype": "m.text", "body": "* Gort: echo bar",
+				"m.new_content": {"msgtype": "m.text", "body": "Gort: echo bar"},
+				"m.relates_to": {"rel_type": "m.replace", "event_id": "$1"}}},
+		{"type": "m.room.message", "sender": "@alice:example.com", "event_id": "$3",
+			"content": {"msgtype": "m.image", "body": "cat.png"}},
+		{"type": "m.room.message", "sender": "@bob:example.com", "event_id": "$4",
+			"content": {"msgtype": "m.text", "body": "can you help, Gort?",
+				"m.mentions": {"user_ids": ["@gort:example.com"]}}},
+		{"type": "m.room.message", "sender": "@gort:example.com", "event_id": "$5",
+			"content": {"msgtype": "m.notice", "body": "hello"}}
+	]}}}}}`,
+
+	"s2": `{"next_batch": "s3", "rooms": {"join": {"!dm:example.com": {"timeline": {"events": [
+		{"type": "m.room.message", "sender": "@alice:example.com", "event_id": "$6",
+			"content": {"msgtype": "m.text", "body": "> <@bob:example.com> earlier\n\nwhoami",
+				"m.relates_to": {"m.in_reply_to": {"event_id": "$0"}}}}
+	]}}}}}`,
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	m := &mockServer{}
+	mux := http.NewServeMux()
+
+	writeError := func(w http.ResponseWriter, status int, code string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(apiError{ErrCode: code, Message: code})
+	}
+
+	mux.HandleFunc("/_matrix/client/v3/account/whoami", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			writeError(w, http.StatusUnauthorized, "M_UNKNOWN_TOKEN")
+			return
+		}
+		w.Write([]byte(`{"user_id": "@gort:example.com"}`))
+	})
+
+	mux.HandleFunc("/_matrix/client/v3/profile/", func(w http.ResponseWriter, r *http.Request) {
+		names := map[string]string{
+			"@gort:example.com":  "Gort",
+			"@alice:example.com": "Alice",
+			"@carol:example.org": "",
+		}
+
+		name, ok := names[strings.TrimPrefix(r.URL.Path, "/_matrix/client/v3/profile/")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "M_NOT_FOUND")
+			return
+		}
+		json.NewEncoder(w).Encode(profile{DisplayName: name})
+	})
+
+	mux.HandleFunc("/_matrix/client/v3/sync", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := mockSyncs[r.URL.Query().Get("since")]
+		if !ok {
+			// Nothing new: wait out the long poll.
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			body = `{"next_batch": "` + r.URL.Query().Get("since") + `"}`
+		}
+		w.Write([]byte(body))
+	})
+
+	mux.HandleFunc("/_matrix/client/v3/createRoom", func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			IsDirect bool     `json:"is_direct"`
+			Invite   []string `json:"invite"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		require.True(t, in.IsDirect)
+
+		m.mu.Lock()
+		m.created = append(m.created, in.Invite...)
+		m.mu.Unlock()
+		w.Write([]byte(`{"room_id": "!newdm:example.com"}`))
+	})
+
+	mux.HandleFunc("/_matrix/client/v3/joined_rooms", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"joined_rooms": ["!dm:example.com", "!ops:example.com"]}`))
+	})
+
+	mux.HandleFunc("/_matrix/client/v3/rooms/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/_matrix/client/v3/rooms/"), "/", 2)
+		roomID, rest := parts[0], parts[1]
+
+		switch {
+		case rest == "join":
+			m.mu.Lock()
+			m.joined = append(m.joined, roomID)
+			m.mu.Unlock()
+			w.Write([]byte(`{"room_id": "` + roomID + `"}`))
+
+		case rest == "joined_members":
+			members, ok := mockRooms[roomID]
+			if !ok {
+				writeError(w, http.StatusForbidden, "M_FORBIDDEN")
+				return
+			}
+			joined := map[string]profile{}
+			for _, id := range members {
+				joined[id] = profile{}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"joined": joined})
+
+		case rest == "state/m.room.name":
+			if roomID != "!ops:example.com" {
+				writeError(w, http.StatusNotFound, "M_NOT_FOUND")
+				return
+			}
+			w.Write([]byte(`{"name": "Ops"}`))
+
+		case strings.HasPrefix(rest, "send/m.room.message/"):
+			var c messageContent
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+
+			m.mu.Lock()
+			m.sent = append(m.sent, sent{RoomID: roomID, Content: c})
+			m.mu.Unlock()
+			w.Write([]byte(`{"event_id": "$sent"}`))
+
+		default:
+			writeError(w, http.StatusNotFound, "M_UNRECOGNIZED")
+		}
+	})
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func (m *mockServer) Joined() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.joined...)
+}
+
+func (m *mockServer) Created() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.created...)
+}
+
+func (m *mockServer) Sent() []sent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]sent(nil), m.sent...)
+}
+
+func newTestAdapter(t *testing.T, m *mockServer) *Adapter {
+	a, err := NewAdapter(data.MatrixProvider{
+		AbstractProvider: data.AbstractProvider{Name: "matrix"},
+		Homeserver:       m.URL,
+		AccessToken:      "token",
+	})
+	require.NoError(t, err)
+	return a.(*Adapter)
+}
+
+func TestNewAdapter(t *testing.T) {
+	_, err := NewAdapter(data.MatrixProvider{AccessToken: "token"})
+	assert.Error(t, err)
+
+	_, err = NewAdapter(data.MatrixProvider{Homeserver: "https://matrix.example.com"})
+	assert.Error(t, err)
+}
+
+func TestListen(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := a.Listen(ctx)
+
+	e := adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventConnected, e.EventType)
+	assert.Equal(t, "matrix", e.Info.Provider.Type)
+
+	// Invites are accepted during the initial sync.
+	assert.Equal(t, []string{"!new:example.com"}, m.Joined())
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "!ops:example.com",
+		Text:      "echo foo",
+		UserID:    "@alice:example.com",
+		IsMention: true,
+	}, e.Data)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "!ops:example.com",
+		Text:      "can you help, Gort?",
+		UserID:    "@bob:example.com",
+		IsMention: true,
+	}, e.Data)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "!ops:example.com",
+		Text:      "hello",
+		UserID:    "@gort:example.com",
+		IsBot:     true,
+		IsSelf:    true,
+	}, e.Data)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventDirectMessage, e.EventType)
+	assert.Equal(t, &adapter.DirectMessageEvent{
+		ChannelID: "!dm:example.com",
+		Text:      "whoami",
+		UserID:    "@alice:example.com",
+	}, e.Data)
+
+	cancel()
+
+	e = adaptertest.NextEvent(t, events)
+	assert.Equal(t, adapter.EventDisconnected, e.EventType)
+}
+
+func TestListenBadToken(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m)
+	a.client.token = "wrong"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := adaptertest.NextEvent(t, a.Listen(ctx))
+	assert.Equal(t, adapter.EventAuthenticationError, e.EventType)
+}
+
+func TestGetUserInfo(t *testing.T) {
+	a := newTestAdapter(t, newMockServer(t))
+	a.self = "@gort:example.com"
+
+	info, err := a.GetUserInfo("@alice:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, &adapter.UserInfo{
+		ID:                    "@alice:example.com",
+		Name:                  "alice",
+		DisplayName:           "Alice",
+		DisplayNameNormalized: "Alice",
+		RealName:              "Alice",
+		RealNameNormalized:    "Alice",
+	}, info)
+
+	// Users from other homeservers keep their server name.
+	info, err = a.GetUserInfo("@carol:example.org")
+	require.NoError(t, err)
+	assert.Equal(t, "carol:example.org", info.Name)
+	assert.Equal(t, "carol", info.DisplayName)
+
+	_, err = a.GetUserInfo("@nobody:example.com")
+	assert.ErrorIs(t, err, errs.ErrNoSuchUser)
+}
+
+func TestGetChannelInfo(t *testing.T) {
+	a := newTestAdapter(t, newMockServer(t))
+
+	info, err := a.GetChannelInfo("!ops:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Ops", info.Name)
+	assert.Equal(t, []string{"@alice:example.com", "@bob:example.com", "@gort:example.com"}, info.Members)
+
+	info, err = a.GetChannelInfo("!dm:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "!dm:example.com", info.Name)
+
+	_, err = a.GetChannelInfo("!elsewhere:example.com")
+	assert.ErrorIs(t, err, adapter.ErrChannelNotFound)
+
+	// Direct message rooms aren't included.
+	channels, err := a.GetPresentChannels()
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, "!ops:example.com", channels[0].ID)
+}
+
+func TestGetDirectMessageChannel(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m)
+
+	id, err := a.GetDirectMessageChannel("@alice:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "!dm:example.com", id)
+	assert.Empty(t, m.Created())
+
+	id, err = a.GetDirectMessageChannel("@bob:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "!newdm:example.com", id)
+
+	// The new room is remembered rather than created again.
+	id, err = a.GetDirectMessageChannel("@bob:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "!newdm:example.com", id)
+	assert.Equal(t, []string{"@bob:example.com"}, m.Created())
+}
+
+func TestSend(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m)
+	ctx := context.Background()
+
+	id, err := a.SendTextEditable(ctx, "!ops:example.com", "working")
+	require.NoError(t, err)
+	assert.Equal(t, "!ops:example.com/$sent", id)
+
+	require.NoError(t, a.EditText(ctx, id, "done"))
+
+	require.NoError(t, a.Send(ctx, "!ops:example.com", templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Header{Title: "Results", Color: "#00FF00"},
+			&templates.Text{Text: "a < b", Monospace: true},
+		},
+	}))
+
+	s := m.Sent()
+	require.Len(t, s, 3)
+
+	assert.Equal(t, messageContent{MsgType: msgTypeNotice, Body: "working"}, s[0].Content)
+
+	assert.Equal(t, messageContent{
+		MsgType:    msgTypeNotice,
+		Body:       "* done",
+		NewContent: &messageContent{MsgType: msgTypeNotice, Body: "done"},
+		RelatesTo:  &relatesTo{RelType: "m.replace", EventID: "$sent"},
+	}, s[1].Content)
+
+	assert.Equal(t, "!ops:example.com", s[2].RoomID)
+	assert.Equal(t, formatHTML, s[2].Content.Format)
+	assert.Equal(t, `<h4><font color="#00FF00">Results</font></h4><pre><code>a &lt; b</code></pre>`, s[2].Content.FormattedBody)
+	assert.Equal(t, "Results\n\na < b", s[2].Content.Body)
+}
+
+func TestBuildContent(t *testing.T) {
+	c, err := buildContent(templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Text{Text: "line one"},
+			&templates.Text{Text: "line two"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, messageContent{MsgType: msgTypeNotice, Body: "line one\nline two"}, c)
+
+	elements := templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Section{
+				Text: &templates.Text{Text: "summary"},
+				Fields: []templates.OutputElement{
+					&templates.Text{Title: "CPU", Text: "12%"},
+				},
+			},
+			&templates.Divider{},
+			&templates.Image{URL: "https://example.com/a.png"},
+			&templates.Alt{Text: "summary: CPU 12%"},
+		},
+	}
+
+	c, err = buildContent(elements)
+	require.NoError(t, err)
+	assert.Equal(t, "summary: CPU 12%", c.Body)
+	assert.Equal(t,
+		`<p>summary</p><p><strong>CPU</strong></p><p>12%</p><hr/><p><a href="https://example.com/a.png">https://example.com/a.png</a></p>`,
+		c.FormattedBody)
+}
+
+func TestStripReplyFallback(t *testing.T) {
+	assert.Equal(t, "whoami", stripReplyFallback("> <@bob:example.com> earlier\n> more\n\nwhoami"))
+	assert.Equal(t, "plain", stripReplyFallback("plain"))
+}
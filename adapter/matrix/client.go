@@ -0,0 +1,255 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// client is a minimal client for the parts of the Matrix client-server API
+// that the adapter uses.
+type client struct {
+	// txnID is used to generate transaction IDs, which make sends
+	// idempotent. It's seeded with the time so that IDs aren't reused
+	// across restarts. It's first so that it's 64-bit aligned for atomic
+	// operations on 32-bit platforms.
+	txnID int64
+
+	homeserver string
+	token      string
+	http       *http.Client
+}
+
+// apiError is the body of an unsuccessful Matrix API response.
+type apiError struct {
+	ErrCode    string `json:"errcode"`
+	Message    string `json:"error"`
+	StatusCode int    `json:"-"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("matrix: %s: %s (%d)", e.ErrCode, e.Message, e.StatusCode)
+}
+
+// event is a room event, as found in a sync response.
+type event struct {
+	Type     string          `json:"type"`
+	Sender   string          `json:"sender"`
+	EventID  string          `json:"event_id"`
+	StateKey *string         `json:"state_key,omitempty"`
+	Content  json.RawMessage `json:"content"`
+}
+
+// messageContent is the content of an m.room.message event.
+type messageContent struct {
+	MsgType       string          `json:"msgtype"`
+	Body          string          `json:"body"`
+	Format        string          `json:"format,omitempty"`
+	FormattedBody string          `json:"formatted_body,omitempty"`
+	NewContent    *messageContent `json:"m.new_content,omitempty"`
+	RelatesTo     *relatesTo      `json:"m.relates_to,omitempty"`
+	Mentions      *mentions       `json:"m.mentions,omitempty"`
+}
+
+type relatesTo struct {
+	RelType   string `json:"rel_type,omitempty"`
+	EventID   string `json:"event_id,omitempty"`
+	InReplyTo *struct {
+		EventID string `json:"event_id"`
+	} `json:"m.in_reply_to,omitempty"`
+}
+
+type mentions struct {
+	UserIDs []string `json:"user_ids,omitempty"`
+}
+
+type syncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []event `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+		Invite map[string]struct {
+			InviteState struct {
+				Events []event `json:"events"`
+			} `json:"invite_state"`
+		} `json:"invite"`
+	} `json:"rooms"`
+}
+
+type profile struct {
+	DisplayName string `json:"displayname"`
+}
+
+func newClient(homeserver, token string) *client {
+	return &client{
+		homeserver: strings.TrimSuffix(homeserver, "/"),
+		token:      token,
+		http:       http.DefaultClient,
+		txnID:      time.Now().UnixNano(),
+	}
+}
+
+// do sends a request to the API, encoding in (if not nil) as the request
+// body and decoding the response body into out (if not nil).
+func (c *client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	u := c.homeserver + "/_matrix/client/v3" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		e := &apiError{Message: resp.Status}
+		json.NewDecoder(resp.Body).Decode(e)
+		e.StatusCode = resp.StatusCode
+		return e
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// whoami returns the user ID that the access token belongs to.
+func (c *client) whoami(ctx context.Context) (string, error) {
+	var resp struct {
+		UserID string `json:"user_id"`
+	}
+	return resp.UserID, c.do(ctx, http.MethodGet, "/account/whoami", nil, nil, &resp)
+}
+
+// sync long-polls for events that have occurred since the since token, for
+// up to timeout. The filter, if not empty, is an inline JSON filter.
+func (c *client) sync(ctx context.Context, since string, timeout time.Duration, filter string) (*syncResponse, error) {
+	q := url.Values{}
+	q.Set("timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+	if since != "" {
+		q.Set("since", since)
+	}
+	if filter != "" {
+		q.Set("filter", filter)
+	}
+
+	resp := &syncResponse{}
+	return resp, c.do(ctx, http.MethodGet, "/sync", q, nil, resp)
+}
+
+func (c *client) joinRoom(ctx context.Context, roomID string) error {
+	return c.do(ctx, http.MethodPost, "/rooms/"+url.PathEscape(roomID)+"/join", nil, struct{}{}, nil)
+}
+
+// createDirectRoom creates a private room flagged as a direct chat and
+// invites the user to it, returning the new room's ID.
+func (c *client) createDirectRoom(ctx context.Context, userID string) (string, error) {
+	in := map[string]interface{}{
+		"is_direct": true,
+		"invite":    []string{userID},
+		"preset":    "trusted_private_chat",
+	}
+
+	var resp struct {
+		RoomID string `json:"room_id"`
+	}
+	return resp.RoomID, c.do(ctx, http.MethodPost, "/createRoom", nil, in, &resp)
+}
+
+func (c *client) joinedRooms(ctx context.Context) ([]string, error) {
+	var resp struct {
+		JoinedRooms []string `json:"joined_rooms"`
+	}
+	return resp.JoinedRooms, c.do(ctx, http.MethodGet, "/joined_rooms", nil, nil, &resp)
+}
+
+// joinedMembers returns the display names of a room's members, keyed by
+// user ID.
+func (c *client) joinedMembers(ctx context.Context, roomID string) (map[string]profile, error) {
+	var resp struct {
+		Joined map[string]profile `json:"joined"`
+	}
+	return resp.Joined, c.do(ctx, http.MethodGet, "/rooms/"+url.PathEscape(roomID)+"/joined_members", nil, nil, &resp)
+}
+
+// roomName returns the name of the room, or an empty string if it doesn't
+// have one.
+func (c *client) roomName(ctx context.Context, roomID string) (string, error) {
+	var resp struct {
+		Name string `json:"name"`
+	}
+
+	err := c.do(ctx, http.MethodGet, "/rooms/"+url.PathEscape(roomID)+"/state/m.room.name", nil, nil, &resp)
+	if e, ok := err.(*apiError); ok && e.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+
+	return resp.Name, err
+}
+
+func (c *client) getProfile(ctx context.Context, userID string) (*profile, error) {
+	p := &profile{}
+	return p, c.do(ctx, http.MethodGet, "/profile/"+url.PathEscape(userID), nil, nil, p)
+}
+
+// sendMessage sends an m.room.message event to a room, returning its
+// event ID.
+func (c *client) sendMessage(ctx context.Context, roomID string, content messageContent) (string, error) {
+	txnID := strconv.FormatInt(atomic.AddInt64(&c.txnID, 1), 10)
+
+	var resp struct {
+		EventID string `json:"event_id"`
+	}
+
+	path := "/rooms/" + url.PathEscape(roomID) + "/send/m.room.message/" + txnID
+	return resp.EventID, c.do(ctx, http.MethodPut, path, nil, content, &resp)
+}
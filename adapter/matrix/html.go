@@ -0,0 +1,143 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package matrix
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/getgort/gort/templates"
+)
+
+// Matrix message types and formats.
+const (
+	msgTypeText   = "m.text"
+	msgTypeNotice = "m.notice"
+	formatHTML    = "org.matrix.custom.html"
+)
+
+// buildContent renders output elements as the content of a Matrix message.
+// Output consisting only of plain text is sent as-is; anything else gets an
+// HTML formatted body, with the alt text as the plain-text fallback.
+//
+// Gort's messages are sent as notices which, by convention, other bots
+// don't respond to.
+func buildContent(elements templates.OutputElements) (messageContent, error) {
+	var flattened []templates.OutputElement
+
+	for _, e := range elements.Elements {
+		if section, ok := e.(*templates.Section); ok {
+			if section.Text != nil {
+				flattened = append(flattened, section.Text)
+			}
+			flattened = append(flattened, section.Fields...)
+		} else {
+			flattened = append(flattened, e)
+		}
+	}
+
+	var b strings.Builder
+	var plain []string
+	var textOnly = true
+
+	writeTitle := func(title, color string) {
+		if title == "" {
+			return
+		}
+		if color != "" {
+			fmt.Fprintf(&b, `<h4><font color="%s">%s</font></h4>`, html.EscapeString(color), html.EscapeString(title))
+		} else {
+			fmt.Fprintf(&b, "<h4>%s</h4>", html.EscapeString(title))
+		}
+		textOnly = false
+	}
+
+	writeTitle(elements.Title, elements.Color)
+
+	for _, e := range flattened {
+		switch t := e.(type) {
+		case *templates.Divider:
+			b.WriteString("<hr/>")
+			textOnly = false
+
+		case *templates.Image:
+			// Matrix clients only display images stored in the media
+			// repository, so others are linked instead.
+			fmt.Fprintf(&b, `<p><a href="%[1]s">%[1]s</a></p>`, html.EscapeString(t.URL))
+			textOnly = false
+
+		case *templates.Header:
+			writeTitle(t.Title, t.Color)
+
+		case *templates.Section:
+			// Sections are flattened above.
+
+		case *templates.Alt:
+			// Ignore Alt, only rendered as fallback
+
+		case *templates.Text:
+			if t.Title != "" {
+				fmt.Fprintf(&b, "<p><strong>%s</strong></p>", html.EscapeString(t.Title))
+				textOnly = false
+			}
+
+			if t.Monospace {
+				fmt.Fprintf(&b, "<pre><code>%s</code></pre>", html.EscapeString(t.Text))
+				textOnly = false
+			} else {
+				text := strings.ReplaceAll(html.EscapeString(t.Text), "\n", "<br/>")
+				fmt.Fprintf(&b, "<p>%s</p>", text)
+			}
+
+			plain = append(plain, t.Text)
+
+		default:
+			return messageContent{}, fmt.Errorf("%T fields are not yet supported by Gort for Matrix", e)
+		}
+	}
+
+	if textOnly {
+		return messageContent{
+			MsgType: msgTypeNotice,
+			Body:    strings.Join(plain, "\n"),
+		}, nil
+	}
+
+	return messageContent{
+		MsgType:       msgTypeNotice,
+		Body:          strings.TrimSpace(elements.Alt()),
+		Format:        formatHTML,
+		FormattedBody: b.String(),
+	}, nil
+}
+
+// stripReplyFallback removes the quoted text that clients prepend to the
+// body of a reply.
+func stripReplyFallback(body string) string {
+	lines := strings.Split(body, "\n")
+
+	i := 0
+	for i < len(lines) && strings.HasPrefix(lines[i], ">") {
+		i++
+	}
+	if i == 0 {
+		return body
+	}
+
+	return strings.TrimLeft(strings.Join(lines[i:], "\n"), "\n")
+}
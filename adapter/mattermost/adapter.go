@@ -0,0 +1,407 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mattermost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+	"github.com/getgort/gort/templates"
+)
+
+// reconnectDelay is how long the adapter waits before reconnecting after
+// its WebSocket connection fails or is lost.
+var reconnectDelay = 5 * time.Second
+
+// NewAdapter will construct a Mattermost Adapter instance for a given
+// provider configuration.
+func NewAdapter(provider data.MattermostProvider) (adapter.Adapter, error) {
+	if provider.URL == "" {
+		return nil, fmt.Errorf("mattermost provider %q has no url", provider.Name)
+	}
+	if provider.Token == "" {
+		return nil, fmt.Errorf("mattermost provider %q has no token", provider.Name)
+	}
+
+	return &Adapter{
+		client:   newClient(provider.URL, provider.Token),
+		provider: provider,
+	}, nil
+}
+
+var _ adapter.Adapter = &Adapter{}
+var _ adapter.MessageEditor = &Adapter{}
+var _ adapter.DirectMessenger = &Adapter{}
+
+// Adapter is the Mattermost provider implementation of a relay, which knows
+// how to receive events from the Mattermost API, translate them into Gort
+// events, and forward them along.
+type Adapter struct {
+	client   *client
+	provider data.MattermostProvider
+	self     *user
+	events   chan *adapter.ProviderEvent
+}
+
+// GetChannelInfo provides info on a specific provider channel accessible
+// to the adapter.
+func (s *Adapter) GetChannelInfo(channelID string) (*adapter.ChannelInfo, error) {
+	ctx := context.Background()
+
+	ch, err := s.client.getChannel(ctx, channelID)
+	if isNotFound(err) {
+		return nil, adapter.ErrChannelNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	members, err := s.client.getChannelMembers(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := newChannelInfoFromMattermostChannel(ch)
+	for _, m := range members {
+		info.Members = append(info.Members, m.UserID)
+	}
+
+	return info, nil
+}
+
+// GetDirectMessageChannel returns the ID of the direct message channel
+// between the bot and the specified user. Mattermost returns the existing
+// channel if there already is one.
+func (s *Adapter) GetDirectMessageChannel(userID string) (string, error) {
+	ctx := context.Background()
+
+	self, err := s.client.getMe(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ch, err := s.client.createDirectChannel(ctx, self.ID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return ch.ID, nil
+}
+
+// GetName provides the name of this adapter as per the configuration.
+func (s *Adapter) GetName() string {
+	return s.provider.Name
+}
+
+// GetPresentChannels returns a slice of the public and private channels, in
+// every team, that the bot is a member of.
+func (s *Adapter) GetPresentChannels() ([]*adapter.ChannelInfo, error) {
+	ctx := context.Background()
+
+	teams, err := s.client.getTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]*adapter.ChannelInfo, 0)
+
+	for _, t := range teams {
+		chs, err := s.client.getChannelsForTeam(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range chs {
+			if chs[i].Type == channelTypeOpen || chs[i].Type == channelTypePrivate {
+				channels = append(channels, newChannelInfoFromMattermostChannel(&chs[i]))
+			}
+		}
+	}
+
+	return channels, nil
+}
+
+// GetUserInfo provides info on a specific provider user accessible
+// to the adapter.
+func (s *Adapter) GetUserInfo(userID string) (*adapter.UserInfo, error) {
+	u, err := s.client.getUser(context.Background(), userID)
+	if isNotFound(err) {
+		return nil, errs.ErrNoSuchUser
+	} else if err != nil {
+		return nil, err
+	}
+
+	return newUserInfoFromMattermostUser(u), nil
+}
+
+// Listen causes the Adapter to initiate a connection to its provider and
+// begin relaying back events (including errors) via the returned channel.
+// If the connection fails or is lost it's re-established until ctx is done,
+// unless the token is rejected.
+func (s *Adapter) Listen(ctx context.Context) <-chan *adapter.ProviderEvent {
+	s.events = make(chan *adapter.ProviderEvent, 100)
+
+	go func() {
+		le := log.WithField("adapter", s.GetName())
+		le.WithField("provider", s.provider.Name).Info("Connecting to Mattermost provider")
+
+		for {
+			conn, err := s.connect(ctx)
+			if err != nil {
+				s.events <- s.onConnectError(err)
+				if isUnauthorized(err) {
+					return
+				}
+			} else {
+				for {
+					var ev wsEvent
+					if err := conn.ReadJSON(&ev); err != nil {
+						le.WithError(err).Debug("Mattermost event: read failed")
+						break
+					}
+
+					if pe := s.onEvent(&ev); pe != nil {
+						s.events <- pe
+					}
+				}
+
+				conn.Close()
+				s.events <- s.wrapEvent(adapter.EventDisconnected, &adapter.DisconnectedEvent{Intentional: ctx.Err() != nil})
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reconnectDelay):
+			}
+		}
+	}()
+
+	return s.events
+}
+
+// connect identifies the bot's account and opens a connection to the
+// WebSocket event stream.
+func (s *Adapter) connect(ctx context.Context) (*websocket.Conn, error) {
+	self, err := s.client.getMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.self = self
+
+	return s.client.connect(ctx)
+}
+
+// Send the contents of a response envelope to a specified channel. If
+// channelID is empty the value of envelope.Request.ChannelID will be used.
+func (s *Adapter) Send(ctx context.Context, channelID string, elements templates.OutputElements) error {
+	p, err := buildPost(elements)
+	if err != nil {
+		return err
+	}
+
+	p.ChannelID = channelID
+
+	_, err = s.client.createPost(ctx, p)
+	return err
+}
+
+// SendText sends a simple text message to the specified channel.
+func (s *Adapter) SendText(ctx context.Context, channelID string, message string) error {
+	_, err := s.client.createPost(ctx, post{ChannelID: channelID, Message: message})
+	return err
+}
+
+// SendTextEditable sends a simple text message to the specified channel,
+// returning the ID of the post, which can be passed to EditText.
+func (s *Adapter) SendTextEditable(ctx context.Context, channelID string, message string) (string, error) {
+	p, err := s.client.createPost(ctx, post{ChannelID: channelID, Message: message})
+	if err != nil {
+		return "", err
+	}
+
+	return p.ID, nil
+}
+
+// EditText replaces the text of a message sent by SendTextEditable.
+func (s *Adapter) EditText(ctx context.Context, messageID string, message string) error {
+	return s.client.patchPost(ctx, messageID, message)
+}
+
+// SendError is a break-glass error message function that's used when the
+// templating function fails somehow. Obviously, it does not utilize the
+// templating engine.
+func (s *Adapter) SendError(ctx context.Context, channelID string, title string, err error) error {
+	if title == "" {
+		title = "Unhandled Error"
+	}
+
+	_, err = s.client.createPost(ctx, post{
+		ChannelID: channelID,
+		Props: map[string]interface{}{
+			"attachments": []attachment{{
+				Fallback: title + ": " + err.Error(),
+				Color:    "#FF0000",
+				Title:    title,
+				Text:     err.Error(),
+			}},
+		},
+	})
+	return err
+}
+
+// onEvent translates a WebSocket event into a ProviderEvent, or returns nil
+// if it's not one that Gort is interested in.
+func (s *Adapter) onEvent(ev *wsEvent) *adapter.ProviderEvent {
+	switch ev.Event {
+	case "hello":
+		return s.wrapEvent(adapter.EventConnected, &adapter.ConnectedEvent{})
+	case "posted":
+		return s.onPosted(ev)
+	default:
+		return nil
+	}
+}
+
+// onPosted is called when a message is posted in any channel that the bot
+// is a member of.
+func (s *Adapter) onPosted(ev *wsEvent) *adapter.ProviderEvent {
+	raw, _ := ev.Data["post"].(string)
+
+	var p post
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		log.WithError(err).WithField("adapter", s.GetName()).
+			Debug("Mattermost event: malformed post")
+		return nil
+	}
+
+	// System messages, such as channel joins, have a type.
+	if p.Type != "" || p.Message == "" {
+		return nil
+	}
+
+	isSelf := s.self != nil && p.UserID == s.self.ID
+	isBot := p.Props["from_bot"] == "true"
+
+	if channelType, _ := ev.Data["channel_type"].(string); channelType == channelTypeDirect {
+		return s.wrapEvent(
+			adapter.EventDirectMessage,
+			&adapter.DirectMessageEvent{
+				ChannelID: p.ChannelID,
+				Text:      p.Message,
+				UserID:    p.UserID,
+				IsBot:     isBot,
+				IsSelf:    isSelf,
+			},
+		)
+	}
+
+	text, mentioned := p.Message, false
+	if s.self != nil {
+		text, mentioned = adapter.StripMention(p.Message, "@"+s.self.Username)
+	}
+
+	return s.wrapEvent(
+		adapter.EventChannelMessage,
+		&adapter.ChannelMessageEvent{
+			ChannelID: p.ChannelID,
+			Text:      text,
+			UserID:    p.UserID,
+			IsBot:     isBot,
+			IsSelf:    isSelf,
+			IsMention: mentioned,
+		},
+	)
+}
+
+// onConnectError is called when the adapter fails to connect to Mattermost.
+func (s *Adapter) onConnectError(err error) *adapter.ProviderEvent {
+	if isUnauthorized(err) {
+		return s.wrapEvent(
+			adapter.EventAuthenticationError,
+			&adapter.AuthenticationErrorEvent{
+				Msg: fmt.Sprintf("Connection failed to %s: invalid credentials", s.provider.Name),
+			},
+		)
+	}
+
+	return s.wrapEvent(
+		adapter.EventConnectionError,
+		&adapter.ErrorEvent{Msg: err.Error()},
+	)
+}
+
+// wrapEvent creates a new ProviderEvent instance with metadata and the Event data attached.
+func (s *Adapter) wrapEvent(eventType adapter.EventType, data interface{}) *adapter.ProviderEvent {
+	return &adapter.ProviderEvent{
+		EventType: eventType,
+		Data:      data,
+		Info: &adapter.Info{
+			Provider: adapter.NewProviderInfoFromConfig(s.provider),
+		},
+		Adapter: s,
+	}
+}
+
+// Mattermost channel types.
+const (
+	channelTypeOpen    = "O"
+	channelTypePrivate = "P"
+	channelTypeDirect  = "D"
+)
+
+func isNotFound(err error) bool {
+	var e *apiError
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+func isUnauthorized(err error) bool {
+	var e *apiError
+	return errors.As(err, &e) && e.StatusCode == http.StatusUnauthorized
+}
+
+func newChannelInfoFromMattermostChannel(ch *channel) *adapter.ChannelInfo {
+	return &adapter.ChannelInfo{
+		ID:   ch.ID,
+		Name: ch.Name,
+	}
+}
+
+func newUserInfoFromMattermostUser(u *user) *adapter.UserInfo {
+	return &adapter.UserInfo{
+		ID:                    u.ID,
+		Name:                  u.Username,
+		DisplayName:           u.Nickname,
+		DisplayNameNormalized: u.Nickname,
+		Email:                 u.Email,
+		FirstName:             u.FirstName,
+		LastName:              u.LastName,
+		RealName:              strings.TrimSpace(u.FirstName + " " + u.LastName),
+		RealNameNormalized:    strings.TrimSpace(u.FirstName + " " + u.LastName),
+		IsBot:                 u.IsBot,
+	}
+}
@@ -0,0 +1,378 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mattermost
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/adapter/adaptertest"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+	"github.com/getgort/gort/templates"
+)
+
+// mockServer is a stand-in for the small part of the Mattermost API that
+// the adapter uses.
+type mockServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	posts      []post
+	events     []string
+	meFailures int
+}
+
+func newMockServer(t *testing.T, events ...string) *mockServer {
+	m := &mockServer{events: events}
+	upgrader := websocket.Upgrader{}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v4/users/me", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		fail := m.meFailures > 0
+		m.meFailures--
+		m.mu.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(apiError{Message: "unavailable"})
+			return
+		}
+		json.NewEncoder(w).Encode(user{ID: "gortid", Username: "gort", IsBot: true})
+	})
+	mux.HandleFunc("/api/v4/users/", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/userid") {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(apiError{ID: "app.user.missing_account.const", Message: "not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(user{ID: "userid", Username: "alice", Email: "alice@example.com", FirstName: "Alice", LastName: "Smith"})
+	})
+	mux.HandleFunc("/api/v4/users/me/teams", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]team{{ID: "teamid", Name: "team"}})
+	})
+	mux.HandleFunc("/api/v4/users/me/teams/teamid/channels", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]channel{
+			{ID: "townid", Name: "town-square", Type: channelTypeOpen},
+			{ID: "privid", Name: "private", Type: channelTypePrivate},
+			{ID: "dmid", Name: "gortid__userid", Type: channelTypeDirect},
+		})
+	})
+	mux.HandleFunc("/api/v4/channels/townid", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(channel{ID: "townid", Name: "town-square", Type: channelTypeOpen})
+	})
+	mux.HandleFunc("/api/v4/channels/townid/members", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]channelMember{{UserID: "gortid"}, {UserID: "userid"}})
+	})
+	mux.HandleFunc("/api/v4/channels/direct", func(w http.ResponseWriter, r *http.Request) {
+		var ids []string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&ids))
+		require.Equal(t, []string{"gortid", "userid"}, ids)
+		json.NewEncoder(w).Encode(channel{ID: "dmid", Name: "gortid__userid", Type: channelTypeDirect})
+	})
+	mux.HandleFunc("/api/v4/channels/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(apiError{Message: "not found"})
+	})
+	mux.HandleFunc("/api/v4/posts", func(w http.ResponseWriter, r *http.Request) {
+		var p post
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+
+		m.mu.Lock()
+		p.ID = "postid"
+		m.posts = append(m.posts, p)
+		m.mu.Unlock()
+
+		json.NewEncoder(w).Encode(p)
+	})
+	mux.HandleFunc("/api/v4/websocket", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		for _, e := range m.events {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(e)))
+		}
+
+		// Block until the client hangs up.
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func (m *mockServer) Posts() []post {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]post(nil), m.posts...)
+}
+
+func newTestAdapter(t *testing.T, m *mockServer) *Adapter {
+	a, err := NewAdapter(data.MattermostProvider{
+		AbstractProvider: data.AbstractProvider{Name: "mm"},
+		URL:              m.URL,
+		Token:            "token",
+	})
+	require.NoError(t, err)
+	return a.(*Adapter)
+}
+
+func postedEvent(t *testing.T, channelType string, p post) string {
+	b, err := json.Marshal(p)
+	require.NoError(t, err)
+
+	e, err := json.Marshal(map[string]interface{}{
+		"event": "posted",
+		"data":  map[string]interface{}{"channel_type": channelType, "post": string(b)},
+	})
+	require.NoError(t, err)
+
+	return string(e)
+}
+
+func TestNewAdapter(t *testing.T) {
+	_, err := NewAdapter(data.MattermostProvider{Token: "token"})
+	assert.Error(t, err)
+
+	_, err = NewAdapter(data.MattermostProvider{URL: "http://localhost"})
+	assert.Error(t, err)
+}
+
+func TestListen(t *testing.T) {
+	m := newMockServer(t,
+		`{"event":"hello","data":{}}`,
+		`{"event":"typing","data":{}}`,
+		postedEvent(t, "O", post{ChannelID: "townid", UserID: "userid", Type: "system_join_channel", Message: "joined"}),
+		postedEvent(t, "O", post{ChannelID: "townid", UserID: "userid", Message: "@gort: echo foo"}),
+		postedEvent(t, "O", post{ChannelID: "townid", UserID: "gortid", Message: "foo", Props: map[string]interface{}{"from_bot": "true"}}),
+		postedEvent(t, "D", post{ChannelID: "dmid", UserID: "userid", Message: "whoami"}),
+	)
+
+	a := newTestAdapter(t, m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := a.Listen(ctx)
+
+	e := adaptertest.NextEvent(t, events)
+	assert.Equal(t, adapter.EventConnected, e.EventType)
+	assert.Equal(t, "mattermost", e.Info.Provider.Type)
+	assert.Equal(t, "mm", e.Info.Provider.Name)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "townid",
+		Text:      "echo foo",
+		UserID:    "userid",
+		IsMention: true,
+	}, e.Data)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventChannelMessage, e.EventType)
+	assert.Equal(t, &adapter.ChannelMessageEvent{
+		ChannelID: "townid",
+		Text:      "foo",
+		UserID:    "gortid",
+		IsBot:     true,
+		IsSelf:    true,
+	}, e.Data)
+
+	e = adaptertest.NextEvent(t, events)
+	require.Equal(t, adapter.EventDirectMessage, e.EventType)
+	assert.Equal(t, &adapter.DirectMessageEvent{
+		ChannelID: "dmid",
+		Text:      "whoami",
+		UserID:    "userid",
+	}, e.Data)
+}
+
+func TestListenBadToken(t *testing.T) {
+	m := newMockServer(t)
+
+	a := newTestAdapter(t, m)
+	a.client.token = "wrong"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := adaptertest.NextEvent(t, a.Listen(ctx))
+	assert.Equal(t, adapter.EventAuthenticationError, e.EventType)
+}
+
+func TestListenRetriesGetMe(t *testing.T) {
+	defer func(d time.Duration) { reconnectDelay = d }(reconnectDelay)
+	reconnectDelay = 10 * time.Millisecond
+
+	m := newMockServer(t, `{"event":"hello","data":{}}`)
+	m.meFailures = 1
+
+	a := newTestAdapter(t, m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := a.Listen(ctx)
+
+	// A server error while identifying the bot isn't fatal.
+	assert.Equal(t, adapter.EventConnectionError, adaptertest.NextEvent(t, events).EventType)
+	assert.Equal(t, adapter.EventConnected, adaptertest.NextEvent(t, events).EventType)
+}
+
+func TestGetUserInfo(t *testing.T) {
+	a := newTestAdapter(t, newMockServer(t))
+
+	info, err := a.GetUserInfo("userid")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", info.Name)
+	assert.Equal(t, "alice@example.com", info.Email)
+	assert.Equal(t, "Alice Smith", info.RealName)
+
+	_, err = a.GetUserInfo("nobody")
+	assert.ErrorIs(t, err, errs.ErrNoSuchUser)
+}
+
+func TestGetChannelInfo(t *testing.T) {
+	a := newTestAdapter(t, newMockServer(t))
+
+	info, err := a.GetChannelInfo("townid")
+	require.NoError(t, err)
+	assert.Equal(t, "town-square", info.Name)
+	assert.Equal(t, []string{"gortid", "userid"}, info.Members)
+
+	_, err = a.GetChannelInfo("nowhere")
+	assert.ErrorIs(t, err, adapter.ErrChannelNotFound)
+}
+
+func TestGetDirectMessageChannel(t *testing.T) {
+	a := newTestAdapter(t, newMockServer(t))
+
+	id, err := a.GetDirectMessageChannel("userid")
+	require.NoError(t, err)
+	assert.Equal(t, "dmid", id)
+}
+
+func TestGetPresentChannels(t *testing.T) {
+	a := newTestAdapter(t, newMockServer(t))
+
+	channels, err := a.GetPresentChannels()
+	require.NoError(t, err)
+	require.Len(t, channels, 2)
+	assert.Equal(t, "town-square", channels[0].Name)
+	assert.Equal(t, "private", channels[1].Name)
+}
+
+func TestSend(t *testing.T) {
+	m := newMockServer(t)
+	a := newTestAdapter(t, m)
+	ctx := context.Background()
+
+	require.NoError(t, a.SendText(ctx, "townid", "hello"))
+
+	id, err := a.SendTextEditable(ctx, "townid", "working")
+	require.NoError(t, err)
+	assert.Equal(t, "postid", id)
+
+	require.NoError(t, a.Send(ctx, "townid", templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Header{Title: "Results", Color: "#00FF00"},
+			&templates.Text{Text: "all good"},
+		},
+	}))
+
+	posts := m.Posts()
+	require.Len(t, posts, 3)
+	assert.Equal(t, "hello", posts[0].Message)
+	assert.Equal(t, "working", posts[1].Message)
+
+	attachments := posts[2].Props["attachments"].([]interface{})
+	require.Len(t, attachments, 1)
+	att := attachments[0].(map[string]interface{})
+	assert.Equal(t, "Results", att["title"])
+	assert.Equal(t, "#00FF00", att["color"])
+	assert.Equal(t, "all good", att["text"])
+}
+
+func TestBuildPost(t *testing.T) {
+	p, err := buildPost(templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Text{Text: "line one"},
+			&templates.Text{Text: "line two", Monospace: true},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "line one\n```\nline two\n```", p.Message)
+	assert.Nil(t, p.Props)
+
+	elements := templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Header{Title: "Status", Color: "FF0000"},
+			&templates.Section{Fields: []templates.OutputElement{
+				&templates.Text{Title: "CPU", Text: "12%", Inline: true},
+				&templates.Text{Title: "Memory", Text: "40%", Inline: true},
+			}},
+			&templates.Divider{},
+			&templates.Image{URL: "https://example.com/a.png"},
+			&templates.Image{URL: "https://example.com/b.png", Thumbnail: true},
+		},
+	}
+
+	p, err = buildPost(elements)
+	require.NoError(t, err)
+	assert.Empty(t, p.Message)
+
+	attachments := p.Props["attachments"].([]attachment)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, attachment{
+		Fallback: elements.Alt(),
+		Color:    "#FF0000",
+		Title:    "Status",
+		Text:     "---",
+		Fields: []attachmentField{
+			{Title: "CPU", Value: "12%", Short: true},
+			{Title: "Memory", Value: "40%", Short: true},
+		},
+		ImageURL: "https://example.com/a.png",
+		ThumbURL: "https://example.com/b.png",
+	}, attachments[0])
+}
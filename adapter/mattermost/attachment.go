@@ -0,0 +1,130 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mattermost
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/getgort/gort/templates"
+)
+
+// attachment is a Mattermost message attachment, which uses the same
+// structure as a Slack attachment.
+type attachment struct {
+	Fallback string            `json:"fallback,omitempty"`
+	Color    string            `json:"color,omitempty"`
+	Title    string            `json:"title,omitempty"`
+	Text     string            `json:"text,omitempty"`
+	Fields   []attachmentField `json:"fields,omitempty"`
+	ImageURL string            `json:"image_url,omitempty"`
+	ThumbURL string            `json:"thumb_url,omitempty"`
+}
+
+type attachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// buildPost renders output elements as a Mattermost post. Output consisting
+// only of text is sent as a plain message; anything else is rendered as a
+// single message attachment.
+func buildPost(elements templates.OutputElements) (post, error) {
+	var flattened []templates.OutputElement
+
+	for _, e := range elements.Elements {
+		if section, ok := e.(*templates.Section); ok {
+			flattened = append(flattened, section.Fields...)
+		} else {
+			flattened = append(flattened, e)
+		}
+	}
+
+	var lines []string
+	var textOnly = true
+
+	att := attachment{
+		Color: elements.Color,
+		Title: elements.Title,
+	}
+
+	for _, e := range flattened {
+		switch t := e.(type) {
+		case *templates.Divider:
+			lines = append(lines, "---")
+
+		case *templates.Image:
+			if t.Thumbnail {
+				att.ThumbURL = t.URL
+			} else {
+				att.ImageURL = t.URL
+			}
+			textOnly = false
+
+		case *templates.Header:
+			att.Color = t.Color
+			att.Title = t.Title
+			textOnly = false
+
+		case *templates.Section:
+			// Sections are flattened above.
+
+		case *templates.Alt:
+			// Ignore Alt, only rendered as fallback
+
+		case *templates.Text:
+			var text = t.Text
+			if t.Monospace {
+				text = fmt.Sprintf("```\n%s\n```", text)
+			}
+
+			if t.Title != "" || t.Inline {
+				att.Fields = append(att.Fields, attachmentField{
+					Title: t.Title,
+					Value: text,
+					Short: t.Inline,
+				})
+				textOnly = false
+			} else {
+				lines = append(lines, text)
+			}
+
+		default:
+			return post{}, fmt.Errorf("%T fields are not yet supported by Gort for Mattermost", e)
+		}
+	}
+
+	text := strings.Join(lines, "\n")
+
+	if att.Color == "" && att.Title == "" && textOnly {
+		return post{Message: text}, nil
+	}
+
+	if att.Color != "" && !strings.HasPrefix(att.Color, "#") {
+		att.Color = "#" + att.Color
+	}
+
+	att.Fallback = elements.Alt()
+	att.Text = text
+
+	return post{
+		Props: map[string]interface{}{
+			"attachments": []attachment{att},
+		},
+	}, nil
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// client is a minimal client for the parts of the Mattermost v4 REST API,
+// and its WebSocket event stream, that the adapter uses.
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// apiError is the body of an unsuccessful Mattermost API response.
+type apiError struct {
+	ID         string `json:"id"`
+	Message    string `json:"message"`
+	StatusCode int    `json:"status_code"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("mattermost: %s (%d)", e.Message, e.StatusCode)
+}
+
+type user struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Nickname  string `json:"nickname"`
+	IsBot     bool   `json:"is_bot"`
+}
+
+type channel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+	TeamID      string `json:"team_id"`
+}
+
+type channelMember struct {
+	UserID string `json:"user_id"`
+}
+
+type team struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type post struct {
+	ID        string                 `json:"id,omitempty"`
+	ChannelID string                 `json:"channel_id"`
+	UserID    string                 `json:"user_id,omitempty"`
+	Message   string                 `json:"message"`
+	Type      string                 `json:"type,omitempty"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+}
+
+// wsEvent is an event received over the WebSocket connection.
+type wsEvent struct {
+	Event     string                 `json:"event"`
+	Data      map[string]interface{} `json:"data"`
+	Broadcast struct {
+		ChannelID string `json:"channel_id"`
+	} `json:"broadcast"`
+}
+
+func newClient(baseURL, token string) *client {
+	return &client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    http.DefaultClient,
+	}
+}
+
+// do sends a request to the API, encoding in (if not nil) as the request
+// body and decoding the response body into out (if not nil).
+func (c *client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/api/v4"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		e := &apiError{StatusCode: resp.StatusCode, Message: resp.Status}
+		json.NewDecoder(resp.Body).Decode(e)
+		e.StatusCode = resp.StatusCode
+		return e
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *client) getMe(ctx context.Context) (*user, error) {
+	u := &user{}
+	return u, c.do(ctx, http.MethodGet, "/users/me", nil, u)
+}
+
+func (c *client) getUser(ctx context.Context, id string) (*user, error) {
+	u := &user{}
+	return u, c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(id), nil, u)
+}
+
+func (c *client) getChannel(ctx context.Context, id string) (*channel, error) {
+	ch := &channel{}
+	return ch, c.do(ctx, http.MethodGet, "/channels/"+url.PathEscape(id), nil, ch)
+}
+
+func (c *client) getChannelMembers(ctx context.Context, id string) ([]channelMember, error) {
+	var members []channelMember
+	return members, c.do(ctx, http.MethodGet, "/channels/"+url.PathEscape(id)+"/members?per_page=200", nil, &members)
+}
+
+func (c *client) createDirectChannel(ctx context.Context, userID, otherUserID string) (*channel, error) {
+	ch := &channel{}
+	return ch, c.do(ctx, http.MethodPost, "/channels/direct", []string{userID, otherUserID}, ch)
+}
+
+func (c *client) getTeams(ctx context.Context) ([]team, error) {
+	var teams []team
+	return teams, c.do(ctx, http.MethodGet, "/users/me/teams", nil, &teams)
+}
+
+func (c *client) getChannelsForTeam(ctx context.Context, teamID string) ([]channel, error) {
+	var channels []channel
+	return channels, c.do(ctx, http.MethodGet, "/users/me/teams/"+url.PathEscape(teamID)+"/channels", nil, &channels)
+}
+
+func (c *client) createPost(ctx context.Context, p post) (*post, error) {
+	created := &post{}
+	return created, c.do(ctx, http.MethodPost, "/posts", p, created)
+}
+
+func (c *client) patchPost(ctx context.Context, id, message string) error {
+	return c.do(ctx, http.MethodPut, "/posts/"+url.PathEscape(id)+"/patch", map[string]string{"message": message}, nil)
+}
+
+// connect opens a connection to the WebSocket event stream.
+func (c *client) connect(ctx context.Context) (*websocket.Conn, error) {
+	u, err := url.Parse(c.baseURL + "/api/v4/websocket")
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.token)
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusUnauthorized {
+		return nil, &apiError{StatusCode: resp.StatusCode, Message: "unauthorized"}
+	}
+
+	return conn, err
+}
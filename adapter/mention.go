@@ -0,0 +1,69 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// StripMention removes a mention of Gort from the start of a message, along
+// with any colon or comma and whitespace that follow it, as in "@gort: echo
+// hi". Each adapter passes the forms that a mention of its bot user takes in
+// its provider's message text. If text doesn't start with one of them, it's
+// returned unchanged along with false.
+func StripMention(text string, mentions ...string) (string, bool) {
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+
+	for _, m := range mentions {
+		if m == "" || !strings.HasPrefix(trimmed, m) {
+			continue
+		}
+
+		// A mention has to be a whole word: "gort" doesn't mention "gortbot".
+		rest := trimmed[len(m):]
+		if rest != "" && !strings.ContainsAny(rest[:1], ":, \t\n") {
+			continue
+		}
+
+		rest = strings.TrimLeft(rest, ":,")
+		return strings.TrimSpace(rest), true
+	}
+
+	return text, false
+}
+
+// stripCommandPrefix removes the command prefix, and any whitespace that
+// follows it, from the start of a message. A prefix that's longer than one
+// character has to be a whole word: "/gort" isn't the prefix of "/gortify".
+// If text doesn't start with the prefix, it's returned unchanged along with
+// false.
+func stripCommandPrefix(text, prefix string) (string, bool) {
+	if prefix == "" || !strings.HasPrefix(text, prefix) {
+		return text, false
+	}
+
+	rest := text[len(prefix):]
+	if utf8.RuneCountInString(prefix) > 1 && rest != "" {
+		if r, _ := utf8.DecodeRuneInString(rest); !unicode.IsSpace(r) {
+			return text, false
+		}
+	}
+
+	return strings.TrimSpace(rest), true
+}
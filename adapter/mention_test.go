@@ -0,0 +1,75 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripMention(t *testing.T) {
+	tests := []struct {
+		text      string
+		expected  string
+		mentioned bool
+	}{
+		{"<@U0GORT> test:cmd arg", "test:cmd arg", true},
+		{"<@!U0GORT> test:cmd arg", "test:cmd arg", true},
+		{"  <@U0GORT>: test:cmd", "test:cmd", true},
+		{"<@U0GORT>, test:cmd", "test:cmd", true},
+		{"<@U0GORT>", "", true},
+		{"gort: test:cmd", "test:cmd", true},
+		{"gortbot: test:cmd", "gortbot: test:cmd", false},
+		{"hey <@U0GORT> test:cmd", "hey <@U0GORT> test:cmd", false},
+		{"<@U0123> test:cmd", "<@U0123> test:cmd", false},
+	}
+
+	for _, test := range tests {
+		text, mentioned := StripMention(test.text, "<@U0GORT>", "<@!U0GORT>", "gort")
+		assert.Equal(t, test.expected, text, test.text)
+		assert.Equal(t, test.mentioned, mentioned, test.text)
+	}
+
+	text, mentioned := StripMention("test:cmd", "")
+	assert.Equal(t, "test:cmd", text)
+	assert.False(t, mentioned)
+}
+
+func TestStripCommandPrefix(t *testing.T) {
+	tests := []struct {
+		text     string
+		prefix   string
+		expected string
+		stripped bool
+	}{
+		{"!test:cmd arg", "!", "test:cmd arg", true},
+		{"! test:cmd arg", "!", "test:cmd arg", true},
+		{"test:cmd arg", "!", "test:cmd arg", false},
+		{"/gort test:cmd arg", "/gort", "test:cmd arg", true},
+		{"/gort", "/gort", "", true},
+		{"/gortify test:cmd arg", "/gort", "/gortify test:cmd arg", false},
+		{"/gort:test:cmd", "/gort", "/gort:test:cmd", false},
+		{"»test:cmd", "»", "test:cmd", true},
+	}
+
+	for _, test := range tests {
+		text, stripped := stripCommandPrefix(test.text, test.prefix)
+		assert.Equal(t, test.expected, text, test.text)
+		assert.Equal(t, test.stripped, stripped, test.text)
+	}
+}
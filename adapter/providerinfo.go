@@ -23,10 +23,24 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// DefaultCommandPrefix is the prefix that marks a channel message as a
+// command if the provider doesn't configure one.
+const DefaultCommandPrefix = "!"
+
 // ProviderInfo contains the basic information for a chat provider.
 type ProviderInfo struct {
-	Type string
-	Name string
+	Type          string
+	Name          string
+	CommandPrefix string
+}
+
+// GetCommandPrefix returns the provider's command prefix, or
+// DefaultCommandPrefix if it doesn't have one.
+func (p *ProviderInfo) GetCommandPrefix() string {
+	if p == nil || p.CommandPrefix == "" {
+		return DefaultCommandPrefix
+	}
+	return p.CommandPrefix
 }
 
 // NewProviderInfoFromConfig can create a ProviderInfo from a data.Provider instance.
@@ -37,9 +51,31 @@ func NewProviderInfoFromConfig(provider data.Provider) *ProviderInfo {
 	case data.DiscordProvider:
 		p.Type = "discord"
 		p.Name = ap.Name
+		p.CommandPrefix = ap.CommandPrefix
+	case data.HTTPProvider:
+		p.Type = "http"
+		p.Name = ap.Name
+		p.CommandPrefix = ap.CommandPrefix
+	case data.IRCProvider:
+		p.Type = "irc"
+		p.Name = ap.Name
+		p.CommandPrefix = ap.CommandPrefix
+	case data.MatrixProvider:
+		p.Type = "matrix"
+		p.Name = ap.Name
+		p.CommandPrefix = ap.CommandPrefix
+	case data.MattermostProvider:
+		p.Type = "mattermost"
+		p.Name = ap.Name
+		p.CommandPrefix = ap.CommandPrefix
 	case data.SlackProvider:
 		p.Type = "slack"
 		p.Name = ap.Name
+		p.CommandPrefix = ap.CommandPrefix
+	case data.TerminalProvider:
+		p.Type = "terminal"
+		p.Name = ap.Name
+		p.CommandPrefix = ap.CommandPrefix
 	default:
 		log.WithField("type", fmt.Sprintf("%T", ap)).
 			Errorf("Unsupported provider type")
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/getgort/gort/auth"
+	gerrs "github.com/getgort/gort/errors"
+)
+
+const (
+	// RedirectAnyPermission allows a user to redirect command output to
+	// channels that they aren't a member of.
+	RedirectAnyPermission = "gort:redirect_any"
+
+	// RedirectToRequestor is the redirect target that sends output to the
+	// requesting user as a direct message.
+	RedirectToRequestor = "me"
+)
+
+// DirectMessenger is implemented by adapters whose providers send direct
+// messages through a channel of their own, rather than accepting a user ID
+// as a channel ID. Adapters that don't implement it have direct messages
+// sent to the user's ID.
+type DirectMessenger interface {
+	// GetDirectMessageChannel returns the ID of the channel used for direct
+	// messages with the user, opening it first if necessary.
+	GetDirectMessageChannel(userID string) (string, error)
+}
+
+// ChannelMemberChecker is implemented by adapters whose providers don't list
+// channel members along with their channels, but can be asked whether a user
+// is in a channel. Adapters that don't implement it are checked against the
+// channel's member list, which is fetched with GetChannelInfo if the channel
+// returned by GetPresentChannels doesn't include one.
+type ChannelMemberChecker interface {
+	// IsChannelMember returns true if the user is a member of the channel.
+	IsChannelMember(channelID, userID string) (bool, error)
+}
+
+// directMessageChannel returns the ID of the channel that direct messages
+// to the user are sent to.
+func directMessageChannel(a Adapter, userID string) (string, error) {
+	if dm, ok := a.(DirectMessenger); ok {
+		return dm.GetDirectMessageChannel(userID)
+	}
+
+	return userID, nil
+}
+
+// resolveRedirects converts the redirect targets typed by a user (channel
+// names with or without a leading "#", channel IDs, or "me") into channel
+// IDs. Targets that aren't channels the adapter is present in produce
+// ErrChannelNotFound. Targets that are channels the requestor isn't a member
+// of produce ErrRedirectNotAllowed unless the requestor has the
+// gort:redirect_any permission.
+func resolveRedirects(ctx context.Context, id RequestorIdentity, targets []string) ([]string, error) {
+	var channels []*ChannelInfo
+	var canRedirectAny *bool
+
+	ids := []string{}
+	seen := map[string]bool{}
+
+	for _, target := range targets {
+		var channelID string
+
+		if target == RedirectToRequestor {
+			var err error
+			if channelID, err = directMessageChannel(id.Adapter, id.ChatUser.ID); err != nil {
+				return nil, err
+			}
+		} else {
+			if channels == nil {
+				var err error
+				if channels, err = id.Adapter.GetPresentChannels(); err != nil {
+					return nil, err
+				}
+			}
+
+			ch := findChannel(channels, strings.TrimPrefix(target, "#"))
+			if ch == nil {
+				return nil, gerrs.Wrap(ErrChannelNotFound, errors.New(target))
+			}
+
+			member, err := isChannelMember(id.Adapter, ch, id.ChatUser)
+			if err != nil {
+				return nil, err
+			}
+
+			if !member {
+				if canRedirectAny == nil {
+					allowed, err := auth.UserHasPermission(ctx, id.GortUser.Username, RedirectAnyPermission)
+					if err != nil {
+						return nil, err
+					}
+					canRedirectAny = &allowed
+				}
+
+				if !*canRedirectAny {
+					return nil, gerrs.Wrap(ErrRedirectNotAllowed, errors.New(target))
+				}
+			}
+
+			channelID = ch.ID
+		}
+
+		if !seen[channelID] {
+			seen[channelID] = true
+			ids = append(ids, channelID)
+		}
+	}
+
+	return ids, nil
+}
+
+// findChannel returns the channel whose ID or name is equal to nameOrID, or
+// nil if there's no such channel.
+func findChannel(channels []*ChannelInfo, nameOrID string) *ChannelInfo {
+	for _, ch := range channels {
+		if ch.ID == nameOrID {
+			return ch
+		}
+	}
+
+	for _, ch := range channels {
+		if ch.Name == nameOrID {
+			return ch
+		}
+	}
+
+	return nil
+}
+
+// isChannelMember returns true if the user is a member of the channel.
+// Providers differ in whether they list members by ID or by name, so both
+// are checked.
+func isChannelMember(a Adapter, ch *ChannelInfo, user *UserInfo) (bool, error) {
+	if mc, ok := a.(ChannelMemberChecker); ok {
+		return mc.IsChannelMember(ch.ID, user.ID)
+	}
+
+	members := ch.Members
+	if len(members) == 0 {
+		info, err := a.GetChannelInfo(ch.ID)
+		if err != nil {
+			return false, err
+		}
+		members = info.Members
+	}
+
+	for _, m := range members {
+		if m == user.ID || (user.Name != "" && m == user.Name) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
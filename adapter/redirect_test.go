@@ -0,0 +1,258 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	gerrs "github.com/getgort/gort/errors"
+)
+
+func TestChannelMessageRedirects(t *testing.T) {
+	var tests = []struct {
+		name      string
+		message   string
+		redirects []string
+		params    []string
+		err       bool
+	}{
+		{
+			name:    "no redirect",
+			message: "!test:cmd arg1",
+		},
+		{
+			name:      "redirect to me",
+			message:   "!test:cmd arg1 > me",
+			redirects: []string{"user"},
+		},
+		{
+			name:      "redirect to channel by name",
+			message:   "!test:cmd arg1 > #ops-alerts",
+			redirects: []string{"C0123"},
+		},
+		{
+			name:      "redirect pipeline to several channels",
+			message:   "!test:cmd arg1 | test:cmd arg2 *> #ops-alerts C0123 mychannel me",
+			redirects: []string{"C0123", "mychannel", "user"},
+		},
+		{
+			name:    "error on unknown channel",
+			message: "!test:cmd arg1 > #nowhere",
+			err:     true,
+		},
+		{
+			name:    "error on several targets with single redirect",
+			message: "!test:cmd arg1 > #ops-alerts mychannel",
+			err:     true,
+		},
+		{
+			name:    "no redirect in unaddressed trigger message",
+			message: "command broke > see #nowhere",
+			params:  []string{"command", "broke", ">", "see", "#nowhere"},
+		},
+		{
+			name:    "no pipeline in unaddressed trigger message",
+			message: "command broke | see #ops",
+			params:  []string{"command", "broke", "|", "see", "#ops"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := OnChannelMessage(
+				context.Background(),
+				&ProviderEvent{
+					EventType: EventChannelMessage,
+					Info: &Info{
+						Provider: &ProviderInfo{Type: "test", Name: "provider"},
+					},
+					Adapter: &testAdapter{},
+				},
+				&ChannelMessageEvent{
+					ChannelID: "mychannel",
+					Text:      test.message,
+					UserID:    "user",
+				},
+			)
+
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			assert.Equal(t, test.redirects, result.Redirects)
+
+			if test.params != nil {
+				assert.Equal(t, test.params, []string(result.Parameters))
+				assert.Nil(t, result.Next)
+			}
+
+			for next := result.Next; next != nil; next = next.Next {
+				assert.Equal(t, test.redirects, next.Redirects)
+			}
+		})
+	}
+}
+
+func TestResolveRedirectsPermission(t *testing.T) {
+	ctx := context.Background()
+
+	da, err := dataaccess.Get()
+	require.NoError(t, err)
+
+	other := rest.User{Username: "other", Email: "other@getgort.io"}
+	require.NoError(t, da.UserCreate(ctx, other))
+	defer da.UserDelete(ctx, other.Username)
+
+	id := RequestorIdentity{
+		Adapter:  &testAdapter{},
+		ChatUser: &UserInfo{ID: "other", Name: "other"},
+		GortUser: &other,
+	}
+
+	// A member may redirect to a channel without any special permission...
+	ids, err := resolveRedirects(ctx, id, []string{"#secret"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"C0456"}, ids)
+
+	// ...but a non-member may not.
+	_, err = resolveRedirects(ctx, id, []string{"#mychannel"})
+	assert.True(t, gerrs.Is(err, ErrRedirectNotAllowed))
+
+	// Granting gort:redirect_any lifts the restriction.
+	require.NoError(t, da.GroupCreate(ctx, rest.Group{Name: "redirectors"}))
+	defer da.GroupDelete(ctx, "redirectors")
+	require.NoError(t, da.RoleCreate(ctx, "redirectors"))
+	defer da.RoleDelete(ctx, "redirectors")
+	require.NoError(t, da.GroupUserAdd(ctx, "redirectors", other.Username))
+	require.NoError(t, da.GroupRoleAdd(ctx, "redirectors", "redirectors"))
+	require.NoError(t, da.RolePermissionAdd(ctx, "redirectors", "gort", "redirect_any"))
+
+	ids, err = resolveRedirects(ctx, id, []string{"#mychannel", "#secret", "mychannel"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mychannel", "C0456"}, ids)
+}
+
+func TestResponseChannels(t *testing.T) {
+	request := data.CommandRequest{ChannelID: "origin"}
+
+	e := data.NewCommandResponseEnvelope(request)
+	assert.Equal(t, []string{"origin"}, responseChannels(e))
+
+	request.Redirects = []string{"a", "b"}
+	e = data.NewCommandResponseEnvelope(request)
+	assert.Equal(t, []string{"a", "b"}, responseChannels(e))
+
+	e = data.NewCommandResponseEnvelope(request, data.WithExitCode(1))
+	assert.Equal(t, []string{"origin"}, responseChannels(e))
+}
+
+// dmAdapter is a testAdapter whose direct messages are sent through a
+// channel of their own, as they are on Discord.
+type dmAdapter struct {
+	testAdapter
+}
+
+var _ DirectMessenger = &dmAdapter{}
+
+func (d *dmAdapter) GetDirectMessageChannel(userID string) (string, error) {
+	return "D-" + userID, nil
+}
+
+func TestResolveRedirectsDirectMessageChannel(t *testing.T) {
+	ctx := context.Background()
+
+	id := RequestorIdentity{
+		Adapter:  &testAdapter{},
+		ChatUser: &UserInfo{ID: "user", Name: "user"},
+	}
+
+	// Adapters that accept a user ID as a channel are sent to it directly...
+	ids, err := resolveRedirects(ctx, id, []string{RedirectToRequestor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user"}, ids)
+
+	// ...but others provide a direct message channel.
+	id.Adapter = &dmAdapter{}
+	ids, err = resolveRedirects(ctx, id, []string{RedirectToRequestor, "#mychannel"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"D-user", "mychannel"}, ids)
+}
+
+// memberlessAdapter is a testAdapter whose channel listing doesn't include
+// members, as on Slack, so they're only available from GetChannelInfo.
+type memberlessAdapter struct {
+	testAdapter
+}
+
+func (m *memberlessAdapter) GetPresentChannels() ([]*ChannelInfo, error) {
+	return []*ChannelInfo{
+		{ID: "C0123", Name: "ops-alerts"},
+		{ID: "C0456", Name: "secret"},
+	}, nil
+}
+
+func (m *memberlessAdapter) GetChannelInfo(channelID string) (*ChannelInfo, error) {
+	members := map[string][]string{"C0123": {"user"}, "C0456": {"other"}}
+	return &ChannelInfo{ID: channelID, Name: channelID, Members: members[channelID]}, nil
+}
+
+// memberCheckAdapter is a memberlessAdapter whose provider is asked whether
+// a user is in a channel instead.
+type memberCheckAdapter struct {
+	memberlessAdapter
+}
+
+var _ ChannelMemberChecker = &memberCheckAdapter{}
+
+func (m *memberCheckAdapter) GetChannelInfo(channelID string) (*ChannelInfo, error) {
+	return &ChannelInfo{ID: channelID, Name: channelID}, nil
+}
+
+func (m *memberCheckAdapter) IsChannelMember(channelID, userID string) (bool, error) {
+	return channelID == "C0123" && userID == "user", nil
+}
+
+func TestResolveRedirectsMembership(t *testing.T) {
+	ctx := context.Background()
+
+	user := rest.User{Username: "user"}
+
+	for _, a := range []Adapter{&memberlessAdapter{}, &memberCheckAdapter{}} {
+		id := RequestorIdentity{
+			Adapter:  a,
+			ChatUser: &UserInfo{ID: "user", Name: "user"},
+			GortUser: &user,
+		}
+
+		ids, err := resolveRedirects(ctx, id, []string{"#ops-alerts"})
+		require.NoError(t, err, "%T", a)
+		assert.Equal(t, []string{"C0123"}, ids, "%T", a)
+
+		_, err = resolveRedirects(ctx, id, []string{"#secret"})
+		assert.True(t, gerrs.Is(err, ErrRedirectNotAllowed), "%T", a)
+	}
+}
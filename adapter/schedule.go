@@ -0,0 +1,117 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	"github.com/getgort/gort/scheduler"
+	"github.com/getgort/gort/telemetry"
+)
+
+// OnSchedule builds a command request for a schedule that's due to run. The
+// request is built exactly as if the schedule's user had typed its command
+// into its channel, so the user must be permitted to execute the command,
+// and the request is audited as usual.
+func OnSchedule(ctx context.Context, s rest.Schedule) (*data.CommandRequest, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "adapter.OnSchedule")
+	defer sp.End()
+
+	sp.SetAttributes(attribute.Int64("schedule.id", s.ID))
+
+	id, err := buildScheduleIdentity(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	adapterLogEntry(ctx, nil, id).
+		WithField("schedule.id", s.ID).
+		WithField("command.raw", s.Command).
+		Info("Running scheduled command")
+
+	rawCommandText := strings.TrimPrefix(s.Command, "!")
+
+	return GetCommandRequest(ctx, rawCommandText, id, commandFromTokensByName)
+}
+
+// buildScheduleIdentity builds the identity of a schedule's Gort user, as
+// seen in the schedule's adapter and channel.
+func buildScheduleIdentity(ctx context.Context, s rest.Schedule) (RequestorIdentity, error) {
+	adapter, err := GetAdapter(s.Adapter)
+	if err != nil {
+		return RequestorIdentity{}, err
+	}
+
+	id := RequestorIdentity{Adapter: adapter}
+
+	if id.ChatChannel, err = adapter.GetChannelInfo(s.ChannelID); err != nil {
+		return id, err
+	}
+
+	da, err := dataaccess.Get()
+	if err != nil {
+		return id, err
+	}
+
+	user, err := da.UserGet(ctx, s.UserName)
+	if err != nil {
+		return id, err
+	}
+	id.GortUser = &user
+
+	// If the user is mapped to a chat user, use it so that (for example)
+	// "> me" redirects work. Otherwise, make do with what Gort knows.
+	if chatID, ok := user.Mappings[adapter.GetName()]; ok {
+		if id.ChatUser, err = adapter.GetUserInfo(chatID); err == nil {
+			return id, nil
+		}
+
+		log.WithError(err).WithField("user.id", chatID).Debug("Can't get scheduled user info")
+	}
+
+	id.ChatUser = &UserInfo{
+		ID:          user.Mappings[adapter.GetName()],
+		Name:        user.Username,
+		DisplayName: user.FullName,
+		Email:       user.Email,
+	}
+
+	return id, nil
+}
+
+// startScheduleListening converts schedules into command requests as they
+// become due.
+func startScheduleListening(ctx context.Context, requests chan<- data.CommandRequest, adapterErrors chan<- error) {
+	for s := range scheduler.Start(ctx) {
+		request, err := OnSchedule(ctx, s)
+		if request != nil {
+			requests <- *request
+		}
+		if err != nil {
+			adapterErrors <- err
+		}
+	}
+}
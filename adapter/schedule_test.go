@@ -0,0 +1,62 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data/rest"
+)
+
+func TestOnSchedule(t *testing.T) {
+	AddAdapter(&testAdapter{})
+	defer delete(adapterLookup, "testAdapter")
+
+	s := rest.Schedule{
+		ID:        1,
+		Cron:      "@hourly",
+		Command:   "!test:cmd arg1 | test:cmd arg2",
+		Adapter:   "testAdapter",
+		ChannelID: "mychannel",
+		UserName:  "user",
+	}
+
+	request, err := OnSchedule(context.Background(), s)
+	require.NoError(t, err)
+	require.NotNil(t, request)
+	assert.Equal(t, "test:cmd arg1 | test:cmd arg2", request.Pipeline())
+	assert.Equal(t, "user", request.UserName)
+	assert.Equal(t, "mychannel", request.ChannelID)
+	assert.NotZero(t, request.RequestID)
+
+	// The schedule's user must be permitted to run the command.
+	s.Command = "test:secret"
+	_, err = OnSchedule(context.Background(), s)
+	assert.Error(t, err)
+
+	s.UserName = "nobody"
+	_, err = OnSchedule(context.Background(), s)
+	assert.Error(t, err)
+
+	s.Adapter = "noSuchAdapter"
+	_, err = OnSchedule(context.Background(), s)
+	assert.ErrorIs(t, err, ErrNoSuchAdapter)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/getgort/gort/adapter"
 	"github.com/getgort/gort/data"
@@ -33,6 +34,7 @@ import (
 var (
 	linkMarkdownRegexShort = regexp.MustCompile(`\<([^|:]*:[^|]*)\>`)
 	linkMarkdownRegexLong  = regexp.MustCompile(`\<[^|:]*:[^|]*\|([^|]*)\>`)
+	channelMarkdownRegex   = regexp.MustCompile(`\<#([A-Z0-9]+)(?:\|([^>]*))?\>`)
 )
 
 // NewAdapter will construct a SlackAdapter instance for a given provider configuration.
@@ -64,6 +66,31 @@ func NewAdapter(provider data.SlackProvider) adapter.Adapter {
 	}
 }
 
+// IsChannelMember returns true if the user is a member of the channel. Slack
+// doesn't include members when listing conversations, so the channel's
+// members are paged through until the user is found.
+func IsChannelMember(ctx context.Context, client *slack.Client, channelID, userID string) (bool, error) {
+	params := &slack.GetUsersInConversationParameters{ChannelID: channelID, Limit: 1000}
+
+	for {
+		members, cursor, err := client.GetUsersInConversationContext(ctx, params)
+		if err != nil {
+			return false, err
+		}
+
+		for _, m := range members {
+			if m == userID {
+				return true, nil
+			}
+		}
+
+		if cursor == "" {
+			return false, nil
+		}
+		params.Cursor = cursor
+	}
+}
+
 // ScrubMarkdown removes unnecessary/undesirable Slack markdown (of links, of
 // example) from text received from Slack.
 // TODO(mtitmus) Can this be replaced by using Slack's "verbatim text" option?
@@ -80,9 +107,37 @@ func ScrubMarkdown(text string) string {
 		text = text[:index[0]] + submatch[1] + text[index[1]:]
 	}
 
+	// Replace channel links of the format "<#C0123|ops>" or "<#C0123>" with
+	// "#ops" or "#C0123", so that they can be used as redirect targets.
+	text = channelMarkdownRegex.ReplaceAllStringFunc(text, func(m string) string {
+		submatch := channelMarkdownRegex.FindStringSubmatch(m)
+		if submatch[2] != "" {
+			return "#" + submatch[2]
+		}
+		return "#" + submatch[1]
+	})
+
 	return text
 }
 
+// messageUser returns the ID of the user that sent a message. Messages sent
+// by bots that don't have a bot user are attributed to the bot's ID.
+func messageUser(user, botID string) string {
+	if user == "" {
+		return botID
+	}
+	return user
+}
+
+// stripMention removes a leading mention of the bot user, which Slack writes
+// as "<@U0123>", from a message.
+func stripMention(text, selfUserID string) (string, bool) {
+	if selfUserID == "" {
+		return text, false
+	}
+	return adapter.StripMention(text, "<@"+selfUserID+">")
+}
+
 // Send the contents of a response envelope to a specified channel. If
 // channelID is empty the value of envelope.Request.ChannelID will be used.
 func Send(ctx context.Context, client *slack.Client, a adapter.Adapter, channelID string, elements templates.OutputElements) error {
@@ -126,6 +181,33 @@ func SendText(ctx context.Context, client *slack.Client, a adapter.Adapter, chan
 	return nil
 }
 
+// SendTextEditable sends a text message to a specified channel, and returns
+// an ID of the form "channel/timestamp" that can be passed to EditText.
+func SendTextEditable(ctx context.Context, client *slack.Client, channelID string, message string) (string, error) {
+	channel, ts, err := client.PostMessage(channelID, slack.MsgOptionText(message, false))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("failed to post Slack message")
+		return "", err
+	}
+
+	return channel + "/" + ts, nil
+}
+
+// EditText replaces the text of a message sent by SendTextEditable.
+func EditText(ctx context.Context, client *slack.Client, messageID string, message string) error {
+	parts := strings.SplitN(messageID, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid Slack message ID: %q", messageID)
+	}
+
+	_, _, _, err := client.UpdateMessage(parts[0], parts[1], slack.MsgOptionText(message, false))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("failed to update Slack message")
+	}
+
+	return err
+}
+
 func SendError(ctx context.Context, client *slack.Client, channelID string, title string, err error) error {
 	if title == "" {
 		title = "Unhandled Error"
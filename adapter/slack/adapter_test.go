@@ -17,9 +17,15 @@
 package slack
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScrubMarkdown(t *testing.T) {
@@ -30,9 +36,53 @@ func TestScrubMarkdown(t *testing.T) {
 		"curl -I <http://very-serio.us>":                             "curl -I http://very-serio.us",
 		"curl -I <http://very-serio.us|very-serio.us>":               "curl -I very-serio.us",
 		"curl -I <https://very-serio.us|https://very-serio.us>":      "curl -I https://very-serio.us",
+		"echo foo > <#C0123ABC|ops-alerts>":                          "echo foo > #ops-alerts",
+		"echo foo *> <#C0123ABC> <#C0456DEF|dev>":                    "echo foo *> #C0123ABC #dev",
 	}
 
 	for test, expected := range tests {
 		assert.Equal(t, expected, ScrubMarkdown(test))
 	}
 }
+
+func TestSocketModeIsSelf(t *testing.T) {
+	s := &SocketModeAdapter{selfUserID: "U0GORT", selfBotID: "B0GORT"}
+
+	assert.True(t, s.isSelf(&slackevents.MessageEvent{User: "U0GORT"}))
+	assert.True(t, s.isSelf(&slackevents.MessageEvent{BotID: "B0GORT"}))
+	assert.False(t, s.isSelf(&slackevents.MessageEvent{User: "U0123", BotID: "B0123"}))
+	assert.False(t, s.isSelf(&slackevents.MessageEvent{User: "U0123"}))
+
+	// Before the bot's identity is known, nothing is recognized as its own.
+	s = &SocketModeAdapter{}
+	assert.False(t, s.isSelf(&slackevents.MessageEvent{}))
+
+	assert.Equal(t, "B0123", messageUser("", "B0123"))
+	assert.Equal(t, "U0123", messageUser("U0123", "B0123"))
+}
+
+func TestIsChannelMember(t *testing.T) {
+	// Members are returned in two pages.
+	pages := map[string]string{
+		"":      `{"ok": true, "members": ["U01", "U02"], "response_metadata": {"next_cursor": "page2"}}`,
+		"page2": `{"ok": true, "members": ["U03"], "response_metadata": {"next_cursor": ""}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "/conversations.members", r.URL.Path)
+		assert.Equal(t, "C0123", r.Form.Get("channel"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(pages[r.Form.Get("cursor")]))
+	}))
+	defer server.Close()
+
+	client := slack.New("token", slack.OptionAPIURL(server.URL+"/"))
+	ctx := context.Background()
+
+	for user, expected := range map[string]bool{"U01": true, "U03": true, "U04": false} {
+		member, err := IsChannelMember(ctx, client, "C0123", user)
+		require.NoError(t, err)
+		assert.Equal(t, expected, member, user)
+	}
+}
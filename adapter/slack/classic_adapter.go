@@ -31,6 +31,7 @@ import (
 )
 
 var _ adapter.Adapter = &ClassicAdapter{}
+var _ adapter.ChannelMemberChecker = &ClassicAdapter{}
 
 // ClassicAdapter is the Slack provider implementation of a relay, which knows how
 // to receive events from the Slack API, translate them into Gort events, and
@@ -74,6 +75,11 @@ func (s ClassicAdapter) GetPresentChannels() ([]*adapter.ChannelInfo, error) {
 	return channels, nil
 }
 
+// IsChannelMember returns true if the user is a member of the channel.
+func (s ClassicAdapter) IsChannelMember(channelID, userID string) (bool, error) {
+	return IsChannelMember(context.Background(), s.client, channelID, userID)
+}
+
 // GetUserInfo returns the UserInfo for a requested user.
 func (s ClassicAdapter) GetUserInfo(userID string) (*adapter.UserInfo, error) {
 	u, err := s.rtm.GetUserInfo(userID)
@@ -245,6 +251,17 @@ func (s *ClassicAdapter) SendText(ctx context.Context, channelID string, message
 	return SendText(ctx, s.client, s, channelID, message)
 }
 
+// SendTextEditable sends a simple text message to the specified channel,
+// returning an ID that can be used to edit it later.
+func (s *ClassicAdapter) SendTextEditable(ctx context.Context, channelID string, message string) (string, error) {
+	return SendTextEditable(ctx, s.client, channelID, message)
+}
+
+// EditText replaces the text of a message sent by SendTextEditable.
+func (s *ClassicAdapter) EditText(ctx context.Context, messageID string, message string) error {
+	return EditText(ctx, s.client, messageID, message)
+}
+
 // SendError is a break-glass error message function that's used when the
 // templating function fails somehow. Obviously, it does not utilize the
 // templating engine.
@@ -254,13 +271,23 @@ func (s *ClassicAdapter) SendError(ctx context.Context, channelID string, title
 
 // onChannelMessage is called when the Slack API emits an MessageEvent for a message in a channel.
 func (s *ClassicAdapter) onChannelMessage(event *slack.MessageEvent, info *adapter.Info) *adapter.ProviderEvent {
+	var selfID string
+	if rtmInfo := s.rtm.GetInfo(); rtmInfo != nil && rtmInfo.User != nil {
+		selfID = rtmInfo.User.ID
+	}
+
+	text, mentioned := stripMention(ScrubMarkdown(event.Msg.Text), selfID)
+
 	return s.wrapEvent(
 		adapter.EventChannelMessage,
 		info,
 		&adapter.ChannelMessageEvent{
 			ChannelID: event.Channel,
-			Text:      ScrubMarkdown(event.Msg.Text),
-			UserID:    event.Msg.User,
+			Text:      text,
+			UserID:    messageUser(event.Msg.User, event.Msg.BotID),
+			IsBot:     event.Msg.BotID != "",
+			IsSelf:    s.isSelf(event.Msg),
+			IsMention: mentioned,
 		},
 	)
 }
@@ -291,7 +318,9 @@ func (s *ClassicAdapter) onDirectMessage(event *slack.MessageEvent, info *adapte
 		&adapter.DirectMessageEvent{
 			ChannelID: event.Channel,
 			Text:      ScrubMarkdown(event.Msg.Text),
-			UserID:    event.Msg.User,
+			UserID:    messageUser(event.Msg.User, event.Msg.BotID),
+			IsBot:     event.Msg.BotID != "",
+			IsSelf:    s.isSelf(event.Msg),
 		},
 	)
 }
@@ -319,7 +348,7 @@ func (s *ClassicAdapter) onInvalidAuth(event *slack.InvalidAuthEvent, info *adap
 // onMessage is called when the Slack API emits a MessageEvent.
 func (s *ClassicAdapter) onMessage(event *slack.MessageEvent, info *adapter.Info) *adapter.ProviderEvent {
 	switch event.Msg.SubType {
-	case "": // Just a plain message. Handle accordingly.
+	case "", "bot_message": // Just a plain message. Handle accordingly.
 		if event.Channel[0] == 'D' {
 			return s.onDirectMessage(event, info)
 		}
@@ -331,9 +360,6 @@ func (s *ClassicAdapter) onMessage(event *slack.MessageEvent, info *adapter.Info
 	case "message_deleted":
 		// Note here for later; ignore for now.
 		return nil
-	case "bot_message":
-		// Note here for later; ignore for now.
-		return nil
 	default:
 		log.WithField("subtype", event.Msg.SubType).
 			Warn("Received message subtype")
@@ -341,6 +367,12 @@ func (s *ClassicAdapter) onMessage(event *slack.MessageEvent, info *adapter.Info
 	}
 }
 
+// isSelf returns true if the message was sent by Gort's own bot user.
+func (s *ClassicAdapter) isSelf(msg slack.Msg) bool {
+	info := s.rtm.GetInfo()
+	return info != nil && info.User != nil && msg.User == info.User.ID
+}
+
 // onRTMError is called when the Slack API emits an RTMError.
 func (s *ClassicAdapter) onRTMError(event *slack.RTMError, info *adapter.Info) *adapter.ProviderEvent {
 	return s.wrapEvent(
@@ -32,6 +32,7 @@ import (
 )
 
 var _ adapter.Adapter = &SocketModeAdapter{}
+var _ adapter.ChannelMemberChecker = &SocketModeAdapter{}
 
 // SocketModeAdapter is the Slack provider implementation of a relay, which knows how
 // to receive events from the Slack API, translate them into Gort events, and
@@ -40,6 +41,11 @@ type SocketModeAdapter struct {
 	client       *slack.Client
 	socketClient *socketmode.Client
 	provider     data.SlackProvider
+
+	// The user and bot IDs that Gort itself posts as, used to recognize
+	// its own messages. Set when a connection is established.
+	selfUserID string
+	selfBotID  string
 }
 
 // GetChannelInfo provides info on a specific provider channel accessible
@@ -75,6 +81,11 @@ func (s *SocketModeAdapter) GetPresentChannels() ([]*adapter.ChannelInfo, error)
 	return channels, nil
 }
 
+// IsChannelMember returns true if the user is a member of the channel.
+func (s *SocketModeAdapter) IsChannelMember(channelID, userID string) (bool, error) {
+	return IsChannelMember(context.Background(), s.client, channelID, userID)
+}
+
 // GetUserInfo provides info on a specific provider user accessible
 // to the adapter.
 func (s *SocketModeAdapter) GetUserInfo(userID string) (*adapter.UserInfo, error) {
@@ -123,6 +134,12 @@ func (s *SocketModeAdapter) Listen(ctx context.Context) <-chan *adapter.Provider
 				e.WithField("attempt", ev.ConnectionCount).
 					Trace("Slack event: connected")
 
+				if auth, err := s.client.AuthTestContext(ctx); err != nil {
+					e.WithError(err).Warn("Slack event: failed to identify bot user")
+				} else {
+					s.selfUserID, s.selfBotID = auth.UserID, auth.BotID
+				}
+
 				events <- s.onConnected(info)
 			case socketmode.EventTypeDisconnect:
 				e.Debug("Slack event: disconnected")
@@ -156,13 +173,6 @@ func (s *SocketModeAdapter) Listen(ctx context.Context) <-chan *adapter.Provider
 						if ev.Text == "" {
 							continue
 						}
-						// Ignore messages from bots
-						if ev.BotID != "" {
-							e.WithField("message.data", fmt.Sprintf("%+v", evt.Data)).
-								WithField("bot_id", ev.BotID).
-								Debug("Slack event: ignoring message from bot")
-							continue
-						}
 						switch ev.ChannelType {
 						case "channel": // Public Channel
 							events <- s.onChannelMessage(ev, info)
@@ -218,6 +228,17 @@ func (s *SocketModeAdapter) SendText(ctx context.Context, channelID string, mess
 	return SendText(ctx, s.client, s, channelID, message)
 }
 
+// SendTextEditable sends a simple text message to the specified channel,
+// returning an ID that can be used to edit it later.
+func (s *SocketModeAdapter) SendTextEditable(ctx context.Context, channelID string, message string) (string, error) {
+	return SendTextEditable(ctx, s.client, channelID, message)
+}
+
+// EditText replaces the text of a message sent by SendTextEditable.
+func (s *SocketModeAdapter) EditText(ctx context.Context, messageID string, message string) error {
+	return EditText(ctx, s.client, messageID, message)
+}
+
 // SendError is a break-glass error message function that's used when the
 // templating function fails somehow. Obviously, it does not utilize the
 // templating engine.
@@ -227,17 +248,28 @@ func (s *SocketModeAdapter) SendError(ctx context.Context, channelID string, tit
 
 // onChannelMessage is called when the Slack API emits an MessageEvent for a message in a channel.
 func (s *SocketModeAdapter) onChannelMessage(event *slackevents.MessageEvent, info *adapter.Info) *adapter.ProviderEvent {
+	text, mentioned := stripMention(ScrubMarkdown(event.Text), s.selfUserID)
+
 	return s.wrapEvent(
 		adapter.EventChannelMessage,
 		info,
 		&adapter.ChannelMessageEvent{
 			ChannelID: event.Channel,
-			Text:      ScrubMarkdown(event.Text),
-			UserID:    event.User,
+			Text:      text,
+			UserID:    messageUser(event.User, event.BotID),
+			IsBot:     event.BotID != "",
+			IsSelf:    s.isSelf(event),
+			IsMention: mentioned,
 		},
 	)
 }
 
+// isSelf returns true if the message was sent by Gort itself.
+func (s *SocketModeAdapter) isSelf(event *slackevents.MessageEvent) bool {
+	return (s.selfUserID != "" && event.User == s.selfUserID) ||
+		(s.selfBotID != "" && event.BotID == s.selfBotID)
+}
+
 // onConnected is called when the Slack API emits a ConnectedEvent.
 func (s *SocketModeAdapter) onConnected(info *adapter.Info) *adapter.ProviderEvent {
 	return s.wrapEvent(
@@ -264,7 +296,9 @@ func (s *SocketModeAdapter) onDirectMessage(event *slackevents.MessageEvent, inf
 		&adapter.DirectMessageEvent{
 			ChannelID: event.Channel,
 			Text:      ScrubMarkdown(event.Text),
-			UserID:    event.User,
+			UserID:    messageUser(event.User, event.BotID),
+			IsBot:     event.BotID != "",
+			IsSelf:    s.isSelf(event),
 		},
 	)
 }
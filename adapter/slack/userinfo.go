@@ -33,6 +33,7 @@ func newUserInfoFromSlackUser(slackUser *slack.User) *adapter.UserInfo {
 	u.LastName = slackUser.Profile.LastName
 	u.RealName = slackUser.RealName
 	u.RealNameNormalized = slackUser.Profile.RealNameNormalized
+	u.IsBot = slackUser.IsBot
 
 	return u
 }
@@ -0,0 +1,165 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/getgort/gort/data"
+)
+
+// streamMessageLimit is the maximum number of characters of output shown in
+// an edited streaming message. Only the tail of the output is shown once it
+// grows past this, which keeps the message within the limits of all of the
+// supported providers.
+const streamMessageLimit = 1900
+
+// MessageEditor is implemented by adapters whose providers support editing
+// messages that have already been sent. Adapters that implement it show the
+// output of streaming commands as a single message that's updated in place;
+// those that don't post each batch of output as a new message.
+type MessageEditor interface {
+	// SendTextEditable sends a simple text message to the specified channel,
+	// and returns an adapter-specific identifier for the message that can be
+	// passed to EditText.
+	SendTextEditable(ctx context.Context, channelID string, message string) (string, error)
+
+	// EditText replaces the text of a message previously sent with
+	// SendTextEditable.
+	EditText(ctx context.Context, messageID string, message string) error
+}
+
+// stream is the state of a single streaming command's output.
+type stream struct {
+	lines    []string
+	messages map[string]string // channel ID -> editable message ID
+}
+
+// streamTracker tracks the streaming commands that have produced partial
+// output but haven't yet completed, keyed by request ID. It isn't safe for
+// concurrent use.
+type streamTracker struct {
+	streams map[int64]*stream
+}
+
+func newStreamTracker() *streamTracker {
+	return &streamTracker{streams: map[int64]*stream{}}
+}
+
+// partial sends a batch of partial output to each of the envelope's
+// response channels, either by editing the message already showing the
+// command's output or by sending a new one.
+func (t *streamTracker) partial(ctx context.Context, a Adapter, envelope data.CommandResponseEnvelope) error {
+	id := envelope.Request.RequestID
+
+	s, ok := t.streams[id]
+	if !ok {
+		s = &stream{messages: map[string]string{}}
+		t.streams[id] = s
+	}
+
+	s.lines = append(s.lines, envelope.Response.Lines...)
+
+	var lastErr error
+
+	for _, channelID := range responseChannels(envelope) {
+		var err error
+
+		if editor, ok := a.(MessageEditor); ok {
+			text := formatStreamOutput(s.lines)
+
+			if messageID, ok := s.messages[channelID]; ok {
+				err = editor.EditText(ctx, messageID, text)
+			} else if messageID, err = editor.SendTextEditable(ctx, channelID, text); err == nil {
+				s.messages[channelID] = messageID
+			}
+		} else {
+			err = a.SendText(ctx, channelID, formatStreamOutput(envelope.Response.Lines))
+		}
+
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// final sends the final response for a request. If the request streamed
+// its output and succeeded, the output has already been shown, so only a
+// short status message is sent. Otherwise the response is sent as usual.
+func (t *streamTracker) final(ctx context.Context, a Adapter, envelope data.CommandResponseEnvelope, tt data.TemplateType) error {
+	id := envelope.Request.RequestID
+
+	_, streamed := t.streams[id]
+	delete(t.streams, id)
+
+	var lastErr error
+
+	for _, channelID := range responseChannels(envelope) {
+		var err error
+
+		if streamed && envelope.Data.ExitCode == 0 {
+			err = a.SendText(ctx, channelID, formatStreamStatus(envelope))
+		} else {
+			err = SendEnvelope(ctx, a, channelID, envelope, tt)
+		}
+
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// formatStreamOutput renders lines of streamed output as a code block,
+// keeping only the tail if it's longer than streamMessageLimit.
+func formatStreamOutput(lines []string) string {
+	text := strings.Join(lines, "\n")
+
+	if len(text) > streamMessageLimit {
+		// Don't start partway through a multibyte character...
+		start := len(text) - streamMessageLimit
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		text = text[start:]
+
+		// ...or partway through a line, unless that would drop too much of
+		// the output, such as all but the end of one very long line.
+		if i := strings.IndexByte(text, '\n'); i >= 0 && i < streamMessageLimit/4 {
+			text = text[i+1:]
+		}
+
+		text = "...\n" + text
+	}
+
+	return "```\n" + text + "\n```"
+}
+
+// formatStreamStatus describes the completion of a streaming command.
+func formatStreamStatus(envelope data.CommandResponseEnvelope) string {
+	return fmt.Sprintf("`%s` finished with exit code %d in %s",
+		envelope.Request.Command.Name,
+		envelope.Data.ExitCode,
+		envelope.Data.Duration.Round(time.Millisecond))
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+)
+
+// streamAdapter is a testAdapter that records the text messages it sends.
+type streamAdapter struct {
+	testAdapter
+	sent []string
+}
+
+func (a *streamAdapter) SendText(ctx context.Context, channelID string, message string) error {
+	a.sent = append(a.sent, channelID+": "+message)
+	return nil
+}
+
+// editingAdapter is a streamAdapter that also supports message editing.
+type editingAdapter struct {
+	streamAdapter
+	edits map[string]string
+}
+
+var _ MessageEditor = &editingAdapter{}
+
+func (a *editingAdapter) SendTextEditable(ctx context.Context, channelID string, message string) (string, error) {
+	id := fmt.Sprintf("%s/%d", channelID, len(a.edits))
+	a.edits[id] = message
+	return id, nil
+}
+
+func (a *editingAdapter) EditText(ctx context.Context, messageID string, message string) error {
+	if _, ok := a.edits[messageID]; !ok {
+		return fmt.Errorf("no such message: %s", messageID)
+	}
+	a.edits[messageID] = message
+	return nil
+}
+
+func streamEnvelopes() []data.CommandResponseEnvelope {
+	request := data.CommandRequest{
+		RequestID: 1,
+		ChannelID: "mychannel",
+		CommandEntry: data.CommandEntry{
+			Command: data.BundleCommand{Name: "deploy", Streaming: true},
+		},
+	}
+
+	final := data.NewCommandResponseEnvelope(request, data.WithResponseLines([]string{"one", "two", "three"}))
+	final.Data.Duration = 1500 * time.Millisecond
+
+	return []data.CommandResponseEnvelope{
+		data.NewCommandResponseEnvelope(request, data.WithResponseLines([]string{"one", "two"}), data.WithPartial()),
+		data.NewCommandResponseEnvelope(request, data.WithResponseLines([]string{"three"}), data.WithPartial()),
+		final,
+	}
+}
+
+func TestStreamTrackerAppends(t *testing.T) {
+	ctx := context.Background()
+	a := &streamAdapter{}
+	streams := newStreamTracker()
+
+	envelopes := streamEnvelopes()
+	require.NoError(t, streams.partial(ctx, a, envelopes[0]))
+	require.NoError(t, streams.partial(ctx, a, envelopes[1]))
+	require.NoError(t, streams.final(ctx, a, envelopes[2], data.Command))
+
+	assert.Equal(t, []string{
+		"mychannel: ```\none\ntwo\n```",
+		"mychannel: ```\nthree\n```",
+		"mychannel: `deploy` finished with exit code 0 in 1.5s",
+	}, a.sent)
+	assert.Empty(t, streams.streams)
+}
+
+func TestStreamTrackerEdits(t *testing.T) {
+	ctx := context.Background()
+	a := &editingAdapter{edits: map[string]string{}}
+	streams := newStreamTracker()
+
+	envelopes := streamEnvelopes()
+	require.NoError(t, streams.partial(ctx, a, envelopes[0]))
+	require.NoError(t, streams.partial(ctx, a, envelopes[1]))
+	require.NoError(t, streams.final(ctx, a, envelopes[2], data.Command))
+
+	assert.Equal(t, map[string]string{"mychannel/0": "```\none\ntwo\nthree\n```"}, a.edits)
+	assert.Equal(t, []string{"mychannel: `deploy` finished with exit code 0 in 1.5s"}, a.sent)
+}
+
+func TestFormatStreamOutput(t *testing.T) {
+	assert.Equal(t, "```\nfoo\nbar\n```", formatStreamOutput([]string{"foo", "bar"}))
+
+	var lines []string
+	for i := 0; i < 500; i++ {
+		lines = append(lines, fmt.Sprintf("line %03d", i))
+	}
+
+	out := formatStreamOutput(lines)
+	assert.LessOrEqual(t, len(out), streamMessageLimit+16)
+	assert.True(t, strings.HasPrefix(out, "```\n...\nline "))
+	assert.True(t, strings.HasSuffix(out, "line 499\n```"))
+
+	// A long line followed by a short one keeps the end of the long line.
+	out = formatStreamOutput([]string{strings.Repeat("#", 5000), "ok"})
+	assert.Equal(t, "```\n...\n"+strings.Repeat("#", streamMessageLimit-3)+"\nok\n```", out)
+
+	// A single long line of multibyte characters is cut between characters.
+	for n := 0; n < 4; n++ {
+		out = formatStreamOutput([]string{strings.Repeat("x", n) + strings.Repeat("é€😀", 1000)})
+		assert.True(t, utf8.ValidString(out), "n=%d", n)
+	}
+}
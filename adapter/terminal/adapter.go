@@ -0,0 +1,261 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+	"github.com/getgort/gort/templates"
+)
+
+// NewAdapter will construct a terminal Adapter that reads lines of input
+// from in and writes its output to out.
+func NewAdapter(provider data.TerminalProvider, in io.Reader, out io.Writer) (*Adapter, error) {
+	if provider.Name == "" {
+		return nil, fmt.Errorf("terminal provider has no name")
+	}
+	if provider.User == "" {
+		return nil, fmt.Errorf("terminal provider %q has no user", provider.Name)
+	}
+
+	return &Adapter{
+		provider: provider,
+		in:       in,
+		out:      out,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+var _ adapter.Adapter = &Adapter{}
+
+// Adapter is an adapter for a local terminal. Each line of input is a
+// direct message from the provider's user, and output is written back to
+// the terminal.
+//
+// There's only one conversation, so every channel is the terminal: output
+// sent to any channel other than the user's is labelled with the channel's
+// ID.
+type Adapter struct {
+	provider data.TerminalProvider
+	in       io.Reader
+	done     chan struct{}
+
+	// mu guards out and prompted, which is true if a prompt is waiting for
+	// input.
+	mu       sync.Mutex
+	out      io.Writer
+	prompted bool
+}
+
+// Done returns a channel that's closed once the adapter stops listening,
+// usually because its input has ended.
+func (s *Adapter) Done() <-chan struct{} {
+	return s.done
+}
+
+// GetChannelInfo provides info on a specific channel. Since every channel
+// is the terminal, any channel ID is accepted.
+func (s *Adapter) GetChannelInfo(channelID string) (*adapter.ChannelInfo, error) {
+	return &adapter.ChannelInfo{
+		ID:      channelID,
+		Name:    channelID,
+		Members: []string{s.provider.User},
+	}, nil
+}
+
+// GetName provides the name of this adapter as per the configuration.
+func (s *Adapter) GetName() string {
+	return s.provider.Name
+}
+
+// GetPresentChannels returns the user's channel, so that Gort greets them
+// when it starts.
+func (s *Adapter) GetPresentChannels() ([]*adapter.ChannelInfo, error) {
+	info, err := s.GetChannelInfo(s.provider.User)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*adapter.ChannelInfo{info}, nil
+}
+
+// GetUserInfo provides info on a specific user. The provider's user is the
+// only one there is.
+func (s *Adapter) GetUserInfo(userID string) (*adapter.UserInfo, error) {
+	if userID != s.provider.User {
+		return nil, errs.ErrNoSuchUser
+	}
+
+	return &adapter.UserInfo{
+		ID:                    userID,
+		Name:                  userID,
+		DisplayName:           userID,
+		DisplayNameNormalized: userID,
+		Email:                 s.provider.Email,
+		RealName:              userID,
+		RealNameNormalized:    userID,
+	}, nil
+}
+
+// Listen begins reading lines of input, relaying each as a direct message
+// via the returned channel, until the input ends or ctx is done. Blank
+// lines are ignored.
+func (s *Adapter) Listen(ctx context.Context) <-chan *adapter.ProviderEvent {
+	events := make(chan *adapter.ProviderEvent, 100)
+	lines := make(chan string)
+
+	var readErr error
+
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(s.in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr = scanner.Err()
+	}()
+
+	go func() {
+		defer close(s.done)
+		defer close(events)
+
+		events <- s.wrapEvent(adapter.EventConnected, &adapter.ConnectedEvent{})
+
+		for {
+			select {
+			case <-ctx.Done():
+				events <- s.wrapEvent(adapter.EventDisconnected, &adapter.DisconnectedEvent{Intentional: true})
+				return
+
+			case text, ok := <-lines:
+				if !ok {
+					if readErr != nil {
+						events <- s.wrapEvent(adapter.EventConnectionError, &adapter.ErrorEvent{Msg: readErr.Error()})
+					}
+					events <- s.wrapEvent(adapter.EventDisconnected, &adapter.DisconnectedEvent{Intentional: readErr == nil})
+					return
+				}
+
+				s.mu.Lock()
+				s.prompted = false
+				s.mu.Unlock()
+
+				text = strings.TrimSpace(text)
+				if text == "" {
+					s.write("", nil)
+					continue
+				}
+
+				events <- s.wrapEvent(
+					adapter.EventDirectMessage,
+					&adapter.DirectMessageEvent{
+						ChannelID: s.provider.User,
+						Text:      text,
+						UserID:    s.provider.User,
+					},
+				)
+			}
+		}
+	}()
+
+	return events
+}
+
+// Send the contents of a response envelope to a specified channel. If
+// channelID is empty the value of envelope.Request.ChannelID will be used.
+func (s *Adapter) Send(ctx context.Context, channelID string, elements templates.OutputElements) error {
+	return s.write(channelID, render(elements))
+}
+
+// SendText sends a simple text message to the specified channel.
+func (s *Adapter) SendText(ctx context.Context, channelID string, message string) error {
+	return s.write(channelID, textLines(message, ""))
+}
+
+// SendError is a break-glass error message function that's used when the
+// templating function fails somehow. Obviously, it does not utilize the
+// templating engine.
+func (s *Adapter) SendError(ctx context.Context, channelID string, title string, err error) error {
+	if title == "" {
+		title = "Unhandled Error"
+	}
+
+	lines := textLines(title, codeBold+codeRed)
+	lines = append(lines, textLines(err.Error(), codeRed)...)
+
+	return s.write(channelID, lines)
+}
+
+// write writes lines of output, followed by a new prompt. If a prompt is
+// already waiting for input it's replaced, so the output doesn't follow it
+// on the same line.
+func (s *Adapter) write(channelID string, lines []line) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	color := !s.provider.NoColor
+
+	var b strings.Builder
+
+	if s.prompted {
+		if color {
+			b.WriteString("\r" + codeClearLine)
+		} else {
+			b.WriteString("\n")
+		}
+	}
+
+	if channelID != "" && channelID != s.provider.User && len(lines) > 0 {
+		label := line{{Text: "→ " + channelID, Style: codeDim}}
+		lines = append([]line{label}, lines...)
+	}
+
+	for _, l := range lines {
+		b.WriteString(l.format(color))
+		b.WriteString("\n")
+	}
+
+	s.prompted = s.provider.Prompt != ""
+	b.WriteString(s.provider.Prompt)
+
+	_, err := io.WriteString(s.out, b.String())
+	return err
+}
+
+func (s *Adapter) wrapEvent(eventType adapter.EventType, data interface{}) *adapter.ProviderEvent {
+	return &adapter.ProviderEvent{
+		EventType: eventType,
+		Data:      data,
+		Info: &adapter.Info{
+			Provider: adapter.NewProviderInfoFromConfig(s.provider),
+		},
+		Adapter: s,
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/adapter"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+	"github.com/getgort/gort/templates"
+)
+
+// syncBuffer is a bytes.Buffer that's safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newTestAdapter(t *testing.T, input string, prompt string) (*Adapter, *syncBuffer) {
+	out := &syncBuffer{}
+	a, err := NewAdapter(data.TerminalProvider{
+		AbstractProvider: data.AbstractProvider{Name: "shell"},
+		User:             "alice",
+		Email:            "alice@example.com",
+		Prompt:           prompt,
+		NoColor:          true,
+	}, strings.NewReader(input), out)
+	require.NoError(t, err)
+	return a, out
+}
+
+func TestNewAdapter(t *testing.T) {
+	_, err := NewAdapter(data.TerminalProvider{User: "alice"}, nil, nil)
+	assert.Error(t, err)
+
+	_, err = NewAdapter(data.TerminalProvider{
+		AbstractProvider: data.AbstractProvider{Name: "shell"},
+	}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestListen(t *testing.T) {
+	a, _ := newTestAdapter(t, "echo hello\n\n   \n  whoami  \n", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var events []*adapter.ProviderEvent
+	for ev := range a.Listen(ctx) {
+		events = append(events, ev)
+	}
+
+	require.Len(t, events, 4)
+	assert.Equal(t, adapter.EventConnected, events[0].EventType)
+	assert.Equal(t, "terminal", events[0].Info.Provider.Type)
+	assert.Equal(t, &adapter.DirectMessageEvent{ChannelID: "alice", Text: "echo hello", UserID: "alice"}, events[1].Data)
+	assert.Equal(t, &adapter.DirectMessageEvent{ChannelID: "alice", Text: "whoami", UserID: "alice"}, events[2].Data)
+	assert.Equal(t, &adapter.DisconnectedEvent{Intentional: true}, events[3].Data)
+
+	select {
+	case <-a.Done():
+	case <-time.After(time.Second):
+		t.Fatal("adapter isn't done")
+	}
+}
+
+func TestSend(t *testing.T) {
+	a, out := newTestAdapter(t, "", "gort> ")
+	ctx := context.Background()
+
+	require.NoError(t, a.SendText(ctx, "alice", "hello"))
+	assert.Equal(t, "hello\ngort> ", out.String())
+
+	// Output replaces the waiting prompt, and output to another channel is
+	// labelled.
+	require.NoError(t, a.Send(ctx, "ops", templates.OutputElements{
+		Elements: []templates.OutputElement{&templates.Text{Text: "deployed"}},
+	}))
+	assert.Equal(t, "hello\ngort> \n→ ops\ndeployed\ngort> ", out.String())
+}
+
+func TestSendError(t *testing.T) {
+	a, out := newTestAdapter(t, "", "")
+	a.provider.NoColor = false
+
+	require.NoError(t, a.SendError(context.Background(), "alice", "", errors.New("boom")))
+	assert.Equal(t, "\x1b[1m\x1b[31mUnhandled Error\x1b[0m\n\x1b[31mboom\x1b[0m\n", out.String())
+}
+
+func TestGetUserInfo(t *testing.T) {
+	a, _ := newTestAdapter(t, "", "")
+
+	info, err := a.GetUserInfo("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", info.Email)
+
+	_, err = a.GetUserInfo("bob")
+	assert.ErrorIs(t, err, errs.ErrNoSuchUser)
+
+	channels, err := a.GetPresentChannels()
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, "alice", channels[0].ID)
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/getgort/gort/templates"
+)
+
+// ANSI escape sequences.
+const (
+	codeBold      = "\x1b[1m"
+	codeClearLine = "\x1b[K"
+	codeDim       = "\x1b[2m"
+	codeMonospace = "\x1b[36m"
+	codeRed       = "\x1b[31m"
+	codeReset     = "\x1b[0m"
+)
+
+const (
+	// columnGap is the space between the columns of a section's fields.
+	columnGap = 4
+
+	// dividerWidth is the length of the rule that a divider is drawn as.
+	dividerWidth = 40
+
+	// monospaceIndent is written before each line of a monospace block.
+	monospaceIndent = "  "
+)
+
+// span is a run of text in a single style.
+type span struct {
+	Text  string
+	Style string
+}
+
+// line is a single line of output. Widths are measured from the spans'
+// text, so the text mustn't contain escape codes of its own.
+type line []span
+
+// width returns the number of runes in the line.
+func (l line) width() int {
+	n := 0
+	for _, s := range l {
+		n += utf8.RuneCountInString(s.Text)
+	}
+	return n
+}
+
+// format returns the line as it's written to the terminal. If color is
+// false the styles are omitted.
+func (l line) format(color bool) string {
+	var b strings.Builder
+	for _, s := range l {
+		if color && s.Style != "" && s.Text != "" {
+			b.WriteString(s.Style + s.Text + codeReset)
+		} else {
+			b.WriteString(s.Text)
+		}
+	}
+	return b.String()
+}
+
+// render converts output elements into lines of terminal output. Headers
+// are set in bold, in their color if they have one; monospace text is
+// indented and highlighted; and the fields of a section are laid out in two
+// columns. A terminal can show everything, so an Alt element is only used
+// if there's nothing else.
+func render(elements templates.OutputElements) []line {
+	var lines []line
+	var alt *templates.Alt
+
+	if elements.Title != "" {
+		lines = append(lines, textLines(elements.Title, codeBold+colorCode(elements.Color))...)
+	}
+
+	for _, e := range elements.Elements {
+		if a, ok := e.(*templates.Alt); ok {
+			if alt == nil {
+				alt = a
+			}
+			continue
+		}
+		lines = append(lines, renderElement(e)...)
+	}
+
+	if len(lines) == 0 && alt != nil {
+		lines = textLines(alt.Text, "")
+	}
+
+	return lines
+}
+
+// renderElement converts a single output element into lines.
+func renderElement(e templates.OutputElement) []line {
+	switch t := e.(type) {
+	case *templates.Header:
+		return textLines(t.Title, codeBold+colorCode(t.Color))
+
+	case *templates.Text:
+		lines := textLines(t.Title, codeBold)
+		if t.Monospace {
+			for _, l := range textLines(t.Text, codeMonospace) {
+				lines = append(lines, append(line{{Text: monospaceIndent}}, l...))
+			}
+		} else {
+			lines = append(lines, textLines(t.Text, "")...)
+		}
+		return lines
+
+	case *templates.Section:
+		return renderSection(t)
+
+	case *templates.Divider:
+		return []line{{{Text: strings.Repeat("─", dividerWidth), Style: codeDim}}}
+
+	case templates.WithAlt:
+		return textLines(t.Alt(), "")
+	}
+
+	return nil
+}
+
+// renderSection converts a section into lines: its text, followed by its
+// fields two to a row. The left column is as wide as its widest field.
+func renderSection(s *templates.Section) []line {
+	var lines []line
+
+	if s.Text != nil {
+		lines = append(lines, renderElement(s.Text)...)
+	}
+
+	fields := make([][]line, len(s.Fields))
+	width := 0
+	for i, f := range s.Fields {
+		fields[i] = renderElement(f)
+		if i%2 == 0 {
+			for _, l := range fields[i] {
+				if w := l.width(); w > width {
+					width = w
+				}
+			}
+		}
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		if i+1 == len(fields) {
+			lines = append(lines, fields[i]...)
+			break
+		}
+		lines = append(lines, columns(fields[i], fields[i+1], width+columnGap)...)
+	}
+
+	return lines
+}
+
+// columns sets left and right side by side, padding the left column to
+// width runes.
+func columns(left, right []line, width int) []line {
+	n := len(left)
+	if len(right) > n {
+		n = len(right)
+	}
+
+	lines := make([]line, n)
+	for i := range lines {
+		var l line
+		if i < len(left) {
+			l = append(l, left[i]...)
+		}
+		if i < len(right) {
+			l = append(l, span{Text: strings.Repeat(" ", width-l.width())})
+			l = append(l, right[i]...)
+		}
+		lines[i] = l
+	}
+
+	return lines
+}
+
+// textLines splits text into lines with the given style. Trailing spaces
+// are trimmed from each line, and blank lines from either end of the text.
+func textLines(text, style string) []line {
+	parts := strings.Split(strings.Trim(text, "\n"), "\n")
+
+	var lines []line
+	for _, s := range parts {
+		s = strings.TrimRight(s, " \t\r")
+		if s == "" && len(lines) == 0 {
+			continue
+		}
+		lines = append(lines, line{{Text: s, Style: style}})
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1].width() == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// colorCode returns the escape sequence that sets the foreground to an RGB
+// hex color such as "#FF0000". Unparseable colors are ignored.
+func colorCode(color string) string {
+	color = strings.TrimPrefix(color, "#")
+	if len(color) != 6 {
+		return ""
+	}
+
+	v, err := strconv.ParseUint(color, 16, 32)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", v>>16, v>>8&0xff, v&0xff)
+}
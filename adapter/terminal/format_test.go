@@ -0,0 +1,93 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getgort/gort/templates"
+)
+
+func plain(lines []line) []string {
+	var out []string
+	for _, l := range lines {
+		out = append(out, l.format(false))
+	}
+	return out
+}
+
+func TestRender(t *testing.T) {
+	lines := render(templates.OutputElements{
+		Elements: []templates.OutputElement{
+			&templates.Header{Title: "Status", Color: "#FF8000"},
+			&templates.Section{
+				Text: &templates.Text{Text: "All systems go"},
+				Fields: []templates.OutputElement{
+					&templates.Text{Title: "CPU", Text: "12%"},
+					&templates.Text{Title: "Memory", Text: "3.2 GB"},
+					&templates.Text{Title: "Uptime", Text: "3d"},
+				},
+			},
+			&templates.Divider{},
+			&templates.Text{Text: "line one\n\nline two", Monospace: true},
+			&templates.Image{URL: "https://example.com/graph.png"},
+			&templates.Alt{Text: "ignored"},
+		},
+	})
+
+	assert.Equal(t, []string{
+		"Status",
+		"All systems go",
+		"CPU       Memory",
+		"12%       3.2 GB",
+		"Uptime",
+		"3d",
+		"────────────────────────────────────────",
+		"  line one",
+		"  ",
+		"  line two",
+		"https://example.com/graph.png",
+	}, plain(lines))
+
+	assert.Equal(t, "\x1b[1m\x1b[38;2;255;128;0mStatus\x1b[0m", lines[0].format(true))
+	assert.Equal(t, "  \x1b[36mline one\x1b[0m", lines[7].format(true))
+
+	// An alt element is used if there's nothing else.
+	lines = render(templates.OutputElements{
+		Elements: []templates.OutputElement{&templates.Alt{Text: "all good"}},
+	})
+	assert.Equal(t, []string{"all good"}, plain(lines))
+}
+
+func TestTextLines(t *testing.T) {
+	assert.Empty(t, textLines("", ""))
+	assert.Empty(t, textLines("\n \n", ""))
+
+	assert.Equal(t,
+		[]string{"one", "", "two"},
+		plain(textLines("\n\none  \n\ntwo\n\n", "")))
+}
+
+func TestColorCode(t *testing.T) {
+	assert.Equal(t, "\x1b[38;2;18;52;86m", colorCode("#123456"))
+	assert.Equal(t, "\x1b[38;2;18;52;86m", colorCode("123456"))
+	assert.Equal(t, "", colorCode(""))
+	assert.Equal(t, "", colorCode("#12345"))
+	assert.Equal(t, "", colorCode("#GGGGGG"))
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess"
+)
+
+// triggerCooldowns records when each trigger that has a cooldown last fired
+// in each channel.
+var triggerCooldowns = struct {
+	sync.Mutex
+	fired map[string]time.Time
+}{fired: map[string]time.Time{}}
+
+// triggerCooldownKey identifies a trigger of a command in a channel.
+func triggerCooldownKey(id RequestorIdentity, entry data.CommandEntry) string {
+	return strings.Join([]string{
+		id.Adapter.GetName(),
+		id.ChatChannel.ID,
+		entry.Bundle.Name,
+		entry.Command.Name,
+		entry.Trigger.Match,
+	}, "\x00")
+}
+
+// triggerConditionsMet returns true if the trigger that matched entry may be
+// fired by the requestor. Entries that weren't found by a trigger always
+// meet its conditions.
+func triggerConditionsMet(ctx context.Context, id RequestorIdentity, entry data.CommandEntry) (bool, error) {
+	if entry.Trigger == nil {
+		return true, nil
+	}
+
+	tc := entry.Trigger.TriggerConditions
+
+	if id.ChatUser != nil && id.ChatUser.IsBot && !tc.AllowBots {
+		return false, nil
+	}
+
+	if len(tc.Adapters) > 0 && !containsFold(tc.Adapters, id.Adapter.GetName()) {
+		return false, nil
+	}
+
+	if id.ChatChannel != nil {
+		if len(tc.Channels) > 0 && !channelListed(tc.Channels, id.ChatChannel) {
+			return false, nil
+		}
+		if channelListed(tc.ExcludeChannels, id.ChatChannel) {
+			return false, nil
+		}
+	}
+
+	if len(tc.Users) > 0 || len(tc.Groups) > 0 {
+		ok, err := senderListed(ctx, id, tc.Users, tc.Groups)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// startTriggerCooldown starts the cooldown, if it has one, of the trigger
+// that matched entry in the requestor's channel. It returns false, without
+// restarting it, if the cooldown is already running. Entries that weren't
+// found by a trigger, or whose trigger has no cooldown, always return true.
+func startTriggerCooldown(id RequestorIdentity, entry data.CommandEntry) bool {
+	if entry.Trigger == nil || entry.Trigger.Cooldown <= 0 || id.ChatChannel == nil {
+		return true
+	}
+
+	key := triggerCooldownKey(id, entry)
+	now := time.Now()
+
+	triggerCooldowns.Lock()
+	defer triggerCooldowns.Unlock()
+
+	if last, ok := triggerCooldowns.fired[key]; ok && now.Sub(last) < entry.Trigger.Cooldown {
+		return false
+	}
+
+	triggerCooldowns.fired[key] = now
+	return true
+}
+
+// channelListed returns true if the channel appears in list, by ID or by name.
+func channelListed(list []string, channel *ChannelInfo) bool {
+	for _, c := range list {
+		c = strings.TrimPrefix(c, "#")
+		if c == channel.ID || strings.EqualFold(c, channel.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// senderListed returns true if the requestor appears in users, by Gort user
+// name or by chat provider user name or ID, or is a member of one of groups.
+func senderListed(ctx context.Context, id RequestorIdentity, users, groups []string) (bool, error) {
+	if id.GortUser != nil && containsFold(users, id.GortUser.Username) {
+		return true, nil
+	}
+
+	if id.ChatUser != nil && (containsFold(users, id.ChatUser.Name) || containsFold(users, id.ChatUser.ID)) {
+		return true, nil
+	}
+
+	if id.GortUser == nil || len(groups) == 0 {
+		return false, nil
+	}
+
+	da, err := dataaccess.Get()
+	if err != nil {
+		return false, err
+	}
+
+	memberOf, err := da.UserGroupList(ctx, id.GortUser.Username)
+	if err != nil {
+		return false, err
+	}
+
+	for _, g := range memberOf {
+		if containsFold(groups, g.Name) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
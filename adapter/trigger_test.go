@@ -0,0 +1,191 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adapter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+)
+
+func TestTriggerConditionsMet(t *testing.T) {
+	ctx := context.Background()
+
+	id := RequestorIdentity{
+		Adapter:     &testAdapter{},
+		ChatUser:    &UserInfo{ID: "U123", Name: "chatuser"},
+		ChatChannel: &ChannelInfo{ID: "C0123", Name: "ops-alerts"},
+		GortUser:    &rest.User{Username: "admin"},
+	}
+
+	bot := id
+	bot.ChatUser = &UserInfo{ID: "B123", Name: "otherbot", IsBot: true}
+
+	tests := []struct {
+		name       string
+		id         RequestorIdentity
+		conditions data.TriggerConditions
+		expected   bool
+	}{
+		{"no conditions", id, data.TriggerConditions{}, true},
+		{"bot ignored by default", bot, data.TriggerConditions{}, false},
+		{"bot allowed", bot, data.TriggerConditions{AllowBots: true}, true},
+		{"adapter listed", id, data.TriggerConditions{Adapters: []string{"testAdapter"}}, true},
+		{"adapter not listed", id, data.TriggerConditions{Adapters: []string{"slack"}}, false},
+		{"channel listed by name", id, data.TriggerConditions{Channels: []string{"#ops-alerts"}}, true},
+		{"channel listed by ID", id, data.TriggerConditions{Channels: []string{"C0123"}}, true},
+		{"channel not listed", id, data.TriggerConditions{Channels: []string{"#random"}}, false},
+		{"channel excluded", id, data.TriggerConditions{ExcludeChannels: []string{"ops-alerts"}}, false},
+		{"other channel excluded", id, data.TriggerConditions{ExcludeChannels: []string{"random"}}, true},
+		{"Gort user listed", id, data.TriggerConditions{Users: []string{"admin"}}, true},
+		{"chat user listed", id, data.TriggerConditions{Users: []string{"chatuser"}}, true},
+		{"user not listed", id, data.TriggerConditions{Users: []string{"someone"}}, false},
+		{"group member", id, data.TriggerConditions{Groups: []string{"admin"}}, true},
+		{"not group member", id, data.TriggerConditions{Groups: []string{"nobody"}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := data.CommandEntry{
+				Bundle:  data.Bundle{Name: "test"},
+				Command: data.BundleCommand{Name: "cmd"},
+				Trigger: &data.Trigger{Match: "deploy", TriggerConditions: test.conditions},
+			}
+
+			ok, err := triggerConditionsMet(ctx, test.id, entry)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, ok)
+		})
+	}
+}
+
+// triggerFinder is a bundles.CommandEntryFinder whose triggers match every
+// message.
+type triggerFinder []data.CommandEntry
+
+func (f triggerFinder) FindCommandEntry(ctx context.Context, bundle, command string) ([]data.CommandEntry, error) {
+	return nil, nil
+}
+
+func (f triggerFinder) FindCommandEntryByTrigger(ctx context.Context, tokens []string) ([]data.CommandEntry, error) {
+	return f, nil
+}
+
+func TestFindAllEntriesByTrigger(t *testing.T) {
+	ctx := context.Background()
+
+	id := RequestorIdentity{
+		Adapter:     &testAdapter{},
+		ChatUser:    &UserInfo{ID: "U123", Name: "chatuser"},
+		ChatChannel: &ChannelInfo{ID: "C0123", Name: "ops-alerts"},
+	}
+
+	entry := func(command, match string, tc data.TriggerConditions) data.CommandEntry {
+		return data.CommandEntry{
+			Bundle:  data.Bundle{Name: "test"},
+			Command: data.BundleCommand{Name: command},
+			Trigger: &data.Trigger{Match: match, TriggerConditions: tc},
+		}
+	}
+
+	finder := triggerFinder{
+		entry("deploy", "first", data.TriggerConditions{Channels: []string{"#random"}}),
+		entry("deploy", "second", data.TriggerConditions{}),
+		entry("deploy", "third", data.TriggerConditions{}),
+		entry("status", "only", data.TriggerConditions{Users: []string{"someone"}}),
+	}
+
+	// A trigger whose conditions aren't met doesn't stop a later trigger of
+	// the same command from being used.
+	entries, err := findAllEntriesByTrigger(ctx, id, []string{"deploy"}, finder)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "deploy", entries[0].Command.Name)
+	assert.Equal(t, "second", entries[0].Trigger.Match)
+}
+
+func TestTriggerCooldown(t *testing.T) {
+	id := RequestorIdentity{
+		Adapter:     &testAdapter{},
+		ChatUser:    &UserInfo{ID: "user", Name: "user"},
+		ChatChannel: &ChannelInfo{ID: "C0456", Name: "secret"},
+	}
+
+	entry := data.CommandEntry{
+		Bundle:  data.Bundle{Name: "test"},
+		Command: data.BundleCommand{Name: "cooldown"},
+		Trigger: &data.Trigger{
+			Match:             "deploy",
+			TriggerConditions: data.TriggerConditions{Cooldown: time.Hour},
+		},
+	}
+
+	assert.True(t, startTriggerCooldown(id, entry))
+	assert.False(t, startTriggerCooldown(id, entry))
+
+	// The cooldown applies only to the channel the trigger fired in.
+	other := id
+	other.ChatChannel = &ChannelInfo{ID: "C0123", Name: "ops-alerts"}
+
+	assert.True(t, startTriggerCooldown(other, entry))
+
+	// Commands that weren't found by a trigger have no cooldown.
+	entry.Trigger = nil
+	assert.True(t, startTriggerCooldown(id, entry))
+	assert.True(t, startTriggerCooldown(id, entry))
+}
+
+func TestTriggerCooldownConcurrent(t *testing.T) {
+	id := RequestorIdentity{
+		Adapter:     &testAdapter{},
+		ChatUser:    &UserInfo{ID: "user", Name: "user"},
+		ChatChannel: &ChannelInfo{ID: "C0789", Name: "busy"},
+	}
+
+	entry := data.CommandEntry{
+		Bundle:  data.Bundle{Name: "test"},
+		Command: data.BundleCommand{Name: "concurrent"},
+		Trigger: &data.Trigger{
+			Match:             "deploy",
+			TriggerConditions: data.TriggerConditions{Cooldown: time.Hour},
+		},
+	}
+
+	var wg sync.WaitGroup
+	var started int32
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if startTriggerCooldown(id, entry) {
+				atomic.AddInt32(&started, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), started)
+}
@@ -17,6 +17,7 @@
 package adapter
 
 // UserInfo contains the basic information for a single user in any chat provider.
+// IsBot is set if the provider reports that the user is a bot or application.
 type UserInfo struct {
 	ID                    string
 	Name                  string
@@ -27,4 +28,5 @@ type UserInfo struct {
 	LastName              string
 	RealName              string
 	RealNameNormalized    string
+	IsBot                 bool
 }
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/config"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/telemetry"
+)
+
+// Sink receives the audit record of every command request once it's been
+// closed.
+type Sink interface {
+	Write(ctx context.Context, record rest.AuditRecord) error
+	Close() error
+}
+
+// A sink that can't be created, such as a syslog sink whose listener is
+// down, is tried again on a later Write. The delay between attempts starts at
+// minSinkRetryDelay and doubles after each failure, up to maxSinkRetryDelay.
+const (
+	minSinkRetryDelay = time.Second
+	maxSinkRetryDelay = 5 * time.Minute
+)
+
+// pendingSink is a configured sink that hasn't been created yet.
+type pendingSink struct {
+	name    string
+	field   string
+	value   string
+	create  func() (Sink, error)
+	retryAt time.Time
+	delay   time.Duration
+}
+
+var (
+	mutex   sync.Mutex
+	configs data.AuditConfigs
+	sinks   []Sink
+	pending []*pendingSink
+)
+
+// NewRecord builds the audit record for a completed command request.
+func NewRecord(envelope data.CommandResponseEnvelope) rest.AuditRecord {
+	exitCode := envelope.Data.ExitCode
+
+	record := rest.AuditRecord{
+		RequestID:     envelope.Request.RequestID,
+		Timestamp:     envelope.Request.Timestamp,
+		Duration:      envelope.Data.Duration,
+		Adapter:       envelope.Request.Adapter,
+		ChannelID:     envelope.Request.ChannelID,
+		UserID:        envelope.Request.UserID,
+		UserEmail:     envelope.Request.UserEmail,
+		UserName:      envelope.Request.UserName,
+		BundleName:    envelope.Request.Bundle.Name,
+		BundleVersion: envelope.Request.Bundle.Version,
+		CommandName:   envelope.Request.Command.Name,
+		Parameters:    envelope.Request.Parameters.String(),
+		ParameterList: envelope.Request.Parameters,
+		Pipeline:      envelope.Request.Pipeline(),
+		ExitCode:      &exitCode,
+	}
+
+	if envelope.Data.Error != nil {
+		record.Error = envelope.Data.Error.Error()
+	}
+
+	return record
+}
+
+// Write sends the audit record for a completed command request to each sink
+// defined in the "audit" config section. Sink errors are logged rather than
+// returned: a failing sink mustn't affect the others, or the command.
+func Write(ctx context.Context, envelope data.CommandResponseEnvelope) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "audit.Write")
+	defer sp.End()
+
+	// Sinks are safe for concurrent use, so only finding them needs the
+	// lock: a slow sink mustn't hold up the writes of other requests.
+	mutex.Lock()
+	updateSinks(config.GetAuditConfigs(), time.Now())
+	current := sinks
+	mutex.Unlock()
+
+	record := NewRecord(envelope)
+
+	for _, s := range current {
+		if err := s.Write(ctx, record); err != nil {
+			log.WithError(err).
+				WithField("request.id", record.RequestID).
+				WithField("sink", reflect.TypeOf(s).String()).
+				Error("Failed to write audit record")
+		}
+	}
+}
+
+// updateSinks (re)creates the sinks if the "audit" config section has changed
+// since they were last created, and tries again to create any that failed
+// whose retry delay has passed. The caller must hold mutex.
+func updateSinks(c data.AuditConfigs, now time.Time) {
+	if sinks == nil || !reflect.DeepEqual(c, configs) {
+		for _, s := range sinks {
+			s.Close()
+		}
+
+		configs = c
+		sinks = []Sink{}
+		pending = configuredSinks(c)
+	}
+
+	var remaining []*pendingSink
+
+	for _, p := range pending {
+		if now.Before(p.retryAt) {
+			remaining = append(remaining, p)
+			continue
+		}
+
+		s, err := p.create()
+		if err != nil {
+			p.delay *= 2
+			if p.delay < minSinkRetryDelay {
+				p.delay = minSinkRetryDelay
+			}
+			if p.delay > maxSinkRetryDelay {
+				p.delay = maxSinkRetryDelay
+			}
+			p.retryAt = now.Add(p.delay)
+
+			log.WithError(err).
+				WithField(p.field, p.value).
+				WithField("retry.delay", p.delay).
+				Error("Failed to create " + p.name)
+
+			remaining = append(remaining, p)
+			continue
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	pending = remaining
+}
+
+// configuredSinks returns the sinks defined in the "audit" config section,
+// none of which have been created yet.
+func configuredSinks(c data.AuditConfigs) []*pendingSink {
+	var ps []*pendingSink
+
+	if c.JSONL.Path != "" {
+		ps = append(ps, &pendingSink{
+			name:   "JSONL audit sink",
+			field:  "path",
+			value:  c.JSONL.Path,
+			create: func() (Sink, error) { return NewJSONLSink(c.JSONL) },
+		})
+	}
+
+	if c.CSV.Path != "" {
+		ps = append(ps, &pendingSink{
+			name:   "CSV audit sink",
+			field:  "path",
+			value:  c.CSV.Path,
+			create: func() (Sink, error) { return NewCSVSink(c.CSV) },
+		})
+	}
+
+	if c.Syslog.Address != "" {
+		ps = append(ps, &pendingSink{
+			name:   "syslog audit sink",
+			field:  "address",
+			value:  c.Syslog.Address,
+			create: func() (Sink, error) { return NewSyslogSink(c.Syslog) },
+		})
+	}
+
+	return ps
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+)
+
+func testEnvelope() data.CommandResponseEnvelope {
+	request := data.CommandRequest{
+		CommandEntry: data.CommandEntry{
+			Bundle:  data.Bundle{Name: "test", Version: "0.0.1"},
+			Command: data.BundleCommand{Name: "echo"},
+		},
+		Adapter:    "slack",
+		ChannelID:  "C123",
+		Parameters: data.CommandParameters{"foo", "bar"},
+		RequestID:  42,
+		Timestamp:  time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC),
+		UserID:     "U123",
+		UserName:   "alice",
+	}
+
+	envelope := data.NewCommandResponseEnvelope(request, data.WithError("", errors.New("boom"), 2))
+	envelope.Data.Duration = 1500 * time.Millisecond
+
+	return envelope
+}
+
+func TestNewRecord(t *testing.T) {
+	record := NewRecord(testEnvelope())
+
+	assert.Equal(t, int64(42), record.RequestID)
+	assert.Equal(t, "alice", record.UserName)
+	assert.Equal(t, "slack", record.Adapter)
+	assert.Equal(t, "C123", record.ChannelID)
+	assert.Equal(t, "test", record.BundleName)
+	assert.Equal(t, "0.0.1", record.BundleVersion)
+	assert.Equal(t, "foo bar", record.Parameters)
+	assert.Equal(t, "test:echo foo bar", record.Pipeline)
+	assert.Equal(t, 1500*time.Millisecond, record.Duration)
+	assert.Equal(t, "boom", record.Error)
+	require.NotNil(t, record.ExitCode)
+	assert.Equal(t, int16(2), *record.ExitCode)
+}
+
+func TestJSONLSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	s, err := NewJSONLSink(data.AuditJSONLConfigs{Path: path})
+	require.NoError(t, err)
+
+	record := NewRecord(testEnvelope())
+	require.NoError(t, s.Write(context.Background(), record))
+	require.NoError(t, s.Write(context.Background(), record))
+	require.NoError(t, s.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); lines++ {
+		var got rest.AuditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &got))
+		assert.Equal(t, int64(42), got.RequestID)
+		assert.Equal(t, "alice", got.UserName)
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestUpdateSinksRetries(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	c := data.AuditConfigs{JSONL: data.AuditJSONLConfigs{Path: filepath.Join(dir, "audit.jsonl")}}
+
+	defer func() {
+		for _, s := range sinks {
+			s.Close()
+		}
+		sinks, pending = nil, nil
+	}()
+
+	// The directory doesn't exist yet, so the sink can't be created.
+	now := time.Now()
+	updateSinks(c, now)
+	assert.Len(t, sinks, 0)
+	require.Len(t, pending, 1)
+
+	require.NoError(t, os.Mkdir(dir, 0700))
+
+	// It isn't tried again until the retry delay has passed...
+	updateSinks(c, now.Add(minSinkRetryDelay/2))
+	assert.Len(t, sinks, 0)
+
+	// ...after which it's created.
+	updateSinks(c, now.Add(minSinkRetryDelay))
+	assert.Len(t, sinks, 1)
+	assert.Len(t, pending, 0)
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+)
+
+// defaultCSVMaxBackups is the number of rotated files kept if the config
+// doesn't specify it.
+const defaultCSVMaxBackups = 3
+
+var csvHeader = []string{
+	"request_id", "timestamp", "user_name", "user_id", "user_email",
+	"adapter", "channel_id", "bundle_name", "bundle_version", "command_name",
+	"parameters", "pipeline", "exit_code", "duration_ms", "error",
+}
+
+// CSVSink writes audit records to a CSV file. If MaxSize is set, the file is
+// rotated before it would exceed that many bytes: path becomes path.1,
+// path.1 becomes path.2, and so on, and the oldest is discarded once there
+// are MaxBackups of them.
+type CSVSink struct {
+	mutex      sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	headerSize int64
+}
+
+// NewCSVSink opens (or creates) the file at the configured path for
+// appending. A header row is written to new files.
+func NewCSVSink(c data.AuditCSVConfigs) (*CSVSink, error) {
+	s := &CSVSink{
+		path:       c.Path,
+		maxSize:    c.MaxSize,
+		maxBackups: c.MaxBackups,
+	}
+
+	if s.maxBackups <= 0 {
+		s.maxBackups = defaultCSVMaxBackups
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Write appends the record to the file, rotating it first if necessary.
+func (s *CSVSink) Write(ctx context.Context, record rest.AuditRecord) error {
+	exitCode := ""
+	if record.ExitCode != nil {
+		exitCode = strconv.Itoa(int(*record.ExitCode))
+	}
+
+	row, err := encodeCSV([]string{
+		strconv.FormatInt(record.RequestID, 10),
+		record.Timestamp.UTC().Format(time.RFC3339Nano),
+		record.UserName,
+		record.UserID,
+		record.UserEmail,
+		record.Adapter,
+		record.ChannelID,
+		record.BundleName,
+		record.BundleVersion,
+		record.CommandName,
+		record.Parameters,
+		record.Pipeline,
+		exitCode,
+		strconv.FormatInt(record.Duration.Milliseconds(), 10),
+		record.Error,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// A file that holds only its header is never rotated, even if the row
+	// alone is bigger than MaxSize.
+	if s.maxSize > 0 && s.size > s.headerSize && s.size+int64(len(row)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(row)
+	s.size += int64(n)
+	return err
+}
+
+// Close closes the file.
+func (s *CSVSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.file.Close()
+}
+
+// open opens the file and writes the header if it's empty.
+func (s *CSVSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	s.file = f
+	s.size = info.Size()
+
+	header, err := encodeCSV(csvHeader)
+	if err != nil {
+		return err
+	}
+	s.headerSize = int64(len(header))
+
+	if s.size == 0 {
+		n, err := f.Write(header)
+		s.size += int64(n)
+		return err
+	}
+
+	return nil
+}
+
+// rotate shifts each existing backup along by one, moves the current file
+// to path.1, and opens a fresh file.
+func (s *CSVSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	for i := s.maxBackups - 1; i > 0; i-- {
+		from := fmt.Sprintf("%s.%d", s.path, i)
+		to := fmt.Sprintf("%s.%d", s.path, i+1)
+
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func encodeCSV(fields []string) ([]byte, error) {
+	b := &bytes.Buffer{}
+	w := csv.NewWriter(b)
+
+	if err := w.Write(fields); err != nil {
+		return nil, err
+	}
+
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+)
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+
+	return rows
+}
+
+func TestCSVSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.csv")
+
+	s, err := NewCSVSink(data.AuditCSVConfigs{Path: path})
+	require.NoError(t, err)
+	require.NoError(t, s.Write(context.Background(), NewRecord(testEnvelope())))
+	require.NoError(t, s.Close())
+
+	// Reopening an existing file doesn't write a second header.
+	s, err = NewCSVSink(data.AuditCSVConfigs{Path: path})
+	require.NoError(t, err)
+	require.NoError(t, s.Write(context.Background(), NewRecord(testEnvelope())))
+	require.NoError(t, s.Close())
+
+	rows := readCSV(t, path)
+	require.Len(t, rows, 3)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, []string{
+		"42", "2021-08-01T09:00:00Z", "alice", "U123", "", "slack", "C123",
+		"test", "0.0.1", "echo", "foo bar", "test:echo foo bar", "2", "1500", "boom",
+	}, rows[1])
+}
+
+func TestCSVSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.csv")
+	record := NewRecord(testEnvelope())
+
+	header, err := encodeCSV(csvHeader)
+	require.NoError(t, err)
+
+	// Room for the header and two records, but not three.
+	s, err := NewCSVSink(data.AuditCSVConfigs{Path: path, MaxSize: int64(len(header)) + 250, MaxBackups: 2})
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 8; i++ {
+		require.NoError(t, s.Write(context.Background(), record))
+	}
+
+	assert.Len(t, readCSV(t, path), 3)
+	assert.Len(t, readCSV(t, path+".1"), 3)
+	assert.Len(t, readCSV(t, path+".2"), 3)
+	assert.NoFileExists(t, path+".3")
+}
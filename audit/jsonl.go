@@ -0,0 +1,65 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+)
+
+// JSONLSink appends each audit record to a file as a single line of JSON.
+type JSONLSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// NewJSONLSink opens (or creates) the file at the configured path for
+// appending.
+func NewJSONLSink(c data.AuditJSONLConfigs) (*JSONLSink, error) {
+	f, err := os.OpenFile(c.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONLSink{file: f}, nil
+}
+
+// Write appends the record to the file.
+func (s *JSONLSink) Write(ctx context.Context, record rest.AuditRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err = s.file.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (s *JSONLSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.file.Close()
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+)
+
+const (
+	// syslogFacility is the "log audit" facility from RFC 5424.
+	syslogFacility = 13
+
+	syslogSeverityWarning = 4
+	syslogSeverityInfo    = 6
+
+	// syslogSDID identifies Gort's structured data element. 32473 is the
+	// private enterprise number that RFC 5612 reserves for documentation.
+	syslogSDID = "gort@32473"
+
+	syslogTimeout = 5 * time.Second
+)
+
+// SyslogSink sends each audit record as an RFC 5424 message to a syslog
+// listener over UDP or TCP. TCP messages are framed using octet counting, as
+// described by RFC 6587. Records for commands that failed are sent with
+// warning severity; all others are informational.
+type SyslogSink struct {
+	mutex    sync.Mutex
+	network  string
+	address  string
+	appName  string
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogSink connects to the configured syslog listener. The network
+// defaults to "udp", and the app name to "gort".
+func NewSyslogSink(c data.AuditSyslogConfigs) (*SyslogSink, error) {
+	s := &SyslogSink{
+		network: c.Network,
+		address: c.Address,
+		appName: c.AppName,
+	}
+
+	if s.network == "" {
+		s.network = "udp"
+	}
+	if s.appName == "" {
+		s.appName = "gort"
+	}
+
+	switch s.network {
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", s.network)
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		s.hostname = hostname
+	} else {
+		s.hostname = "-"
+	}
+
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Write sends the record to the listener. If the connection has been lost
+// it's re-established and the message is sent again, once.
+func (s *SyslogSink) Write(ctx context.Context, record rest.AuditRecord) error {
+	msg := s.format(record)
+	if s.network == "tcp" {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.send(msg)
+	if err != nil {
+		if err = s.dial(); err == nil {
+			err = s.send(msg)
+		}
+	}
+
+	return err
+}
+
+// Close closes the connection to the listener.
+func (s *SyslogSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	return s.conn.Close()
+}
+
+func (s *SyslogSink) dial() error {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	conn, err := net.DialTimeout(s.network, s.address, syslogTimeout)
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	return nil
+}
+
+func (s *SyslogSink) send(msg string) error {
+	if s.conn == nil {
+		return fmt.Errorf("not connected to %s", s.address)
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+	_, err := s.conn.Write([]byte(msg))
+	return err
+}
+
+// format renders the record as an RFC 5424 message. The command pipeline is
+// the message body; everything else is structured data.
+func (s *SyslogSink) format(record rest.AuditRecord) string {
+	severity := syslogSeverityInfo
+	exitCode := "-"
+	if record.ExitCode != nil {
+		exitCode = strconv.Itoa(int(*record.ExitCode))
+		if *record.ExitCode != 0 {
+			severity = syslogSeverityWarning
+		}
+	}
+
+	params := [][2]string{
+		{"request_id", strconv.FormatInt(record.RequestID, 10)},
+		{"user", record.UserName},
+		{"user_id", record.UserID},
+		{"adapter", record.Adapter},
+		{"channel", record.ChannelID},
+		{"bundle", record.BundleName},
+		{"version", record.BundleVersion},
+		{"command", record.CommandName},
+		{"parameters", record.Parameters},
+		{"exit_code", exitCode},
+		{"duration_ms", strconv.FormatInt(record.Duration.Milliseconds(), 10)},
+	}
+	if record.Error != "" {
+		params = append(params, [2]string{"error", record.Error})
+	}
+
+	sd := strings.Builder{}
+	sd.WriteString("[" + syslogSDID)
+	for _, p := range params {
+		fmt.Fprintf(&sd, ` %s="%s"`, p[0], escapeSDParam(p[1]))
+	}
+	sd.WriteString("]")
+
+	// RFC 5424 allows at most 6 digits of fractional seconds.
+	timestamp := record.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		syslogFacility*8+severity,
+		timestamp,
+		s.hostname,
+		s.appName,
+		os.Getpid(),
+		"request",
+		sd.String(),
+		record.Pipeline,
+	)
+}
+
+// escapeSDParam escapes the characters that RFC 5424 doesn't allow
+// unescaped in a structured data parameter value.
+func escapeSDParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
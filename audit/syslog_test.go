@@ -0,0 +1,110 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+)
+
+func TestSyslogFormat(t *testing.T) {
+	s := &SyslogSink{appName: "gort", hostname: "host"}
+
+	record := NewRecord(testEnvelope())
+	record.Error = `bad "thing"]`
+
+	expected := fmt.Sprintf(`<108>1 2021-08-01T09:00:00.000000Z host gort %d request `+
+		`[gort@32473 request_id="42" user="alice" user_id="U123" adapter="slack" `+
+		`channel="C123" bundle="test" version="0.0.1" command="echo" `+
+		`parameters="foo bar" exit_code="2" duration_ms="1500" error="bad \"thing\"\]"] `+
+		`test:echo foo bar`, os.Getpid())
+
+	assert.Equal(t, expected, s.format(record))
+
+	*record.ExitCode = 0
+	assert.True(t, strings.HasPrefix(s.format(record), "<110>1 "))
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	s, err := NewSyslogSink(data.AuditSyslogConfigs{Address: pc.LocalAddr().String()})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Write(context.Background(), NewRecord(testEnvelope())))
+
+	buf := make([]byte, 2048)
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<108>1 "), msg)
+	assert.True(t, strings.HasSuffix(msg, "test:echo foo bar"), msg)
+}
+
+func TestSyslogSinkTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		length, _ := r.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err == nil {
+			received <- string(buf)
+		}
+	}()
+
+	s, err := NewSyslogSink(data.AuditSyslogConfigs{Network: "tcp", Address: l.Addr().String(), AppName: "audit"})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Write(context.Background(), NewRecord(testEnvelope())))
+
+	msg := <-received
+	assert.Contains(t, msg, " audit ")
+	assert.True(t, strings.HasSuffix(msg, "test:echo foo bar"), msg)
+}
+
+func TestSyslogSinkBadNetwork(t *testing.T) {
+	_, err := NewSyslogSink(data.AuditSyslogConfigs{Network: "unix", Address: "/dev/log"})
+	assert.Error(t, err)
+}
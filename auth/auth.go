@@ -17,11 +17,13 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 
 	// "fmt"
 
 	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess"
 	gerrs "github.com/getgort/gort/errors"
 	"github.com/getgort/gort/rules"
 )
@@ -77,6 +79,34 @@ func EvaluateCommandEntry(perms []string, ce data.CommandEntry, env rules.Evalua
 	return EvaluateRules(perms, r, env)
 }
 
+// MayExecute returns true if the provided permissions allow at least some
+// invocations of the command: that is, if the permissions satisfy every one
+// of its unconditional rules, and at least one of its rules of any kind.
+// Conditions aren't evaluated, since they depend on an invocation's options
+// and arguments. It's used to decide which commands to show a user.
+func MayExecute(perms []string, ce data.CommandEntry) (bool, error) {
+	r, err := ParseCommandEntry(ce)
+	if err != nil {
+		return false, gerrs.Wrap(ErrRuleLoadError, err)
+	}
+
+	if commandsRequireAtLeastOneRule && len(r) == 0 {
+		return false, ErrNoRulesDefined
+	}
+
+	allowed := false
+
+	for _, r := range r {
+		if r.Allowed(perms) {
+			allowed = true
+		} else if len(r.Conditions) == 0 {
+			return false, nil
+		}
+	}
+
+	return allowed, nil
+}
+
 // ParseCommandEntry is a helper function that accepts a fully-constructed
 // data.CommandEntry, tokenizes and parses all of the command's rule strings,
 // and returns a []Rules value.
@@ -96,3 +126,25 @@ func ParseCommandEntry(ce data.CommandEntry) ([]rules.Rule, error) {
 
 	return rr, nil
 }
+
+// UserHasPermission returns true if the user has been granted the named
+// permission, such as "gort:cancel_any", through any of their roles.
+func UserHasPermission(ctx context.Context, username, permission string) (bool, error) {
+	da, err := dataaccess.Get()
+	if err != nil {
+		return false, err
+	}
+
+	perms, err := da.UserPermissionList(ctx, username)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range perms.Strings() {
+		if p == permission {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -17,9 +17,15 @@
 package bundles
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+	gerrs "github.com/getgort/gort/errors"
 )
 
 func TestLoadBundleFromFile(t *testing.T) {
@@ -54,6 +60,35 @@ Usage:
 	assert.Equal(t, []string{"/bin/echo"}, cmd.Executable)
 	assert.Len(t, cmd.Rules, 1)
 	assert.Equal(t, "must have test:echox", cmd.Rules[0])
+	assert.True(t, cmd.Streaming)
+	assert.False(t, b.Commands["echoa"].Streaming)
+
+	// Limits
+	assert.Equal(t, 5*time.Minute, b.Timeout)
+	assert.Equal(t, 4, b.MaxConcurrent)
+	assert.Equal(t, 30*time.Second, cmd.Timeout)
+	assert.Equal(t, "128Mi", cmd.Memory)
+	assert.Equal(t, "500m", cmd.CPU)
+	assert.Equal(t, 1, cmd.MaxConcurrent)
+
+	// Option and argument schema
+	require.Len(t, cmd.Options, 2)
+	assert.Equal(t, []string{"e"}, cmd.Options["escapes"].Aliases)
+	assert.Empty(t, cmd.Options["n"].Type)
+	require.Len(t, cmd.Arguments, 1)
+	assert.Equal(t, "string", cmd.Arguments[0].Name)
+	assert.Equal(t, data.TypeList, cmd.Arguments[0].Type)
+	assert.False(t, b.Commands["echoa"].HasSchema())
+
+	// Webhooks
+	require.Len(t, b.Webhooks, 1)
+	hook := b.Webhooks["deployed"]
+	assert.Equal(t, "deployed", hook.Name)
+	assert.Equal(t, "echox", hook.Command)
+	assert.Equal(t, []string{"deployed", "{{ .repository.name }}"}, hook.Parameters)
+	assert.Equal(t, "admin", hook.User)
+	assert.Equal(t, "test-secret", hook.Secret)
+	assert.Equal(t, "C0123", hook.ChannelID)
 
 	// Command templates
 	assert.Equal(t, "Template:Command:CommandError", cmd.Templates.CommandError)
@@ -61,3 +96,99 @@ Usage:
 	assert.Equal(t, "Template:Command:MessageError", cmd.Templates.MessageError)
 	assert.Equal(t, "Template:Command:Message", cmd.Templates.Message)
 }
+
+func TestLoadBundleInvalidSchema(t *testing.T) {
+	const yml = `---
+gort_bundle_version: 1
+name: test
+version: 0.0.1
+commands:
+  echo:
+    executable: [ "/bin/echo" ]
+    options:
+      format:
+        type: enum
+    rules:
+      - allow
+`
+
+	_, err := LoadBundle(strings.NewReader(yml))
+	require.Error(t, err)
+	assert.True(t, gerrs.Is(err, data.ErrInvalidSchema), err.Error())
+}
+
+func TestLoadBundleInvalidTrigger(t *testing.T) {
+	const yml = `---
+gort_bundle_version: 1
+name: test
+version: 0.0.1
+commands:
+  echo:
+    executable: [ "/bin/echo" ]
+    triggers:
+      - match: "deploy (?P<env>"
+    rules:
+      - allow
+`
+
+	_, err := LoadBundle(strings.NewReader(yml))
+	require.Error(t, err)
+	assert.True(t, gerrs.Is(err, data.ErrInvalidTrigger), err.Error())
+}
+
+func TestLoadBundleInvalidLimits(t *testing.T) {
+	const yml = `---
+gort_bundle_version: 1
+name: test
+version: 0.0.1
+memory: 256Mi
+commands:
+  echo:
+    executable: [ "/bin/echo" ]
+    cpu: half
+    rules:
+      - allow
+`
+
+	_, err := LoadBundle(strings.NewReader(yml))
+	require.Error(t, err)
+	assert.True(t, gerrs.Is(err, data.ErrInvalidLimits), err.Error())
+}
+
+func TestLoadBundleTriggerConditions(t *testing.T) {
+	const yml = `---
+gort_bundle_version: 1
+name: test
+version: 0.0.1
+commands:
+  deploy:
+    executable: [ "/bin/deploy" ]
+    triggers:
+      - match: "^deploy (?P<service>\\S+)$"
+        adapters: [ slack ]
+        channels: [ "#ops" ]
+        exclude_channels: [ "#random" ]
+        users: [ alice ]
+        groups: [ sre ]
+        allow_bots: true
+        cooldown: 5m
+    rules:
+      - allow
+`
+
+	b, err := LoadBundle(strings.NewReader(yml))
+	require.NoError(t, err)
+
+	triggers := b.Commands["deploy"].Triggers
+	require.Len(t, triggers, 1)
+	assert.Equal(t, `^deploy (?P<service>\S+)$`, triggers[0].Match)
+	assert.Equal(t, data.TriggerConditions{
+		Adapters:        []string{"slack"},
+		Channels:        []string{"#ops"},
+		ExcludeChannels: []string{"#random"},
+		Users:           []string{"alice"},
+		Groups:          []string{"sre"},
+		AllowBots:       true,
+		Cooldown:        5 * time.Minute,
+	}, triggers[0].TriggerConditions)
+}
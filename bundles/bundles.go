@@ -64,6 +64,23 @@ func unmarshal(dat []byte) (data.Bundle, error) {
 	// Ensure that the command name is propagated from the map key.
 	for n := range bun.Commands {
 		(bun.Commands[n]).Name = n
+
+		if err := bun.Commands[n].ValidateSchema(); err != nil {
+			return data.Bundle{}, err
+		}
+
+		if err := bun.Commands[n].ValidateTriggers(); err != nil {
+			return data.Bundle{}, err
+		}
+	}
+
+	if err := bun.ValidateLimits(); err != nil {
+		return data.Bundle{}, err
+	}
+
+	// Likewise for webhook names.
+	for n := range bun.Webhooks {
+		(bun.Webhooks[n]).Name = n
 	}
 
 	return bun, nil
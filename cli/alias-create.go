@@ -0,0 +1,101 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+	"github.com/getgort/gort/data/rest"
+)
+
+const (
+	aliasCreateUse   = "create"
+	aliasCreateShort = "Create an alias"
+	aliasCreateLong  = `Create an alias for a command line.
+
+The alias is yours unless --site is given, in which case everyone can use it.
+Use "--" to separate the command line from any flags that it takes, and quote
+any pipes or redirects so that they're part of the alias.
+
+For example:
+
+  gort alias create deploy-prod -- "deploy:run --env prod | notify:post ops"`
+	aliasCreateUsage = `Usage:
+  gort alias create [flags] alias_name command [args...]
+
+Flags:
+  -h, --help          Show this message and exit
+  -s, --site          Create a site alias, rather than a user alias
+  -u, --user string   Create the alias for this user, rather than yourself
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+var (
+	flagAliasCreateSite bool
+	flagAliasCreateUser string
+)
+
+// GetAliasCreateCmd is a command
+func GetAliasCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   aliasCreateUse,
+		Short: aliasCreateShort,
+		Long:  aliasCreateLong,
+		RunE:  aliasCreateCmd,
+		Args:  cobra.MinimumNArgs(2),
+	}
+
+	cmd.Flags().BoolVarP(&flagAliasCreateSite, "site", "s", false, "Create a site alias, rather than a user alias")
+	cmd.Flags().StringVarP(&flagAliasCreateUser, "user", "u", "", "Create the alias for this user, rather than yourself")
+
+	cmd.SetUsageTemplate(aliasCreateUsage)
+
+	return cmd
+}
+
+func aliasCreateCmd(cmd *cobra.Command, args []string) error {
+	if flagAliasCreateSite && flagAliasCreateUser != "" {
+		return fmt.Errorf("--site and --user can't be used together")
+	}
+
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	alias := rest.Alias{
+		Name:    args[0],
+		Command: strings.Join(args[1:], " "),
+		Scope:   aliasScope(flagAliasCreateSite),
+		Owner:   flagAliasCreateUser,
+	}
+
+	if err := c.AliasSave(alias); err != nil {
+		return err
+	}
+
+	fmt.Printf("Alias %q created.\n", alias.Name)
+
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+	"github.com/getgort/gort/data/rest"
+)
+
+const (
+	aliasDeleteUse   = "delete"
+	aliasDeleteShort = "Delete an alias"
+	aliasDeleteLong  = "Delete one of your aliases or, with --site, a site alias."
+	aliasDeleteUsage = `Usage:
+  gort alias delete [flags] alias_name
+
+Flags:
+  -h, --help          Show this message and exit
+  -s, --site          Delete a site alias, rather than a user alias
+  -u, --user string   Delete an alias belonging to this user, rather than yourself
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+var (
+	flagAliasDeleteSite bool
+	flagAliasDeleteUser string
+)
+
+// GetAliasDeleteCmd is a command
+func GetAliasDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   aliasDeleteUse,
+		Short: aliasDeleteShort,
+		Long:  aliasDeleteLong,
+		RunE:  aliasDeleteCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.Flags().BoolVarP(&flagAliasDeleteSite, "site", "s", false, "Delete a site alias, rather than a user alias")
+	cmd.Flags().StringVarP(&flagAliasDeleteUser, "user", "u", "", "Delete an alias belonging to this user, rather than yourself")
+
+	cmd.SetUsageTemplate(aliasDeleteUsage)
+
+	return cmd
+}
+
+func aliasDeleteCmd(cmd *cobra.Command, args []string) error {
+	if flagAliasDeleteSite && flagAliasDeleteUser != "" {
+		return fmt.Errorf("--site and --user can't be used together")
+	}
+
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	alias := rest.Alias{
+		Name:  args[0],
+		Scope: aliasScope(flagAliasDeleteSite),
+		Owner: flagAliasDeleteUser,
+	}
+
+	if err := c.AliasDelete(alias); err != nil {
+		return err
+	}
+
+	fmt.Printf("Alias %q deleted.\n", alias.Name)
+
+	return nil
+}
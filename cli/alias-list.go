@@ -0,0 +1,74 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+const (
+	aliasListUse   = "list"
+	aliasListShort = "List aliases"
+	aliasListLong  = `List the site aliases and your own aliases. Users with the
+gort:manage_aliases permission see every user's aliases.`
+	aliasListUsage = `Usage:
+  gort alias list [flags]
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetAliasListCmd is a command
+func GetAliasListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   aliasListUse,
+		Short: aliasListShort,
+		Long:  aliasListLong,
+		RunE:  aliasListCmd,
+	}
+
+	cmd.SetUsageTemplate(aliasListUsage)
+
+	return cmd
+}
+
+func aliasListCmd(cmd *cobra.Command, args []string) error {
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := gortClient.AliasList()
+	if err != nil {
+		return err
+	}
+
+	c := &Columnizer{}
+	c.StringColumn("NAME", func(i int) string { return aliases[i].Name })
+	c.StringColumn("SCOPE", func(i int) string { return string(aliases[i].Scope) })
+	c.StringColumn("OWNER", func(i int) string { return aliases[i].Owner })
+	c.StringColumn("COMMAND", func(i int) string { return aliases[i].Command })
+	c.Print(aliases)
+
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/data/rest"
+)
+
+const (
+	aliasUse   = "alias"
+	aliasShort = "Manage command aliases"
+	aliasLong  = `Manage command aliases.
+
+An alias gives a short name to a longer command line, which may be a pipeline.
+When an alias is invoked, it's replaced by that command line, followed by any
+arguments given to the alias. User aliases belong to a single user, and only
+they can invoke them; site aliases can be invoked by everyone, but only
+administrators can manage them. A user's own aliases take precedence over site
+aliases with the same name, and installed commands take precedence over both.`
+)
+
+// GetAliasCmd alias
+func GetAliasCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   aliasUse,
+		Short: aliasShort,
+		Long:  aliasLong,
+	}
+
+	cmd.AddCommand(GetAliasCreateCmd())
+	cmd.AddCommand(GetAliasDeleteCmd())
+	cmd.AddCommand(GetAliasListCmd())
+
+	return cmd
+}
+
+// aliasScope returns the scope of the alias that's described by the --site
+// flag.
+func aliasScope(site bool) rest.AliasScope {
+	if site {
+		return rest.AliasScopeSite
+	}
+	return rest.AliasScopeUser
+}
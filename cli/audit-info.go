@@ -0,0 +1,103 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+const (
+	auditInfoUse   = "info"
+	auditInfoShort = "Show the details of a single request"
+	auditInfoLong  = "Show the details of a single request from the audit log."
+	auditInfoUsage = `Usage:
+  gort audit info [flags] request_id
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetAuditInfoCmd is a command
+func GetAuditInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   auditInfoUse,
+		Short: auditInfoShort,
+		Long:  auditInfoLong,
+		RunE:  auditInfoCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.SetUsageTemplate(auditInfoUsage)
+
+	return cmd
+}
+
+func auditInfoCmd(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %q", args[0])
+	}
+
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	r, err := gortClient.RequestGet(id)
+	if err != nil {
+		return err
+	}
+
+	const format = `Request ID  %d
+Time        %s
+Duration    %s
+User        %s
+Email       %s
+Adapter     %s
+Channel     %s
+Chat User   %s
+Bundle      %s
+Command     %s
+Status      %s
+Error       %s
+`
+
+	fmt.Printf(format,
+		r.RequestID,
+		r.Timestamp.Local().Format("2006-01-02 15:04:05 MST"),
+		r.Duration,
+		process(r.UserName),
+		process(r.UserEmail),
+		process(r.Adapter),
+		process(r.ChannelID),
+		process(r.UserID),
+		process(fmt.Sprintf("%s %s", r.BundleName, r.BundleVersion)),
+		process(r.Pipeline),
+		auditStatus(r),
+		process(r.Error),
+	)
+
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+	"github.com/getgort/gort/data/rest"
+)
+
+const (
+	auditListUse   = "list"
+	auditListShort = "List recorded requests"
+	auditListLong  = `List recorded requests, newest first.
+
+The --since and --until flags accept either an RFC 3339 timestamp, such as
+"2021-08-01T09:00:00Z", or a duration relative to now, such as "2h".`
+	auditListUsage = `Usage:
+  gort audit list [flags]
+
+Flags:
+  -a, --adapter string   Only list requests from this adapter
+  -b, --bundle string    Only list requests for this bundle
+      --channel string   Only list requests from this channel ID
+  -c, --command string   Only list requests for this command
+  -h, --help             Show this message and exit
+  -l, --limit int        The maximum number of requests to list (default 100)
+  -o, --offset int       The number of requests to skip
+      --since string     Only list requests made at or after this time
+  -s, --status string    Only list requests that exited with this code
+      --until string     Only list requests made before this time
+  -u, --user string      Only list requests made by this Gort user
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+var (
+	flagAuditListAdapter string
+	flagAuditListBundle  string
+	flagAuditListChannel string
+	flagAuditListCommand string
+	flagAuditListLimit   int
+	flagAuditListOffset  int
+	flagAuditListSince   string
+	flagAuditListStatus  string
+	flagAuditListUntil   string
+	flagAuditListUser    string
+)
+
+// GetAuditListCmd is a command
+func GetAuditListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   auditListUse,
+		Short: auditListShort,
+		Long:  auditListLong,
+		RunE:  auditListCmd,
+	}
+
+	cmd.Flags().StringVarP(&flagAuditListAdapter, "adapter", "a", "", "Only list requests from this adapter")
+	cmd.Flags().StringVarP(&flagAuditListBundle, "bundle", "b", "", "Only list requests for this bundle")
+	cmd.Flags().StringVar(&flagAuditListChannel, "channel", "", "Only list requests from this channel ID")
+	cmd.Flags().StringVarP(&flagAuditListCommand, "command", "c", "", "Only list requests for this command")
+	cmd.Flags().IntVarP(&flagAuditListLimit, "limit", "l", 0, "The maximum number of requests to list")
+	cmd.Flags().IntVarP(&flagAuditListOffset, "offset", "o", 0, "The number of requests to skip")
+	cmd.Flags().StringVar(&flagAuditListSince, "since", "", "Only list requests made at or after this time")
+	cmd.Flags().StringVarP(&flagAuditListStatus, "status", "s", "", "Only list requests that exited with this code")
+	cmd.Flags().StringVar(&flagAuditListUntil, "until", "", "Only list requests made before this time")
+	cmd.Flags().StringVarP(&flagAuditListUser, "user", "u", "", "Only list requests made by this Gort user")
+
+	cmd.SetUsageTemplate(auditListUsage)
+
+	return cmd
+}
+
+func auditListCmd(cmd *cobra.Command, args []string) error {
+	filter := rest.AuditFilter{
+		Adapter:     flagAuditListAdapter,
+		BundleName:  flagAuditListBundle,
+		ChannelID:   flagAuditListChannel,
+		CommandName: flagAuditListCommand,
+		Limit:       flagAuditListLimit,
+		Offset:      flagAuditListOffset,
+		UserName:    flagAuditListUser,
+	}
+
+	if flagAuditListStatus != "" {
+		code, err := strconv.ParseInt(flagAuditListStatus, 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid exit status: %q", flagAuditListStatus)
+		}
+		c := int16(code)
+		filter.ExitCode = &c
+	}
+
+	var err error
+	if filter.Since, err = parseAuditTime(flagAuditListSince); err != nil {
+		return err
+	}
+	if filter.Until, err = parseAuditTime(flagAuditListUntil); err != nil {
+		return err
+	}
+
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	records, err := gortClient.RequestList(filter)
+	if err != nil {
+		return err
+	}
+
+	c := &Columnizer{}
+	c.StringColumn("ID", func(i int) string { return strconv.FormatInt(records[i].RequestID, 10) })
+	c.StringColumn("TIME", func(i int) string { return records[i].Timestamp.Local().Format("2006-01-02 15:04:05") })
+	c.StringColumn("USER", func(i int) string { return records[i].UserName })
+	c.StringColumn("ADAPTER", func(i int) string { return records[i].Adapter })
+	c.StringColumn("STATUS", func(i int) string { return auditStatus(records[i]) })
+	c.StringColumn("DURATION", func(i int) string { return records[i].Duration.String() })
+	c.StringColumn("COMMAND", func(i int) string { return records[i].Pipeline })
+	c.Print(records)
+
+	return nil
+}
+
+// parseAuditTime accepts an RFC 3339 timestamp or a duration, which is
+// interpreted as that long before now. An empty string is the zero time.
+func parseAuditTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time: %q", s)
+	}
+
+	return t, nil
+}
+
+// auditStatus describes the record's exit code, or "running" if it has none.
+func auditStatus(r rest.AuditRecord) string {
+	if r.ExitCode == nil {
+		return "running"
+	}
+	return strconv.Itoa(int(*r.ExitCode))
+}
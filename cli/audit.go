@@ -0,0 +1,43 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	auditUse   = "audit"
+	auditShort = "Query the command audit log"
+	auditLong  = `Query the command audit log.
+
+Every command that Gort executes is recorded in the audit log, along with who
+executed it, where, and how it exited.`
+)
+
+// GetAuditCmd audit
+func GetAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   auditUse,
+		Short: auditShort,
+		Long:  auditLong,
+	}
+
+	cmd.AddCommand(GetAuditInfoCmd())
+	cmd.AddCommand(GetAuditListCmd())
+
+	return cmd
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+const (
+	cancelUse   = "cancel"
+	cancelShort = "Cancel a running command"
+	cancelLong  = `Cancel a running command, given the request ID reported when it was started.
+
+Users may cancel their own commands; cancelling anyone else's requires the
+gort:cancel_any permission.`
+	cancelUsage = `Usage:
+  gort cancel [flags] request_id
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetCancelCmd is a command
+func GetCancelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cancelUse,
+		Short: cancelShort,
+		Long:  cancelLong,
+		RunE:  cancelCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.SetUsageTemplate(cancelUsage)
+
+	return cmd
+}
+
+func cancelCmd(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %q", args[0])
+	}
+
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.RequestCancel(id); err != nil {
+		return err
+	}
+
+	fmt.Printf("Request %d cancelled.\n", id)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+const (
+	execUse   = "exec"
+	execShort = "Execute a bundle command"
+	execLong  = `Execute a bundle command, exactly as it would be typed in chat but without
+the command prefix, and print its output.
+
+The command is checked against your permissions just as it would be in chat.
+Pipelines are supported, but output redirection isn't. The command line must
+be a single argument, quoted so that your shell passes its spaces, quotes, and
+pipes through intact. If the command exits with a non-zero status, so does
+gort exec.
+
+For example:
+
+  gort exec gort:whoami
+  gort exec 'echo "hello   world"'
+  gort exec 'deploy:build --verbose | deploy:notify'`
+	execUsage = `Usage:
+  gort exec [flags] 'command [args...]'
+
+Flags:
+  -h, --help   Show this message and exit
+  -j, --json   Print the full command response as JSON
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+var (
+	flagExecJSON bool
+)
+
+// GetExecCmd is a command
+func GetExecCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   execUse,
+		Short: execShort,
+		Long:  execLong,
+		RunE:  execCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.Flags().BoolVarP(&flagExecJSON, "json", "j", false, "Print the full command response as JSON")
+
+	cmd.SetUsageTemplate(execUsage)
+
+	return cmd
+}
+
+func execCmd(cmd *cobra.Command, args []string) error {
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	envelope, err := c.CommandExecute(args[0])
+	if err != nil {
+		return err
+	}
+
+	if flagExecJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(envelope); err != nil {
+			return err
+		}
+	} else {
+		for _, line := range envelope.Response.Lines {
+			fmt.Println(line)
+		}
+	}
+
+	if code := envelope.Data.ExitCode; code != 0 {
+		return fmt.Errorf("request %d exited with status %d", envelope.Request.RequestID, code)
+	}
+
+	return nil
+}
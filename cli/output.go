@@ -0,0 +1,92 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+const (
+	outputUse   = "output"
+	outputShort = "Show the output of an earlier command"
+	outputLong  = `Show the stored output of an earlier command, given the request ID reported
+when it was started.
+
+Output is only stored if the "audit.output" config section enables it. Users
+may view the output of their own commands; viewing anyone else's requires the
+gort:view_audit permission.`
+	outputUsage = `Usage:
+  gort output [flags] request_id
+
+Flags:
+  -h, --help       Show this message and exit
+  -r, --rendered   Print the output as formatted by the command's templates
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+var (
+	flagOutputRendered bool
+)
+
+// GetOutputCmd is a command
+func GetOutputCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   outputUse,
+		Short: outputShort,
+		Long:  outputLong,
+		RunE:  outputCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.Flags().BoolVarP(&flagOutputRendered, "rendered", "r", false, "Print the output as formatted by the command's templates")
+
+	cmd.SetUsageTemplate(outputUsage)
+
+	return cmd
+}
+
+func outputCmd(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %q", args[0])
+	}
+
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	output, err := c.RequestOutput(id)
+	if err != nil {
+		return err
+	}
+
+	if flagOutputRendered {
+		fmt.Println(output.Rendered)
+	} else {
+		fmt.Println(output.Output)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+// $ cogctl relay-group assign --help
+// Usage: cogctl relay-group assign [OPTIONS] NAME BUNDLES...
+//
+//   Assign bundles to a relay group.
+//
+// Options:
+//   --help  Show this message and exit.
+
+const (
+	relayGroupAssignUse   = "assign"
+	relayGroupAssignShort = "Assign a bundle to a relay group"
+	relayGroupAssignLong  = "Assign a bundle to a relay group."
+	relayGroupAssignUsage = `Usage:
+  gort relay-group assign [flags] group_name bundle_name...
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetRelayGroupAssignCmd is a command
+func GetRelayGroupAssignCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayGroupAssignUse,
+		Short: relayGroupAssignShort,
+		Long:  relayGroupAssignLong,
+		RunE:  relayGroupAssignCmd,
+		Args:  cobra.MinimumNArgs(2),
+	}
+
+	cmd.SetUsageTemplate(relayGroupAssignUsage)
+
+	return cmd
+}
+
+func relayGroupAssignCmd(cmd *cobra.Command, args []string) error {
+	groupname := args[0]
+	names := args[1:]
+
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	var errs int
+
+	for _, name := range names {
+		var output string
+
+		if err := gortClient.RelayGroupBundleAdd(groupname, name); err != nil {
+			output = fmt.Sprintf("Bundle NOT assigned to %s: %s (%s)", groupname, name, err.Error())
+			errs++
+		} else {
+			output = fmt.Sprintf("Bundle assigned to %s: %s", groupname, name)
+		}
+
+		fmt.Println(output)
+	}
+
+	fmt.Printf("%d bundle(s) assigned to; %d not assigned to.\n", len(names)-errs, errs)
+
+	return nil
+}
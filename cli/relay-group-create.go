@@ -0,0 +1,90 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+// $ cogctl relay-group create --help
+// Usage: cogctl relay-group create [OPTIONS] NAME
+//
+//   Create a relay group.
+//
+// Options:
+//   --help  Show this message and exit.
+
+const (
+	relayGroupCreateUse   = "create"
+	relayGroupCreateShort = "Create a new relay group"
+	relayGroupCreateLong  = "Create a new relay group."
+	relayGroupCreateUsage = `Usage:
+  gort relay-group create [flags] group_name
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetRelayGroupCreateCmd is a command
+func GetRelayGroupCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayGroupCreateUse,
+		Short: relayGroupCreateShort,
+		Long:  relayGroupCreateLong,
+		RunE:  relayGroupCreateCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.SetUsageTemplate(relayGroupCreateUsage)
+
+	return cmd
+}
+
+func relayGroupCreateCmd(cmd *cobra.Command, args []string) error {
+	groupname := args[0]
+
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	// Only allow this operation if the group doesn't already exist.
+	exists, err := c.RelayGroupExists(groupname)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return client.ErrResourceExists
+	}
+
+	err = c.RelayGroupCreate(groupname)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Relay group %q created.\n", groupname)
+
+	return nil
+}
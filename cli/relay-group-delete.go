@@ -0,0 +1,81 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+// $ cogctl relay-group delete --help
+// Usage: cogctl relay-group delete [OPTIONS] NAME...
+//
+//   Delete relay groups.
+//
+// Options:
+//   --help  Show this message and exit.
+
+const (
+	relayGroupDeleteUse   = "delete"
+	relayGroupDeleteShort = "Delete an existing relay group"
+	relayGroupDeleteLong  = "Delete an existing relay group."
+	relayGroupDeleteUsage = `Usage:
+  gort relay-group delete [flags] group_name
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetRelayGroupDeleteCmd is a command
+func GetRelayGroupDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayGroupDeleteUse,
+		Short: relayGroupDeleteShort,
+		Long:  relayGroupDeleteLong,
+		RunE:  relayGroupDeleteCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.SetUsageTemplate(relayGroupDeleteUsage)
+
+	return cmd
+}
+
+func relayGroupDeleteCmd(cmd *cobra.Command, args []string) error {
+	groupname := args[0]
+
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	err = c.RelayGroupDelete(groupname)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Relay group %q deleted.\n", groupname)
+
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+// $ cogctl relay-group info --help
+// Usage: cogctl relay-group info [OPTIONS] NAME
+//
+//   Show relay group details.
+//
+// Options:
+//   --help  Show this message and exit.
+
+const (
+	relayGroupInfoUse   = "info"
+	relayGroupInfoShort = "Show info on a specific relay group"
+	relayGroupInfoLong  = "Show info on a specific relay group, including which of its relays are connected."
+	relayGroupInfoUsage = `Usage:
+  gort relay-group info [flags] group_name
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetRelayGroupInfoCmd is a command
+func GetRelayGroupInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayGroupInfoUse,
+		Short: relayGroupInfoShort,
+		Long:  relayGroupInfoLong,
+		RunE:  relayGroupInfoCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.SetUsageTemplate(relayGroupInfoUsage)
+
+	return cmd
+}
+
+func relayGroupInfoCmd(cmd *cobra.Command, args []string) error {
+	groupname := args[0]
+
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	group, err := gortClient.RelayGroupGet(groupname)
+	if err != nil {
+		return err
+	}
+
+	connected, err := gortClient.RelayList()
+	if err != nil {
+		return err
+	}
+
+	online := map[string]bool{}
+	for _, r := range connected {
+		online[r.Name] = true
+	}
+
+	var relays []string
+	for _, r := range group.Relays {
+		if online[r] {
+			relays = append(relays, r+" (connected)")
+		} else {
+			relays = append(relays, r+" (disconnected)")
+		}
+	}
+
+	const format = `Name     %s
+Relays   %s
+Bundles  %s
+`
+
+	fmt.Printf(
+		format,
+		group.Name,
+		strings.Join(relays, ", "),
+		strings.Join(group.Bundles, ", "),
+	)
+
+	return nil
+}
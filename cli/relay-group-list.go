@@ -0,0 +1,84 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+// $ cogctl relay-group --help
+// Usage: cogctl relay-group [OPTIONS] COMMAND [ARGS]...
+//
+//   Manage relay groups.
+//
+//   If invoked without a subcommand, lists all relay groups.
+//
+// Options:
+//   --help  Show this message and exit.
+
+const (
+	relayGroupListUse   = "list"
+	relayGroupListShort = "List all existing relay groups"
+	relayGroupListLong  = "List all existing relay groups."
+	relayGroupListUsage = `Usage:
+  gort relay-group list [flags]
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetRelayGroupListCmd is a command
+func GetRelayGroupListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayGroupListUse,
+		Short: relayGroupListShort,
+		Long:  relayGroupListLong,
+		RunE:  relayGroupListCmd,
+	}
+
+	cmd.SetUsageTemplate(relayGroupListUsage)
+
+	return cmd
+}
+
+func relayGroupListCmd(cmd *cobra.Command, args []string) error {
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	groups, err := gortClient.RelayGroupList()
+	if err != nil {
+		return err
+	}
+
+	c := &Columnizer{}
+	c.StringColumn("RELAY GROUP NAME", func(i int) string { return groups[i].Name })
+	c.StringColumn("RELAYS", func(i int) string { return strings.Join(groups[i].Relays, ", ") })
+	c.StringColumn("BUNDLES", func(i int) string { return strings.Join(groups[i].Bundles, ", ") })
+	c.Print(groups)
+
+	return nil
+}
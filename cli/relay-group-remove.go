@@ -0,0 +1,92 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+// $ cogctl relay-group remove --help
+// Usage: cogctl relay-group remove [OPTIONS] NAME RELAYS...
+//
+//   Remove relays from a group.
+//
+// Options:
+//   --help  Show this message and exit.
+
+const (
+	relayGroupRemoveUse   = "remove"
+	relayGroupRemoveShort = "Remove a relay from a relay group"
+	relayGroupRemoveLong  = "Remove a relay from a relay group."
+	relayGroupRemoveUsage = `Usage:
+  gort relay-group remove [flags] group_name relay_name...
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetRelayGroupRemoveCmd is a command
+func GetRelayGroupRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayGroupRemoveUse,
+		Short: relayGroupRemoveShort,
+		Long:  relayGroupRemoveLong,
+		RunE:  relayGroupRemoveCmd,
+		Args:  cobra.MinimumNArgs(2),
+	}
+
+	cmd.SetUsageTemplate(relayGroupRemoveUsage)
+
+	return cmd
+}
+
+func relayGroupRemoveCmd(cmd *cobra.Command, args []string) error {
+	groupname := args[0]
+	names := args[1:]
+
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	var errs int
+
+	for _, name := range names {
+		var output string
+
+		if err := gortClient.RelayGroupRelayDelete(groupname, name); err != nil {
+			output = fmt.Sprintf("Relay NOT removed from %s: %s (%s)", groupname, name, err.Error())
+			errs++
+		} else {
+			output = fmt.Sprintf("Relay removed from %s: %s", groupname, name)
+		}
+
+		fmt.Println(output)
+	}
+
+	fmt.Printf("%d relay(s) removed from; %d not removed from.\n", len(names)-errs, errs)
+
+	return nil
+}
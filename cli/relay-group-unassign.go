@@ -0,0 +1,92 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+// $ cogctl relay-group unassign --help
+// Usage: cogctl relay-group unassign [OPTIONS] NAME BUNDLES...
+//
+//   Unassign bundles from a relay group.
+//
+// Options:
+//   --help  Show this message and exit.
+
+const (
+	relayGroupUnassignUse   = "unassign"
+	relayGroupUnassignShort = "Remove a bundle from a relay group"
+	relayGroupUnassignLong  = "Remove a bundle from a relay group."
+	relayGroupUnassignUsage = `Usage:
+  gort relay-group unassign [flags] group_name bundle_name...
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetRelayGroupUnassignCmd is a command
+func GetRelayGroupUnassignCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayGroupUnassignUse,
+		Short: relayGroupUnassignShort,
+		Long:  relayGroupUnassignLong,
+		RunE:  relayGroupUnassignCmd,
+		Args:  cobra.MinimumNArgs(2),
+	}
+
+	cmd.SetUsageTemplate(relayGroupUnassignUsage)
+
+	return cmd
+}
+
+func relayGroupUnassignCmd(cmd *cobra.Command, args []string) error {
+	groupname := args[0]
+	names := args[1:]
+
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	var errs int
+
+	for _, name := range names {
+		var output string
+
+		if err := gortClient.RelayGroupBundleDelete(groupname, name); err != nil {
+			output = fmt.Sprintf("Bundle NOT unassigned from %s: %s (%s)", groupname, name, err.Error())
+			errs++
+		} else {
+			output = fmt.Sprintf("Bundle unassigned from %s: %s", groupname, name)
+		}
+
+		fmt.Println(output)
+	}
+
+	fmt.Printf("%d bundle(s) unassigned from; %d not unassigned from.\n", len(names)-errs, errs)
+
+	return nil
+}
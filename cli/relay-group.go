@@ -0,0 +1,70 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// $ cogctl relay-group --help
+// Usage: cogctl relay-group [OPTIONS] COMMAND [ARGS]...
+//
+//   Manage relay groups.
+//
+//   If invoked without a subcommand, lists all relay groups.
+//
+// Options:
+//   --help  Show this message and exit.
+//
+// Commands:
+//   add       Add relays to a group.
+//   assign    Assign bundles to a relay group.
+//   create    Create a relay group.
+//   delete    Delete relay groups.
+//   info      Show relay group details.
+//   remove    Remove relays from a group.
+//   rename    Rename a relay group.
+//   unassign  Unassign bundles from a relay group.
+
+const (
+	relayGroupUse   = "relay-group"
+	relayGroupShort = "Manage relay groups"
+	relayGroupLong  = `Manage relay groups.
+
+A relay group is a named set of relays. Commands from a bundle that's assigned
+to a relay group will only be executed by a relay in that group.`
+)
+
+// GetRelayGroupCmd relay-group
+func GetRelayGroupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayGroupUse,
+		Short: relayGroupShort,
+		Long:  relayGroupLong,
+	}
+
+	cmd.AddCommand(GetRelayGroupAddCmd())
+	cmd.AddCommand(GetRelayGroupAssignCmd())
+	cmd.AddCommand(GetRelayGroupCreateCmd())
+	cmd.AddCommand(GetRelayGroupDeleteCmd())
+	cmd.AddCommand(GetRelayGroupInfoCmd())
+	cmd.AddCommand(GetRelayGroupListCmd())
+	cmd.AddCommand(GetRelayGroupRemoveCmd())
+	cmd.AddCommand(GetRelayGroupUnassignCmd())
+
+	return cmd
+}
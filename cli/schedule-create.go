@@ -0,0 +1,115 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+	"github.com/getgort/gort/data/rest"
+)
+
+const (
+	scheduleCreateUse   = "create"
+	scheduleCreateShort = "Schedule a command"
+	scheduleCreateLong  = `Schedule a command to be executed periodically.
+
+The schedule is a standard five-field cron expression (minute, hour, day of
+month, month, and day of week), or one of @hourly, @daily, @weekly, @monthly,
+or @yearly. It's evaluated in the given time zone, or UTC if none is given.
+
+The command runs as the given Gort user, or as you if no user is given, and
+its output is sent to the given adapter and channel. Use "--" to separate the
+command from any flags that it takes.
+
+For example:
+
+  gort schedule create -c "0 9 * * mon-fri" -a slack --channel C0123 -- echo -n Good morning!`
+	scheduleCreateUsage = `Usage:
+  gort schedule create [flags] command [args...]
+
+Flags:
+  -a, --adapter string    The adapter to run the command through (required)
+      --channel string    The ID of the channel to run the command in (required)
+  -c, --cron string       The cron expression that describes when to run (required)
+  -h, --help              Show this message and exit
+  -z, --timezone string   The time zone to evaluate the cron expression in (default UTC)
+  -u, --user string       The Gort user to run the command as
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+var (
+	flagScheduleCreateAdapter  string
+	flagScheduleCreateChannel  string
+	flagScheduleCreateCron     string
+	flagScheduleCreateTimezone string
+	flagScheduleCreateUser     string
+)
+
+// GetScheduleCreateCmd is a command
+func GetScheduleCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   scheduleCreateUse,
+		Short: scheduleCreateShort,
+		Long:  scheduleCreateLong,
+		RunE:  scheduleCreateCmd,
+		Args:  cobra.MinimumNArgs(1),
+	}
+
+	cmd.Flags().StringVarP(&flagScheduleCreateAdapter, "adapter", "a", "", "The adapter to run the command through")
+	cmd.Flags().StringVar(&flagScheduleCreateChannel, "channel", "", "The ID of the channel to run the command in")
+	cmd.Flags().StringVarP(&flagScheduleCreateCron, "cron", "c", "", "The cron expression that describes when to run")
+	cmd.Flags().StringVarP(&flagScheduleCreateTimezone, "timezone", "z", "", "The time zone to evaluate the cron expression in")
+	cmd.Flags().StringVarP(&flagScheduleCreateUser, "user", "u", "", "The Gort user to run the command as")
+
+	cmd.MarkFlagRequired("adapter")
+	cmd.MarkFlagRequired("channel")
+	cmd.MarkFlagRequired("cron")
+
+	cmd.SetUsageTemplate(scheduleCreateUsage)
+
+	return cmd
+}
+
+func scheduleCreateCmd(cmd *cobra.Command, args []string) error {
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := c.ScheduleCreate(rest.Schedule{
+		Cron:      flagScheduleCreateCron,
+		Timezone:  flagScheduleCreateTimezone,
+		Command:   strings.Join(args, " "),
+		Adapter:   flagScheduleCreateAdapter,
+		ChannelID: flagScheduleCreateChannel,
+		UserName:  flagScheduleCreateUser,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Schedule %d created; next run at %s.\n", schedule.ID, scheduleNextRun(schedule))
+
+	return nil
+}
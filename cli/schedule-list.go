@@ -0,0 +1,92 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+	"github.com/getgort/gort/data/rest"
+)
+
+const (
+	scheduleListUse   = "list"
+	scheduleListShort = "List all schedules"
+	scheduleListLong  = "List all schedules."
+	scheduleListUsage = `Usage:
+  gort schedule list [flags]
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetScheduleListCmd is a command
+func GetScheduleListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   scheduleListUse,
+		Short: scheduleListShort,
+		Long:  scheduleListLong,
+		RunE:  scheduleListCmd,
+	}
+
+	cmd.SetUsageTemplate(scheduleListUsage)
+
+	return cmd
+}
+
+func scheduleListCmd(cmd *cobra.Command, args []string) error {
+	gortClient, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	schedules, err := gortClient.ScheduleList()
+	if err != nil {
+		return err
+	}
+
+	c := &Columnizer{}
+	c.StringColumn("ID", func(i int) string { return strconv.FormatInt(schedules[i].ID, 10) })
+	c.StringColumn("CRON", func(i int) string { return schedules[i].Cron })
+	c.StringColumn("TIMEZONE", func(i int) string { return process(schedules[i].Timezone) })
+	c.StringColumn("USER", func(i int) string { return schedules[i].UserName })
+	c.StringColumn("ADAPTER", func(i int) string { return schedules[i].Adapter })
+	c.StringColumn("CHANNEL", func(i int) string { return schedules[i].ChannelID })
+	c.StringColumn("NEXT RUN", func(i int) string { return scheduleNextRun(schedules[i]) })
+	c.StringColumn("COMMAND", func(i int) string { return schedules[i].Command })
+	c.Print(schedules)
+
+	return nil
+}
+
+// scheduleNextRun describes when a schedule will next run, or "paused".
+func scheduleNextRun(s rest.Schedule) string {
+	switch {
+	case s.Paused:
+		return "paused"
+	case s.NextRun.IsZero():
+		return "never"
+	default:
+		return s.NextRun.Local().Format("2006-01-02 15:04")
+	}
+}
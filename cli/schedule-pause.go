@@ -0,0 +1,77 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+const (
+	schedulePauseUse   = "pause"
+	schedulePauseShort = "Pause a schedule"
+	schedulePauseLong  = `Pause a schedule. It won't run again until it's resumed with
+"gort schedule resume".`
+	schedulePauseUsage = `Usage:
+  gort schedule pause [flags] schedule_id
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetSchedulePauseCmd is a command
+func GetSchedulePauseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   schedulePauseUse,
+		Short: schedulePauseShort,
+		Long:  schedulePauseLong,
+		RunE:  schedulePauseCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.SetUsageTemplate(schedulePauseUsage)
+
+	return cmd
+}
+
+func schedulePauseCmd(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid schedule ID: %q", args[0])
+	}
+
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.SchedulePause(id); err != nil {
+		return err
+	}
+
+	fmt.Printf("Schedule %d paused.\n", id)
+
+	return nil
+}
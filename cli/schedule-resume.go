@@ -0,0 +1,77 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/client"
+)
+
+const (
+	scheduleResumeUse   = "resume"
+	scheduleResumeShort = "Resume a paused schedule"
+	scheduleResumeLong  = `Resume a paused schedule. Any runs that were missed while it was paused
+are skipped.`
+	scheduleResumeUsage = `Usage:
+  gort schedule resume [flags] schedule_id
+
+Flags:
+  -h, --help   Show this message and exit
+
+Global Flags:
+  -P, --profile string   The Gort profile within the config file to use
+`
+)
+
+// GetScheduleResumeCmd is a command
+func GetScheduleResumeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   scheduleResumeUse,
+		Short: scheduleResumeShort,
+		Long:  scheduleResumeLong,
+		RunE:  scheduleResumeCmd,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.SetUsageTemplate(scheduleResumeUsage)
+
+	return cmd
+}
+
+func scheduleResumeCmd(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid schedule ID: %q", args[0])
+	}
+
+	c, err := client.Connect(FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.ScheduleResume(id); err != nil {
+		return err
+	}
+
+	fmt.Printf("Schedule %d resumed.\n", id)
+
+	return nil
+}
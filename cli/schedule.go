@@ -0,0 +1,48 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	scheduleUse   = "schedule"
+	scheduleShort = "Run commands on a schedule"
+	scheduleLong  = `Manage scheduled commands.
+
+A schedule executes a command periodically, according to a cron expression,
+in a chat channel and on behalf of a Gort user. Scheduled commands are
+subject to the same permission checks and auditing as any other command.`
+)
+
+// GetScheduleCmd schedule
+func GetScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   scheduleUse,
+		Short: scheduleShort,
+		Long:  scheduleLong,
+	}
+
+	cmd.AddCommand(GetScheduleCreateCmd())
+	cmd.AddCommand(GetScheduleDeleteCmd())
+	cmd.AddCommand(GetScheduleListCmd())
+	cmd.AddCommand(GetSchedulePauseCmd())
+	cmd.AddCommand(GetScheduleResumeCmd())
+
+	return cmd
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/getgort/gort/data/rest"
+)
+
+// AliasDelete deletes an alias. If the alias is a user alias and its owner
+// is empty, it's assumed to belong to the current user.
+func (c *GortClient) AliasDelete(alias rest.Alias) error {
+	resp, err := c.doRequest("DELETE", c.aliasURL(alias), []byte{})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return getResponseError(resp)
+	}
+
+	return nil
+}
+
+// AliasList retrieves all of the aliases that the current user may see: the
+// site aliases and their own, or all aliases if they're permitted to manage
+// them.
+func (c *GortClient) AliasList() ([]rest.Alias, error) {
+	url := fmt.Sprintf("%s/v2/aliases", c.profile.URL.String())
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return []rest.Alias{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return []rest.Alias{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []rest.Alias{}, err
+	}
+
+	aliases := []rest.Alias{}
+	err = json.Unmarshal(body, &aliases)
+	if err != nil {
+		return []rest.Alias{}, err
+	}
+
+	return aliases, nil
+}
+
+// AliasSave creates an alias. If the alias is a user alias and its owner is
+// empty, it's created for the current user.
+func (c *GortClient) AliasSave(alias rest.Alias) error {
+	bytes, err := json.Marshal(alias)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doRequest("PUT", c.aliasURL(alias), bytes)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return getResponseError(resp)
+	}
+
+	return nil
+}
+
+func (c *GortClient) aliasURL(alias rest.Alias) string {
+	scope := alias.Scope
+	if scope == "" {
+		scope = rest.AliasScopeUser
+	}
+
+	u := fmt.Sprintf("%s/v2/aliases/%s/%s", c.profile.URL.String(), scope, url.PathEscape(alias.Name))
+	if scope == rest.AliasScopeUser && alias.Owner != "" {
+		u += "?user=" + url.QueryEscape(alias.Owner)
+	}
+
+	return u
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+)
+
+// CommandExecute executes a command line, exactly as it would be typed in
+// chat but without the command prefix, and waits for it to complete. The
+// command is permission-checked as the client's user.
+func (c *GortClient) CommandExecute(line string) (data.CommandResponseEnvelope, error) {
+	url := fmt.Sprintf("%s/v2/commands/execute", c.profile.URL.String())
+
+	bytes, err := json.Marshal(rest.CommandExecution{Command: line})
+	if err != nil {
+		return data.CommandResponseEnvelope{}, err
+	}
+
+	resp, err := c.doRequest("POST", url, bytes)
+	if err != nil {
+		return data.CommandResponseEnvelope{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return data.CommandResponseEnvelope{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return data.CommandResponseEnvelope{}, err
+	}
+
+	envelope := data.CommandResponseEnvelope{}
+	err = json.Unmarshal(body, &envelope)
+	if err != nil {
+		return data.CommandResponseEnvelope{}, err
+	}
+
+	return envelope, nil
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/getgort/gort/data/rest"
+)
+
+// RelayGroupBundleAdd assigns a bundle to a relay group.
+func (c *GortClient) RelayGroupBundleAdd(groupname string, bundlename string) error {
+	url := fmt.Sprintf("%s/v2/relay-groups/%s/bundles/%s", c.profile.URL.String(), groupname, bundlename)
+	return c.doRelayGroupRequest("PUT", url)
+}
+
+// RelayGroupBundleDelete removes a bundle from a relay group.
+func (c *GortClient) RelayGroupBundleDelete(groupname string, bundlename string) error {
+	url := fmt.Sprintf("%s/v2/relay-groups/%s/bundles/%s", c.profile.URL.String(), groupname, bundlename)
+	return c.doRelayGroupRequest("DELETE", url)
+}
+
+// RelayGroupCreate creates a relay group. It's not an error if the group
+// already exists.
+func (c *GortClient) RelayGroupCreate(groupname string) error {
+	url := fmt.Sprintf("%s/v2/relay-groups/%s", c.profile.URL.String(), groupname)
+	return c.doRelayGroupRequest("PUT", url)
+}
+
+// RelayGroupDelete deletes a relay group.
+func (c *GortClient) RelayGroupDelete(groupname string) error {
+	url := fmt.Sprintf("%s/v2/relay-groups/%s", c.profile.URL.String(), groupname)
+	return c.doRelayGroupRequest("DELETE", url)
+}
+
+// RelayGroupExists simply returns true if a relay group exists with the
+// specified name; false otherwise.
+func (c *GortClient) RelayGroupExists(groupname string) (bool, error) {
+	url := fmt.Sprintf("%s/v2/relay-groups/%s", c.profile.URL.String(), groupname)
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, getResponseError(resp)
+	}
+}
+
+// RelayGroupGet retrieves a relay group, including its relays and bundles.
+func (c *GortClient) RelayGroupGet(groupname string) (rest.RelayGroup, error) {
+	url := fmt.Sprintf("%s/v2/relay-groups/%s", c.profile.URL.String(), groupname)
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return rest.RelayGroup{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return rest.RelayGroup{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return rest.RelayGroup{}, err
+	}
+
+	group := rest.RelayGroup{}
+	err = json.Unmarshal(body, &group)
+	if err != nil {
+		return rest.RelayGroup{}, err
+	}
+
+	return group, nil
+}
+
+// RelayGroupList retrieves all relay groups.
+func (c *GortClient) RelayGroupList() ([]rest.RelayGroup, error) {
+	url := fmt.Sprintf("%s/v2/relay-groups", c.profile.URL.String())
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return []rest.RelayGroup{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return []rest.RelayGroup{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []rest.RelayGroup{}, err
+	}
+
+	groups := []rest.RelayGroup{}
+	err = json.Unmarshal(body, &groups)
+	if err != nil {
+		return []rest.RelayGroup{}, err
+	}
+
+	return groups, nil
+}
+
+// RelayGroupRelayAdd adds a relay to a relay group.
+func (c *GortClient) RelayGroupRelayAdd(groupname string, relayname string) error {
+	url := fmt.Sprintf("%s/v2/relay-groups/%s/relays/%s", c.profile.URL.String(), groupname, relayname)
+	return c.doRelayGroupRequest("PUT", url)
+}
+
+// RelayGroupRelayDelete removes a relay from a relay group.
+func (c *GortClient) RelayGroupRelayDelete(groupname string, relayname string) error {
+	url := fmt.Sprintf("%s/v2/relay-groups/%s/relays/%s", c.profile.URL.String(), groupname, relayname)
+	return c.doRelayGroupRequest("DELETE", url)
+}
+
+// doRelayGroupRequest executes a request that has no response body.
+func (c *GortClient) doRelayGroupRequest(method string, url string) error {
+	resp, err := c.doRequest(method, url, []byte{})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return getResponseError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/getgort/gort/data/rest"
+	gerrs "github.com/getgort/gort/errors"
+)
+
+// RelayConnect opens a websocket connection to the controller's relay
+// endpoint. The caller is responsible for speaking the relay protocol over
+// the returned connection, and for closing it.
+func (c *GortClient) RelayConnect(ctx context.Context) (*websocket.Conn, error) {
+	token, err := c.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	u := *c.profile.URL
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	url := fmt.Sprintf("%s/v2/relays/connect", u.String())
+
+	dialer := *websocket.DefaultDialer
+	if c.profile.AllowInsecure {
+		dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	header := http.Header{}
+	header.Add("X-Session-Token", token.Token)
+
+	conn, resp, err := dialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			return nil, getResponseError(resp)
+		}
+
+		return nil, gerrs.Wrap(ErrConnectionFailed, err)
+	}
+
+	return conn, nil
+}
+
+// RelayList retrieves all remote relays currently connected to the
+// controller.
+func (c *GortClient) RelayList() ([]rest.Relay, error) {
+	url := fmt.Sprintf("%s/v2/relays", c.profile.URL.String())
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return []rest.Relay{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return []rest.Relay{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []rest.Relay{}, err
+	}
+
+	relays := []rest.Relay{}
+	err = json.Unmarshal(body, &relays)
+	if err != nil {
+		return []rest.Relay{}, err
+	}
+
+	return relays, nil
+}
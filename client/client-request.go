@@ -0,0 +1,163 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/getgort/gort/data/rest"
+)
+
+// RequestCancel cancels a command request that's currently executing.
+func (c *GortClient) RequestCancel(id int64) error {
+	url := fmt.Sprintf("%s/v2/requests/%d", c.profile.URL.String(), id)
+	resp, err := c.doRequest("DELETE", url, []byte{})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return getResponseError(resp)
+	}
+
+	return nil
+}
+
+// RequestGet retrieves the audit log record for a single command request.
+func (c *GortClient) RequestGet(id int64) (rest.AuditRecord, error) {
+	url := fmt.Sprintf("%s/v2/requests/%d", c.profile.URL.String(), id)
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return rest.AuditRecord{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return rest.AuditRecord{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return rest.AuditRecord{}, err
+	}
+
+	record := rest.AuditRecord{}
+	err = json.Unmarshal(body, &record)
+	if err != nil {
+		return rest.AuditRecord{}, err
+	}
+
+	return record, nil
+}
+
+// RequestOutput retrieves the stored output of a command request.
+func (c *GortClient) RequestOutput(id int64) (rest.RequestOutput, error) {
+	url := fmt.Sprintf("%s/v2/requests/%d/output", c.profile.URL.String(), id)
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return rest.RequestOutput{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return rest.RequestOutput{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return rest.RequestOutput{}, err
+	}
+
+	output := rest.RequestOutput{}
+	err = json.Unmarshal(body, &output)
+	if err != nil {
+		return rest.RequestOutput{}, err
+	}
+
+	return output, nil
+}
+
+// RequestList retrieves the audit log records that match the filter, newest
+// first.
+func (c *GortClient) RequestList(filter rest.AuditFilter) ([]rest.AuditRecord, error) {
+	url := fmt.Sprintf("%s/v2/requests?%s", c.profile.URL.String(), auditQuery(filter).Encode())
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return []rest.AuditRecord{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return []rest.AuditRecord{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []rest.AuditRecord{}, err
+	}
+
+	records := []rest.AuditRecord{}
+	err = json.Unmarshal(body, &records)
+	if err != nil {
+		return []rest.AuditRecord{}, err
+	}
+
+	return records, nil
+}
+
+// auditQuery encodes the non-zero fields of an audit filter as the query
+// parameters expected by "GET /v2/requests".
+func auditQuery(filter rest.AuditFilter) url.Values {
+	q := url.Values{}
+
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+
+	set("user", filter.UserName)
+	set("bundle", filter.BundleName)
+	set("command", filter.CommandName)
+	set("adapter", filter.Adapter)
+	set("channel", filter.ChannelID)
+
+	if filter.ExitCode != nil {
+		q.Set("status", strconv.Itoa(int(*filter.ExitCode)))
+	}
+	if !filter.Since.IsZero() {
+		q.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		q.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		q.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		q.Set("offset", strconv.Itoa(filter.Offset))
+	}
+
+	return q
+}
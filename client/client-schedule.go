@@ -0,0 +1,129 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/getgort/gort/data/rest"
+)
+
+// ScheduleCreate creates a new schedule, and returns it as stored by the
+// server, including its ID and next run time.
+func (c *GortClient) ScheduleCreate(schedule rest.Schedule) (rest.Schedule, error) {
+	url := fmt.Sprintf("%s/v2/schedules", c.profile.URL.String())
+
+	bytes, err := json.Marshal(schedule)
+	if err != nil {
+		return rest.Schedule{}, err
+	}
+
+	resp, err := c.doRequest("POST", url, bytes)
+	if err != nil {
+		return rest.Schedule{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return rest.Schedule{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return rest.Schedule{}, err
+	}
+
+	created := rest.Schedule{}
+	err = json.Unmarshal(body, &created)
+	if err != nil {
+		return rest.Schedule{}, err
+	}
+
+	return created, nil
+}
+
+// ScheduleDelete deletes a schedule.
+func (c *GortClient) ScheduleDelete(id int64) error {
+	url := fmt.Sprintf("%s/v2/schedules/%d", c.profile.URL.String(), id)
+	resp, err := c.doRequest("DELETE", url, []byte{})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return getResponseError(resp)
+	}
+
+	return nil
+}
+
+// ScheduleList retrieves all schedules.
+func (c *GortClient) ScheduleList() ([]rest.Schedule, error) {
+	url := fmt.Sprintf("%s/v2/schedules", c.profile.URL.String())
+	resp, err := c.doRequest("GET", url, []byte{})
+	if err != nil {
+		return []rest.Schedule{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return []rest.Schedule{}, getResponseError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []rest.Schedule{}, err
+	}
+
+	schedules := []rest.Schedule{}
+	err = json.Unmarshal(body, &schedules)
+	if err != nil {
+		return []rest.Schedule{}, err
+	}
+
+	return schedules, nil
+}
+
+// SchedulePause pauses a schedule. It won't run again until it's resumed.
+func (c *GortClient) SchedulePause(id int64) error {
+	return c.doSchedulePause(id, true)
+}
+
+// ScheduleResume resumes a paused schedule. Any runs that were missed while
+// it was paused are skipped.
+func (c *GortClient) ScheduleResume(id int64) error {
+	return c.doSchedulePause(id, false)
+}
+
+func (c *GortClient) doSchedulePause(id int64, paused bool) error {
+	url := fmt.Sprintf("%s/v2/schedules/%d?paused=%v", c.profile.URL.String(), id, paused)
+	resp, err := c.doRequest("PATCH", url, []byte{})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return getResponseError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	relayUse   = "relay"
+	relayShort = "Run a standalone Gort relay"
+	relayLong  = `Connects to the Gort controller as a remote relay, and executes any commands
+that the controller sends to it.
+
+The controller is specified by the active client profile, and the user
+associated with that profile must have the gort:manage_relays permission.`
+)
+
+var (
+	flagRelayConfigfile   string
+	flagRelayVerboseCount int
+)
+
+// GetRelayCmd relay
+func GetRelayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   relayUse,
+		Short: relayShort,
+		Long:  relayLong,
+		RunE:  relayCmd,
+	}
+
+	cmd.Flags().StringVarP(&flagRelayConfigfile, "config", "c", "config.yml", "The location of the config file to use")
+	cmd.Flags().CountVarP(&flagRelayVerboseCount, "verbose", "v", "Verbose mode (can be used multiple times)")
+
+	return cmd
+}
+
+func relayCmd(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	return startRelay(ctx, flagRelayConfigfile, flagRelayVerboseCount)
+}
@@ -38,14 +38,23 @@ func GetRootCmd() *cobra.Command {
 	}
 
 	root.AddCommand(GetStartCmd())
+	root.AddCommand(GetRelayCmd())
+	root.AddCommand(GetShellCmd())
+	root.AddCommand(cli.GetAliasCmd())
+	root.AddCommand(cli.GetAuditCmd())
 	root.AddCommand(cli.GetBootstrapCmd())
 	root.AddCommand(cli.GetBundleCmd())
+	root.AddCommand(cli.GetCancelCmd())
 	root.AddCommand(cli.GetConfigCmd())
+	root.AddCommand(cli.GetExecCmd())
 	root.AddCommand(cli.GetGroupCmd())
 	root.AddCommand(cli.GetHiddenCmd())
+	root.AddCommand(cli.GetOutputCmd())
 	root.AddCommand(cli.GetPermissionCmd())
 	root.AddCommand(cli.GetProfileCmd())
+	root.AddCommand(cli.GetRelayGroupCmd())
 	root.AddCommand(cli.GetRoleCmd())
+	root.AddCommand(cli.GetScheduleCmd())
 	root.AddCommand(cli.GetUserCmd())
 	root.AddCommand(cli.GetVersionCmd())
 
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/getgort/gort/data"
+)
+
+const (
+	shellUse   = "shell"
+	shellShort = "Run Gort interactively in the terminal"
+	shellLong  = `Runs a Gort controller that talks to the terminal instead of a chat provider.
+Each line of input is a direct message to Gort from the user given by --user,
+and Gort's responses are written back to the terminal. Chat adapters in the
+config file are not started. Input ends with Ctrl+D.
+
+If the data store hasn't been bootstrapped it's bootstrapped, with the user
+as the administrator. Bundles given with --bundle are installed and enabled, and
+their permissions are granted to the administrator role, making this a
+self-contained environment for developing bundles.
+
+The in-memory data store is used unless the config file has a database
+section. Because the shell bootstraps and installs bundles into it, a
+configured database is only used if --use-database is given.`
+)
+
+// shellAdapterName is the name of the terminal adapter, as used in Gort
+// user mappings.
+const shellAdapterName = "shell"
+
+var (
+	flagShellBundles      []string
+	flagShellConfigfile   string
+	flagShellEmail        string
+	flagShellNoColor      bool
+	flagShellUseDatabase  bool
+	flagShellUser         string
+	flagShellVerboseCount int
+)
+
+// GetShellCmd shell
+func GetShellCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   shellUse,
+		Short: shellShort,
+		Long:  shellLong,
+		RunE:  shellCmd,
+	}
+
+	cmd.Flags().StringArrayVarP(&flagShellBundles, "bundle", "b", nil, "A bundle file to install and enable (can be used multiple times)")
+	cmd.Flags().StringVarP(&flagShellConfigfile, "config", "c", "config.yml", "The location of the config file to use")
+	cmd.Flags().StringVarP(&flagShellEmail, "email", "e", "", "The email address of the user")
+	cmd.Flags().BoolVar(&flagShellNoColor, "no-color", false, "Don't use colors in output")
+	cmd.Flags().BoolVar(&flagShellUseDatabase, "use-database", false, "Allow the shell to use the database in the config file")
+	cmd.Flags().StringVarP(&flagShellUser, "user", "u", "developer", "The ID of the user that input is sent as")
+	cmd.Flags().CountVarP(&flagShellVerboseCount, "verbose", "v", "Verbose mode (can be used multiple times)")
+
+	return cmd
+}
+
+func shellCmd(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	provider := data.TerminalProvider{
+		AbstractProvider: data.AbstractProvider{Name: shellAdapterName},
+		User:             flagShellUser,
+		Email:            flagShellEmail,
+		NoColor:          flagShellNoColor || os.Getenv("NO_COLOR") != "" || !isTerminal(os.Stdout),
+	}
+
+	// Prompting only makes sense if someone's typing.
+	if isTerminal(os.Stdin) {
+		provider.Prompt = "gort> "
+	}
+
+	return startShell(ctx, flagShellConfigfile, flagShellVerboseCount, provider, flagShellBundles, flagShellUseDatabase)
+}
+
+// isTerminal returns true if f is a character device, such as a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -22,6 +22,19 @@ import (
 	"unicode"
 )
 
+const (
+	// PipeToken is the token that separates the stages of a command pipeline.
+	PipeToken = "|"
+
+	// RedirectToken precedes the single destination that a command's output
+	// should be sent to in place of the originating channel.
+	RedirectToken = ">"
+
+	// RedirectMultiToken precedes one or more destinations that a command's
+	// output should be sent to in place of the originating channel.
+	RedirectMultiToken = "*>"
+)
+
 // Tokenize takes an input string and splits it into tokens. Any control
 // character sequences ("\n", "\t", etc), pass through in their original
 // form.
@@ -29,6 +42,10 @@ import (
 //    echo -n foo bar -> {"echo", "-n", "foo", "bar"}
 //    echo -n "foo bar" -> {"echo", "-n", "foo bar"}
 //    echo "What's" "\"this\"?" -> {"echo", "What's", "\"this\"?"}
+//    seed 5|echo -> {"seed", "5", "|", "echo"}
+//
+// An unquoted pipe character is always emitted as a token of its own, so
+// that the result can be divided into stages by SplitPipeline.
 func Tokenize(input string) ([]string, error) {
 	const RuneNull = rune(0)
 
@@ -62,6 +79,14 @@ func Tokenize(input string) ([]string, error) {
 			}
 			b.Reset()
 
+		// Pipes outside of quotes are token delimitters and tokens.
+		case ch == '|' && quote == RuneNull:
+			if t := b.String(); len(t) > 0 {
+				tokens = append(tokens, t)
+			}
+			tokens = append(tokens, PipeToken)
+			b.Reset()
+
 		// Everything inside a pair of quotes is added to the same token.
 		case ch == quote:
 			b.WriteRune(ch)
@@ -102,6 +127,70 @@ func Tokenize(input string) ([]string, error) {
 	return tokens, nil
 }
 
+// SplitPipeline divides a slice of tokens, as returned by Tokenize, into
+// pipeline stages delimited by PipeToken. Input without any pipes is
+// returned as a single stage. An error is returned if any stage is empty.
+// Examples:
+//    {"echo", "foo"} -> {{"echo", "foo"}}
+//    {"seed", "5", "|", "echo"} -> {{"seed", "5"}, {"echo"}}
+func SplitPipeline(tokens []string) ([][]string, error) {
+	stages := [][]string{}
+	start := 0
+
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && tokens[i] != PipeToken {
+			continue
+		}
+
+		if i == start && (i < len(tokens) || len(stages) > 0) {
+			return nil, TokenizeError{"empty pipeline stage at token %d", i + 1}
+		}
+
+		stages = append(stages, tokens[start:i])
+		start = i + 1
+	}
+
+	return stages, nil
+}
+
+// SplitRedirect separates an output redirect, if any, from the end of a slice
+// of tokens as returned by Tokenize. It returns the tokens that precede the
+// redirect, and the redirect's targets exactly as they were typed. If there's
+// no redirect, the tokens are returned unchanged with nil targets. An error
+// is returned if RedirectToken isn't followed by exactly one target, or if
+// RedirectMultiToken isn't followed by at least one.
+// Examples:
+//    {"echo", "foo"} -> {"echo", "foo"}, nil
+//    {"echo", "foo", ">", "me"} -> {"echo", "foo"}, {"me"}
+//    {"echo", "foo", "*>", "#a", "#b"} -> {"echo", "foo"}, {"#a", "#b"}
+func SplitRedirect(tokens []string) ([]string, []string, error) {
+	for i, t := range tokens {
+		if t != RedirectToken && t != RedirectMultiToken {
+			continue
+		}
+
+		targets := tokens[i+1:]
+
+		for j, target := range targets {
+			switch target {
+			case PipeToken, RedirectToken, RedirectMultiToken:
+				return tokens[:i], nil, TokenizeError{"unexpected token after redirect at %d", i + j + 2}
+			}
+		}
+
+		switch {
+		case len(targets) == 0:
+			return tokens[:i], nil, TokenizeError{"missing redirect target at %d", i + 2}
+		case t == RedirectToken && len(targets) > 1:
+			return tokens[:i], nil, TokenizeError{"too many redirect targets at %d; use *> for multiple targets", i + 3}
+		}
+
+		return tokens[:i], targets, nil
+	}
+
+	return tokens, nil, nil
+}
+
 type TokenizeError struct {
 	Text     string
 	Position int
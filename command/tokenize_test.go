@@ -56,3 +56,106 @@ func TestTokenizeErrors(t *testing.T) {
 		assert.IsType(t, TokenizeError{}, err, in)
 	}
 }
+
+func TestTokenizePipes(t *testing.T) {
+	inputs := map[string][]string{
+		`seed 5 | echo`:     {`seed`, `5`, `|`, `echo`},
+		`seed 5|echo`:       {`seed`, `5`, `|`, `echo`},
+		`echo "a | b" | wc`: {`echo`, `"a | b"`, `|`, `wc`},
+		`echo a\|b`:         {`echo`, `a\|b`},
+		`echo 'a|b'|wc -l`:  {`echo`, `'a|b'`, `|`, `wc`, `-l`},
+		`a | b | c`:         {`a`, `|`, `b`, `|`, `c`},
+		`echo foo |`:        {`echo`, `foo`, `|`},
+	}
+
+	for in, expected := range inputs {
+		token, err := Tokenize(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expected, token, in)
+	}
+}
+
+func TestSplitPipeline(t *testing.T) {
+	inputs := map[string][][]string{
+		`echo foo`:         {{`echo`, `foo`}},
+		`seed 5 | echo`:    {{`seed`, `5`}, {`echo`}},
+		`a -x | b 1 2 | c`: {{`a`, `-x`}, {`b`, `1`, `2`}, {`c`}},
+		`echo "a | b"`:     {{`echo`, `"a | b"`}},
+		``:                 {{}},
+	}
+
+	for in, expected := range inputs {
+		tokens, err := Tokenize(in)
+		assert.NoError(t, err, in)
+
+		stages, err := SplitPipeline(tokens)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expected, stages, in)
+	}
+}
+
+func TestSplitPipelineErrors(t *testing.T) {
+	inputs := map[string]string{
+		`echo foo |`:   "empty pipeline stage at token 4",
+		`| echo foo`:   "empty pipeline stage at token 1",
+		`echo | | foo`: "empty pipeline stage at token 3",
+		`|`:            "empty pipeline stage at token 1",
+	}
+
+	for in, expected := range inputs {
+		tokens, err := Tokenize(in)
+		assert.NoError(t, err, in)
+
+		_, err = SplitPipeline(tokens)
+		assert.Error(t, err, in)
+		assert.Equal(t, expected, err.Error(), in)
+		assert.IsType(t, TokenizeError{}, err, in)
+	}
+}
+
+func TestSplitRedirect(t *testing.T) {
+	type result struct {
+		tokens  []string
+		targets []string
+	}
+
+	inputs := map[string]result{
+		`echo foo`:               {[]string{`echo`, `foo`}, nil},
+		`echo foo > me`:          {[]string{`echo`, `foo`}, []string{`me`}},
+		`echo foo > #ops-alerts`: {[]string{`echo`, `foo`}, []string{`#ops-alerts`}},
+		`echo foo *> #a #b`:      {[]string{`echo`, `foo`}, []string{`#a`, `#b`}},
+		`echo foo | wc *> #a`:    {[]string{`echo`, `foo`, `|`, `wc`}, []string{`#a`}},
+		`echo ">" foo`:           {[]string{`echo`, `">"`, `foo`}, nil},
+		`echo foo>bar`:           {[]string{`echo`, `foo>bar`}, nil},
+	}
+
+	for in, expected := range inputs {
+		tokens, err := Tokenize(in)
+		assert.NoError(t, err, in)
+
+		tokens, targets, err := SplitRedirect(tokens)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expected.tokens, tokens, in)
+		assert.Equal(t, expected.targets, targets, in)
+	}
+}
+
+func TestSplitRedirectErrors(t *testing.T) {
+	inputs := map[string]string{
+		`echo foo >`:       "missing redirect target at 4",
+		`echo foo *>`:      "missing redirect target at 4",
+		`echo foo > #a #b`: "too many redirect targets at 5; use *> for multiple targets",
+		`echo > #a | wc`:   "unexpected token after redirect at 4",
+		`echo *> #a > #b`:  "unexpected token after redirect at 4",
+	}
+
+	for in, expected := range inputs {
+		tokens, err := Tokenize(in)
+		assert.NoError(t, err, in)
+
+		_, _, err = SplitRedirect(tokens)
+		assert.Error(t, err, in)
+		assert.Equal(t, expected, err.Error(), in)
+		assert.IsType(t, TokenizeError{}, err, in)
+	}
+}
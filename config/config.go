@@ -104,6 +104,14 @@ func CurrentState() State {
 	return currentState
 }
 
+// GetAuditConfigs returns the data wrapper for the "audit" config section.
+func GetAuditConfigs() data.AuditConfigs {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	return config.AuditConfigs
+}
+
 // GetDatabaseConfigs returns the data wrapper for the "database" config section.
 func GetDatabaseConfigs() data.DatabaseConfigs {
 	configMutex.RLock()
@@ -152,6 +160,22 @@ func GetGortServerConfigs() data.GortServerConfigs {
 	return config.GortServerConfigs
 }
 
+// GetHTTPProviders returns the data wrapper for the "http" config section.
+func GetHTTPProviders() []data.HTTPProvider {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	return config.HTTPProviders
+}
+
+// GetIRCProviders returns the data wrapper for the "irc" config section.
+func GetIRCProviders() []data.IRCProvider {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	return config.IRCProviders
+}
+
 // GetJaegerConfigs returns the data wrapper for the "jaeger" config section.
 func GetJaegerConfigs() data.JaegerConfigs {
 	configMutex.RLock()
@@ -168,6 +192,30 @@ func GetKubernetesConfigs() data.KubernetesConfigs {
 	return config.KubernetesConfigs
 }
 
+// GetMatrixProviders returns the data wrapper for the "matrix" config section.
+func GetMatrixProviders() []data.MatrixProvider {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	return config.MatrixProviders
+}
+
+// GetMattermostProviders returns the data wrapper for the "mattermost" config section.
+func GetMattermostProviders() []data.MattermostProvider {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	return config.MattermostProviders
+}
+
+// GetRelayConfigs returns the data wrapper for the "relay" config section.
+func GetRelayConfigs() data.RelayConfigs {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	return config.RelayConfigs
+}
+
 // GetSlackProviders returns the data wrapper for the "slack" config section.
 func GetSlackProviders() []data.SlackProvider {
 	configMutex.RLock()
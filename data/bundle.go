@@ -17,14 +17,21 @@
 package data
 
 import (
-	"context"
-	"regexp"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/coreos/go-semver/semver"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	gerrs "github.com/getgort/gort/errors"
 )
 
+// ErrInvalidLimits is returned by Bundle.ValidateLimits if a memory or CPU
+// limit isn't a valid quantity.
+var ErrInvalidLimits = errors.New("invalid resource limit")
+
 // BundleInfo wraps a minimal amount of data about a bundle.
 type BundleInfo struct {
 	Name           string
@@ -50,8 +57,10 @@ type Bundle struct {
 	Kubernetes        BundleKubernetes          `yaml:",omitempty" json:",omitempty"`
 	Permissions       []string                  `yaml:",omitempty" json:",omitempty"`
 	Commands          map[string]*BundleCommand `yaml:",omitempty" json:",omitempty"`
+	Webhooks          map[string]*Webhook       `yaml:",omitempty" json:",omitempty"`
 	Default           bool                      `yaml:"-" json:",omitempty"`
 	Templates         Templates                 `yaml:",omitempty" json:",omitempty"`
+	Limits            `yaml:",inline"`
 }
 
 // ImageFull returns the full image name, consisting of a repository and tag.
@@ -100,36 +109,103 @@ func (b Bundle) Semver() semver.Version {
 // BundleCommand represents a bundle command, as defined in the "bundles/commands"
 // section of the config.
 type BundleCommand struct {
-	Description     string    `yaml:",omitempty" json:"description,omitempty"`
-	Executable      []string  `yaml:",omitempty,flow" json:"executable,omitempty"`
-	LongDescription string    `yaml:"long_description,omitempty" json:"long_description,omitempty"`
-	Name            string    `yaml:"-" json:"-"`
-	Triggers        []Trigger `yaml:"triggers,omitempty" json:"trigger,omitempty"`
-	Rules           []string  `yaml:",omitempty" json:"rules,omitempty"`
-	Templates       Templates `yaml:",omitempty" json:"templates,omitempty"`
+	Description     string                          `yaml:",omitempty" json:"description,omitempty"`
+	Executable      []string                        `yaml:",omitempty,flow" json:"executable,omitempty"`
+	LongDescription string                          `yaml:"long_description,omitempty" json:"long_description,omitempty"`
+	Name            string                          `yaml:"-" json:"-"`
+	Options         map[string]*BundleCommandOption `yaml:",omitempty" json:"options,omitempty"`
+	Arguments       []BundleCommandArgument         `yaml:",omitempty" json:"arguments,omitempty"`
+	Triggers        []Trigger                       `yaml:"triggers,omitempty" json:"trigger,omitempty"`
+	Rules           []string                        `yaml:",omitempty" json:"rules,omitempty"`
+	Streaming       bool                            `yaml:",omitempty" json:"streaming,omitempty"`
+	Templates       Templates                       `yaml:",omitempty" json:"templates,omitempty"`
+	Limits          `yaml:",inline"`
 }
 
-// Trigger represents the configuration for a command trigger as defined
-// in the bundles/commands/triggers section of the config.
-type Trigger struct {
-	Match string `yaml:"match" json:"match"`
+// Limits describes the resources that a command may use when it's executed,
+// as defined on a bundle or on a single command. A zero value for any field
+// means that it's not limited. Memory and CPU use Kubernetes quantity
+// notation, such as "256Mi" or "500m".
+type Limits struct {
+	Timeout       time.Duration `yaml:",omitempty" json:"timeout,omitempty"`
+	Memory        string        `yaml:",omitempty" json:"memory,omitempty"`
+	CPU           string        `yaml:",omitempty" json:"cpu,omitempty"`
+	MaxConcurrent int           `yaml:"max_concurrent,omitempty" json:"max_concurrent,omitempty"`
 }
 
-func (c *BundleCommand) MatchTrigger(ctx context.Context, message string) (bool, error) {
-	for _, trigger := range c.Triggers {
-		if c == nil || len(trigger.Match) == 0 {
-			return false, nil
+// ValidateLimits checks that the memory and CPU limits of the bundle and each
+// of its commands are non-negative quantities, so that a bad limit is
+// rejected when the bundle is installed instead of when a command is run.
+func (b Bundle) ValidateLimits() error {
+	if err := b.Limits.validate(); err != nil {
+		return gerrs.Wrap(ErrInvalidLimits, fmt.Errorf("bundle %s: %w", b.Name, err))
+	}
+
+	for name, c := range b.Commands {
+		if err := c.Limits.validate(); err != nil {
+			return gerrs.Wrap(ErrInvalidLimits, fmt.Errorf("command %s: %w", name, err))
 		}
-		// TODO: Compile regexes up-front for improved performance
-		re, err := regexp.Compile(trigger.Match)
+	}
+
+	return nil
+}
+
+func (l Limits) validate() error {
+	for _, f := range [][2]string{{"memory", l.Memory}, {"cpu", l.CPU}} {
+		kind, v := f[0], f[1]
+		if v == "" {
+			continue
+		}
+
+		q, err := resource.ParseQuantity(v)
 		if err != nil {
-			return false, err
+			return fmt.Errorf("%s %q: %w", kind, v, err)
 		}
-		if re.MatchString(message) {
-			return true, nil
+		if q.Sign() < 0 {
+			return fmt.Errorf("%s %q is negative", kind, v)
 		}
 	}
-	return false, nil
+
+	return nil
+}
+
+// merge returns l with its unset resource limits set from those of
+// defaults. MaxConcurrent isn't inherited.
+func (l Limits) merge(defaults Limits) Limits {
+	if l.Timeout <= 0 {
+		l.Timeout = defaults.Timeout
+	}
+	if l.Memory == "" {
+		l.Memory = defaults.Memory
+	}
+	if l.CPU == "" {
+		l.CPU = defaults.CPU
+	}
+	return l
+}
+
+// Trigger represents the configuration for a command trigger as defined
+// in the bundles/commands/triggers section of the config.
+type Trigger struct {
+	Match             string `yaml:"match" json:"match"`
+	TriggerConditions `yaml:",inline"`
+}
+
+// TriggerConditions restrict where, and by whom, a trigger may be fired. An
+// empty list places no restriction. Channels may be given by ID or by name,
+// with or without a leading "#". Users may be given by Gort user name or by
+// chat provider user name or ID. Messages sent by bots never fire a trigger
+// unless AllowBots is set, and the allow_bot_triggers server setting permits
+// it. If Cooldown is set, the trigger won't fire again
+// in the same channel until that long after it last fired there.
+type TriggerConditions struct {
+	Adapters        []string      `yaml:",omitempty" json:"adapters,omitempty"`
+	Channels        []string      `yaml:",omitempty" json:"channels,omitempty"`
+	ExcludeChannels []string      `yaml:"exclude_channels,omitempty" json:"exclude_channels,omitempty"`
+	Users           []string      `yaml:",omitempty" json:"users,omitempty"`
+	Groups          []string      `yaml:",omitempty" json:"groups,omitempty"`
+	AllowBots       bool          `yaml:"allow_bots,omitempty" json:"allow_bots,omitempty"`
+	Cooldown        time.Duration `yaml:",omitempty" json:"cooldown,omitempty"`
 }
 
 // BundleKubernetes represents the "bundles/kubernetes" subsection of the config doc
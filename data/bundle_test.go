@@ -137,3 +137,25 @@ func TestSemver(t *testing.T) {
 		assert.Equal(t, test.Expected, result, "Test case: %q", test.Version)
 	}
 }
+
+func TestBundleValidateLimits(t *testing.T) {
+	tests := []struct {
+		Limits Limits
+		Valid  bool
+	}{
+		{Limits{}, true},
+		{Limits{Memory: "256Mi", CPU: "500m"}, true},
+		{Limits{Memory: "1.5G", CPU: "2"}, true},
+		{Limits{Memory: "lots"}, false},
+		{Limits{CPU: "half"}, false},
+		{Limits{Memory: "-1Gi"}, false},
+	}
+
+	for _, test := range tests {
+		b := Bundle{Name: "test", Limits: test.Limits}
+		assert.Equal(t, test.Valid, b.ValidateLimits() == nil, test.Limits)
+
+		b = Bundle{Name: "test", Commands: map[string]*BundleCommand{"cmd": {Limits: test.Limits}}}
+		assert.Equal(t, test.Valid, b.ValidateLimits() == nil, test.Limits)
+	}
+}
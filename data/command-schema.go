@@ -0,0 +1,477 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getgort/gort/command"
+	gerrs "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/types"
+)
+
+var (
+	// ErrInvalidSchema is returned by BundleCommand.ValidateSchema if a
+	// command's options or arguments are declared incorrectly.
+	ErrInvalidSchema = errors.New("invalid command schema")
+
+	// ErrInvalidInvocation is returned by CommandEntry.Parse if a command's
+	// options or arguments don't satisfy its schema. The wrapped error
+	// describes the problem and includes the command's usage.
+	ErrInvalidInvocation = errors.New("invalid command invocation")
+)
+
+// ValueType is the type of a command option or argument value.
+type ValueType string
+
+const (
+	// TypeBool is a boolean. Options of this type are flags, which don't
+	// take a value.
+	TypeBool ValueType = "bool"
+
+	// TypeInt is an integer.
+	TypeInt ValueType = "int"
+
+	// TypeString is any string.
+	TypeString ValueType = "string"
+
+	// TypeEnum is one of a fixed set of strings.
+	TypeEnum ValueType = "enum"
+
+	// TypeList is a list of strings. An option of this type takes a
+	// comma-separated value; an argument of this type takes all of the
+	// remaining parameters, so it must be the last argument.
+	TypeList ValueType = "list"
+)
+
+// BundleCommandOption describes an option accepted by a bundle command, as
+// defined in the "bundles/commands/options" section of the config. Options
+// are keyed by their name. If Type is empty, the option is a bool flag.
+type BundleCommandOption struct {
+	Description string    `yaml:",omitempty" json:"description,omitempty"`
+	Aliases     []string  `yaml:",omitempty,flow" json:"aliases,omitempty"`
+	Type        ValueType `yaml:",omitempty" json:"type,omitempty"`
+	Values      []string  `yaml:",omitempty,flow" json:"values,omitempty"`
+	Default     string    `yaml:",omitempty" json:"default,omitempty"`
+	Required    bool      `yaml:",omitempty" json:"required,omitempty"`
+}
+
+// BundleCommandArgument describes a positional argument accepted by a
+// bundle command, as defined in the "bundles/commands/arguments" section of
+// the config. If Type is empty, the argument is a string.
+type BundleCommandArgument struct {
+	Name        string    `yaml:",omitempty" json:"name,omitempty"`
+	Description string    `yaml:",omitempty" json:"description,omitempty"`
+	Type        ValueType `yaml:",omitempty" json:"type,omitempty"`
+	Values      []string  `yaml:",omitempty,flow" json:"values,omitempty"`
+	Default     string    `yaml:",omitempty" json:"default,omitempty"`
+	Required    bool      `yaml:",omitempty" json:"required,omitempty"`
+}
+
+func (o BundleCommandOption) valueType() ValueType {
+	if o.Type == "" {
+		return TypeBool
+	}
+	return o.Type
+}
+
+func (a BundleCommandArgument) valueType() ValueType {
+	if a.Type == "" {
+		return TypeString
+	}
+	return a.Type
+}
+
+// HasSchema returns true if the command declares any options or arguments.
+// Commands without a schema are parsed permissively, and receive only their
+// positional parameters.
+func (c BundleCommand) HasSchema() bool {
+	return len(c.Options) > 0 || len(c.Arguments) > 0
+}
+
+// ValidateSchema checks that the command's options and arguments are
+// declared correctly.
+func (c BundleCommand) ValidateSchema() error {
+	invalid := func(format string, a ...interface{}) error {
+		return gerrs.Wrap(ErrInvalidSchema, fmt.Errorf("command %s: "+format, append([]interface{}{c.Name}, a...)...))
+	}
+
+	names := map[string]bool{}
+
+	for _, name := range c.optionNames() {
+		o := c.Options[name]
+
+		for _, n := range append([]string{name}, o.Aliases...) {
+			if n == "" || strings.HasPrefix(n, "-") {
+				return invalid("invalid option name %q", n)
+			}
+			if names[n] {
+				return invalid("option name %q is used more than once", n)
+			}
+			names[n] = true
+		}
+
+		if err := validateValueType(o.valueType(), o.Values, o.Default); err != nil {
+			return invalid("option %s: %v", name, err)
+		}
+		if o.valueType() == TypeBool && o.Default != "" {
+			return invalid("option %s: flags can't have a default", name)
+		}
+	}
+
+	optional := false
+
+	for i, a := range c.Arguments {
+		if a.Name == "" {
+			return invalid("argument %d has no name", i+1)
+		}
+		if err := validateValueType(a.valueType(), a.Values, a.Default); err != nil {
+			return invalid("argument %s: %v", a.Name, err)
+		}
+		if a.valueType() == TypeList && i != len(c.Arguments)-1 {
+			return invalid("argument %s: only the last argument can be a list", a.Name)
+		}
+		if a.Required && optional {
+			return invalid("argument %s: required arguments can't follow optional ones", a.Name)
+		}
+		optional = optional || !a.Required
+	}
+
+	return nil
+}
+
+// ParseOptions returns the command.Parse options that describe the
+// command's declared options.
+func (e CommandEntry) ParseOptions() []command.ParseOption {
+	var opts []command.ParseOption
+
+	for name, o := range e.Command.Options {
+		opts = append(opts, command.ParseOptionHasArgument(name, o.valueType() != TypeBool))
+
+		for _, alias := range o.Aliases {
+			opts = append(opts, command.ParseOptionAlias(alias, name))
+		}
+	}
+
+	return opts
+}
+
+// Parse parses a tokenized command line, the first token of which is the
+// command name, according to the command's schema. Option and argument
+// values are converted to their declared types and defaults are filled in.
+// An ErrInvalidInvocation error is returned if the command line doesn't
+// satisfy the schema. If the command has no schema, tokens are parsed
+// exactly as by command.Parse.
+func (e CommandEntry) Parse(tokens []string) (command.Command, error) {
+	if !e.Command.HasSchema() {
+		return command.Parse(tokens)
+	}
+
+	cmd, err := command.Parse(tokens, e.ParseOptions()...)
+	if err != nil {
+		return cmd, e.invocationError("%v", err)
+	}
+
+	for name, opt := range cmd.Options {
+		o, ok := e.Command.Options[name]
+		if !ok {
+			return cmd, e.invocationError("unknown option %s", optionFlag(name))
+		}
+
+		if o.valueType() == TypeBool {
+			continue
+		}
+
+		// The parser leaves an option that's missing its value set to true.
+		if _, isBool := opt.Value.(types.BoolValue); isBool {
+			return cmd, e.invocationError("option %s requires a value", optionFlag(name))
+		}
+
+		v, err := convertValue(o.valueType(), o.Values, rawValue(opt.Value))
+		if err != nil {
+			return cmd, e.invocationError("option %s: %v", optionFlag(name), err)
+		}
+		cmd.Options[name] = command.CommandOption{Name: name, Value: v}
+	}
+
+	for _, name := range e.Command.optionNames() {
+		o := e.Command.Options[name]
+		if _, ok := cmd.Options[name]; ok {
+			continue
+		}
+
+		switch {
+		case o.Default != "":
+			v, _ := convertValue(o.valueType(), o.Values, o.Default)
+			cmd.Options[name] = command.CommandOption{Name: name, Value: v}
+		case o.Required:
+			return cmd, e.invocationError("option %s is required", optionFlag(name))
+		}
+	}
+
+	params := cmd.Parameters
+	cmd.Parameters = command.CommandParameters{}
+
+	for i, a := range e.Command.Arguments {
+		if a.valueType() == TypeList {
+			if len(params) <= i && a.Required {
+				return cmd, e.invocationError("argument %s is required", a.Name)
+			}
+			for j := i; j < len(params); j++ {
+				cmd.Parameters = append(cmd.Parameters, types.StringValue{V: rawValue(params[j])})
+			}
+			return cmd, nil
+		}
+
+		raw := a.Default
+		if i < len(params) {
+			raw = rawValue(params[i])
+		} else if a.Required {
+			return cmd, e.invocationError("argument %s is required", a.Name)
+		} else if raw == "" {
+			break
+		}
+
+		v, err := convertValue(a.valueType(), a.Values, raw)
+		if err != nil {
+			return cmd, e.invocationError("argument %s: %v", a.Name, err)
+		}
+		cmd.Parameters = append(cmd.Parameters, v)
+	}
+
+	if len(params) > len(e.Command.Arguments) {
+		return cmd, e.invocationError("too many arguments")
+	}
+
+	return cmd, nil
+}
+
+// Parameters returns the parameters that a command, as returned by Parse,
+// is executed with. If the command has a schema its options are included,
+// in their long form and in name order, before its arguments; otherwise
+// only its positional parameters are.
+func (e CommandEntry) Parameters(cmd command.Command) []string {
+	var out []string
+
+	if e.Command.HasSchema() {
+		names := make([]string, 0, len(cmd.Options))
+		for name := range cmd.Options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			v := cmd.Options[name].Value
+
+			if e.Command.Options[name].valueType() == TypeBool {
+				if v.Equals(types.BoolValue{V: true}) {
+					out = append(out, optionFlag(name))
+				}
+				continue
+			}
+
+			value := v.String()
+			if l, ok := v.(types.ListValue); ok {
+				value = joinList(l)
+			}
+			out = append(out, optionFlag(name), value)
+		}
+	}
+
+	for _, p := range cmd.Parameters {
+		out = append(out, p.String())
+	}
+
+	return out
+}
+
+// Usage returns a description of the command's options and arguments,
+// suitable for showing to a user who invoked it incorrectly.
+func (e CommandEntry) Usage() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Usage: %s:%s", e.Bundle.Name, e.Command.Name)
+	if len(e.Command.Options) > 0 {
+		b.WriteString(" [options]")
+	}
+	for _, a := range e.Command.Arguments {
+		name := "<" + a.Name + ">"
+		if a.valueType() == TypeList {
+			name += "..."
+		}
+		if !a.Required {
+			name = "[" + name + "]"
+		}
+		b.WriteString(" " + name)
+	}
+
+	if len(e.Command.Options) > 0 {
+		b.WriteString("\n\nOptions:")
+
+		for _, name := range e.Command.optionNames() {
+			o := e.Command.Options[name]
+
+			flags := []string{}
+			for _, alias := range o.Aliases {
+				flags = append(flags, optionFlag(alias))
+			}
+			flags = append(flags, optionFlag(name))
+
+			line := strings.Join(flags, ", ")
+			if o.valueType() != TypeBool {
+				line += " " + valuePlaceholder(o.valueType(), o.Values)
+			}
+			b.WriteString("\n  " + line)
+			writeUsageDetails(&b, o.Description, o.Default, o.Required)
+		}
+	}
+
+	if len(e.Command.Arguments) > 0 {
+		b.WriteString("\n\nArguments:")
+
+		for _, a := range e.Command.Arguments {
+			b.WriteString("\n  " + a.Name + " " + valuePlaceholder(a.valueType(), a.Values))
+			writeUsageDetails(&b, a.Description, a.Default, a.Required)
+		}
+	}
+
+	return b.String()
+}
+
+func (e CommandEntry) invocationError(format string, a ...interface{}) error {
+	return gerrs.Wrap(ErrInvalidInvocation, fmt.Errorf(format+"\n\n%s", append(a, e.Usage())...))
+}
+
+// optionNames returns the names of the command's options, sorted.
+func (c BundleCommand) optionNames() []string {
+	names := make([]string, 0, len(c.Options))
+	for name := range c.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// convertValue converts a raw option or argument value to the given type.
+func convertValue(t ValueType, values []string, raw string) (types.Value, error) {
+	switch t {
+	case TypeBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a boolean", raw)
+		}
+		return types.BoolValue{V: b}, nil
+
+	case TypeInt:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't an integer", raw)
+		}
+		return types.IntValue{V: i}, nil
+
+	case TypeEnum:
+		for _, v := range values {
+			if v == raw {
+				return types.StringValue{V: raw}, nil
+			}
+		}
+		return nil, fmt.Errorf("%q must be one of %s", raw, strings.Join(values, ", "))
+
+	case TypeList:
+		list := types.ListValue{}
+		for _, s := range strings.Split(raw, ",") {
+			list.V = append(list.V, types.StringValue{V: strings.TrimSpace(s)})
+		}
+		return list, nil
+
+	default:
+		return types.StringValue{V: raw}, nil
+	}
+}
+
+func joinList(l types.ListValue) string {
+	ss := make([]string, len(l.V))
+	for i, v := range l.V {
+		ss[i] = v.String()
+	}
+	return strings.Join(ss, ",")
+}
+
+func optionFlag(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// rawValue returns a parsed value as it was typed, without any quotes.
+func rawValue(v types.Value) string {
+	if s, ok := v.(types.StringValue); ok {
+		return s.V
+	}
+	return v.String()
+}
+
+func validateValueType(t ValueType, values []string, def string) error {
+	switch t {
+	case TypeBool, TypeInt, TypeString, TypeList:
+		if len(values) > 0 {
+			return fmt.Errorf("values can only be set for enums")
+		}
+	case TypeEnum:
+		if len(values) == 0 {
+			return fmt.Errorf("enums must have values")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", t)
+	}
+
+	if def != "" {
+		if _, err := convertValue(t, values, def); err != nil {
+			return fmt.Errorf("invalid default: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func valuePlaceholder(t ValueType, values []string) string {
+	if t == TypeEnum {
+		return "<" + strings.Join(values, "|") + ">"
+	}
+	return "<" + string(t) + ">"
+}
+
+func writeUsageDetails(b *strings.Builder, description, def string, required bool) {
+	var details []string
+	if description != "" {
+		details = append(details, description)
+	}
+	if def != "" {
+		details = append(details, "(default "+def+")")
+	}
+	if required {
+		details = append(details, "(required)")
+	}
+	if len(details) > 0 {
+		b.WriteString("\n      " + strings.Join(details, " "))
+	}
+}
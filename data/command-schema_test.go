@@ -0,0 +1,113 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/command"
+	gerrs "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/types"
+)
+
+var schemaEntry = CommandEntry{
+	Bundle: Bundle{Name: "deploy"},
+	Command: BundleCommand{
+		Name: "release",
+		Options: map[string]*BundleCommandOption{
+			"verbose": {Aliases: []string{"v"}},
+			"count":   {Aliases: []string{"c"}, Type: TypeInt, Default: "1"},
+			"env":     {Type: TypeEnum, Values: []string{"staging", "prod"}, Required: true},
+			"tags":    {Type: TypeList},
+		},
+		Arguments: []BundleCommandArgument{
+			{Name: "service", Required: true},
+			{Name: "hosts", Type: TypeList},
+		},
+	},
+}
+
+func TestCommandEntryParse(t *testing.T) {
+	cmd, err := schemaEntry.Parse([]string{"deploy:release", "-v", "--env", "prod", "--tags", "a,b", "api", "h1", "h2"})
+	require.NoError(t, err)
+
+	assert.Equal(t, types.BoolValue{V: true}, cmd.Options["verbose"].Value)
+	assert.Equal(t, types.IntValue{V: 1}, cmd.Options["count"].Value)
+	assert.Equal(t, types.StringValue{V: "prod"}, cmd.Options["env"].Value)
+	assert.Equal(t, command.CommandParameters{
+		types.StringValue{V: "api"},
+		types.StringValue{V: "h1"},
+		types.StringValue{V: "h2"},
+	}, cmd.Parameters)
+
+	assert.Equal(t,
+		[]string{"--count", "1", "--env", "prod", "--tags", "a,b", "--verbose", "api", "h1", "h2"},
+		schemaEntry.Parameters(cmd))
+}
+
+func TestCommandEntryParseInvalid(t *testing.T) {
+	tests := map[string][]string{
+		"missing required option":   {"deploy:release", "api"},
+		"missing required argument": {"deploy:release", "--env", "prod"},
+		"unknown option":            {"deploy:release", "--env", "prod", "--force", "api"},
+		"missing option value":      {"deploy:release", "api", "--env"},
+		"bad int":                   {"deploy:release", "--env", "prod", "-c", "many", "api"},
+		"bad enum":                  {"deploy:release", "--env", "dev", "api"},
+	}
+
+	for name, tokens := range tests {
+		_, err := schemaEntry.Parse(tokens)
+		if assert.Error(t, err, name) {
+			assert.True(t, gerrs.Is(err, ErrInvalidInvocation), name)
+			assert.Contains(t, err.Error(), "Usage: deploy:release [options] <service> [<hosts>...]", name)
+		}
+	}
+}
+
+func TestCommandEntryParseNoSchema(t *testing.T) {
+	entry := CommandEntry{Bundle: Bundle{Name: "test"}, Command: BundleCommand{Name: "echo"}}
+
+	cmd, err := entry.Parse([]string{"test:echo", "-n", "foo", "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, entry.Parameters(cmd))
+}
+
+func TestValidateSchema(t *testing.T) {
+	assert.NoError(t, schemaEntry.Command.ValidateSchema())
+
+	tests := map[string]BundleCommand{
+		"unknown type":      {Options: map[string]*BundleCommandOption{"x": {Type: "float"}}},
+		"enum no values":    {Options: map[string]*BundleCommandOption{"x": {Type: TypeEnum}}},
+		"values not enum":   {Options: map[string]*BundleCommandOption{"x": {Type: TypeString, Values: []string{"a"}}}},
+		"bad default":       {Options: map[string]*BundleCommandOption{"x": {Type: TypeInt, Default: "one"}}},
+		"flag default":      {Options: map[string]*BundleCommandOption{"x": {Default: "true"}}},
+		"duplicate alias":   {Options: map[string]*BundleCommandOption{"x": {}, "y": {Aliases: []string{"x"}}}},
+		"unnamed argument":  {Arguments: []BundleCommandArgument{{}}},
+		"list not last":     {Arguments: []BundleCommandArgument{{Name: "a", Type: TypeList}, {Name: "b"}}},
+		"required optional": {Arguments: []BundleCommandArgument{{Name: "a"}, {Name: "b", Required: true}}},
+	}
+
+	for name, cmd := range tests {
+		err := cmd.ValidateSchema()
+		if assert.Error(t, err, name) {
+			assert.True(t, gerrs.Is(err, ErrInvalidSchema), name)
+		}
+	}
+}
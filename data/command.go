@@ -25,9 +25,21 @@ import (
 )
 
 // CommandEntry conveniently wraps a bundle and one command within that bundle.
+// If the command was found by matching one of its triggers, Trigger is the
+// trigger that matched and Captures contains the values of its named groups.
 type CommandEntry struct {
-	Bundle  Bundle
-	Command BundleCommand
+	Bundle   Bundle
+	Command  BundleCommand
+	Trigger  *Trigger        `json:",omitempty"`
+	Captures TriggerCaptures `json:",omitempty"`
+}
+
+// EffectiveLimits returns the resource limits for the command. Limits set on
+// the command itself take precedence over those set on its bundle. A
+// bundle's MaxConcurrent caps all of its commands together rather than each
+// one individually, so it isn't inherited.
+func (e CommandEntry) EffectiveLimits() Limits {
+	return e.Command.Limits.merge(e.Bundle.Limits)
 }
 
 type CommandParameters []string
@@ -42,8 +54,11 @@ type CommandRequest struct {
 	CommandEntry
 	Adapter    string            // The name of the adapter this request originated from
 	ChannelID  string            // The provider ID of the channel that the request originated in
-	Context    context.Context   // The request context
+	Context    context.Context   `json:"-"`          // The request context
+	Input      string            `json:",omitempty"` // The output of the previous pipeline stage, if any
+	Next       *CommandRequest   `json:",omitempty"` // The next pipeline stage, if any
 	Parameters CommandParameters // Tokenized command parameters
+	Redirects  []string          `json:",omitempty"` // Channel IDs to send output to instead of ChannelID, if any
 	RequestID  int64             // A unique requestID
 	Timestamp  time.Time         // The time this request was triggered
 	UserID     string            // The provider ID of user making this request
@@ -57,6 +72,18 @@ func (r CommandRequest) String() string {
 	return fmt.Sprintf("%s:%s %s", r.Bundle.Name, r.Command.Name, r.Parameters)
 }
 
+// Pipeline is like String, but describes every stage of the request's
+// pipeline, starting with this one, separated by pipes.
+func (r CommandRequest) Pipeline() string {
+	stages := []string{strings.TrimSpace(r.String())}
+
+	for n := r.Next; n != nil; n = n.Next {
+		stages = append(stages, strings.TrimSpace(n.String()))
+	}
+
+	return strings.Join(stages, " | ")
+}
+
 // CommandResponse wraps the response text emitted by an executed command.
 type CommandResponse struct {
 	// Lines contains the command output (from both stdout and stderr) as
@@ -93,6 +120,17 @@ type CommandResponseData struct {
 
 	// Error is set by the relay under certain internal error conditions.
 	Error error
+
+	// Partial is true if the envelope contains incremental output from a
+	// streaming command, rather than its final result. Response.Lines
+	// contains only the lines produced since the previous partial envelope.
+	// A final, non-partial envelope always follows.
+	Partial bool
+
+	// Template, if set, is the type of template used to format a successful
+	// response in place of Command. It's set by commands that the controller
+	// executes natively, such as help.
+	Template TemplateType `json:",omitempty"`
 }
 
 // CommandResponseEnvelope encapsulates the data and metadata around a command
@@ -143,6 +181,14 @@ func WithExitCode(code int16) CommandResponseEnvelopeOption {
 	}
 }
 
+// WithPartial sets Data.Partial, marking the envelope as incremental output
+// from a streaming command.
+func WithPartial() CommandResponseEnvelopeOption {
+	return func(e *CommandResponseEnvelope) {
+		e.Data.Partial = true
+	}
+}
+
 // WithError sets Data.Error, Data.ExitCode, Response.Lines, Response.Out,
 // Response.Structured, Response.Title, and Payload (as err.Error).
 func WithError(title string, err error, code int16) CommandResponseEnvelopeOption {
@@ -89,3 +89,25 @@ func TestNewCommandResponseEnvelope_WithStructuredResponseLines(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, "Matt", p["Name"])
 }
+
+func TestCommandRequestPipeline(t *testing.T) {
+	echo := CommandEntry{Bundle: Bundle{Name: "gort"}, Command: BundleCommand{Name: "echo"}}
+	seed := CommandEntry{Bundle: Bundle{Name: "test"}, Command: BundleCommand{Name: "seed"}}
+
+	r := CommandRequest{CommandEntry: seed, Parameters: CommandParameters{"5"}}
+	assert.Equal(t, "test:seed 5", r.Pipeline())
+
+	r.Next = &CommandRequest{CommandEntry: echo, Parameters: CommandParameters{"$value"}}
+	assert.Equal(t, "test:seed 5 | gort:echo $value", r.Pipeline())
+
+	r.Next.Parameters = nil
+	assert.Equal(t, "test:seed 5 | gort:echo", r.Pipeline())
+}
+
+func TestNewCommandResponseEnvelope_WithPartial(t *testing.T) {
+	e := NewCommandResponseEnvelope(request, WithResponseLines([]string{"foo"}), WithPartial())
+
+	assert.True(t, e.Data.Partial)
+	assert.Equal(t, []string{"foo"}, e.Response.Lines)
+	assert.False(t, NewCommandResponseEnvelope(request).Data.Partial)
+}
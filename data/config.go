@@ -20,27 +20,36 @@ import "time"
 
 // GortConfig is the top-level configuration object
 type GortConfig struct {
-	GortServerConfigs GortServerConfigs `yaml:"gort,omitempty"`
-	GlobalConfigs     GlobalConfigs     `yaml:"global,omitempty"`
-	DatabaseConfigs   DatabaseConfigs   `yaml:"database,omitempty"`
-	DockerConfigs     DockerConfigs     `yaml:"docker,omitempty"`
-	DynamicConfigs    DynamicConfigs    `yaml:"dynamic_configuration,omitempty"`
-	JaegerConfigs     JaegerConfigs     `yaml:"jaeger,omitempty"`
-	KubernetesConfigs KubernetesConfigs `yaml:"kubernetes,omitempty"`
-	SlackProviders    []SlackProvider   `yaml:"slack,omitempty"`
-	DiscordProviders  []DiscordProvider `yaml:"discord,omitempty"`
-	Templates         Templates         `yaml:"templates,omitempty"`
+	GortServerConfigs   GortServerConfigs    `yaml:"gort,omitempty"`
+	AuditConfigs        AuditConfigs         `yaml:"audit,omitempty"`
+	GlobalConfigs       GlobalConfigs        `yaml:"global,omitempty"`
+	DatabaseConfigs     DatabaseConfigs      `yaml:"database,omitempty"`
+	DockerConfigs       DockerConfigs        `yaml:"docker,omitempty"`
+	DynamicConfigs      DynamicConfigs       `yaml:"dynamic_configuration,omitempty"`
+	JaegerConfigs       JaegerConfigs        `yaml:"jaeger,omitempty"`
+	KubernetesConfigs   KubernetesConfigs    `yaml:"kubernetes,omitempty"`
+	RelayConfigs        RelayConfigs         `yaml:"relay,omitempty"`
+	SlackProviders      []SlackProvider      `yaml:"slack,omitempty"`
+	DiscordProviders    []DiscordProvider    `yaml:"discord,omitempty"`
+	MattermostProviders []MattermostProvider `yaml:"mattermost,omitempty"`
+	IRCProviders        []IRCProvider        `yaml:"irc,omitempty"`
+	MatrixProviders     []MatrixProvider     `yaml:"matrix,omitempty"`
+	HTTPProviders       []HTTPProvider       `yaml:"http,omitempty"`
+	Templates           Templates            `yaml:"templates,omitempty"`
 }
 
 // GortServerConfigs is the data wrapper for the "gort" section.
 type GortServerConfigs struct {
-	AllowSelfRegistration bool   `yaml:"allow_self_registration,omitempty"`
-	APIAddress            string `yaml:"api_address,omitempty"`
-	APIURLBase            string `yaml:"api_url_base,omitempty"`
-	DevelopmentMode       bool   `yaml:"development_mode,omitempty"`
-	EnableSpokenCommands  bool   `yaml:"enable_spoken_commands,omitempty"`
-	TLSCertFile           string `yaml:"tls_cert_file,omitempty"`
-	TLSKeyFile            string `yaml:"tls_key_file,omitempty"`
+	AllowBotCommands       bool   `yaml:"allow_bot_commands,omitempty"`
+	AllowBotTriggers       bool   `yaml:"allow_bot_triggers,omitempty"`
+	AllowSelfRegistration  bool   `yaml:"allow_self_registration,omitempty"`
+	APIAddress             string `yaml:"api_address,omitempty"`
+	APIURLBase             string `yaml:"api_url_base,omitempty"`
+	DevelopmentMode        bool   `yaml:"development_mode,omitempty"`
+	EnableSpokenCommands   bool   `yaml:"enable_spoken_commands,omitempty"`
+	RelayUnassignedBundles bool   `yaml:"relay_unassigned_bundles,omitempty"`
+	TLSCertFile            string `yaml:"tls_cert_file,omitempty"`
+	TLSKeyFile             string `yaml:"tls_key_file,omitempty"`
 }
 
 // GlobalConfigs is the data wrapper for the "global" section
@@ -48,6 +57,47 @@ type GlobalConfigs struct {
 	CommandTimeout time.Duration `yaml:"command_timeout,omitempty"`
 }
 
+// AuditConfigs is the data wrapper for the "audit" section. Each sink is
+// enabled if its path or address is set.
+type AuditConfigs struct {
+	JSONL  AuditJSONLConfigs  `yaml:"jsonl,omitempty"`
+	CSV    AuditCSVConfigs    `yaml:"csv,omitempty"`
+	Syslog AuditSyslogConfigs `yaml:"syslog,omitempty"`
+	Output AuditOutputConfigs `yaml:"output,omitempty"`
+}
+
+// AuditJSONLConfigs configures the audit sink that appends one JSON object
+// per line to a file.
+type AuditJSONLConfigs struct {
+	Path string `yaml:"path,omitempty"`
+}
+
+// AuditCSVConfigs configures the audit sink that writes CSV to a file,
+// rotating it when it reaches MaxSize bytes.
+type AuditCSVConfigs struct {
+	Path       string `yaml:"path,omitempty"`
+	MaxSize    int64  `yaml:"max_size,omitempty"`
+	MaxBackups int    `yaml:"max_backups,omitempty"`
+}
+
+// AuditSyslogConfigs configures the audit sink that sends RFC 5424 messages
+// to a syslog listener.
+type AuditSyslogConfigs struct {
+	Network string `yaml:"network,omitempty"`
+	Address string `yaml:"address,omitempty"`
+	AppName string `yaml:"app_name,omitempty"`
+}
+
+// AuditOutputConfigs controls whether each command's output is stored along
+// with its audit record, so that it can be retrieved later. Output longer
+// than MaxSize bytes is truncated, and anything matching one of the Redact
+// regular expressions is removed before it's stored.
+type AuditOutputConfigs struct {
+	Enabled bool     `yaml:"enabled,omitempty"`
+	MaxSize int      `yaml:"max_size,omitempty"`
+	Redact  []string `yaml:"redact,omitempty"`
+}
+
 // DatabaseConfigs is the data wrapper for the "database" section.
 type DatabaseConfigs struct {
 	Host                  string        `yaml:"host,omitempty"`
@@ -80,6 +130,13 @@ type JaegerConfigs struct {
 	Username string `yaml:"username,omitempty"`
 }
 
+// RelayConfigs is the data wrapper for the "relay" section. It's only used
+// by standalone relays (started with "gort relay").
+type RelayConfigs struct {
+	Name string   `yaml:"name,omitempty"`
+	Tags []string `yaml:"tags,omitempty"`
+}
+
 // KubernetesConfigs is the data wrapper for the "kubernetes" section.
 type KubernetesConfigs struct {
 	Namespace             string `yaml:"namespace,omitempty"`
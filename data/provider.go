@@ -16,6 +16,8 @@
 
 package data
 
+import "time"
+
 //// The wrappers for the "slack" section.
 //// Other providers will eventually get their own sections
 
@@ -26,8 +28,9 @@ type Provider interface{}
 // AbstractProvider is used to contain the general properties shared by
 // all providers.
 type AbstractProvider struct {
-	BotName string `yaml:"bot_name,omitempty"`
-	Name    string `yaml:"name,omitempty"`
+	BotName       string `yaml:"bot_name,omitempty"`
+	CommandPrefix string `yaml:"command_prefix,omitempty"`
+	Name          string `yaml:"name,omitempty"`
 }
 
 // SlackProvider is the data wrapper for a Slack App provider.
@@ -49,3 +52,102 @@ type DiscordProvider struct {
 
 	BotToken string `yaml:"bot_token,omitempty"`
 }
+
+// MattermostProvider is the data wrapper for a Mattermost bot account.
+type MattermostProvider struct {
+	AbstractProvider `yaml:",inline"`
+
+	// The base URL of the Mattermost server, such as
+	// https://mattermost.example.com.
+	URL string `yaml:"url,omitempty"`
+
+	// A bot access token or personal access token.
+	Token string `yaml:"token,omitempty"`
+}
+
+// HTTPProvider is the data wrapper for a generic HTTP adapter, which lets
+// any system that can send and receive JSON act as a chat front-end.
+type HTTPProvider struct {
+	AbstractProvider `yaml:",inline"`
+
+	// The shared secret that inbound messages must be signed with (or
+	// present as a token), and that outbound messages are signed with.
+	Secret string `yaml:"secret,omitempty"`
+
+	// The URL that Gort's messages are POSTed to.
+	CallbackURL string `yaml:"callback_url,omitempty"`
+
+	// How long to wait for the callback to respond. Defaults to 10s.
+	CallbackTimeout time.Duration `yaml:"callback_timeout,omitempty"`
+}
+
+// MatrixProvider is the data wrapper for a Matrix bot account.
+type MatrixProvider struct {
+	AbstractProvider `yaml:",inline"`
+
+	// The base URL of the homeserver, such as https://matrix.example.com.
+	Homeserver string `yaml:"homeserver,omitempty"`
+
+	// The bot account's access token.
+	AccessToken string `yaml:"access_token,omitempty"`
+}
+
+// IRCProvider is the data wrapper for a connection to an IRC network.
+type IRCProvider struct {
+	AbstractProvider `yaml:",inline"`
+
+	// The address of the server, as host:port.
+	Server string `yaml:"server,omitempty"`
+
+	// Connect using TLS. Certificate verification may be disabled with
+	// tls_skip_verify, but that should only be done for testing.
+	TLS           bool `yaml:"tls,omitempty"`
+	TLSSkipVerify bool `yaml:"tls_skip_verify,omitempty"`
+
+	// The bot's nickname. Defaults to bot_name, or "gort" if neither is set.
+	Nick string `yaml:"nick,omitempty"`
+
+	// The server password, if the server requires one.
+	Password string `yaml:"password,omitempty"`
+
+	// Credentials for SASL PLAIN authentication. SASL is only attempted if
+	// sasl_user is set.
+	SASLUser     string `yaml:"sasl_user,omitempty"`
+	SASLPassword string `yaml:"sasl_password,omitempty"`
+
+	// The channels to join once connected.
+	Channels []string `yaml:"channels,omitempty"`
+
+	// Users are identified by their account name, which requires the
+	// server to support the IRCv3 account-tag capability. If it doesn't,
+	// the adapter won't connect unless allow_nick_identity is set, in which
+	// case users are identified by their nick. Nicks aren't authenticated,
+	// so that's only safe on networks that enforce nick registration.
+	AllowNickIdentity bool `yaml:"allow_nick_identity,omitempty"`
+
+	// Flood protection: up to flood_burst messages are sent at once, after
+	// which messages are sent no more often than every flood_delay.
+	// Defaults to 4 and 1s.
+	FloodBurst int           `yaml:"flood_burst,omitempty"`
+	FloodDelay time.Duration `yaml:"flood_delay,omitempty"`
+}
+
+// TerminalProvider is the data wrapper for the terminal adapter used by
+// "gort shell". It has no section in the configuration file: its values
+// come from the command line.
+type TerminalProvider struct {
+	AbstractProvider
+
+	// The ID of the user that each line of input is attributed to.
+	User string
+
+	// The user's email address. Optional.
+	Email string
+
+	// The prompt that's written before each line of input. If it's empty
+	// no prompt is written.
+	Prompt string
+
+	// Write output as plain text, without ANSI escape codes.
+	NoColor bool
+}
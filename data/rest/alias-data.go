@@ -0,0 +1,41 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+// AliasScope describes who an alias applies to.
+type AliasScope string
+
+const (
+	// AliasScopeUser aliases are owned by, and only expand for, a single
+	// Gort user.
+	AliasScopeUser AliasScope = "user"
+
+	// AliasScopeSite aliases are managed by administrators, and expand for
+	// every user.
+	AliasScopeSite AliasScope = "site"
+)
+
+// Alias binds a short name to a longer command line, which may be a pipeline.
+// When a user invokes an alias by name, it's replaced by that command line,
+// followed by any arguments that the user provided. A user's own aliases take
+// precedence over site aliases with the same name.
+type Alias struct {
+	Name    string     `json:"name,omitempty"`
+	Command string     `json:"command,omitempty"`
+	Scope   AliasScope `json:"scope,omitempty"`
+	Owner   string     `json:"owner,omitempty"`
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rest
+
+import "time"
+
+// AuditRecord describes a single command invocation, as recorded in the
+// audit log. ExitCode is nil if the request hasn't completed. Parameters
+// holds the command's parameters joined by spaces, for display, and
+// ParameterList holds them individually.
+type AuditRecord struct {
+	RequestID     int64         `json:"request_id"`
+	Timestamp     time.Time     `json:"timestamp,omitempty"`
+	Duration      time.Duration `json:"duration,omitempty"`
+	Adapter       string        `json:"adapter,omitempty"`
+	ChannelID     string        `json:"channel_id,omitempty"`
+	UserID        string        `json:"user_id,omitempty"`
+	UserEmail     string        `json:"user_email,omitempty"`
+	UserName      string        `json:"user_name,omitempty"`
+	BundleName    string        `json:"bundle_name,omitempty"`
+	BundleVersion string        `json:"bundle_version,omitempty"`
+	CommandName   string        `json:"command_name,omitempty"`
+	Parameters    string        `json:"parameters,omitempty"`
+	ParameterList []string      `json:"parameter_list,omitempty"`
+	Pipeline      string        `json:"pipeline,omitempty"`
+	ExitCode      *int16        `json:"exit_code,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// RequestOutput is the stored output of a command request. Rendered is the
+// output as transformed by the command's templates, ready to be encoded for
+// a chat provider.
+type RequestOutput struct {
+	RequestID int64  `json:"request_id"`
+	Output    string `json:"output"`
+	Rendered  string `json:"rendered,omitempty"`
+}
+
+// AuditFilter selects records from the audit log. Zero-valued fields match
+// everything. Records are returned newest first; Limit and Offset page
+// through them.
+type AuditFilter struct {
+	UserName    string    `json:"user_name,omitempty"`
+	BundleName  string    `json:"bundle_name,omitempty"`
+	CommandName string    `json:"command_name,omitempty"`
+	Adapter     string    `json:"adapter,omitempty"`
+	ChannelID   string    `json:"channel_id,omitempty"`
+	ExitCode    *int16    `json:"exit_code,omitempty"`
+	Since       time.Time `json:"since,omitempty"`
+	Until       time.Time `json:"until,omitempty"`
+	Limit       int       `json:"limit,omitempty"`
+	Offset      int       `json:"offset,omitempty"`
+}
+
+// Matches returns true if the record satisfies every field of the filter.
+// Limit and Offset are ignored.
+func (f AuditFilter) Matches(r AuditRecord) bool {
+	switch {
+	case f.UserName != "" && f.UserName != r.UserName:
+		return false
+	case f.BundleName != "" && f.BundleName != r.BundleName:
+		return false
+	case f.CommandName != "" && f.CommandName != r.CommandName:
+		return false
+	case f.Adapter != "" && f.Adapter != r.Adapter:
+		return false
+	case f.ChannelID != "" && f.ChannelID != r.ChannelID:
+		return false
+	case f.ExitCode != nil && (r.ExitCode == nil || *f.ExitCode != *r.ExitCode):
+		return false
+	case !f.Since.IsZero() && r.Timestamp.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !r.Timestamp.Before(f.Until):
+		return false
+	}
+
+	return true
+}
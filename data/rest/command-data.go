@@ -0,0 +1,36 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import "time"
+
+// CommandResult describes the outcome of a command that was executed through
+// the API. ExitCode is nil if the command was started but not waited for, in
+// which case its output is sent to a chat channel instead.
+type CommandResult struct {
+	RequestID int64         `json:"request_id"`
+	ExitCode  *int16        `json:"exit_code,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Output    []string      `json:"output,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// CommandExecution is a request to execute a command line, exactly as it
+// would be typed in chat but without the command prefix, through the API.
+type CommandExecution struct {
+	Command string `json:"command"`
+}
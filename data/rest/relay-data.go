@@ -0,0 +1,36 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import "time"
+
+// Relay describes a remote relay that's currently connected to the
+// controller.
+type Relay struct {
+	Name      string    `json:"name,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Connected time.Time `json:"connected,omitempty"`
+	LastSeen  time.Time `json:"last_seen,omitempty"`
+}
+
+// RelayGroup is a named set of relays, to which bundles can be assigned. A
+// command from an assigned bundle will only be executed by a member relay.
+type RelayGroup struct {
+	Name    string   `json:"name,omitempty"`
+	Relays  []string `json:"relays,omitempty"`
+	Bundles []string `json:"bundles,omitempty"`
+}
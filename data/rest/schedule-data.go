@@ -0,0 +1,35 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import "time"
+
+// Schedule describes a command that Gort executes periodically, according to
+// a cron expression, on behalf of a Gort user.
+type Schedule struct {
+	ID        int64     `json:"id,omitempty"`
+	Cron      string    `json:"cron,omitempty"`
+	Timezone  string    `json:"timezone,omitempty"`
+	Command   string    `json:"command,omitempty"`
+	Adapter   string    `json:"adapter,omitempty"`
+	ChannelID string    `json:"channel_id,omitempty"`
+	UserName  string    `json:"user_name,omitempty"`
+	Paused    bool      `json:"paused,omitempty"`
+	Created   time.Time `json:"created,omitempty"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	NextRun   time.Time `json:"next_run,omitempty"`
+}
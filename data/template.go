@@ -38,6 +38,10 @@ const (
 	// MessageError templates are used to format error messages from the Gor
 	// system (not commands).
 	MessageError TemplateType = "message_error"
+
+	// Help templates are used to format the output of the native help
+	// command.
+	Help TemplateType = "help"
 )
 
 // Templates describes (or not) a set of templates that can be used to format
@@ -59,6 +63,10 @@ type Templates struct {
 	// MessageError templates are used to format error messages from the Gort
 	// system (not commands).
 	MessageError string `yaml:"message_error,omitempty" json:"message_error,omitempty"`
+
+	// Help templates are used to format the output of the native help
+	// command.
+	Help string `yaml:"help,omitempty" json:"help,omitempty"`
 }
 
 // Get returns a template string. If no template is defined for the given
@@ -73,6 +81,8 @@ func (t Templates) Get(tt TemplateType) (string, error) {
 		return t.Message, nil
 	case MessageError:
 		return t.MessageError, nil
+	case Help:
+		return t.Help, nil
 	default:
 		return "", fmt.Errorf("invalid template type %q", string(tt))
 	}
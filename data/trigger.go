@@ -0,0 +1,200 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	gerrs "github.com/getgort/gort/errors"
+)
+
+// ErrInvalidTrigger is returned by BundleCommand.ValidateTriggers if one of a
+// command's trigger patterns isn't a valid regular expression.
+var ErrInvalidTrigger = errors.New("invalid trigger pattern")
+
+// TriggerCapture is the value of a named group in the trigger pattern that
+// matched a message.
+type TriggerCapture struct {
+	Name  string
+	Value string
+}
+
+// TriggerCaptures are the values of all of a trigger's named groups, in the
+// order that the groups appear in its pattern.
+type TriggerCaptures []TriggerCapture
+
+// Values returns the captured values, in order.
+func (c TriggerCaptures) Values() []string {
+	values := make([]string, len(c))
+	for i, tc := range c {
+		values[i] = tc.Value
+	}
+	return values
+}
+
+// Environment returns the captured values as environment variables, named
+// GORT_TRIGGER_ followed by the upper-cased group name.
+func (c TriggerCaptures) Environment() map[string]string {
+	env := map[string]string{}
+	for _, tc := range c {
+		env["GORT_TRIGGER_"+strings.ToUpper(tc.Name)] = tc.Value
+	}
+	return env
+}
+
+// ValidateTriggers checks that each of the command's trigger patterns is a
+// valid regular expression, and that no trigger has a negative cooldown.
+func (c BundleCommand) ValidateTriggers() error {
+	for _, t := range c.Triggers {
+		if t.Match == "" {
+			return gerrs.Wrap(ErrInvalidTrigger, fmt.Errorf("command %s: trigger pattern is empty", c.Name))
+		}
+		if _, err := regexp.Compile(t.Match); err != nil {
+			return gerrs.Wrap(ErrInvalidTrigger, fmt.Errorf("command %s: %w", c.Name, err))
+		}
+		if t.Cooldown < 0 {
+			return gerrs.Wrap(ErrInvalidTrigger, fmt.Errorf("command %s: trigger cooldown is negative", c.Name))
+		}
+	}
+
+	return nil
+}
+
+// TriggerIndex holds the compiled trigger patterns of every command in the
+// enabled bundles, so that a message can be matched against all of them
+// without reloading the bundles or recompiling their patterns. The index is
+// built when it's first used, and rebuilt the first time it's used after
+// being invalidated, which should be done whenever a bundle is installed,
+// enabled, disabled, or removed. It's safe for concurrent use.
+type TriggerIndex struct {
+	mu       sync.RWMutex
+	current  bool
+	triggers []indexedTrigger
+}
+
+type indexedTrigger struct {
+	entry    CommandEntry
+	triggers []Trigger
+	patterns []*regexp.Regexp
+}
+
+// Invalidate marks the index as out of date.
+func (x *TriggerIndex) Invalidate() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.current = false
+	x.triggers = nil
+}
+
+// Match returns an entry for each trigger of an enabled command that matches
+// message, with Trigger set to that trigger and Captures set to the values
+// of its named groups. A command's entries are in the order its triggers
+// are declared. Trigger conditions aren't evaluated, so the caller can fall
+// back to a later trigger if an earlier one's conditions aren't met. If the
+// index is out of date it's first rebuilt from the bundles returned by load,
+// which need not all be enabled.
+func (x *TriggerIndex) Match(message string, load func() ([]Bundle, error)) ([]CommandEntry, error) {
+	if err := x.refresh(load); err != nil {
+		return nil, err
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	entries := make([]CommandEntry, 0)
+
+	for _, t := range x.triggers {
+		for i, re := range t.patterns {
+			m := re.FindStringSubmatchIndex(message)
+			if m == nil {
+				continue
+			}
+
+			trigger := t.triggers[i]
+			entry := t.entry
+			entry.Trigger = &trigger
+			for i, name := range re.SubexpNames() {
+				if name != "" && m[2*i] >= 0 {
+					entry.Captures = append(entry.Captures, TriggerCapture{Name: name, Value: message[m[2*i]:m[2*i+1]]})
+				}
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// refresh rebuilds the index if it's out of date.
+func (x *TriggerIndex) refresh(load func() ([]Bundle, error)) error {
+	x.mu.RLock()
+	current := x.current
+	x.mu.RUnlock()
+
+	if current {
+		return nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	// Another caller may have rebuilt the index in the meantime.
+	if x.current {
+		return nil
+	}
+
+	bundles, err := load()
+	if err != nil {
+		return err
+	}
+
+	x.triggers = nil
+
+	for _, b := range bundles {
+		if !b.Enabled {
+			continue
+		}
+
+		for name, c := range b.Commands {
+			t := indexedTrigger{entry: CommandEntry{Bundle: b, Command: *c}}
+			t.entry.Command.Name = name
+
+			// Invalid patterns are rejected when a bundle is installed, so
+			// any found here can only be skipped.
+			for _, trigger := range c.Triggers {
+				if re, err := regexp.Compile(trigger.Match); err == nil && trigger.Match != "" {
+					t.triggers = append(t.triggers, trigger)
+					t.patterns = append(t.patterns, re)
+				}
+			}
+
+			if len(t.patterns) > 0 {
+				x.triggers = append(x.triggers, t)
+			}
+		}
+	}
+
+	x.current = true
+
+	return nil
+}
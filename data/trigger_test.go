@@ -0,0 +1,159 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gerrs "github.com/getgort/gort/errors"
+)
+
+func triggerTestBundles() []Bundle {
+	return []Bundle{
+		{
+			Name:    "deploy",
+			Version: "1.0.0",
+			Enabled: true,
+			Commands: map[string]*BundleCommand{
+				"run": {
+					Triggers: []Trigger{
+						{Match: `^deploy (?P<service>\S+) to (?P<env>\S+)$`},
+						{Match: `^ship (?P<service>\S+)(?: to (?P<env>\S+))?$`},
+					},
+				},
+				"status": {},
+			},
+		},
+		{
+			Name:    "deploy",
+			Version: "0.9.0",
+			Commands: map[string]*BundleCommand{
+				"run": {Triggers: []Trigger{{Match: `deploy`}}},
+			},
+		},
+	}
+}
+
+func TestTriggerIndexMatch(t *testing.T) {
+	var x TriggerIndex
+
+	loads := 0
+	load := func() ([]Bundle, error) {
+		loads++
+		return triggerTestBundles(), nil
+	}
+
+	entries, err := x.Match("deploy api to prod", load)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "1.0.0", entries[0].Bundle.Version)
+	assert.Equal(t, "run", entries[0].Command.Name)
+	assert.Equal(t, TriggerCaptures{{"service", "api"}, {"env", "prod"}}, entries[0].Captures)
+	assert.Equal(t, []string{"api", "prod"}, entries[0].Captures.Values())
+	assert.Equal(t, map[string]string{
+		"GORT_TRIGGER_SERVICE": "api",
+		"GORT_TRIGGER_ENV":     "prod",
+	}, entries[0].Captures.Environment())
+
+	// Groups that didn't participate in the match aren't captured.
+	entries, err = x.Match("ship api", load)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, TriggerCaptures{{"service", "api"}}, entries[0].Captures)
+
+	entries, err = x.Match("nothing to see here", load)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assert.Equal(t, 1, loads)
+
+	x.Invalidate()
+	_, err = x.Match("deploy api to prod", load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads)
+}
+
+func TestTriggerIndexMatchEveryTrigger(t *testing.T) {
+	var x TriggerIndex
+
+	bundles := triggerTestBundles()
+	run := bundles[0].Commands["run"]
+	run.Triggers = append(run.Triggers, Trigger{Match: `api`})
+
+	// Every matching trigger of a command is returned, in order.
+	entries, err := x.Match("deploy api to prod", func() ([]Bundle, error) {
+		return bundles, nil
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, run.Triggers[0].Match, entries[0].Trigger.Match)
+	assert.Equal(t, TriggerCaptures{{"service", "api"}, {"env", "prod"}}, entries[0].Captures)
+	assert.Equal(t, `api`, entries[1].Trigger.Match)
+	assert.Empty(t, entries[1].Captures)
+}
+
+func TestTriggerIndexLoadError(t *testing.T) {
+	var x TriggerIndex
+
+	errLoad := errors.New("load failed")
+
+	_, err := x.Match("deploy api to prod", func() ([]Bundle, error) {
+		return nil, errLoad
+	})
+	assert.Equal(t, errLoad, err)
+
+	// A failed load leaves the index out of date, to be retried.
+	entries, err := x.Match("deploy api to prod", func() ([]Bundle, error) {
+		return triggerTestBundles(), nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestValidateTriggers(t *testing.T) {
+	tests := []struct {
+		Match string
+		Valid bool
+	}{
+		{`^deploy (?P<env>\S+)$`, true},
+		{`com+and`, true},
+		{`deploy (?P<env>`, false},
+		{``, false},
+	}
+
+	for _, test := range tests {
+		c := BundleCommand{Name: "run", Triggers: []Trigger{{Match: test.Match}}}
+
+		err := c.ValidateTriggers()
+		if test.Valid {
+			assert.NoError(t, err, test.Match)
+		} else {
+			assert.True(t, gerrs.Is(err, ErrInvalidTrigger), test.Match)
+		}
+	}
+
+	c := BundleCommand{Name: "run", Triggers: []Trigger{{
+		Match:             "deploy",
+		TriggerConditions: TriggerConditions{Cooldown: -time.Second},
+	}}}
+	assert.True(t, gerrs.Is(c.ValidateTriggers(), ErrInvalidTrigger))
+}
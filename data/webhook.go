@@ -0,0 +1,58 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package data
+
+// Webhook describes an authenticated HTTP endpoint, served at
+// "/v2/hooks/{bundle}/{name}", that executes one of a bundle's commands when
+// it's called. Webhooks can be declared in the "webhooks" section of a
+// bundle's definition, or created independently using the REST API.
+//
+// Each element of Parameters is a Go template that's executed against the
+// request body, decoded as JSON, to produce one command parameter; the raw
+// body is available as {{ .Body }}. If Parameters is empty, the raw body is
+// passed to the command as a single parameter.
+//
+// If Secret is set, callers must sign the request body with it (see
+// WebhookSignatureHeader); if Token is set, callers must present it (see
+// WebhookTokenHeader). At least one of the two is required.
+//
+// The command is executed as User. If ChannelID is set the command's output
+// is posted to that channel, through Adapter, and the call returns as soon
+// as the command has been started; otherwise the call waits for the command
+// to complete and returns its output.
+type Webhook struct {
+	Bundle     string   `yaml:"-" json:"bundle,omitempty"`
+	Name       string   `yaml:"-" json:"name,omitempty"`
+	Command    string   `yaml:",omitempty" json:"command,omitempty"`
+	Parameters []string `yaml:",omitempty" json:"parameters,omitempty"`
+	User       string   `yaml:",omitempty" json:"user,omitempty"`
+	Secret     string   `yaml:",omitempty" json:"secret,omitempty"`
+	Token      string   `yaml:",omitempty" json:"token,omitempty"`
+	Adapter    string   `yaml:",omitempty" json:"adapter,omitempty"`
+	ChannelID  string   `yaml:"channel_id,omitempty" json:"channel_id,omitempty"`
+}
+
+const (
+	// WebhookSignatureHeader is the request header that carries the
+	// hex-encoded HMAC-SHA256 signature of a webhook request's body,
+	// optionally prefixed with "sha256=".
+	WebhookSignatureHeader = "X-Gort-Signature"
+
+	// WebhookTokenHeader is the request header that carries a webhook's
+	// token.
+	WebhookTokenHeader = "X-Gort-Token"
+)
@@ -0,0 +1,36 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package errs
+
+import (
+	"errors"
+)
+
+// ErrEmptyRelayGroupName indicates...
+var ErrEmptyRelayGroupName = errors.New("relay group name is empty")
+
+// ErrEmptyRelayName indicates...
+var ErrEmptyRelayName = errors.New("relay name is empty")
+
+// ErrNoSuchRelay is returned when a relay isn't a member of a relay group.
+var ErrNoSuchRelay = errors.New("no such relay")
+
+// ErrNoSuchRelayGroup indicates...
+var ErrNoSuchRelayGroup = errors.New("no such relay group")
+
+// ErrRelayGroupExists TBD
+var ErrRelayGroupExists = errors.New("relay group already exists")
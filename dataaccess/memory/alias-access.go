@@ -0,0 +1,97 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+)
+
+// AliasCreate creates a new alias. An alias with an owner is a user alias;
+// one without is a site alias.
+func (da *InMemoryDataAccess) AliasCreate(ctx context.Context, alias rest.Alias) error {
+	if alias.Name == "" {
+		return errs.ErrEmptyAliasName
+	}
+	if alias.Command == "" {
+		return errs.ErrFieldRequired
+	}
+
+	key := aliasKey(alias.Owner, alias.Name)
+	if _, exists := da.aliases[key]; exists {
+		return errs.ErrAliasExists
+	}
+
+	alias.Scope = aliasScope(alias.Owner)
+	da.aliases[key] = &alias
+
+	return nil
+}
+
+// AliasDelete deletes an alias. The owner of a site alias is empty.
+func (da *InMemoryDataAccess) AliasDelete(ctx context.Context, owner, name string) error {
+	key := aliasKey(owner, name)
+	if _, exists := da.aliases[key]; !exists {
+		return errs.ErrNoSuchAlias
+	}
+
+	delete(da.aliases, key)
+
+	return nil
+}
+
+// AliasGet returns an alias. The owner of a site alias is empty.
+func (da *InMemoryDataAccess) AliasGet(ctx context.Context, owner, name string) (rest.Alias, error) {
+	alias, exists := da.aliases[aliasKey(owner, name)]
+	if !exists {
+		return rest.Alias{}, errs.ErrNoSuchAlias
+	}
+
+	return *alias, nil
+}
+
+// AliasList returns all aliases, ordered by owner and name. Site aliases,
+// which have no owner, come first.
+func (da *InMemoryDataAccess) AliasList(ctx context.Context) ([]rest.Alias, error) {
+	list := make([]rest.Alias, 0, len(da.aliases))
+	for _, a := range da.aliases {
+		list = append(list, *a)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Owner != list[j].Owner {
+			return list[i].Owner < list[j].Owner
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	return list, nil
+}
+
+func aliasKey(owner, name string) string {
+	return owner + ":" + name
+}
+
+func aliasScope(owner string) rest.AliasScope {
+	if owner == "" {
+		return rest.AliasScopeSite
+	}
+	return rest.AliasScopeUser
+}
@@ -46,6 +46,7 @@ func (da *InMemoryDataAccess) BundleCreate(ctx context.Context, bundle data.Bund
 	bundle.Image = bundle.ImageFull()
 
 	da.bundles[bundleKey(bundle.Name, bundle.Version)] = &bundle
+	da.triggers.Invalidate()
 
 	return nil
 }
@@ -69,6 +70,7 @@ func (da *InMemoryDataAccess) BundleDelete(ctx context.Context, name, version st
 	}
 
 	delete(da.bundles, bundleKey(name, version))
+	da.triggers.Invalidate()
 
 	return nil
 }
@@ -94,6 +96,8 @@ func (da *InMemoryDataAccess) BundleDisable(ctx context.Context, name, version s
 		return errs.ErrNoSuchBundle
 	}
 
+	da.triggers.Invalidate()
+
 	return nil
 }
 
@@ -123,6 +127,8 @@ func (da *InMemoryDataAccess) BundleEnable(ctx context.Context, name, version st
 		v.Enabled = (version == v.Version)
 	}
 
+	da.triggers.Invalidate()
+
 	return nil
 }
 
@@ -249,6 +255,7 @@ func (da *InMemoryDataAccess) BundleUpdate(ctx context.Context, bundle data.Bund
 	}
 
 	da.bundles[bundleKey(bundle.Name, bundle.Version)] = &bundle
+	da.triggers.Invalidate()
 
 	return nil
 }
@@ -279,27 +286,13 @@ func (da *InMemoryDataAccess) FindCommandEntry(ctx context.Context, bundleName,
 	return entries, nil
 }
 
+// FindCommandEntryByTrigger returns an entry for each trigger of an enabled
+// command that matches the tokens, using an index of compiled trigger
+// patterns that's rebuilt whenever a bundle changes.
 func (da *InMemoryDataAccess) FindCommandEntryByTrigger(ctx context.Context, tokens []string) ([]data.CommandEntry, error) {
-	entries := make([]data.CommandEntry, 0)
-
-	for _, bundle := range da.bundles {
-		if !bundle.Enabled {
-			continue
-		}
-
-		for _, cmd := range bundle.Commands {
-			matched, err := cmd.MatchTrigger(ctx, strings.Join(tokens, " "))
-			if err != nil {
-				return nil, err
-			}
-			if matched {
-				e := data.CommandEntry{Bundle: *bundle, Command: *cmd}
-				entries = append(entries, e)
-			}
-		}
-	}
-
-	return entries, nil
+	return da.triggers.Match(strings.Join(tokens, " "), func() ([]data.Bundle, error) {
+		return da.BundleList(ctx)
+	})
 }
 
 func bundleKey(name, version string) string {
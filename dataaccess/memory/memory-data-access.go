@@ -18,27 +18,46 @@ package memory
 
 import (
 	"context"
+	"sync"
 
 	"github.com/getgort/gort/data"
 	"github.com/getgort/gort/data/rest"
 )
 
 var dataAccess = &InMemoryDataAccess{
-	bundles: make(map[string]*data.Bundle),
-	configs: make(map[string]*data.DynamicConfiguration),
-	groups:  make(map[string]*rest.Group),
-	roles:   make(map[string]*rest.Role),
-	users:   make(map[string]*rest.User),
+	aliases:     make(map[string]*rest.Alias),
+	bundles:     make(map[string]*data.Bundle),
+	configs:     make(map[string]*data.DynamicConfiguration),
+	groups:      make(map[string]*rest.Group),
+	relayGroups: make(map[string]*rest.RelayGroup),
+	requests:    make(map[int64]*rest.AuditRecord),
+	outputs:     make(map[int64]string),
+	roles:       make(map[string]*rest.Role),
+	schedules:   make(map[int64]*rest.Schedule),
+	users:       make(map[string]*rest.User),
+	webhooks:    make(map[string]*data.Webhook),
 }
 
 // InMemoryDataAccess is an entirely in-memory representation of a data access layer.
 // Great for testing and development. Terrible for production.
 type InMemoryDataAccess struct {
-	bundles map[string]*data.Bundle
-	configs map[string]*data.DynamicConfiguration
-	groups  map[string]*rest.Group
-	roles   map[string]*rest.Role
-	users   map[string]*rest.User
+	aliases     map[string]*rest.Alias
+	bundles     map[string]*data.Bundle
+	configs     map[string]*data.DynamicConfiguration
+	groups      map[string]*rest.Group
+	relayGroups map[string]*rest.RelayGroup
+	requestID   int64
+	requests    map[int64]*rest.AuditRecord
+	requestIDs  []int64
+	outputs     map[int64]string
+	requestMu   sync.Mutex
+	roles       map[string]*rest.Role
+	scheduleID  int64
+	schedules   map[int64]*rest.Schedule
+	scheduleMu  sync.Mutex
+	triggers    data.TriggerIndex
+	users       map[string]*rest.User
+	webhooks    map[string]*data.Webhook
 }
 
 // NewInMemoryDataAccess returns a new InMemoryDataAccess instance.
@@ -52,9 +71,21 @@ func (da *InMemoryDataAccess) Initialize(ctx context.Context) error {
 }
 
 func Reset() {
+	dataAccess.aliases = make(map[string]*rest.Alias)
 	dataAccess.bundles = make(map[string]*data.Bundle)
 	dataAccess.configs = make(map[string]*data.DynamicConfiguration)
 	dataAccess.groups = make(map[string]*rest.Group)
+	dataAccess.relayGroups = make(map[string]*rest.RelayGroup)
+	dataAccess.requestMu.Lock()
+	dataAccess.requests = make(map[int64]*rest.AuditRecord)
+	dataAccess.requestIDs = nil
+	dataAccess.outputs = make(map[int64]string)
+	dataAccess.requestMu.Unlock()
 	dataAccess.roles = make(map[string]*rest.Role)
+	dataAccess.scheduleMu.Lock()
+	dataAccess.schedules = make(map[int64]*rest.Schedule)
+	dataAccess.scheduleMu.Unlock()
+	dataAccess.triggers.Invalidate()
 	dataAccess.users = make(map[string]*rest.User)
+	dataAccess.webhooks = make(map[string]*data.Webhook)
 }
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+)
+
+// RelayGroupBundleAdd assigns a bundle to a relay group.
+func (da *InMemoryDataAccess) RelayGroupBundleAdd(ctx context.Context, groupname, bundlename string) error {
+	group, exists := da.relayGroups[groupname]
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	exists, err := da.BundleExists(ctx, bundlename)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrNoSuchBundle
+	}
+
+	group.Bundles = addUnique(group.Bundles, bundlename)
+
+	return nil
+}
+
+// RelayGroupBundleDelete removes a bundle from a relay group.
+func (da *InMemoryDataAccess) RelayGroupBundleDelete(ctx context.Context, groupname, bundlename string) error {
+	group, exists := da.relayGroups[groupname]
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	var removed bool
+	if group.Bundles, removed = remove(group.Bundles, bundlename); !removed {
+		return errs.ErrNoSuchBundle
+	}
+
+	return nil
+}
+
+// RelayGroupCreate creates a new relay group.
+func (da *InMemoryDataAccess) RelayGroupCreate(ctx context.Context, group rest.RelayGroup) error {
+	if group.Name == "" {
+		return errs.ErrEmptyRelayGroupName
+	}
+
+	exists, err := da.RelayGroupExists(ctx, group.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errs.ErrRelayGroupExists
+	}
+
+	da.relayGroups[group.Name] = &rest.RelayGroup{Name: group.Name}
+
+	return nil
+}
+
+// RelayGroupDelete deletes a relay group.
+func (da *InMemoryDataAccess) RelayGroupDelete(ctx context.Context, groupname string) error {
+	if groupname == "" {
+		return errs.ErrEmptyRelayGroupName
+	}
+
+	exists, err := da.RelayGroupExists(ctx, groupname)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	delete(da.relayGroups, groupname)
+
+	return nil
+}
+
+// RelayGroupExists is used to determine whether a relay group exists in the
+// data store.
+func (da *InMemoryDataAccess) RelayGroupExists(ctx context.Context, groupname string) (bool, error) {
+	_, exists := da.relayGroups[groupname]
+
+	return exists, nil
+}
+
+// RelayGroupGet gets a specific relay group.
+func (da *InMemoryDataAccess) RelayGroupGet(ctx context.Context, groupname string) (rest.RelayGroup, error) {
+	if groupname == "" {
+		return rest.RelayGroup{}, errs.ErrEmptyRelayGroupName
+	}
+
+	group, exists := da.relayGroups[groupname]
+	if !exists {
+		return rest.RelayGroup{}, errs.ErrNoSuchRelayGroup
+	}
+
+	return copyRelayGroup(group), nil
+}
+
+// RelayGroupList returns a list of all known relay groups in the datastore.
+func (da *InMemoryDataAccess) RelayGroupList(ctx context.Context) ([]rest.RelayGroup, error) {
+	list := make([]rest.RelayGroup, 0)
+
+	for _, g := range da.relayGroups {
+		list = append(list, copyRelayGroup(g))
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list, nil
+}
+
+// RelayGroupListByBundle returns all relay groups that a bundle is assigned
+// to.
+func (da *InMemoryDataAccess) RelayGroupListByBundle(ctx context.Context, bundlename string) ([]rest.RelayGroup, error) {
+	list := make([]rest.RelayGroup, 0)
+
+	for _, g := range da.relayGroups {
+		for _, b := range g.Bundles {
+			if b == bundlename {
+				list = append(list, copyRelayGroup(g))
+				break
+			}
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list, nil
+}
+
+// RelayGroupRelayAdd adds a relay to a relay group. The relay doesn't need
+// to be connected.
+func (da *InMemoryDataAccess) RelayGroupRelayAdd(ctx context.Context, groupname, relayname string) error {
+	if relayname == "" {
+		return errs.ErrEmptyRelayName
+	}
+
+	group, exists := da.relayGroups[groupname]
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	group.Relays = addUnique(group.Relays, relayname)
+
+	return nil
+}
+
+// RelayGroupRelayDelete removes a relay from a relay group.
+func (da *InMemoryDataAccess) RelayGroupRelayDelete(ctx context.Context, groupname, relayname string) error {
+	group, exists := da.relayGroups[groupname]
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	var removed bool
+	if group.Relays, removed = remove(group.Relays, relayname); !removed {
+		return errs.ErrNoSuchRelay
+	}
+
+	return nil
+}
+
+// addUnique adds s to a sorted slice, if it isn't already present.
+func addUnique(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+
+	ss = append(ss, s)
+	sort.Strings(ss)
+
+	return ss
+}
+
+func copyRelayGroup(g *rest.RelayGroup) rest.RelayGroup {
+	return rest.RelayGroup{
+		Name:    g.Name,
+		Relays:  append([]string{}, g.Relays...),
+		Bundles: append([]string{}, g.Bundles...),
+	}
+}
+
+// remove removes s from a slice, and returns true if it was present.
+func remove(ss []string, s string) ([]string, bool) {
+	for i, v := range ss {
+		if v == s {
+			return append(ss[:i], ss[i+1:]...), true
+		}
+	}
+
+	return ss, false
+}
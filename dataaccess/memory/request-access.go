@@ -13,19 +13,26 @@
  * See the License for the specific language governing permissions and
  * limitations under the License.
  */
-
 package memory
 
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync/atomic"
 
 	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
 	"github.com/getgort/gort/telemetry"
 	"go.opentelemetry.io/otel"
 )
 
-// Will not implement
+// requestHistoryLimit is the number of requests retained in the in-memory
+// audit log. Older requests are discarded.
+const requestHistoryLimit = 1000
+
+// RequestBegin assigns the request an ID and records it in the audit log.
 func (da *InMemoryDataAccess) RequestBegin(ctx context.Context, req *data.CommandRequest) error {
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	_, sp := tr.Start(ctx, "memory.RequestBegin")
@@ -35,38 +42,66 @@ func (da *InMemoryDataAccess) RequestBegin(ctx context.Context, req *data.Comman
 		return fmt.Errorf("command request ID already set")
 	}
 
-	req.RequestID++
+	// Request IDs are never reused, so that concurrent requests can always
+	// be told apart.
+	req.RequestID = atomic.AddInt64(&da.requestID, 1)
+
+	da.requestMu.Lock()
+	defer da.requestMu.Unlock()
+
+	record := &rest.AuditRecord{RequestID: req.RequestID}
+	updateAuditRecord(record, *req)
+	da.requests[req.RequestID] = record
+	da.requestIDs = append(da.requestIDs, req.RequestID)
+
+	if len(da.requestIDs) > requestHistoryLimit {
+		drop := len(da.requestIDs) - requestHistoryLimit
+		for _, id := range da.requestIDs[:drop] {
+			delete(da.requests, id)
+			delete(da.outputs, id)
+		}
+		da.requestIDs = append([]int64(nil), da.requestIDs[drop:]...)
+	}
 
 	return nil
 }
 
-// Will not implement
-func (da *InMemoryDataAccess) RequestError(ctx context.Context, result data.CommandRequest, err error) error {
+// RequestError closes the request with the given error and an exit code of 1.
+func (da *InMemoryDataAccess) RequestError(ctx context.Context, req data.CommandRequest, err error) error {
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
-	_, sp := tr.Start(ctx, "memory.RequestUpdate")
+	ctx, sp := tr.Start(ctx, "memory.RequestError")
 	defer sp.End()
 
-	if result.RequestID == 0 {
+	if req.RequestID == 0 {
 		return fmt.Errorf("command request ID unset")
 	}
 
-	return nil
+	return da.RequestClose(ctx, data.NewCommandResponseEnvelope(req, data.WithError("", err, 1)))
 }
 
-// Will not implement
-func (da *InMemoryDataAccess) RequestUpdate(ctx context.Context, result data.CommandRequest) error {
+// RequestUpdate updates the audit log with the request's current values.
+// Requests that have aged out of the log are ignored.
+func (da *InMemoryDataAccess) RequestUpdate(ctx context.Context, req data.CommandRequest) error {
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	_, sp := tr.Start(ctx, "memory.RequestUpdate")
 	defer sp.End()
 
-	if result.RequestID == 0 {
+	if req.RequestID == 0 {
 		return fmt.Errorf("command request ID unset")
 	}
 
+	da.requestMu.Lock()
+	defer da.requestMu.Unlock()
+
+	if record, ok := da.requests[req.RequestID]; ok {
+		updateAuditRecord(record, req)
+	}
+
 	return nil
 }
 
-// Will not implement
+// RequestClose records the result of the request in the audit log. Requests
+// that have aged out of the log are ignored.
 func (da *InMemoryDataAccess) RequestClose(ctx context.Context, envelope data.CommandResponseEnvelope) error {
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	_, sp := tr.Start(ctx, "memory.RequestClose")
@@ -76,5 +111,145 @@ func (da *InMemoryDataAccess) RequestClose(ctx context.Context, envelope data.Co
 		return fmt.Errorf("command request ID unset")
 	}
 
+	da.requestMu.Lock()
+	defer da.requestMu.Unlock()
+
+	record, ok := da.requests[envelope.Request.RequestID]
+	if !ok {
+		return nil
+	}
+
+	updateAuditRecord(record, envelope.Request)
+
+	exitCode := envelope.Data.ExitCode
+	record.Duration = envelope.Data.Duration
+	record.ExitCode = &exitCode
+	record.Error = ""
+	if envelope.Data.Error != nil {
+		record.Error = envelope.Data.Error.Error()
+	}
+
 	return nil
 }
+
+// RequestGet returns the audit log record for the request with the given ID.
+func (da *InMemoryDataAccess) RequestGet(ctx context.Context, id int64) (rest.AuditRecord, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.RequestGet")
+	defer sp.End()
+
+	da.requestMu.Lock()
+	defer da.requestMu.Unlock()
+
+	record, ok := da.requests[id]
+	if !ok {
+		return rest.AuditRecord{}, errs.ErrNoSuchRequest
+	}
+
+	return copyAuditRecord(record), nil
+}
+
+// RequestList returns the audit log records that match the filter, newest
+// first.
+func (da *InMemoryDataAccess) RequestList(ctx context.Context, filter rest.AuditFilter) ([]rest.AuditRecord, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.RequestList")
+	defer sp.End()
+
+	da.requestMu.Lock()
+	defer da.requestMu.Unlock()
+
+	records := []rest.AuditRecord{}
+
+	for _, record := range da.requests {
+		if filter.Matches(*record) {
+			records = append(records, copyAuditRecord(record))
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].RequestID > records[j].RequestID
+	})
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(records) {
+			return []rest.AuditRecord{}, nil
+		}
+		records = records[filter.Offset:]
+	}
+
+	if filter.Limit > 0 && filter.Limit < len(records) {
+		records = records[:filter.Limit]
+	}
+
+	return records, nil
+}
+
+// RequestOutputGet returns the output stored for the request with the given
+// ID.
+func (da *InMemoryDataAccess) RequestOutputGet(ctx context.Context, id int64) (string, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.RequestOutputGet")
+	defer sp.End()
+
+	da.requestMu.Lock()
+	defer da.requestMu.Unlock()
+
+	if _, ok := da.requests[id]; !ok {
+		return "", errs.ErrNoSuchRequest
+	}
+
+	output, ok := da.outputs[id]
+	if !ok {
+		return "", errs.ErrNoSuchOutput
+	}
+
+	return output, nil
+}
+
+// RequestOutputSet stores the output of the request with the given ID.
+// Requests that have aged out of the log are ignored.
+func (da *InMemoryDataAccess) RequestOutputSet(ctx context.Context, id int64, output string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.RequestOutputSet")
+	defer sp.End()
+
+	if id == 0 {
+		return fmt.Errorf("command request ID unset")
+	}
+
+	da.requestMu.Lock()
+	defer da.requestMu.Unlock()
+
+	if _, ok := da.requests[id]; ok {
+		da.outputs[id] = output
+	}
+
+	return nil
+}
+
+func updateAuditRecord(record *rest.AuditRecord, req data.CommandRequest) {
+	record.Timestamp = req.Timestamp
+	record.Adapter = req.Adapter
+	record.ChannelID = req.ChannelID
+	record.UserID = req.UserID
+	record.UserEmail = req.UserEmail
+	record.UserName = req.UserName
+	record.BundleName = req.Bundle.Name
+	record.BundleVersion = req.Bundle.Version
+	record.CommandName = req.Command.Name
+	record.Parameters = req.Parameters.String()
+	record.ParameterList = append([]string(nil), req.Parameters...)
+	record.Pipeline = req.Pipeline()
+}
+
+// copyAuditRecord returns a copy of the record that doesn't share its
+// ExitCode with the original.
+func copyAuditRecord(record *rest.AuditRecord) rest.AuditRecord {
+	c := *record
+	if record.ExitCode != nil {
+		code := *record.ExitCode
+		c.ExitCode = &code
+	}
+	return c
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+	"github.com/getgort/gort/telemetry"
+	"go.opentelemetry.io/otel"
+)
+
+// ScheduleCreate stores a new schedule, and sets its ID and creation time.
+func (da *InMemoryDataAccess) ScheduleCreate(ctx context.Context, schedule *rest.Schedule) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.ScheduleCreate")
+	defer sp.End()
+
+	da.scheduleMu.Lock()
+	defer da.scheduleMu.Unlock()
+
+	da.scheduleID++
+	schedule.ID = da.scheduleID
+	schedule.Created = time.Now().UTC()
+	schedule.NextRun = time.Time{}
+
+	s := *schedule
+	da.schedules[s.ID] = &s
+
+	return nil
+}
+
+// ScheduleDelete deletes a schedule.
+func (da *InMemoryDataAccess) ScheduleDelete(ctx context.Context, id int64) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.ScheduleDelete")
+	defer sp.End()
+
+	da.scheduleMu.Lock()
+	defer da.scheduleMu.Unlock()
+
+	if _, ok := da.schedules[id]; !ok {
+		return errs.ErrNoSuchSchedule
+	}
+
+	delete(da.schedules, id)
+
+	return nil
+}
+
+// ScheduleGet returns a schedule by its ID.
+func (da *InMemoryDataAccess) ScheduleGet(ctx context.Context, id int64) (rest.Schedule, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.ScheduleGet")
+	defer sp.End()
+
+	da.scheduleMu.Lock()
+	defer da.scheduleMu.Unlock()
+
+	s, ok := da.schedules[id]
+	if !ok {
+		return rest.Schedule{}, errs.ErrNoSuchSchedule
+	}
+
+	return *s, nil
+}
+
+// ScheduleList returns all schedules, ordered by ID.
+func (da *InMemoryDataAccess) ScheduleList(ctx context.Context) ([]rest.Schedule, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.ScheduleList")
+	defer sp.End()
+
+	da.scheduleMu.Lock()
+	defer da.scheduleMu.Unlock()
+
+	list := make([]rest.Schedule, 0, len(da.schedules))
+	for _, s := range da.schedules {
+		list = append(list, *s)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	return list, nil
+}
+
+// ScheduleUpdate replaces an existing schedule. Its ID and creation time
+// can't be changed.
+func (da *InMemoryDataAccess) ScheduleUpdate(ctx context.Context, schedule rest.Schedule) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	_, sp := tr.Start(ctx, "memory.ScheduleUpdate")
+	defer sp.End()
+
+	da.scheduleMu.Lock()
+	defer da.scheduleMu.Unlock()
+
+	s, ok := da.schedules[schedule.ID]
+	if !ok {
+		return errs.ErrNoSuchSchedule
+	}
+
+	schedule.Created = s.Created
+	schedule.NextRun = time.Time{}
+	*s = schedule
+
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+)
+
+// WebhookCreate creates a new webhook.
+func (da *InMemoryDataAccess) WebhookCreate(ctx context.Context, webhook data.Webhook) error {
+	if webhook.Bundle == "" {
+		return errs.ErrEmptyBundleName
+	}
+	if webhook.Name == "" {
+		return errs.ErrEmptyWebhookName
+	}
+
+	key := webhookKey(webhook.Bundle, webhook.Name)
+	if _, exists := da.webhooks[key]; exists {
+		return errs.ErrWebhookExists
+	}
+
+	webhook.Parameters = append([]string(nil), webhook.Parameters...)
+	da.webhooks[key] = &webhook
+
+	return nil
+}
+
+// WebhookDelete deletes a webhook.
+func (da *InMemoryDataAccess) WebhookDelete(ctx context.Context, bundlename, name string) error {
+	key := webhookKey(bundlename, name)
+	if _, exists := da.webhooks[key]; !exists {
+		return errs.ErrNoSuchWebhook
+	}
+
+	delete(da.webhooks, key)
+
+	return nil
+}
+
+// WebhookGet returns a webhook.
+func (da *InMemoryDataAccess) WebhookGet(ctx context.Context, bundlename, name string) (data.Webhook, error) {
+	webhook, exists := da.webhooks[webhookKey(bundlename, name)]
+	if !exists {
+		return data.Webhook{}, errs.ErrNoSuchWebhook
+	}
+
+	return *webhook, nil
+}
+
+// WebhookList returns all webhooks, ordered by bundle and name.
+func (da *InMemoryDataAccess) WebhookList(ctx context.Context) ([]data.Webhook, error) {
+	list := make([]data.Webhook, 0, len(da.webhooks))
+	for _, w := range da.webhooks {
+		list = append(list, *w)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Bundle != list[j].Bundle {
+			return list[i].Bundle < list[j].Bundle
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	return list, nil
+}
+
+func webhookKey(bundlename, name string) string {
+	return bundlename + ":" + name
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+	gerr "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/telemetry"
+)
+
+const aliasSelect = `SELECT owner, name, command FROM aliases`
+
+// AliasCreate creates a new alias. An alias with an owner is a user alias;
+// one without is a site alias.
+func (da PostgresDataAccess) AliasCreate(ctx context.Context, alias rest.Alias) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.AliasCreate")
+	defer sp.End()
+
+	if alias.Name == "" {
+		return errs.ErrEmptyAliasName
+	}
+	if alias.Command == "" {
+		return errs.ErrFieldRequired
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `INSERT INTO aliases (owner, name, command) VALUES ($1, $2, $3);`
+	_, err = conn.ExecContext(ctx, query, alias.Owner, alias.Name, alias.Command)
+
+	switch {
+	case err == nil:
+		return nil
+	case strings.Contains(err.Error(), "duplicate key"):
+		return errs.ErrAliasExists
+	case strings.Contains(err.Error(), "violates"):
+		return gerr.Wrap(errs.ErrFieldRequired, err)
+	default:
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+}
+
+// AliasDelete deletes an alias. The owner of a site alias is empty.
+func (da PostgresDataAccess) AliasDelete(ctx context.Context, owner, name string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.AliasDelete")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `DELETE FROM aliases WHERE owner=$1 AND name=$2;`
+	res, err := conn.ExecContext(ctx, query, owner, name)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	} else if n == 0 {
+		return errs.ErrNoSuchAlias
+	}
+
+	return nil
+}
+
+// AliasGet returns an alias. The owner of a site alias is empty.
+func (da PostgresDataAccess) AliasGet(ctx context.Context, owner, name string) (rest.Alias, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.AliasGet")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return rest.Alias{}, err
+	}
+	defer conn.Close()
+
+	query := aliasSelect + ` WHERE owner=$1 AND name=$2`
+	rows, err := conn.QueryContext(ctx, query, owner, name)
+	if err != nil {
+		return rest.Alias{}, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	list, err := scanAliases(rows)
+	if err != nil {
+		return rest.Alias{}, err
+	}
+	if len(list) == 0 {
+		return rest.Alias{}, errs.ErrNoSuchAlias
+	}
+
+	return list[0], nil
+}
+
+// AliasList returns all aliases, ordered by owner and name. Site aliases,
+// which have no owner, come first.
+func (da PostgresDataAccess) AliasList(ctx context.Context) ([]rest.Alias, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.AliasList")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, aliasSelect+` ORDER BY owner, name`)
+	if err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return scanAliases(rows)
+}
+
+// scanAliases reads aliases from rows selected with aliasSelect, and closes
+// rows.
+func scanAliases(rows *sql.Rows) ([]rest.Alias, error) {
+	defer rows.Close()
+
+	list := []rest.Alias{}
+
+	for rows.Next() {
+		var a rest.Alias
+
+		if err := rows.Scan(&a.Owner, &a.Name, &a.Command); err != nil {
+			return nil, gerr.Wrap(errs.ErrDataAccess, err)
+		}
+
+		if a.Owner == "" {
+			a.Scope = rest.AliasScopeSite
+		} else {
+			a.Scope = rest.AliasScopeUser
+		}
+
+		list = append(list, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return list, nil
+}
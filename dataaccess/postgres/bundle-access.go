@@ -19,6 +19,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
@@ -46,6 +47,7 @@ func (da PostgresDataAccess) BundleCreate(ctx context.Context, bundle data.Bundl
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	ctx, sp := tr.Start(ctx, "postgres.BundleCreate")
 	defer sp.End()
+	defer da.triggers.Invalidate()
 
 	if bundle.Name == "" {
 		return errs.ErrEmptyBundleName
@@ -110,6 +112,13 @@ func (da PostgresDataAccess) BundleCreate(ctx context.Context, bundle data.Bundl
 		return err
 	}
 
+	// Save webhooks
+	err = da.doBundleInsertWebhooks(ctx, tx, bundle)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		tx.Rollback()
@@ -124,6 +133,7 @@ func (da PostgresDataAccess) BundleDelete(ctx context.Context, name, version str
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	ctx, sp := tr.Start(ctx, "postgres.BundleDelete")
 	defer sp.End()
+	defer da.triggers.Invalidate()
 
 	if name == "" {
 		return errs.ErrEmptyBundleName
@@ -179,6 +189,7 @@ func (da PostgresDataAccess) BundleDisable(ctx context.Context, name, version st
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	ctx, sp := tr.Start(ctx, "postgres.BundleDisable")
 	defer sp.End()
+	defer da.triggers.Invalidate()
 
 	conn, err := da.connect(ctx)
 	if err != nil {
@@ -211,6 +222,7 @@ func (da PostgresDataAccess) BundleEnable(ctx context.Context, name, version str
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	ctx, sp := tr.Start(ctx, "postgres.BundleEnable")
 	defer sp.End()
+	defer da.triggers.Invalidate()
 
 	conn, err := da.connect(ctx)
 	if err != nil {
@@ -404,6 +416,7 @@ func (da PostgresDataAccess) BundleUpdate(ctx context.Context, bundle data.Bundl
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	ctx, sp := tr.Start(ctx, "postgres.BundleUpdate")
 	defer sp.End()
+	defer da.triggers.Invalidate()
 
 	if bundle.Name == "" {
 		return errs.ErrEmptyBundleName
@@ -526,20 +539,17 @@ func (da PostgresDataAccess) FindCommandEntry(ctx context.Context, bundleName, c
 	return da.doFindCommandEntry(ctx, tx, bundleName, commandName)
 }
 
+// FindCommandEntryByTrigger returns an entry for each trigger of an enabled
+// command that matches the tokens. Trigger patterns are compiled once and cached until a
+// bundle is created, updated, deleted, enabled, or disabled.
 func (da PostgresDataAccess) FindCommandEntryByTrigger(ctx context.Context, tokens []string) ([]data.CommandEntry, error) {
-	conn, err := da.connect(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer conn.Close()
-
-	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
-	if err != nil {
-		return nil, gerr.Wrap(errs.ErrDataAccess, err)
-	}
-	defer tx.Commit()
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.FindCommandEntryByTrigger")
+	defer sp.End()
 
-	return da.doFindCommandEntryByTrigger(ctx, tx, tokens)
+	return da.triggers.Match(strings.Join(tokens, " "), func() ([]data.Bundle, error) {
+		return da.BundleList(ctx)
+	})
 }
 
 func (da PostgresDataAccess) doBundleDelete(ctx context.Context, tx *sql.Tx, name string, version string) error {
@@ -567,6 +577,12 @@ func (da PostgresDataAccess) doBundleDelete(ctx context.Context, tx *sql.Tx, nam
 		return gerr.Wrap(errs.ErrDataAccess, err)
 	}
 
+	query = "DELETE FROM webhooks WHERE bundle_name=$1 AND bundle_version=$2;"
+	_, err = tx.ExecContext(ctx, query, name, version)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
 	query = "DELETE FROM bundles WHERE name=$1 AND version=$2;"
 	_, err = tx.ExecContext(ctx, query, name, version)
 	if err != nil {
@@ -694,37 +710,10 @@ func (da PostgresDataAccess) doFindCommandEntry(ctx context.Context, tx *sql.Tx,
 	return entries, nil
 }
 
-func (da PostgresDataAccess) doFindCommandEntryByTrigger(ctx context.Context, tx *sql.Tx, tokens []string) ([]data.CommandEntry, error) {
-	bundles, err := da.BundleList(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	entries := make([]data.CommandEntry, 0)
-
-	for _, bundle := range bundles {
-		for _, cmd := range bundle.Commands {
-			matched, err := cmd.MatchTrigger(ctx, strings.Join(tokens, " "))
-			if err != nil {
-				return nil, err
-			}
-			if matched {
-				entries = append(entries, data.CommandEntry{
-					Bundle:  bundle,
-					Command: *cmd,
-				})
-			}
-		}
-
-	}
-
-	return entries, nil
-}
-
 func (da PostgresDataAccess) doBundleGet(ctx context.Context, tx *sql.Tx, name string, version string) (data.Bundle, error) {
 	query := `SELECT gort_bundle_version, name, version, author, homepage,
 			description, long_description, image_repository, image_tag,
-			install_timestamp, install_user
+			install_timestamp, install_user, timeout, memory, cpu, max_concurrent
 		FROM bundles
 		WHERE name=$1 AND version=$2`
 
@@ -735,7 +724,8 @@ func (da PostgresDataAccess) doBundleGet(ctx context.Context, tx *sql.Tx, name s
 	err := row.Scan(&bundle.GortBundleVersion, &bundle.Name, &bundle.Version,
 		&bundle.Author, &bundle.Homepage, &bundle.Description,
 		&bundle.LongDescription, &repository, &tag,
-		&bundle.InstalledOn, &bundle.InstalledBy)
+		&bundle.InstalledOn, &bundle.InstalledBy, &bundle.Timeout,
+		&bundle.Memory, &bundle.CPU, &bundle.MaxConcurrent)
 	if err != nil {
 		return bundle, gerr.Wrap(errs.ErrNoSuchBundle, err)
 	}
@@ -781,6 +771,11 @@ func (da PostgresDataAccess) doBundleGet(ctx context.Context, tx *sql.Tx, name s
 		return bundle, gerr.Wrap(fmt.Errorf("failed to get bundle kubernetes config"), err)
 	}
 
+	bundle.Webhooks, err = da.doBundleGetWebhooks(ctx, tx, name, version)
+	if err != nil {
+		return bundle, gerr.Wrap(fmt.Errorf("failed to get bundle webhooks"), err)
+	}
+
 	return bundle, nil
 }
 
@@ -801,12 +796,16 @@ func (da PostgresDataAccess) doBundleGetCommandsData(ctx context.Context, tx *sq
 	}
 
 	if enabledOnly {
-		query = `SELECT bundle_commands.bundle_name, bundle_commands.bundle_version, name, description, executable, long_description
+		query = `SELECT bundle_commands.bundle_name, bundle_commands.bundle_version, name, description, executable, long_description, streaming,
+				bundle_commands.timeout, bundle_commands.memory, bundle_commands.cpu, bundle_commands.max_concurrent,
+				bundle_commands.options, bundle_commands.arguments
 			FROM bundle_commands
 			INNER JOIN bundle_enabled ON bundle_commands.bundle_name=bundle_enabled.bundle_name
 			WHERE bundle_commands.bundle_name LIKE $1 AND bundle_commands.bundle_version LIKE $2 AND name LIKE $3`
 	} else {
-		query = `SELECT bundle_commands.bundle_name, bundle_commands.bundle_version, name, description, executable, long_description
+		query = `SELECT bundle_commands.bundle_name, bundle_commands.bundle_version, name, description, executable, long_description, streaming,
+				bundle_commands.timeout, bundle_commands.memory, bundle_commands.cpu, bundle_commands.max_concurrent,
+				bundle_commands.options, bundle_commands.arguments
 			FROM bundle_commands
 			WHERE bundle_commands.bundle_name LIKE $1 AND bundle_commands.bundle_version LIKE $2 AND name LIKE $3`
 	}
@@ -820,15 +819,23 @@ func (da PostgresDataAccess) doBundleGetCommandsData(ctx context.Context, tx *sq
 	commands := make([]bundleCommandData, 0)
 
 	for rows.Next() {
-		var enc string
+		var enc, options, arguments string
 		cd := bundleCommandData{}
 
-		err = rows.Scan(&cd.BundleName, &cd.BundleVersion, &cd.Name, &cd.Description, &enc, &cd.LongDescription)
+		err = rows.Scan(&cd.BundleName, &cd.BundleVersion, &cd.Name, &cd.Description, &enc, &cd.LongDescription, &cd.Streaming,
+			&cd.Timeout, &cd.Memory, &cd.CPU, &cd.MaxConcurrent, &options, &arguments)
 		if err != nil {
 			return nil, gerr.Wrap(errs.ErrDataAccess, err)
 		}
 
 		cd.Executable = decodeStringSlice(enc)
+
+		if err := decodeJSON(options, &cd.Options); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON(arguments, &cd.Arguments); err != nil {
+			return nil, err
+		}
 		commands = append(commands, cd)
 	}
 
@@ -868,7 +875,7 @@ func (da PostgresDataAccess) doBundleGetCommands(ctx context.Context, tx *sql.Tx
 }
 
 func (da PostgresDataAccess) doBundleGetCommandTriggers(ctx context.Context, tx *sql.Tx, bundleName, bundleVersion, commandName string) ([]data.Trigger, error) {
-	cmdQuery := `SELECT match
+	cmdQuery := `SELECT match, conditions
 		FROM bundle_command_triggers
 		WHERE bundle_name=$1 AND bundle_version=$2 AND command_name=$3`
 
@@ -881,12 +888,17 @@ func (da PostgresDataAccess) doBundleGetCommandTriggers(ctx context.Context, tx
 	var triggers []data.Trigger
 	for rows.Next() {
 		var trigger data.Trigger
+		var conditions string
 
-		err = rows.Scan(&trigger.Match)
+		err = rows.Scan(&trigger.Match, &conditions)
 		if err != nil {
 			return nil, gerr.Wrap(errs.ErrDataAccess, err)
 		}
 
+		if err := decodeJSON(conditions, &trigger.TriggerConditions); err != nil {
+			return nil, err
+		}
+
 		triggers = append(triggers, trigger)
 	}
 
@@ -920,14 +932,14 @@ func (da PostgresDataAccess) doBundleGetCommandRules(ctx context.Context, tx *sq
 }
 
 func (da PostgresDataAccess) doBundleGetCommandTemplates(ctx context.Context, tx *sql.Tx, bundleName, bundleVersion, commandName string) (data.Templates, error) {
-	query := `SELECT command, command_error, message, message_error
+	query := `SELECT command, command_error, message, message_error, help
 		FROM bundle_command_templates
 		WHERE bundle_name=$1 AND bundle_version=$2 AND command_name=$3`
 
 	var templates data.Templates
 
 	err := tx.QueryRowContext(ctx, query, bundleName, bundleVersion, commandName).
-		Scan(&templates.Command, &templates.CommandError, &templates.Message, &templates.MessageError)
+		Scan(&templates.Command, &templates.CommandError, &templates.Message, &templates.MessageError, &templates.Help)
 
 	switch {
 	case err == sql.ErrNoRows:
@@ -990,13 +1002,13 @@ func (da PostgresDataAccess) doBundleGetPermissions(ctx context.Context, tx *sql
 }
 
 func (da PostgresDataAccess) doBundleGetTemplates(ctx context.Context, tx *sql.Tx, bundleName, bundleVersion string) (data.Templates, error) {
-	query := `SELECT command, command_error, message, message_error FROM bundle_templates
+	query := `SELECT command, command_error, message, message_error, help FROM bundle_templates
 		WHERE bundle_name=$1 AND bundle_version=$2`
 
 	var templates data.Templates
 
 	err := tx.QueryRowContext(ctx, query, bundleName, bundleVersion).
-		Scan(&templates.Command, &templates.CommandError, &templates.Message, &templates.MessageError)
+		Scan(&templates.Command, &templates.CommandError, &templates.Message, &templates.MessageError, &templates.Help)
 
 	switch {
 	case err == sql.ErrNoRows:
@@ -1011,14 +1023,15 @@ func (da PostgresDataAccess) doBundleGetTemplates(ctx context.Context, tx *sql.T
 func (da PostgresDataAccess) doBundleInsert(ctx context.Context, tx *sql.Tx, bundle data.Bundle) error {
 	query := `INSERT INTO bundles (gort_bundle_version, name, version, author,
 		homepage, description, long_description, image_repository, image_tag,
-		install_user)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`
+		install_user, timeout, memory, cpu, max_concurrent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);`
 
 	repository, tag := bundle.ImageFullParts()
 
 	_, err := tx.ExecContext(ctx, query, bundle.GortBundleVersion, bundle.Name, bundle.Version,
 		bundle.Author, bundle.Homepage, bundle.Description, bundle.LongDescription,
-		repository, tag, bundle.InstalledBy)
+		repository, tag, bundle.InstalledBy, bundle.Timeout, bundle.Memory,
+		bundle.CPU, bundle.MaxConcurrent)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "violates") {
@@ -1037,11 +1050,16 @@ func (da PostgresDataAccess) doBundleInsertCommandTriggers(ctx context.Context,
 	tx *sql.Tx, bundle data.Bundle, command *data.BundleCommand) error {
 
 	query := `INSERT INTO bundle_command_triggers
-		(bundle_name, bundle_version, command_name, match)
-		VALUES ($1, $2, $3, $4);`
+		(bundle_name, bundle_version, command_name, match, conditions)
+		VALUES ($1, $2, $3, $4, $5);`
 
 	for _, trigger := range command.Triggers {
-		_, err := tx.ExecContext(ctx, query, bundle.Name, bundle.Version, command.Name, trigger.Match)
+		conditions, err := encodeJSON(trigger.TriggerConditions)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, bundle.Name, bundle.Version, command.Name, trigger.Match, conditions)
 		if err != nil {
 			if strings.Contains(err.Error(), "violates") {
 				err = gerr.Wrap(errs.ErrFieldRequired, err)
@@ -1083,12 +1101,12 @@ func (da PostgresDataAccess) doBundleInsertCommandTemplates(ctx context.Context,
 	tx *sql.Tx, bundle data.Bundle, command *data.BundleCommand) error {
 
 	query := `INSERT INTO bundle_command_templates
-		(bundle_name, bundle_version, command_name, command, command_error, message, message_error)
-		VALUES ($1, $2, $3, $4, $5, $6, $7);`
+		(bundle_name, bundle_version, command_name, command, command_error, message, message_error, help)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
 
 	_, err := tx.ExecContext(ctx, query, bundle.Name, bundle.Version, command.Name,
 		command.Templates.Command, command.Templates.CommandError,
-		command.Templates.Message, command.Templates.MessageError)
+		command.Templates.Message, command.Templates.MessageError, command.Templates.Help)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "violates") {
@@ -1105,16 +1123,28 @@ func (da PostgresDataAccess) doBundleInsertCommandTemplates(ctx context.Context,
 
 func (da PostgresDataAccess) doBundleInsertCommands(ctx context.Context, tx *sql.Tx, bundle data.Bundle) error {
 	query := `INSERT INTO bundle_commands
-		(bundle_name, bundle_version, name, description, executable, long_description)
-		VALUES ($1, $2, $3, $4, $5, $6);`
+		(bundle_name, bundle_version, name, description, executable, long_description, streaming,
+			timeout, memory, cpu, max_concurrent, options, arguments)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13);`
 
 	for name, cmd := range bundle.Commands {
 		cmd.Name = name
 
 		enc := encodeStringSlice(cmd.Executable)
 
-		_, err := tx.ExecContext(ctx, query, bundle.Name, bundle.Version,
-			cmd.Name, cmd.Description, enc, cmd.LongDescription)
+		options, err := encodeJSON(cmd.Options)
+		if err != nil {
+			return err
+		}
+
+		arguments, err := encodeJSON(cmd.Arguments)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, bundle.Name, bundle.Version,
+			cmd.Name, cmd.Description, enc, cmd.LongDescription, cmd.Streaming,
+			cmd.Timeout, cmd.Memory, cmd.CPU, cmd.MaxConcurrent, options, arguments)
 
 		if err != nil {
 			if strings.Contains(err.Error(), "violates") {
@@ -1168,12 +1198,12 @@ func (da PostgresDataAccess) doBundleInsertPermissions(ctx context.Context, tx *
 
 func (da PostgresDataAccess) doBundleInsertTemplates(ctx context.Context, tx *sql.Tx, bundle data.Bundle) error {
 	query := `INSERT INTO bundle_templates
-		(bundle_name, bundle_version, command, command_error, message, message_error)
-		VALUES ($1, $2, $3, $4, $5, $6);`
+		(bundle_name, bundle_version, command, command_error, message, message_error, help)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`
 
 	_, err := tx.ExecContext(ctx, query, bundle.Name, bundle.Version,
 		bundle.Templates.Command, bundle.Templates.CommandError,
-		bundle.Templates.Message, bundle.Templates.MessageError)
+		bundle.Templates.Message, bundle.Templates.MessageError, bundle.Templates.Help)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "violates") {
@@ -1209,6 +1239,74 @@ func (da PostgresDataAccess) doBundleInsertKubernetes(ctx context.Context, tx *s
 	return nil
 }
 
+// doBundleGetWebhooks returns the webhooks declared by a bundle, or nil if it
+// declares none.
+func (da PostgresDataAccess) doBundleGetWebhooks(ctx context.Context, tx *sql.Tx, bundleName, bundleVersion string) (map[string]*data.Webhook, error) {
+	query := webhookSelect + ` WHERE bundle_name=$1 AND bundle_version=$2`
+
+	rows, err := tx.QueryContext(ctx, query, bundleName, bundleVersion)
+	if err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	list, err := scanWebhooks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	webhooks := make(map[string]*data.Webhook, len(list))
+	for i := range list {
+		// Webhooks declared by a bundle don't record the bundle's name.
+		list[i].Bundle = ""
+		webhooks[list[i].Name] = &list[i]
+	}
+
+	return webhooks, nil
+}
+
+func (da PostgresDataAccess) doBundleInsertWebhooks(ctx context.Context, tx *sql.Tx, bundle data.Bundle) error {
+	for name, w := range bundle.Webhooks {
+		webhook := *w
+		webhook.Bundle = bundle.Name
+		webhook.Name = name
+
+		if err := doWebhookInsert(ctx, tx, webhook, bundle.Version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeJSON decodes a value stored by encodeJSON. An empty string, as found
+// in rows written before the column was added, leaves v unchanged.
+func decodeJSON(str string, v interface{}) error {
+	if str == "" {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(str), v); err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
+// encodeJSON encodes a structured value, such as a command's option schema,
+// for storage in a text column.
+func encodeJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return string(b), nil
+}
+
 func decodeStringSlice(str string) []string {
 	if str == "" {
 		return []string{}
@@ -38,18 +38,20 @@ const (
 
 // PostgresDataAccess is a data access implementation backed by a database.
 type PostgresDataAccess struct {
-	configs data.DatabaseConfigs
-	dbs     map[string]*sql.DB
-	mutex   *sync.Mutex
+	configs  data.DatabaseConfigs
+	dbs      map[string]*sql.DB
+	mutex    *sync.Mutex
+	triggers *data.TriggerIndex
 }
 
 // NewPostgresDataAccess returns a new PostgresDataAccess based on the
 // supplied config.
 func NewPostgresDataAccess(configs data.DatabaseConfigs) PostgresDataAccess {
 	return PostgresDataAccess{
-		configs: configs,
-		dbs:     map[string]*sql.DB{},
-		mutex:   &sync.Mutex{},
+		configs:  configs,
+		dbs:      map[string]*sql.DB{},
+		mutex:    &sync.Mutex{},
+		triggers: &data.TriggerIndex{},
 	}
 }
 
@@ -97,6 +99,12 @@ func (da PostgresDataAccess) initializeAuditData(ctx context.Context) error {
 		}
 	}
 
+	// Add any columns that older commands tables may lack.
+	err = da.updateCommandsTable(ctx, conn)
+	if err != nil {
+		return gerr.Wrap(fmt.Errorf("failed to update commands table"), err)
+	}
+
 	return nil
 }
 
@@ -202,6 +210,30 @@ func (da PostgresDataAccess) initializeGortData(ctx context.Context) error {
 		}
 	}
 
+	// Upsert the relay groups tables
+	err = da.createRelayGroupsTables(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	// Upsert the schedules table
+	err = da.createSchedulesTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	// Upsert the webhooks table
+	err = da.createWebhooksTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	// Upsert the aliases table
+	err = da.createAliasesTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+
 	// Check whether the configs table exists
 	exists, err = da.tableExists(ctx, "configs", conn)
 	if err != nil {
@@ -251,6 +283,10 @@ func (da PostgresDataAccess) createBundlesTables(ctx context.Context, conn *sql.
 	);
 
 	ALTER TABLE bundles ALTER COLUMN install_timestamp SET DEFAULT now();
+	ALTER TABLE bundles ADD COLUMN IF NOT EXISTS timeout BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE bundles ADD COLUMN IF NOT EXISTS memory TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bundles ADD COLUMN IF NOT EXISTS cpu TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bundles ADD COLUMN IF NOT EXISTS max_concurrent INT NOT NULL DEFAULT 0;
 
 	CREATE TABLE IF NOT EXISTS bundle_enabled (
 		bundle_name			TEXT NOT NULL,
@@ -285,6 +321,8 @@ func (da PostgresDataAccess) createBundlesTables(ctx context.Context, conn *sql.
 		ON DELETE CASCADE
 	);
 
+	ALTER TABLE bundle_templates ADD COLUMN IF NOT EXISTS help TEXT NOT NULL DEFAULT '';
+
 	CREATE TABLE IF NOT EXISTS bundle_commands (
 		bundle_name			TEXT NOT NULL,
 		bundle_version		TEXT NOT NULL,
@@ -298,6 +336,14 @@ func (da PostgresDataAccess) createBundlesTables(ctx context.Context, conn *sql.
 		ON DELETE CASCADE
 	);
 
+	ALTER TABLE bundle_commands ADD COLUMN IF NOT EXISTS streaming BOOLEAN NOT NULL DEFAULT false;
+	ALTER TABLE bundle_commands ADD COLUMN IF NOT EXISTS timeout BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE bundle_commands ADD COLUMN IF NOT EXISTS memory TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bundle_commands ADD COLUMN IF NOT EXISTS cpu TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bundle_commands ADD COLUMN IF NOT EXISTS max_concurrent INT NOT NULL DEFAULT 0;
+	ALTER TABLE bundle_commands ADD COLUMN IF NOT EXISTS options TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bundle_commands ADD COLUMN IF NOT EXISTS arguments TEXT NOT NULL DEFAULT '';
+
 	CREATE TABLE IF NOT EXISTS bundle_command_triggers (
 		bundle_name			TEXT NOT NULL,
 		bundle_version		TEXT NOT NULL,
@@ -308,6 +354,7 @@ func (da PostgresDataAccess) createBundlesTables(ctx context.Context, conn *sql.
 		REFERENCES 			bundle_commands(bundle_name, bundle_version, name)
 		ON DELETE CASCADE
 	);
+	ALTER TABLE bundle_command_triggers ADD COLUMN IF NOT EXISTS conditions TEXT NOT NULL DEFAULT '';
 
 	CREATE TABLE IF NOT EXISTS bundle_command_rules (
 		bundle_name			TEXT NOT NULL,
@@ -334,6 +381,8 @@ func (da PostgresDataAccess) createBundlesTables(ctx context.Context, conn *sql.
 		REFERENCES 			bundle_commands(bundle_name, bundle_version, name)
 		ON DELETE CASCADE
 	);
+
+	ALTER TABLE bundle_command_templates ADD COLUMN IF NOT EXISTS help TEXT NOT NULL DEFAULT '';
 	`
 
 	_, err = conn.ExecContext(ctx, createBundlesQuery)
@@ -415,6 +464,39 @@ func (da PostgresDataAccess) createGroupUsersTable(ctx context.Context, conn *sq
 	return nil
 }
 
+func (da PostgresDataAccess) createRelayGroupsTables(ctx context.Context, conn *sql.Conn) error {
+	var err error
+
+	createRelayGroupsQuery := `CREATE TABLE IF NOT EXISTS relay_groups (
+		group_name		TEXT NOT NULL,
+		PRIMARY KEY		(group_name)
+	);
+
+	CREATE TABLE IF NOT EXISTS relay_group_relays (
+		group_name		TEXT NOT NULL,
+		relay_name		TEXT NOT NULL,
+		PRIMARY KEY		(group_name, relay_name),
+		FOREIGN KEY		(group_name) REFERENCES relay_groups(group_name)
+		ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS relay_group_bundles (
+		group_name		TEXT NOT NULL,
+		bundle_name		TEXT NOT NULL,
+		PRIMARY KEY		(group_name, bundle_name),
+		FOREIGN KEY		(group_name) REFERENCES relay_groups(group_name)
+		ON DELETE CASCADE
+	);
+	`
+
+	_, err = conn.ExecContext(ctx, createRelayGroupsQuery)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
 func (da PostgresDataAccess) createRolesTables(ctx context.Context, conn *sql.Conn) error {
 	var err error
 
@@ -451,6 +533,32 @@ func (da PostgresDataAccess) createRolesTables(ctx context.Context, conn *sql.Co
 	return nil
 }
 
+func (da PostgresDataAccess) createSchedulesTable(ctx context.Context, conn *sql.Conn) error {
+	var err error
+
+	createSchedulesQuery := `CREATE TABLE IF NOT EXISTS schedules (
+		schedule_id		BIGSERIAL,
+		cron			TEXT NOT NULL CHECK(cron <> ''),
+		timezone		TEXT NOT NULL DEFAULT '',
+		command			TEXT NOT NULL CHECK(command <> ''),
+		adapter			TEXT NOT NULL,
+		channel_id		TEXT NOT NULL,
+		user_name		TEXT NOT NULL,
+		paused			BOOLEAN NOT NULL DEFAULT false,
+		created			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+		last_run		TIMESTAMP WITH TIME ZONE,
+		PRIMARY KEY		(schedule_id)
+	);
+	`
+
+	_, err = conn.ExecContext(ctx, createSchedulesQuery)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
 func (da PostgresDataAccess) createTokensTable(ctx context.Context, conn *sql.Conn) error {
 	var err error
 
@@ -473,6 +581,27 @@ func (da PostgresDataAccess) createTokensTable(ctx context.Context, conn *sql.Co
 	return nil
 }
 
+// createAliasesTable creates the table that stores command aliases. Site
+// aliases are stored with an empty owner.
+func (da PostgresDataAccess) createAliasesTable(ctx context.Context, conn *sql.Conn) error {
+	var err error
+
+	createAliasesQuery := `CREATE TABLE IF NOT EXISTS aliases (
+		owner		TEXT NOT NULL,
+		name		TEXT NOT NULL CHECK(name <> ''),
+		command		TEXT NOT NULL CHECK(command <> ''),
+		PRIMARY KEY	(owner, name)
+	);
+	`
+
+	_, err = conn.ExecContext(ctx, createAliasesQuery)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
 func (da PostgresDataAccess) createUsersTable(ctx context.Context, conn *sql.Conn) error {
 	var err error
 
@@ -513,6 +642,35 @@ func (da PostgresDataAccess) createUsersAdapterIDsTable(ctx context.Context, con
 	return nil
 }
 
+// createWebhooksTable creates the table that stores webhooks. Those that are
+// declared by a bundle are stored with that bundle's version; those that are
+// created through the API have an empty bundle version.
+func (da PostgresDataAccess) createWebhooksTable(ctx context.Context, conn *sql.Conn) error {
+	var err error
+
+	createWebhooksQuery := `CREATE TABLE IF NOT EXISTS webhooks (
+		bundle_name		TEXT NOT NULL CHECK(bundle_name <> ''),
+		bundle_version	TEXT NOT NULL,
+		name			TEXT NOT NULL CHECK(name <> ''),
+		command			TEXT NOT NULL,
+		parameters		TEXT NOT NULL DEFAULT '',
+		user_name		TEXT NOT NULL DEFAULT '',
+		secret			TEXT NOT NULL DEFAULT '',
+		token			TEXT NOT NULL DEFAULT '',
+		adapter			TEXT NOT NULL DEFAULT '',
+		channel_id		TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY		(bundle_name, bundle_version, name)
+	);
+	`
+
+	_, err = conn.ExecContext(ctx, createWebhooksQuery)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
 func (da PostgresDataAccess) databaseExists(ctx context.Context, conn *sql.Conn, dbName string) (bool, error) {
 	const query = `SELECT datname
 		FROM pg_database
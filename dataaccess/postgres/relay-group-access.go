@@ -0,0 +1,402 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+	gerr "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/telemetry"
+)
+
+// RelayGroupBundleAdd assigns a bundle to a relay group.
+func (da PostgresDataAccess) RelayGroupBundleAdd(ctx context.Context, groupname, bundlename string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupBundleAdd")
+	defer sp.End()
+
+	exists, err := da.RelayGroupExists(ctx, groupname)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	exists, err = da.BundleExists(ctx, bundlename)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrNoSuchBundle
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `INSERT INTO relay_group_bundles (group_name, bundle_name)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;`
+	_, err = conn.ExecContext(ctx, query, groupname, bundlename)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
+// RelayGroupBundleDelete removes a bundle from a relay group.
+func (da PostgresDataAccess) RelayGroupBundleDelete(ctx context.Context, groupname, bundlename string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupBundleDelete")
+	defer sp.End()
+
+	exists, err := da.RelayGroupExists(ctx, groupname)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `DELETE FROM relay_group_bundles WHERE group_name=$1 AND bundle_name=$2;`
+	result, err := conn.ExecContext(ctx, query, groupname, bundlename)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	} else if n == 0 {
+		return errs.ErrNoSuchBundle
+	}
+
+	return nil
+}
+
+// RelayGroupCreate creates a new relay group.
+func (da PostgresDataAccess) RelayGroupCreate(ctx context.Context, group rest.RelayGroup) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupCreate")
+	defer sp.End()
+
+	if group.Name == "" {
+		return errs.ErrEmptyRelayGroupName
+	}
+
+	exists, err := da.RelayGroupExists(ctx, group.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errs.ErrRelayGroupExists
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `INSERT INTO relay_groups (group_name) VALUES ($1);`
+	_, err = conn.ExecContext(ctx, query, group.Name)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
+// RelayGroupDelete deletes a relay group, along with its relay and bundle
+// assignments.
+func (da PostgresDataAccess) RelayGroupDelete(ctx context.Context, groupname string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupDelete")
+	defer sp.End()
+
+	if groupname == "" {
+		return errs.ErrEmptyRelayGroupName
+	}
+
+	exists, err := da.RelayGroupExists(ctx, groupname)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `DELETE FROM relay_groups WHERE group_name=$1;`
+	_, err = conn.ExecContext(ctx, query, groupname)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
+// RelayGroupExists is used to determine whether a relay group exists in the
+// data store.
+func (da PostgresDataAccess) RelayGroupExists(ctx context.Context, groupname string) (bool, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupExists")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	query := "SELECT EXISTS(SELECT 1 FROM relay_groups WHERE group_name=$1)"
+	exists := false
+
+	err = conn.QueryRowContext(ctx, query, groupname).Scan(&exists)
+	if err != nil {
+		return false, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return exists, nil
+}
+
+// RelayGroupGet gets a specific relay group.
+func (da PostgresDataAccess) RelayGroupGet(ctx context.Context, groupname string) (rest.RelayGroup, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupGet")
+	defer sp.End()
+
+	if groupname == "" {
+		return rest.RelayGroup{}, errs.ErrEmptyRelayGroupName
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return rest.RelayGroup{}, err
+	}
+	defer conn.Close()
+
+	query := `SELECT group_name FROM relay_groups WHERE group_name=$1`
+
+	group := rest.RelayGroup{}
+	err = conn.QueryRowContext(ctx, query, groupname).Scan(&group.Name)
+	if err == sql.ErrNoRows {
+		return group, errs.ErrNoSuchRelayGroup
+	} else if err != nil {
+		return group, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	if err := da.doRelayGroupMembers(ctx, conn, &group); err != nil {
+		return group, err
+	}
+
+	return group, nil
+}
+
+// RelayGroupList returns a list of all known relay groups in the datastore.
+func (da PostgresDataAccess) RelayGroupList(ctx context.Context) ([]rest.RelayGroup, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupList")
+	defer sp.End()
+
+	query := `SELECT group_name FROM relay_groups ORDER BY group_name`
+
+	return da.doRelayGroupQuery(ctx, query)
+}
+
+// RelayGroupListByBundle returns all relay groups that a bundle is assigned
+// to.
+func (da PostgresDataAccess) RelayGroupListByBundle(ctx context.Context, bundlename string) ([]rest.RelayGroup, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupListByBundle")
+	defer sp.End()
+
+	query := `SELECT group_name
+		FROM relay_group_bundles
+		WHERE bundle_name=$1
+		ORDER BY group_name`
+
+	return da.doRelayGroupQuery(ctx, query, bundlename)
+}
+
+// RelayGroupRelayAdd adds a relay to a relay group. The relay doesn't need
+// to be connected.
+func (da PostgresDataAccess) RelayGroupRelayAdd(ctx context.Context, groupname, relayname string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupRelayAdd")
+	defer sp.End()
+
+	if relayname == "" {
+		return errs.ErrEmptyRelayName
+	}
+
+	exists, err := da.RelayGroupExists(ctx, groupname)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `INSERT INTO relay_group_relays (group_name, relay_name)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;`
+	_, err = conn.ExecContext(ctx, query, groupname, relayname)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
+// RelayGroupRelayDelete removes a relay from a relay group.
+func (da PostgresDataAccess) RelayGroupRelayDelete(ctx context.Context, groupname, relayname string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RelayGroupRelayDelete")
+	defer sp.End()
+
+	exists, err := da.RelayGroupExists(ctx, groupname)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrNoSuchRelayGroup
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `DELETE FROM relay_group_relays WHERE group_name=$1 AND relay_name=$2;`
+	result, err := conn.ExecContext(ctx, query, groupname, relayname)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	} else if n == 0 {
+		return errs.ErrNoSuchRelay
+	}
+
+	return nil
+}
+
+// doRelayGroupMembers populates the Relays and Bundles fields of a group.
+func (da PostgresDataAccess) doRelayGroupMembers(ctx context.Context, conn *sql.Conn, group *rest.RelayGroup) error {
+	relays, err := da.doStringQuery(ctx, conn,
+		`SELECT relay_name FROM relay_group_relays WHERE group_name=$1 ORDER BY relay_name`,
+		group.Name)
+	if err != nil {
+		return err
+	}
+
+	bundles, err := da.doStringQuery(ctx, conn,
+		`SELECT bundle_name FROM relay_group_bundles WHERE group_name=$1 ORDER BY bundle_name`,
+		group.Name)
+	if err != nil {
+		return err
+	}
+
+	group.Relays = relays
+	group.Bundles = bundles
+
+	return nil
+}
+
+// doRelayGroupQuery executes a query that returns a single column of relay
+// group names, and returns the fully populated groups.
+func (da PostgresDataAccess) doRelayGroupQuery(ctx context.Context, query string, args ...interface{}) ([]rest.RelayGroup, error) {
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	names, err := da.doStringQuery(ctx, conn, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]rest.RelayGroup, 0, len(names))
+
+	for _, name := range names {
+		group := rest.RelayGroup{Name: name}
+
+		if err := da.doRelayGroupMembers(ctx, conn, &group); err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// doStringQuery executes a query that returns a single text column.
+func (da PostgresDataAccess) doStringQuery(ctx context.Context, conn *sql.Conn, query string, args ...interface{}) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+	defer rows.Close()
+
+	ss := make([]string, 0)
+
+	for rows.Next() {
+		var s string
+
+		if err = rows.Scan(&s); err != nil {
+			return nil, gerr.Wrap(errs.ErrDataAccess, err)
+		}
+
+		ss = append(ss, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return ss, nil
+}
@@ -21,8 +21,10 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
 	"github.com/getgort/gort/dataaccess/errs"
 	gerr "github.com/getgort/gort/errors"
 	"github.com/getgort/gort/telemetry"
@@ -46,8 +48,9 @@ func (da PostgresDataAccess) RequestBegin(ctx context.Context, req *data.Command
 
 	const query = `INSERT INTO commands (bundle_name, bundle_version, command_name,
 		command_executable, command_parameters, adapter, user_id,
-		user_email, channel_id, gort_user_name, timestamp)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		user_email, channel_id, gort_user_name, timestamp, pipeline,
+		parameter_list)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING request_id;`
 
 	stmt, err := conn.PrepareContext(ctx, query)
@@ -67,7 +70,9 @@ func (da PostgresDataAccess) RequestBegin(ctx context.Context, req *data.Command
 		req.UserEmail,
 		req.ChannelID,
 		req.UserName,
-		req.Timestamp).Scan(&req.RequestID)
+		req.Timestamp,
+		req.Pipeline(),
+		encodeStringSlice(req.Parameters)).Scan(&req.RequestID)
 	if err != nil {
 		return gerr.Wrap(errs.ErrDataAccess, err)
 	}
@@ -101,8 +106,9 @@ func (da PostgresDataAccess) RequestUpdate(ctx context.Context, req data.Command
 	const query = `UPDATE commands
 		SET bundle_name=$1, bundle_version=$2, command_name=$3,
 			command_executable=$4, command_parameters=$5, adapter=$6, user_id=$7,
-			user_email=$8, channel_id=$9, gort_user_name=$10
-		WHERE request_id=$11;`
+			user_email=$8, channel_id=$9, gort_user_name=$10, pipeline=$11,
+			parameter_list=$12
+		WHERE request_id=$13;`
 
 	_, err = conn.ExecContext(ctx, query,
 		req.Bundle.Name,
@@ -115,6 +121,8 @@ func (da PostgresDataAccess) RequestUpdate(ctx context.Context, req data.Command
 		req.UserEmail,
 		req.ChannelID,
 		req.UserName,
+		req.Pipeline(),
+		encodeStringSlice(req.Parameters),
 		req.RequestID)
 	if err != nil {
 		err = gerr.Wrap(errs.ErrDataAccess, err)
@@ -142,8 +150,9 @@ func (da PostgresDataAccess) RequestClose(ctx context.Context, envelope data.Com
 		SET bundle_name=$1, bundle_version=$2, command_name=$3,
 			command_executable=$4, command_parameters=$5, adapter=$6, user_id=$7,
 			user_email=$8, channel_id=$9, gort_user_name=$10, timestamp=$11,
-			duration=$12, result_status=$13, result_error=$14
-		WHERE request_id=$15;`
+			duration=$12, result_status=$13, result_error=$14, pipeline=$15,
+			parameter_list=$16
+		WHERE request_id=$17;`
 
 	errMsg := ""
 	if envelope.Data.Error != nil {
@@ -165,6 +174,8 @@ func (da PostgresDataAccess) RequestClose(ctx context.Context, envelope data.Com
 		envelope.Data.Duration.Milliseconds(),
 		envelope.Data.ExitCode,
 		errMsg,
+		envelope.Request.Pipeline(),
+		encodeStringSlice(envelope.Request.Parameters),
 		envelope.Request.RequestID)
 	if err != nil {
 		err = gerr.Wrap(errs.ErrDataAccess, err)
@@ -173,6 +184,193 @@ func (da PostgresDataAccess) RequestClose(ctx context.Context, envelope data.Com
 	return err
 }
 
+// RequestGet returns the audit log record for the request with the given ID.
+func (da PostgresDataAccess) RequestGet(ctx context.Context, id int64) (rest.AuditRecord, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RequestGet")
+	defer sp.End()
+
+	query := auditSelect + ` WHERE request_id=$1`
+
+	records, err := da.doAuditQuery(ctx, query, id)
+	if err != nil {
+		return rest.AuditRecord{}, err
+	}
+	if len(records) == 0 {
+		return rest.AuditRecord{}, errs.ErrNoSuchRequest
+	}
+
+	return records[0], nil
+}
+
+// RequestList returns the audit log records that match the filter, newest
+// first.
+func (da PostgresDataAccess) RequestList(ctx context.Context, filter rest.AuditFilter) ([]rest.AuditRecord, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RequestList")
+	defer sp.End()
+
+	var where []string
+	var args []interface{}
+
+	add := func(clause string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.UserName != "" {
+		add("gort_user_name=$%d", filter.UserName)
+	}
+	if filter.BundleName != "" {
+		add("bundle_name=$%d", filter.BundleName)
+	}
+	if filter.CommandName != "" {
+		add("command_name=$%d", filter.CommandName)
+	}
+	if filter.Adapter != "" {
+		add("adapter=$%d", filter.Adapter)
+	}
+	if filter.ChannelID != "" {
+		add("channel_id=$%d", filter.ChannelID)
+	}
+	if filter.ExitCode != nil {
+		add("result_status=$%d", *filter.ExitCode)
+	}
+	if !filter.Since.IsZero() {
+		add("timestamp>=$%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("timestamp<$%d", filter.Until)
+	}
+
+	query := auditSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY request_id DESC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", filter.Offset)
+	}
+
+	return da.doAuditQuery(ctx, query, args...)
+}
+
+// RequestOutputGet returns the output stored for the request with the given
+// ID.
+func (da PostgresDataAccess) RequestOutputGet(ctx context.Context, id int64) (string, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RequestOutputGet")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	const query = `SELECT output FROM commands WHERE request_id=$1`
+
+	var output sql.NullString
+	err = conn.QueryRowContext(ctx, query, id).Scan(&output)
+	switch {
+	case err == sql.ErrNoRows:
+		return "", errs.ErrNoSuchRequest
+	case err != nil:
+		return "", gerr.Wrap(errs.ErrDataAccess, err)
+	case !output.Valid:
+		return "", errs.ErrNoSuchOutput
+	}
+
+	return output.String, nil
+}
+
+// RequestOutputSet stores the output of the request with the given ID.
+func (da PostgresDataAccess) RequestOutputSet(ctx context.Context, id int64, output string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.RequestOutputSet")
+	defer sp.End()
+
+	if id == 0 {
+		return fmt.Errorf("command request ID unset")
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	const query = `UPDATE commands SET output=$1 WHERE request_id=$2;`
+
+	_, err = conn.ExecContext(ctx, query, output, id)
+	if err != nil {
+		err = gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return err
+}
+
+const auditSelect = `SELECT request_id, timestamp, duration, bundle_name,
+		bundle_version, command_name, command_parameters, adapter, user_id,
+		user_email, channel_id, gort_user_name, result_status, result_error,
+		pipeline, parameter_list
+	FROM commands`
+
+func (da PostgresDataAccess) doAuditQuery(ctx context.Context, query string, args ...interface{}) ([]rest.AuditRecord, error) {
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+	defer rows.Close()
+
+	records := []rest.AuditRecord{}
+
+	for rows.Next() {
+		var r rest.AuditRecord
+		var timestamp sql.NullTime
+		var duration, status sql.NullInt64
+		var errMsg sql.NullString
+		var parameters string
+
+		err = rows.Scan(&r.RequestID, &timestamp, &duration, &r.BundleName,
+			&r.BundleVersion, &r.CommandName, &r.Parameters, &r.Adapter, &r.UserID,
+			&r.UserEmail, &r.ChannelID, &r.UserName, &status, &errMsg,
+			&r.Pipeline, &parameters)
+		if err != nil {
+			return nil, gerr.Wrap(errs.ErrDataAccess, err)
+		}
+
+		r.Timestamp = timestamp.Time
+		r.Duration = time.Duration(duration.Int64) * time.Millisecond
+		r.Error = errMsg.String
+		if parameters != "" {
+			r.ParameterList = decodeStringSlice(parameters)
+		}
+		if status.Valid {
+			code := int16(status.Int64)
+			r.ExitCode = &code
+		}
+
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return records, nil
+}
+
 func (da PostgresDataAccess) createCommandsTable(ctx context.Context, conn *sql.Conn) error {
 	createCommandsQuery := `CREATE TABLE commands(
 		request_id          BIGSERIAL,
@@ -189,7 +387,10 @@ func (da PostgresDataAccess) createCommandsTable(ctx context.Context, conn *sql.
 		channel_id		    TEXT NOT NULL,
 		gort_user_name      TEXT NOT NULL,
 		result_status		INT,
-		result_error        TEXT
+		result_error        TEXT,
+		pipeline            TEXT NOT NULL DEFAULT '',
+		output              TEXT,
+		parameter_list      TEXT NOT NULL DEFAULT ''
 	);`
 
 	_, err := conn.ExecContext(ctx, createCommandsQuery)
@@ -199,3 +400,19 @@ func (da PostgresDataAccess) createCommandsTable(ctx context.Context, conn *sql.
 
 	return nil
 }
+
+// updateCommandsTable adds the pipeline, output, and parameter_list columns
+// to commands tables that were created before they were introduced.
+func (da PostgresDataAccess) updateCommandsTable(ctx context.Context, conn *sql.Conn) error {
+	const query = `ALTER TABLE commands
+		ADD COLUMN IF NOT EXISTS pipeline TEXT NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS output TEXT,
+		ADD COLUMN IF NOT EXISTS parameter_list TEXT NOT NULL DEFAULT '';`
+
+	_, err := conn.ExecContext(ctx, query)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+	gerr "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/telemetry"
+)
+
+const scheduleSelect = `SELECT schedule_id, cron, timezone, command, adapter,
+	channel_id, user_name, paused, created, last_run
+	FROM schedules`
+
+// ScheduleCreate stores a new schedule, and sets its ID and creation time.
+func (da PostgresDataAccess) ScheduleCreate(ctx context.Context, schedule *rest.Schedule) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.ScheduleCreate")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `INSERT INTO schedules (cron, timezone, command, adapter,
+			channel_id, user_name, paused)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING schedule_id, created;`
+
+	err = conn.QueryRowContext(ctx, query, schedule.Cron, schedule.Timezone,
+		schedule.Command, schedule.Adapter, schedule.ChannelID,
+		schedule.UserName, schedule.Paused).
+		Scan(&schedule.ID, &schedule.Created)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return nil
+}
+
+// ScheduleDelete deletes a schedule.
+func (da PostgresDataAccess) ScheduleDelete(ctx context.Context, id int64) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.ScheduleDelete")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(ctx, `DELETE FROM schedules WHERE schedule_id=$1;`, id)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	} else if n == 0 {
+		return errs.ErrNoSuchSchedule
+	}
+
+	return nil
+}
+
+// ScheduleGet returns a schedule by its ID.
+func (da PostgresDataAccess) ScheduleGet(ctx context.Context, id int64) (rest.Schedule, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.ScheduleGet")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return rest.Schedule{}, err
+	}
+	defer conn.Close()
+
+	list, err := da.doScheduleQuery(ctx, conn, scheduleSelect+` WHERE schedule_id=$1`, id)
+	if err != nil {
+		return rest.Schedule{}, err
+	}
+	if len(list) == 0 {
+		return rest.Schedule{}, errs.ErrNoSuchSchedule
+	}
+
+	return list[0], nil
+}
+
+// ScheduleList returns all schedules, ordered by ID.
+func (da PostgresDataAccess) ScheduleList(ctx context.Context) ([]rest.Schedule, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.ScheduleList")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return da.doScheduleQuery(ctx, conn, scheduleSelect+` ORDER BY schedule_id`)
+}
+
+// ScheduleUpdate replaces an existing schedule. Its ID and creation time
+// can't be changed.
+func (da PostgresDataAccess) ScheduleUpdate(ctx context.Context, schedule rest.Schedule) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.ScheduleUpdate")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var lastRun sql.NullTime
+	if !schedule.LastRun.IsZero() {
+		lastRun = sql.NullTime{Time: schedule.LastRun, Valid: true}
+	}
+
+	query := `UPDATE schedules
+		SET cron=$1, timezone=$2, command=$3, adapter=$4, channel_id=$5,
+			user_name=$6, paused=$7, last_run=$8
+		WHERE schedule_id=$9;`
+
+	res, err := conn.ExecContext(ctx, query, schedule.Cron, schedule.Timezone,
+		schedule.Command, schedule.Adapter, schedule.ChannelID,
+		schedule.UserName, schedule.Paused, lastRun, schedule.ID)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	} else if n == 0 {
+		return errs.ErrNoSuchSchedule
+	}
+
+	return nil
+}
+
+func (da PostgresDataAccess) doScheduleQuery(ctx context.Context, conn *sql.Conn, query string, args ...interface{}) ([]rest.Schedule, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+	defer rows.Close()
+
+	list := []rest.Schedule{}
+
+	for rows.Next() {
+		var s rest.Schedule
+		var lastRun sql.NullTime
+
+		err = rows.Scan(&s.ID, &s.Cron, &s.Timezone, &s.Command, &s.Adapter,
+			&s.ChannelID, &s.UserName, &s.Paused, &s.Created, &lastRun)
+		if err != nil {
+			return nil, gerr.Wrap(errs.ErrDataAccess, err)
+		}
+
+		if lastRun.Valid {
+			s.LastRun = lastRun.Time
+		}
+
+		list = append(list, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+	gerr "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/telemetry"
+)
+
+const webhookSelect = `SELECT bundle_name, name, command, parameters,
+	user_name, secret, token, adapter, channel_id
+	FROM webhooks`
+
+// execer is implemented by both *sql.Conn and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// WebhookCreate creates a new webhook.
+func (da PostgresDataAccess) WebhookCreate(ctx context.Context, webhook data.Webhook) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.WebhookCreate")
+	defer sp.End()
+
+	if webhook.Bundle == "" {
+		return errs.ErrEmptyBundleName
+	}
+	if webhook.Name == "" {
+		return errs.ErrEmptyWebhookName
+	}
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return doWebhookInsert(ctx, conn, webhook, "")
+}
+
+// WebhookDelete deletes a webhook.
+func (da PostgresDataAccess) WebhookDelete(ctx context.Context, bundlename, name string) error {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.WebhookDelete")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `DELETE FROM webhooks
+		WHERE bundle_name=$1 AND bundle_version='' AND name=$2;`
+	res, err := conn.ExecContext(ctx, query, bundlename, name)
+	if err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	} else if n == 0 {
+		return errs.ErrNoSuchWebhook
+	}
+
+	return nil
+}
+
+// WebhookGet returns a webhook.
+func (da PostgresDataAccess) WebhookGet(ctx context.Context, bundlename, name string) (data.Webhook, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.WebhookGet")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return data.Webhook{}, err
+	}
+	defer conn.Close()
+
+	query := webhookSelect + ` WHERE bundle_name=$1 AND bundle_version='' AND name=$2`
+	rows, err := conn.QueryContext(ctx, query, bundlename, name)
+	if err != nil {
+		return data.Webhook{}, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	list, err := scanWebhooks(rows)
+	if err != nil {
+		return data.Webhook{}, err
+	}
+	if len(list) == 0 {
+		return data.Webhook{}, errs.ErrNoSuchWebhook
+	}
+
+	return list[0], nil
+}
+
+// WebhookList returns all webhooks, ordered by bundle and name.
+func (da PostgresDataAccess) WebhookList(ctx context.Context) ([]data.Webhook, error) {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "postgres.WebhookList")
+	defer sp.End()
+
+	conn, err := da.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	query := webhookSelect + ` WHERE bundle_version='' ORDER BY bundle_name, name`
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return scanWebhooks(rows)
+}
+
+// doWebhookInsert inserts a webhook. Webhooks that were declared by a bundle
+// are stored with the bundle's version; all others have an empty version.
+func doWebhookInsert(ctx context.Context, ex execer, webhook data.Webhook, bundleVersion string) error {
+	query := `INSERT INTO webhooks (bundle_name, bundle_version, name, command,
+			parameters, user_name, secret, token, adapter, channel_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`
+
+	_, err := ex.ExecContext(ctx, query, webhook.Bundle, bundleVersion,
+		webhook.Name, webhook.Command, encodeStringSlice(webhook.Parameters),
+		webhook.User, webhook.Secret, webhook.Token, webhook.Adapter,
+		webhook.ChannelID)
+
+	switch {
+	case err == nil:
+		return nil
+	case strings.Contains(err.Error(), "duplicate key"):
+		return errs.ErrWebhookExists
+	case strings.Contains(err.Error(), "violates"):
+		return gerr.Wrap(errs.ErrFieldRequired, err)
+	default:
+		return gerr.Wrap(errs.ErrDataAccess, err)
+	}
+}
+
+// scanWebhooks reads webhooks from rows selected with webhookSelect, and
+// closes rows.
+func scanWebhooks(rows *sql.Rows) ([]data.Webhook, error) {
+	defer rows.Close()
+
+	list := []data.Webhook{}
+
+	for rows.Next() {
+		var w data.Webhook
+		var parameters string
+
+		err := rows.Scan(&w.Bundle, &w.Name, &w.Command, &parameters,
+			&w.User, &w.Secret, &w.Token, &w.Adapter, &w.ChannelID)
+		if err != nil {
+			return nil, gerr.Wrap(errs.ErrDataAccess, err)
+		}
+
+		if parameters != "" {
+			w.Parameters = decodeStringSlice(parameters)
+		}
+
+		list = append(list, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, gerr.Wrap(errs.ErrDataAccess, err)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (da DataAccessTester) testAliasAccess(t *testing.T) {
+	t.Run("testAliasCreate", da.testAliasCreate)
+	t.Run("testAliasDelete", da.testAliasDelete)
+	t.Run("testAliasList", da.testAliasList)
+}
+
+func (da DataAccessTester) testAliasCreate(t *testing.T) {
+	err := da.AliasCreate(da.ctx, rest.Alias{Command: "echo foo"})
+	assert.ErrorIs(t, err, errs.ErrEmptyAliasName)
+
+	err = da.AliasCreate(da.ctx, rest.Alias{Name: "test-alias-create"})
+	assert.ErrorIs(t, err, errs.ErrFieldRequired)
+
+	site := rest.Alias{Name: "test-alias-create", Command: "echo site", Scope: rest.AliasScopeSite}
+	user := rest.Alias{Name: "test-alias-create", Command: "echo user", Scope: rest.AliasScopeUser, Owner: "test-user"}
+
+	err = da.AliasCreate(da.ctx, site)
+	require.NoError(t, err)
+	defer da.AliasDelete(da.ctx, "", site.Name)
+
+	err = da.AliasCreate(da.ctx, site)
+	assert.ErrorIs(t, err, errs.ErrAliasExists)
+
+	// A user alias may share a name with a site alias.
+	err = da.AliasCreate(da.ctx, user)
+	require.NoError(t, err)
+	defer da.AliasDelete(da.ctx, user.Owner, user.Name)
+
+	got, err := da.AliasGet(da.ctx, "", site.Name)
+	require.NoError(t, err)
+	assert.Equal(t, site, got)
+
+	got, err = da.AliasGet(da.ctx, user.Owner, user.Name)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	_, err = da.AliasGet(da.ctx, "another-user", user.Name)
+	assert.ErrorIs(t, err, errs.ErrNoSuchAlias)
+}
+
+func (da DataAccessTester) testAliasDelete(t *testing.T) {
+	a := rest.Alias{Name: "test-alias-delete", Command: "echo foo", Scope: rest.AliasScopeUser, Owner: "test-user"}
+
+	err := da.AliasDelete(da.ctx, a.Owner, a.Name)
+	assert.ErrorIs(t, err, errs.ErrNoSuchAlias)
+
+	err = da.AliasCreate(da.ctx, a)
+	require.NoError(t, err)
+
+	err = da.AliasDelete(da.ctx, "", a.Name)
+	assert.ErrorIs(t, err, errs.ErrNoSuchAlias)
+
+	err = da.AliasDelete(da.ctx, a.Owner, a.Name)
+	assert.NoError(t, err)
+
+	_, err = da.AliasGet(da.ctx, a.Owner, a.Name)
+	assert.ErrorIs(t, err, errs.ErrNoSuchAlias)
+}
+
+func (da DataAccessTester) testAliasList(t *testing.T) {
+	a := rest.Alias{Name: "test-alias-list-a", Command: "echo a", Scope: rest.AliasScopeSite}
+	b := rest.Alias{Name: "test-alias-list-b", Command: "echo b", Scope: rest.AliasScopeSite}
+	c := rest.Alias{Name: "test-alias-list-a", Command: "echo c", Scope: rest.AliasScopeUser, Owner: "test-user"}
+
+	require.NoError(t, da.AliasCreate(da.ctx, c))
+	defer da.AliasDelete(da.ctx, c.Owner, c.Name)
+	require.NoError(t, da.AliasCreate(da.ctx, b))
+	defer da.AliasDelete(da.ctx, b.Owner, b.Name)
+	require.NoError(t, da.AliasCreate(da.ctx, a))
+	defer da.AliasDelete(da.ctx, a.Owner, a.Name)
+
+	list, err := da.AliasList(da.ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []rest.Alias{a, b, c}, list)
+}
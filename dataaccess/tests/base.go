@@ -40,7 +40,11 @@ func (da DataAccessTester) RunAllTests(t *testing.T) {
 	t.Run("testGroupAccess", da.testGroupAccess)
 	t.Run("testTokenAccess", da.testTokenAccess)
 	t.Run("testBundleAccess", da.testBundleAccess)
+	t.Run("testRelayGroupAccess", da.testRelayGroupAccess)
 	t.Run("testRoleAccess", da.testRoleAccess)
 	t.Run("testRequestAccess", da.testRequestAccess)
+	t.Run("testScheduleAccess", da.testScheduleAccess)
+	t.Run("testWebhookAccess", da.testWebhookAccess)
+	t.Run("testAliasAccess", da.testAliasAccess)
 	t.Run("testDynamicConfigurationAccess", da.testDynamicConfigurationAccess)
 }
@@ -43,6 +43,7 @@ func (da DataAccessTester) testBundleAccess(t *testing.T) {
 	t.Run("testBundleList", da.testBundleList)
 	t.Run("testBundleVersionList", da.testBundleVersionList)
 	t.Run("testFindCommandEntry", da.testFindCommandEntry)
+	t.Run("testFindCommandEntryByTrigger", da.testFindCommandEntryByTrigger)
 }
 
 // Fail-fast: can the test bundle be loaded?
@@ -343,6 +344,7 @@ func (da DataAccessTester) testBundleGet(t *testing.T) {
 	assert.ElementsMatch(t, bundleCreate.Permissions, bundleGet.Permissions)
 	assert.Equal(t, bundleCreate.Commands, bundleGet.Commands)
 	assert.Equal(t, bundleCreate.Kubernetes, bundleGet.Kubernetes)
+	assert.Equal(t, bundleCreate.Limits, bundleGet.Limits)
 
 	// Compare everything for good measure
 	assert.Equal(t, bundleCreate, bundleGet)
@@ -455,6 +457,39 @@ func (da DataAccessTester) testFindCommandEntry(t *testing.T) {
 	assert.Equal(t, tc.Triggers, cmd.Triggers)
 }
 
+func (da DataAccessTester) testFindCommandEntryByTrigger(t *testing.T) {
+	bundle, err := getTestBundle()
+	require.NoError(t, err)
+	bundle.Name = "test-trigger"
+	bundle.Commands["echox"].Triggers = []data.Trigger{{Match: `^trigger-test (?P<word>\S+)$`}}
+
+	err = da.BundleCreate(da.ctx, bundle)
+	require.NoError(t, err)
+	defer da.BundleDelete(da.ctx, bundle.Name, bundle.Version)
+
+	// Not yet enabled. Should find nothing.
+	ce, err := da.FindCommandEntryByTrigger(da.ctx, []string{"trigger-test", "foo"})
+	require.NoError(t, err)
+	assert.Len(t, ce, 0)
+
+	err = da.BundleEnable(da.ctx, bundle.Name, bundle.Version)
+	require.NoError(t, err)
+
+	ce, err = da.FindCommandEntryByTrigger(da.ctx, []string{"trigger-test", "foo"})
+	require.NoError(t, err)
+	require.Len(t, ce, 1)
+	assert.Equal(t, bundle.Name, ce[0].Bundle.Name)
+	assert.Equal(t, "echox", ce[0].Command.Name)
+	assert.Equal(t, data.TriggerCaptures{{Name: "word", Value: "foo"}}, ce[0].Captures)
+
+	err = da.BundleDisable(da.ctx, bundle.Name, bundle.Version)
+	require.NoError(t, err)
+
+	ce, err = da.FindCommandEntryByTrigger(da.ctx, []string{"trigger-test", "foo"})
+	require.NoError(t, err)
+	assert.Len(t, ce, 0)
+}
+
 func getTestBundle() (data.Bundle, error) {
 	return bundles.LoadBundleFromFile("../../testing/test-bundle.yml")
 }
@@ -36,6 +36,15 @@ type TestDataAccess interface {
 	RequestUpdate(ctx context.Context, request data.CommandRequest) error
 	RequestError(ctx context.Context, request data.CommandRequest, err error) error
 	RequestClose(ctx context.Context, result data.CommandResponseEnvelope) error
+	RequestGet(ctx context.Context, id int64) (rest.AuditRecord, error)
+	RequestList(ctx context.Context, filter rest.AuditFilter) ([]rest.AuditRecord, error)
+	RequestOutputGet(ctx context.Context, id int64) (string, error)
+	RequestOutputSet(ctx context.Context, id int64, output string) error
+
+	AliasCreate(ctx context.Context, alias rest.Alias) error
+	AliasDelete(ctx context.Context, owner, name string) error
+	AliasGet(ctx context.Context, owner, name string) (rest.Alias, error)
+	AliasList(ctx context.Context) ([]rest.Alias, error)
 
 	BundleCreate(ctx context.Context, bundle data.Bundle) error
 	BundleDelete(ctx context.Context, name string, version string) error
@@ -69,6 +78,17 @@ type TestDataAccess interface {
 	GroupUserDelete(ctx context.Context, groupname string, username string) error
 	GroupUserList(ctx context.Context, groupname string) ([]rest.User, error)
 
+	RelayGroupBundleAdd(ctx context.Context, groupname, bundlename string) error
+	RelayGroupBundleDelete(ctx context.Context, groupname, bundlename string) error
+	RelayGroupCreate(ctx context.Context, group rest.RelayGroup) error
+	RelayGroupDelete(ctx context.Context, groupname string) error
+	RelayGroupExists(ctx context.Context, groupname string) (bool, error)
+	RelayGroupGet(ctx context.Context, groupname string) (rest.RelayGroup, error)
+	RelayGroupList(ctx context.Context) ([]rest.RelayGroup, error)
+	RelayGroupListByBundle(ctx context.Context, bundlename string) ([]rest.RelayGroup, error)
+	RelayGroupRelayAdd(ctx context.Context, groupname, relayname string) error
+	RelayGroupRelayDelete(ctx context.Context, groupname, relayname string) error
+
 	RoleCreate(ctx context.Context, rolename string) error
 	RoleDelete(ctx context.Context, rolename string) error
 	RoleGet(ctx context.Context, rolename string) (rest.Role, error)
@@ -83,6 +103,12 @@ type TestDataAccess interface {
 	RolePermissionExists(ctx context.Context, rolename, bundlename, permission string) (bool, error)
 	RolePermissionList(ctx context.Context, rolename string) (rest.RolePermissionList, error)
 
+	ScheduleCreate(ctx context.Context, schedule *rest.Schedule) error
+	ScheduleDelete(ctx context.Context, id int64) error
+	ScheduleGet(ctx context.Context, id int64) (rest.Schedule, error)
+	ScheduleList(ctx context.Context) ([]rest.Schedule, error)
+	ScheduleUpdate(ctx context.Context, schedule rest.Schedule) error
+
 	TokenEvaluate(ctx context.Context, token string) bool
 	TokenGenerate(ctx context.Context, username string, duration time.Duration) (rest.Token, error)
 	TokenInvalidate(ctx context.Context, token string) error
@@ -103,4 +129,9 @@ type TestDataAccess interface {
 	UserPermissionList(ctx context.Context, username string) (rest.RolePermissionList, error)
 	UserRoleList(ctx context.Context, username string) ([]rest.Role, error)
 	UserUpdate(ctx context.Context, user rest.User) error
+
+	WebhookCreate(ctx context.Context, webhook data.Webhook) error
+	WebhookDelete(ctx context.Context, bundlename, name string) error
+	WebhookGet(ctx context.Context, bundlename, name string) (data.Webhook, error)
+	WebhookList(ctx context.Context) ([]data.Webhook, error)
 }
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (da DataAccessTester) testRelayGroupAccess(t *testing.T) {
+	t.Run("testRelayGroupCreate", da.testRelayGroupCreate)
+	t.Run("testRelayGroupDelete", da.testRelayGroupDelete)
+	t.Run("testRelayGroupExists", da.testRelayGroupExists)
+	t.Run("testRelayGroupList", da.testRelayGroupList)
+	t.Run("testRelayGroupRelayAdd", da.testRelayGroupRelayAdd)
+	t.Run("testRelayGroupRelayDelete", da.testRelayGroupRelayDelete)
+	t.Run("testRelayGroupBundleAdd", da.testRelayGroupBundleAdd)
+	t.Run("testRelayGroupBundleDelete", da.testRelayGroupBundleDelete)
+}
+
+func (da DataAccessTester) testRelayGroupCreate(t *testing.T) {
+	err := da.RelayGroupCreate(da.ctx, rest.RelayGroup{})
+	assert.ErrorIs(t, err, errs.ErrEmptyRelayGroupName)
+
+	err = da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: "test-relay-group-create"})
+	defer da.RelayGroupDelete(da.ctx, "test-relay-group-create")
+	assert.NoError(t, err)
+
+	err = da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: "test-relay-group-create"})
+	assert.ErrorIs(t, err, errs.ErrRelayGroupExists)
+}
+
+func (da DataAccessTester) testRelayGroupDelete(t *testing.T) {
+	const groupname = "test-relay-group-delete"
+
+	err := da.RelayGroupDelete(da.ctx, "")
+	assert.ErrorIs(t, err, errs.ErrEmptyRelayGroupName)
+
+	err = da.RelayGroupDelete(da.ctx, groupname)
+	assert.ErrorIs(t, err, errs.ErrNoSuchRelayGroup)
+
+	require.NoError(t, da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: groupname}))
+	require.NoError(t, da.RelayGroupRelayAdd(da.ctx, groupname, "relay-a"))
+
+	err = da.RelayGroupDelete(da.ctx, groupname)
+	assert.NoError(t, err)
+
+	exists, _ := da.RelayGroupExists(da.ctx, groupname)
+	assert.False(t, exists)
+}
+
+func (da DataAccessTester) testRelayGroupExists(t *testing.T) {
+	const groupname = "test-relay-group-exists"
+
+	exists, err := da.RelayGroupExists(da.ctx, groupname)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: groupname})
+	defer da.RelayGroupDelete(da.ctx, groupname)
+
+	exists, err = da.RelayGroupExists(da.ctx, groupname)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func (da DataAccessTester) testRelayGroupList(t *testing.T) {
+	da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: "test-relay-group-list-0"})
+	defer da.RelayGroupDelete(da.ctx, "test-relay-group-list-0")
+	da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: "test-relay-group-list-1"})
+	defer da.RelayGroupDelete(da.ctx, "test-relay-group-list-1")
+
+	groups, err := da.RelayGroupList(da.ctx)
+	require.NoError(t, err)
+
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+
+	assert.Contains(t, names, "test-relay-group-list-0")
+	assert.Contains(t, names, "test-relay-group-list-1")
+}
+
+func (da DataAccessTester) testRelayGroupRelayAdd(t *testing.T) {
+	const groupname = "test-relay-group-relay-add"
+
+	err := da.RelayGroupRelayAdd(da.ctx, groupname, "relay-a")
+	assert.ErrorIs(t, err, errs.ErrNoSuchRelayGroup)
+
+	da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: groupname})
+	defer da.RelayGroupDelete(da.ctx, groupname)
+
+	err = da.RelayGroupRelayAdd(da.ctx, groupname, "")
+	assert.ErrorIs(t, err, errs.ErrEmptyRelayName)
+
+	assert.NoError(t, da.RelayGroupRelayAdd(da.ctx, groupname, "relay-b"))
+	assert.NoError(t, da.RelayGroupRelayAdd(da.ctx, groupname, "relay-a"))
+
+	// Adding the same relay twice is a no-op.
+	assert.NoError(t, da.RelayGroupRelayAdd(da.ctx, groupname, "relay-a"))
+
+	group, err := da.RelayGroupGet(da.ctx, groupname)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"relay-a", "relay-b"}, group.Relays)
+}
+
+func (da DataAccessTester) testRelayGroupRelayDelete(t *testing.T) {
+	const groupname = "test-relay-group-relay-delete"
+
+	da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: groupname})
+	defer da.RelayGroupDelete(da.ctx, groupname)
+
+	da.RelayGroupRelayAdd(da.ctx, groupname, "relay-a")
+	da.RelayGroupRelayAdd(da.ctx, groupname, "relay-b")
+
+	err := da.RelayGroupRelayDelete(da.ctx, groupname, "relay-c")
+	assert.ErrorIs(t, err, errs.ErrNoSuchRelay)
+
+	err = da.RelayGroupRelayDelete(da.ctx, groupname, "relay-a")
+	assert.NoError(t, err)
+
+	group, err := da.RelayGroupGet(da.ctx, groupname)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"relay-b"}, group.Relays)
+}
+
+func (da DataAccessTester) testRelayGroupBundleAdd(t *testing.T) {
+	const groupname = "test-relay-group-bundle-add"
+
+	bundle, err := getTestBundle()
+	require.NoError(t, err)
+	bundle.Name = "test-relay-group-bundle-add"
+
+	da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: groupname})
+	defer da.RelayGroupDelete(da.ctx, groupname)
+
+	err = da.RelayGroupBundleAdd(da.ctx, groupname, bundle.Name)
+	assert.ErrorIs(t, err, errs.ErrNoSuchBundle)
+
+	require.NoError(t, da.BundleCreate(da.ctx, bundle))
+	defer da.BundleDelete(da.ctx, bundle.Name, bundle.Version)
+
+	err = da.RelayGroupBundleAdd(da.ctx, groupname, bundle.Name)
+	assert.NoError(t, err)
+
+	group, err := da.RelayGroupGet(da.ctx, groupname)
+	require.NoError(t, err)
+	assert.Equal(t, []string{bundle.Name}, group.Bundles)
+
+	groups, err := da.RelayGroupListByBundle(da.ctx, bundle.Name)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, groupname, groups[0].Name)
+
+	groups, err = da.RelayGroupListByBundle(da.ctx, "no-such-bundle")
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func (da DataAccessTester) testRelayGroupBundleDelete(t *testing.T) {
+	const groupname = "test-relay-group-bundle-delete"
+
+	bundle, err := getTestBundle()
+	require.NoError(t, err)
+	bundle.Name = "test-relay-group-bundle-delete"
+
+	da.RelayGroupCreate(da.ctx, rest.RelayGroup{Name: groupname})
+	defer da.RelayGroupDelete(da.ctx, groupname)
+
+	require.NoError(t, da.BundleCreate(da.ctx, bundle))
+	defer da.BundleDelete(da.ctx, bundle.Name, bundle.Version)
+
+	err = da.RelayGroupBundleDelete(da.ctx, groupname, bundle.Name)
+	assert.ErrorIs(t, err, errs.ErrNoSuchBundle)
+
+	da.RelayGroupBundleAdd(da.ctx, groupname, bundle.Name)
+
+	err = da.RelayGroupBundleDelete(da.ctx, groupname, bundle.Name)
+	assert.NoError(t, err)
+
+	groups, err := da.RelayGroupListByBundle(da.ctx, bundle.Name)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
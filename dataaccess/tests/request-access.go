@@ -22,13 +22,19 @@ import (
 	"time"
 
 	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func (da DataAccessTester) testRequestAccess(t *testing.T) {
 	t.Run("testRequestBegin", da.testRequestBegin)
 	t.Run("testRequestUpdate", da.testRequestUpdate)
 	t.Run("testRequestClose", da.testRequestClose)
+	t.Run("testRequestGet", da.testRequestGet)
+	t.Run("testRequestList", da.testRequestList)
+	t.Run("testRequestOutput", da.testRequestOutput)
 }
 
 func (da DataAccessTester) testRequestBegin(t *testing.T) {
@@ -116,3 +122,138 @@ func (da DataAccessTester) testRequestClose(t *testing.T) {
 	err = da.RequestClose(da.ctx, env)
 	assert.NoError(t, err)
 }
+
+func (da DataAccessTester) testRequestGet(t *testing.T) {
+	bundle, err := getTestBundle()
+	require.NoError(t, err)
+
+	req := data.CommandRequest{
+		CommandEntry: data.CommandEntry{
+			Bundle:  bundle,
+			Command: *bundle.Commands["echox"],
+		},
+		Adapter:    "testRequestGet",
+		ChannelID:  "testChannelID",
+		Parameters: []string{"foo", "bar"},
+		Timestamp:  time.Now().UTC().Truncate(time.Millisecond),
+		UserID:     "testUserID",
+		UserEmail:  "testUserEmail",
+		UserName:   "testUserName",
+	}
+
+	err = da.RequestBegin(da.ctx, &req)
+	require.NoError(t, err)
+
+	record, err := da.RequestGet(da.ctx, req.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, req.RequestID, record.RequestID)
+	assert.Equal(t, "test", record.BundleName)
+	assert.Equal(t, "echox", record.CommandName)
+	assert.Equal(t, "foo bar", record.Parameters)
+	assert.Equal(t, []string{"foo", "bar"}, record.ParameterList)
+	assert.Equal(t, "testRequestGet", record.Adapter)
+	assert.Equal(t, "testUserName", record.UserName)
+	assert.True(t, req.Timestamp.Equal(record.Timestamp))
+	assert.Nil(t, record.ExitCode)
+
+	env := data.NewCommandResponseEnvelope(req, data.WithError("", fmt.Errorf("fake error"), 3))
+	env.Data.Duration = 1500 * time.Millisecond
+	err = da.RequestClose(da.ctx, env)
+	require.NoError(t, err)
+
+	record, err = da.RequestGet(da.ctx, req.RequestID)
+	require.NoError(t, err)
+	require.NotNil(t, record.ExitCode)
+	assert.Equal(t, int16(3), *record.ExitCode)
+	assert.Equal(t, "fake error", record.Error)
+	assert.Equal(t, 1500*time.Millisecond, record.Duration)
+
+	_, err = da.RequestGet(da.ctx, req.RequestID+1000000)
+	assert.ErrorIs(t, err, errs.ErrNoSuchRequest)
+}
+
+func (da DataAccessTester) testRequestList(t *testing.T) {
+	bundle, err := getTestBundle()
+	require.NoError(t, err)
+
+	start := time.Now().UTC().Truncate(time.Millisecond)
+
+	var ids []int64
+
+	for i, user := range []string{"alice", "bob", "alice", "alice"} {
+		req := data.CommandRequest{
+			CommandEntry: data.CommandEntry{
+				Bundle:  bundle,
+				Command: *bundle.Commands["echox"],
+			},
+			Adapter:   "testRequestList",
+			ChannelID: "testChannelID",
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			UserName:  user,
+		}
+
+		err = da.RequestBegin(da.ctx, &req)
+		require.NoError(t, err)
+		ids = append(ids, req.RequestID)
+
+		env := data.NewCommandResponseEnvelope(req, data.WithExitCode(int16(i%2)))
+		err = da.RequestClose(da.ctx, env)
+		require.NoError(t, err)
+	}
+
+	list := func(f rest.AuditFilter) []int64 {
+		f.Adapter = "testRequestList"
+		records, err := da.RequestList(da.ctx, f)
+		require.NoError(t, err)
+
+		var got []int64
+		for _, r := range records {
+			got = append(got, r.RequestID)
+		}
+		return got
+	}
+
+	assert.Equal(t, []int64{ids[3], ids[2], ids[1], ids[0]}, list(rest.AuditFilter{}))
+	assert.Equal(t, []int64{ids[3], ids[2], ids[0]}, list(rest.AuditFilter{UserName: "alice"}))
+	assert.Equal(t, []int64{ids[2], ids[0]}, list(rest.AuditFilter{ExitCode: new(int16)}))
+	assert.Equal(t, []int64{ids[2], ids[1]}, list(rest.AuditFilter{
+		Since: start.Add(time.Minute),
+		Until: start.Add(3 * time.Minute),
+	}))
+	assert.Equal(t, []int64{ids[2], ids[1]}, list(rest.AuditFilter{Limit: 2, Offset: 1}))
+	assert.Empty(t, list(rest.AuditFilter{BundleName: "nosuchbundle"}))
+}
+
+func (da DataAccessTester) testRequestOutput(t *testing.T) {
+	bundle, err := getTestBundle()
+	require.NoError(t, err)
+
+	req := data.CommandRequest{
+		CommandEntry: data.CommandEntry{
+			Bundle:  bundle,
+			Command: *bundle.Commands["echox"],
+		},
+		Adapter:   "testRequestOutput",
+		Timestamp: time.Now(),
+		UserName:  "testUserName",
+	}
+
+	err = da.RequestBegin(da.ctx, &req)
+	require.NoError(t, err)
+
+	_, err = da.RequestOutputGet(da.ctx, req.RequestID)
+	assert.ErrorIs(t, err, errs.ErrNoSuchOutput)
+
+	err = da.RequestOutputSet(da.ctx, req.RequestID, "foo\nbar")
+	require.NoError(t, err)
+
+	output, err := da.RequestOutputGet(da.ctx, req.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar", output)
+
+	_, err = da.RequestOutputGet(da.ctx, req.RequestID+1000000)
+	assert.ErrorIs(t, err, errs.ErrNoSuchRequest)
+
+	err = da.RequestOutputSet(da.ctx, 0, "foo")
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess/errs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (da DataAccessTester) testScheduleAccess(t *testing.T) {
+	t.Run("testScheduleCreate", da.testScheduleCreate)
+	t.Run("testScheduleDelete", da.testScheduleDelete)
+	t.Run("testScheduleList", da.testScheduleList)
+	t.Run("testScheduleUpdate", da.testScheduleUpdate)
+}
+
+func newTestSchedule(command string) rest.Schedule {
+	return rest.Schedule{
+		Cron:      "*/5 * * * *",
+		Timezone:  "UTC",
+		Command:   command,
+		Adapter:   "testAdapter",
+		ChannelID: "testChannelID",
+		UserName:  "admin",
+	}
+}
+
+func (da DataAccessTester) testScheduleCreate(t *testing.T) {
+	s := newTestSchedule("echo create")
+
+	err := da.ScheduleCreate(da.ctx, &s)
+	require.NoError(t, err)
+	defer da.ScheduleDelete(da.ctx, s.ID)
+
+	assert.NotZero(t, s.ID)
+	assert.False(t, s.Created.IsZero())
+
+	got, err := da.ScheduleGet(da.ctx, s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, s.ID, got.ID)
+	assert.Equal(t, s.Cron, got.Cron)
+	assert.Equal(t, s.Timezone, got.Timezone)
+	assert.Equal(t, s.Command, got.Command)
+	assert.Equal(t, s.Adapter, got.Adapter)
+	assert.Equal(t, s.ChannelID, got.ChannelID)
+	assert.Equal(t, s.UserName, got.UserName)
+	assert.False(t, got.Paused)
+	assert.True(t, got.LastRun.IsZero())
+
+	_, err = da.ScheduleGet(da.ctx, s.ID+1000)
+	assert.ErrorIs(t, err, errs.ErrNoSuchSchedule)
+}
+
+func (da DataAccessTester) testScheduleDelete(t *testing.T) {
+	s := newTestSchedule("echo delete")
+
+	err := da.ScheduleCreate(da.ctx, &s)
+	require.NoError(t, err)
+
+	err = da.ScheduleDelete(da.ctx, s.ID)
+	assert.NoError(t, err)
+
+	err = da.ScheduleDelete(da.ctx, s.ID)
+	assert.ErrorIs(t, err, errs.ErrNoSuchSchedule)
+
+	_, err = da.ScheduleGet(da.ctx, s.ID)
+	assert.ErrorIs(t, err, errs.ErrNoSuchSchedule)
+}
+
+func (da DataAccessTester) testScheduleList(t *testing.T) {
+	a := newTestSchedule("echo list a")
+	b := newTestSchedule("echo list b")
+
+	require.NoError(t, da.ScheduleCreate(da.ctx, &a))
+	defer da.ScheduleDelete(da.ctx, a.ID)
+	require.NoError(t, da.ScheduleCreate(da.ctx, &b))
+	defer da.ScheduleDelete(da.ctx, b.ID)
+
+	list, err := da.ScheduleList(da.ctx)
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, s := range list {
+		ids = append(ids, s.ID)
+	}
+
+	assert.Contains(t, ids, a.ID)
+	assert.Contains(t, ids, b.ID)
+}
+
+func (da DataAccessTester) testScheduleUpdate(t *testing.T) {
+	s := newTestSchedule("echo update")
+
+	err := da.ScheduleCreate(da.ctx, &s)
+	require.NoError(t, err)
+	defer da.ScheduleDelete(da.ctx, s.ID)
+
+	lastRun := time.Now().UTC().Truncate(time.Second)
+	s.Paused = true
+	s.LastRun = lastRun
+
+	err = da.ScheduleUpdate(da.ctx, s)
+	require.NoError(t, err)
+
+	got, err := da.ScheduleGet(da.ctx, s.ID)
+	require.NoError(t, err)
+	assert.True(t, got.Paused)
+	assert.True(t, lastRun.Equal(got.LastRun))
+
+	s.ID += 1000
+	err = da.ScheduleUpdate(da.ctx, s)
+	assert.ErrorIs(t, err, errs.ErrNoSuchSchedule)
+}
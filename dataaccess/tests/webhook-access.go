@@ -0,0 +1,100 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess/errs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (da DataAccessTester) testWebhookAccess(t *testing.T) {
+	t.Run("testWebhookCreate", da.testWebhookCreate)
+	t.Run("testWebhookDelete", da.testWebhookDelete)
+	t.Run("testWebhookList", da.testWebhookList)
+}
+
+func newTestWebhook(name string) data.Webhook {
+	return data.Webhook{
+		Bundle:     "test",
+		Name:       name,
+		Command:    "echox",
+		Parameters: []string{"hello", "{{ .name }}"},
+		User:       "admin",
+		Token:      "test-token",
+	}
+}
+
+func (da DataAccessTester) testWebhookCreate(t *testing.T) {
+	err := da.WebhookCreate(da.ctx, data.Webhook{Name: "test-webhook-create"})
+	assert.ErrorIs(t, err, errs.ErrEmptyBundleName)
+
+	err = da.WebhookCreate(da.ctx, data.Webhook{Bundle: "test"})
+	assert.ErrorIs(t, err, errs.ErrEmptyWebhookName)
+
+	w := newTestWebhook("test-webhook-create")
+
+	err = da.WebhookCreate(da.ctx, w)
+	require.NoError(t, err)
+	defer da.WebhookDelete(da.ctx, w.Bundle, w.Name)
+
+	err = da.WebhookCreate(da.ctx, w)
+	assert.ErrorIs(t, err, errs.ErrWebhookExists)
+
+	got, err := da.WebhookGet(da.ctx, w.Bundle, w.Name)
+	require.NoError(t, err)
+	assert.Equal(t, w, got)
+
+	_, err = da.WebhookGet(da.ctx, w.Bundle, "test-webhook-missing")
+	assert.ErrorIs(t, err, errs.ErrNoSuchWebhook)
+}
+
+func (da DataAccessTester) testWebhookDelete(t *testing.T) {
+	w := newTestWebhook("test-webhook-delete")
+
+	err := da.WebhookDelete(da.ctx, w.Bundle, w.Name)
+	assert.ErrorIs(t, err, errs.ErrNoSuchWebhook)
+
+	err = da.WebhookCreate(da.ctx, w)
+	require.NoError(t, err)
+
+	err = da.WebhookDelete(da.ctx, w.Bundle, w.Name)
+	assert.NoError(t, err)
+
+	_, err = da.WebhookGet(da.ctx, w.Bundle, w.Name)
+	assert.ErrorIs(t, err, errs.ErrNoSuchWebhook)
+}
+
+func (da DataAccessTester) testWebhookList(t *testing.T) {
+	a := newTestWebhook("test-webhook-list-a")
+	b := newTestWebhook("test-webhook-list-b")
+
+	require.NoError(t, da.WebhookCreate(da.ctx, b))
+	defer da.WebhookDelete(da.ctx, b.Bundle, b.Name)
+	require.NoError(t, da.WebhookCreate(da.ctx, a))
+	defer da.WebhookDelete(da.ctx, a.Bundle, a.Name)
+
+	list, err := da.WebhookList(da.ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, a, list[0])
+	assert.Equal(t, b, list[1])
+}
@@ -19,21 +19,34 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/getgort/gort/adapter"
 	"github.com/getgort/gort/adapter/discord"
+	httpadapter "github.com/getgort/gort/adapter/http"
+	"github.com/getgort/gort/adapter/irc"
+	"github.com/getgort/gort/adapter/matrix"
+	"github.com/getgort/gort/adapter/mattermost"
 	"github.com/getgort/gort/adapter/slack"
+	"github.com/getgort/gort/adapter/terminal"
+	"github.com/getgort/gort/bundles"
+	"github.com/getgort/gort/cli"
+	"github.com/getgort/gort/client"
 	"github.com/getgort/gort/config"
 	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
 	"github.com/getgort/gort/relay"
 	"github.com/getgort/gort/service"
 	"github.com/getgort/gort/telemetry"
 	"github.com/getgort/gort/version"
+	"github.com/getgort/gort/worker"
 )
 
 func initializeConfig(configFile string) error {
@@ -59,8 +72,12 @@ func setLoggerVerbosity(verbose int) {
 func installAdapters() error {
 	slackAdapters := config.GetSlackProviders()
 	discordAdapters := config.GetDiscordProviders()
+	mattermostAdapters := config.GetMattermostProviders()
+	ircAdapters := config.GetIRCProviders()
+	matrixAdapters := config.GetMatrixProviders()
+	httpAdapters := config.GetHTTPProviders()
 
-	if len(slackAdapters)+len(discordAdapters) == 0 {
+	if len(slackAdapters)+len(discordAdapters)+len(mattermostAdapters)+len(ircAdapters)+len(matrixAdapters)+len(httpAdapters) == 0 {
 		return fmt.Errorf("no adapters configured")
 	}
 
@@ -76,6 +93,41 @@ func installAdapters() error {
 		}
 		adapter.AddAdapter(ad)
 	}
+	for _, mp := range mattermostAdapters {
+		log.WithField("adapter.name", mp.Name).Info("Installing Mattermost adapter")
+		ad, err := mattermost.NewAdapter(mp)
+		if err != nil {
+			return err
+		}
+		adapter.AddAdapter(ad)
+	}
+	for _, ip := range ircAdapters {
+		log.WithField("adapter.name", ip.Name).Info("Installing IRC adapter")
+		ad, err := irc.NewAdapter(ip)
+		if err != nil {
+			return err
+		}
+		adapter.AddAdapter(ad)
+	}
+	for _, mp := range matrixAdapters {
+		log.WithField("adapter.name", mp.Name).Info("Installing Matrix adapter")
+		ad, err := matrix.NewAdapter(mp)
+		if err != nil {
+			return err
+		}
+		adapter.AddAdapter(ad)
+	}
+	for _, hp := range httpAdapters {
+		log.WithField("adapter.name", hp.Name).Info("Installing HTTP adapter")
+		ad, err := httpadapter.NewAdapter(hp)
+		if err != nil {
+			return err
+		}
+		adapter.AddAdapter(ad)
+
+		// Inbound messages are received through the REST API.
+		service.RegisterAdapterHandler(hp.Name, ad.(http.Handler))
+	}
 
 	return nil
 }
@@ -110,8 +162,8 @@ func startGort(ctx context.Context, configFile string, verboseCount int) error {
 	// Returns channels to get user command requests and adapter errors out.
 	requestsFrom, responsesTo, adapterErrorsFrom := adapter.StartListening(ctx)
 
-	// Starts the relay (currently just a local goroutine).
-	// Returns channels to send user command request in and get command
+	// Starts the relay, which executes commands locally or forwards them to
+	// a connected remote relay. Returns channels to send user command request in and get command
 	// responses out.
 	requestsTo, responsesFrom := relay.StartListening()
 
@@ -122,6 +174,11 @@ func startGort(ctx context.Context, configFile string, verboseCount int) error {
 		case request := <-requestsFrom:
 			requestsTo <- request
 
+		// A command request was started through the REST API (by a webhook,
+		// for example) and its output is to be sent to a chat channel.
+		case request := <-service.CommandRequests():
+			requestsTo <- request
+
 		// A user command response is received from the relay.
 		// Send it back to the adapter manager.
 		case response := <-responsesFrom:
@@ -135,6 +192,194 @@ func startGort(ctx context.Context, configFile string, verboseCount int) error {
 	}
 }
 
+func startRelay(ctx context.Context, configFile string, verboseCount int) error {
+	setLoggerVerbosity(verboseCount)
+
+	go catchSignals()
+
+	// Load the Gort configuration.
+	err := initializeConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	err = telemetry.CreateAndRegisterExporters()
+	if err != nil {
+		return err
+	}
+
+	if !worker.Defined() {
+		return fmt.Errorf("relay requires a docker or kubernetes config section")
+	}
+
+	rc := config.GetRelayConfigs()
+	if rc.Name == "" {
+		if rc.Name, err = os.Hostname(); err != nil {
+			return err
+		}
+	}
+
+	gc, err := client.Connect(cli.FlagGortProfile)
+	if err != nil {
+		return err
+	}
+
+	log.WithField("version", version.Version).
+		WithField("relay.name", rc.Name).
+		Infof("Starting Gort relay")
+
+	agent := relay.Agent{
+		Name: rc.Name,
+		Tags: rc.Tags,
+		Dial: func(ctx context.Context) (relay.Conn, error) {
+			return gc.RelayConnect(ctx)
+		},
+	}
+
+	return agent.Run(ctx)
+}
+
+func startShell(ctx context.Context, configFile string, verboseCount int, provider data.TerminalProvider, bundleFiles []string, useDatabase bool) error {
+	// Logs share the terminal with the shell, so only warnings and errors
+	// are shown unless more are asked for.
+	if verboseCount == 0 {
+		log.SetLevel(log.WarnLevel)
+	} else {
+		setLoggerVerbosity(verboseCount - 1)
+	}
+
+	go catchSignals()
+
+	// Load the Gort configuration.
+	err := initializeConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	// The shell bootstraps Gort and installs bundles, so it won't touch a
+	// real database unless it's been explicitly told to.
+	if !config.Undefined(config.GetDatabaseConfigs()) && !useDatabase {
+		return fmt.Errorf("config file %s has a database section: use --use-database to run the shell against it", configFile)
+	}
+
+	err = prepareShellData(ctx, provider, bundleFiles)
+	if err != nil {
+		return err
+	}
+
+	// Only the terminal adapter is installed: none of the configured chat
+	// providers are connected to.
+	ta, err := terminal.NewAdapter(provider, os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	adapter.AddAdapter(ta)
+
+	requestsFrom, responsesTo, adapterErrorsFrom := adapter.StartListening(ctx)
+	requestsTo, responsesFrom := relay.StartListening()
+
+	for {
+		select {
+		case request := <-requestsFrom:
+			requestsTo <- request
+
+		case response := <-responsesFrom:
+			responsesTo <- response
+
+		case aerr := <-adapterErrorsFrom:
+			log.WithError(aerr).Error("Error reported by adapter")
+
+		// The input has ended.
+		case <-ta.Done():
+			return nil
+		}
+	}
+}
+
+// prepareShellData readies the data access layer for "gort shell". If Gort
+// hasn't been bootstrapped it's bootstrapped, with the shell's user mapped
+// to the administrator. Then each bundle file is installed and enabled, and
+// its permissions granted to the administrator role.
+func prepareShellData(ctx context.Context, provider data.TerminalProvider, bundleFiles []string) error {
+	const adminRole = "admin"
+
+	da, err := waitForDataAccess(ctx, 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	bootstrapped, err := da.UserExists(ctx, "admin")
+	if err != nil {
+		return err
+	}
+
+	if !bootstrapped {
+		user := rest.User{
+			Email:    provider.Email,
+			Mappings: map[string]string{provider.Name: provider.User},
+		}
+
+		if _, err := service.DoBootstrap(ctx, user); err != nil {
+			return fmt.Errorf("failed to bootstrap: %w", err)
+		}
+
+		log.WithField("user", provider.User).Info("Bootstrapped Gort for the shell user")
+	}
+
+	for _, f := range bundleFiles {
+		b, err := bundles.LoadBundleFromFile(f)
+		if err != nil {
+			return fmt.Errorf("cannot load bundle %s: %w", f, err)
+		}
+
+		if err := da.BundleCreate(ctx, b); err != nil {
+			return fmt.Errorf("cannot install bundle %s: %w", f, err)
+		}
+
+		if err := da.BundleEnable(ctx, b.Name, b.Version); err != nil {
+			return fmt.Errorf("cannot enable bundle %s: %w", f, err)
+		}
+
+		for _, p := range b.Permissions {
+			if err := da.RolePermissionAdd(ctx, adminRole, b.Name, p); err != nil {
+				return fmt.Errorf("cannot grant permission %s:%s: %w", b.Name, p, err)
+			}
+		}
+
+		log.WithField("bundle.name", b.Name).
+			WithField("bundle.version", b.Version).
+			Info("Installed bundle")
+	}
+
+	return nil
+}
+
+// waitForDataAccess waits for the data access layer, which is initialized
+// in the background once the configuration is loaded, to become usable.
+func waitForDataAccess(ctx context.Context, timeout time.Duration) (dataaccess.DataAccess, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		da, err := dataaccess.Get()
+		if err == nil {
+			_, err = da.UserExists(ctx, "admin")
+		}
+		if err == nil {
+			return da, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(250 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
 func catchSignals() {
 	c := make(chan os.Signal, 1)
 
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/telemetry"
+	"github.com/getgort/gort/worker"
+)
+
+// maxReconnectDelay is the longest an Agent will wait between attempts to
+// reconnect to the controller.
+const maxReconnectDelay = time.Minute
+
+// Agent is the relay side of a remote relay connection. It connects to the
+// controller, registers itself, and executes any command requests that it
+// receives using a local worker.
+type Agent struct {
+	// Name uniquely identifies this relay to the controller.
+	Name string
+
+	// Tags are arbitrary labels reported to the controller.
+	Tags []string
+
+	// Dial opens a new connection to the controller.
+	Dial func(ctx context.Context) (Conn, error)
+
+	mutex   sync.Mutex
+	running map[int64]context.CancelFunc
+}
+
+// Run connects to the controller and services requests until ctx is
+// cancelled. If the connection is lost it reconnects with an exponential
+// backoff. It always returns a non-nil error.
+func (a *Agent) Run(ctx context.Context) error {
+	delay := time.Second
+
+	for {
+		connected, err := a.serve(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if connected {
+			delay = time.Second
+		}
+
+		log.WithError(err).
+			WithField("relay.name", a.Name).
+			WithField("retry", delay).
+			Warn("Lost connection to controller")
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// serve handles a single connection to the controller. It returns true if
+// the connection was successfully established before it failed.
+func (a *Agent) serve(ctx context.Context) (bool, error) {
+	c, err := a.Dial(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	conn := &syncConn{Conn: c}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	registration := Registration{Name: a.Name, Tags: a.Tags}
+	if err := conn.WriteJSON(Message{Type: MessageRegister, Register: &registration}); err != nil {
+		return false, err
+	}
+
+	log.WithField("relay.name", a.Name).Info("Connected to controller")
+
+	go a.heartbeat(ctx, conn)
+
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return true, err
+		}
+
+		switch msg.Type {
+		case MessageRequest:
+			if msg.Request != nil {
+				go a.execute(ctx, conn, *msg.Request)
+			}
+		case MessageCancel:
+			if msg.Request != nil {
+				a.cancel(msg.Request.ID)
+			}
+		default:
+			log.WithField("message.type", msg.Type).Warn("Unexpected message from controller")
+		}
+	}
+}
+
+// execute runs a single remote request to completion and sends the result
+// back to the controller.
+func (a *Agent) execute(ctx context.Context, conn Conn, rr RemoteRequest) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	a.mutex.Lock()
+	if a.running == nil {
+		a.running = map[int64]context.CancelFunc{}
+	}
+	a.running[rr.ID] = cancel
+	a.mutex.Unlock()
+
+	defer func() {
+		a.mutex.Lock()
+		delete(a.running, rr.ID)
+		a.mutex.Unlock()
+		cancel()
+	}()
+
+	request := rr.CommandRequest()
+	request.Context = ctx
+
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "relay.Agent.execute")
+	defer sp.End()
+
+	log.WithField("request.id", request.RequestID).
+		WithField("command", request.String()).
+		Info("Received command request")
+
+	var envelope data.CommandResponseEnvelope
+
+	if w, err := worker.New(request, rr.Token); err != nil {
+		envelope = data.NewCommandResponseEnvelope(
+			request,
+			data.WithError("Failed to spawn worker", err, ExitSystemErr),
+		)
+	} else {
+		var progress progressFunc
+		if rr.Stream {
+			progress = func(lines []string) {
+				partial := RemoteResponse{ID: rr.ID, Lines: lines}
+				if err := conn.WriteJSON(Message{Type: MessageProgress, Response: &partial}); err != nil {
+					log.WithError(err).
+						WithField("request.id", request.RequestID).
+						Warn("Failed to send partial command output")
+				}
+			}
+		}
+
+		w.Initialize(rr.Configs)
+		envelope = runWorker(ctx, w, request, progress)
+	}
+
+	response := NewRemoteResponse(rr.ID, envelope)
+	if err := conn.WriteJSON(Message{Type: MessageResponse, Response: &response}); err != nil {
+		log.WithError(err).
+			WithField("request.id", request.RequestID).
+			Error("Failed to send command response")
+	}
+}
+
+// cancel stops the execution of a remote request, if it's still running.
+func (a *Agent) cancel(id int64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if cancel, ok := a.running[id]; ok {
+		cancel()
+	}
+}
+
+// heartbeat periodically notifies the controller that this relay is alive.
+func (a *Agent) heartbeat(ctx context.Context, conn Conn) {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := conn.WriteJSON(Message{Type: MessageHeartbeat}); err != nil {
+				conn.Close()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/getgort/gort/data"
+)
+
+var (
+	// ErrNoSuchRequest is returned by Cancel when no in-flight request has
+	// the given ID.
+	ErrNoSuchRequest = errors.New("no such request")
+
+	// ErrCancelled is the error reported for a request that was cancelled
+	// before it completed.
+	ErrCancelled = errors.New("command cancelled")
+)
+
+// inFlight tracks the requests that are currently being executed, so that
+// they can be cancelled.
+var inFlight = &requestRegistry{requests: map[int64]*runningRequest{}}
+
+type requestRegistry struct {
+	mutex    sync.Mutex
+	requests map[int64]*runningRequest
+}
+
+type runningRequest struct {
+	request data.CommandRequest
+	cancel  context.CancelFunc
+}
+
+// add registers a request as in flight, returning a context that's done
+// when the request is cancelled (or ctx is done).
+func (r *requestRegistry) add(ctx context.Context, request data.CommandRequest) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.mutex.Lock()
+	r.requests[request.RequestID] = &runningRequest{request: request, cancel: cancel}
+	r.mutex.Unlock()
+
+	return ctx
+}
+
+// remove unregisters a request once it's complete.
+func (r *requestRegistry) remove(id int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if rr, ok := r.requests[id]; ok {
+		rr.cancel()
+		delete(r.requests, id)
+	}
+}
+
+// InFlight returns the request with the given ID if it's currently being
+// executed.
+func InFlight(id int64) (data.CommandRequest, bool) {
+	inFlight.mutex.Lock()
+	defer inFlight.mutex.Unlock()
+
+	rr, ok := inFlight.requests[id]
+	if !ok {
+		return data.CommandRequest{}, false
+	}
+
+	return rr.request, true
+}
+
+// Cancel stops the in-flight request with the given ID. Its worker is
+// stopped, and its result is reported with the ExitCancelled exit code. If
+// no such request is in flight, ErrNoSuchRequest is returned.
+func Cancel(id int64) error {
+	inFlight.mutex.Lock()
+	defer inFlight.mutex.Unlock()
+
+	rr, ok := inFlight.requests[id]
+	if !ok {
+		return ErrNoSuchRequest
+	}
+
+	rr.cancel()
+
+	return nil
+}
+
+// interruptedEnvelope builds the response for a command that was stopped
+// before it completed because ctx is done, either because the request was
+// cancelled or because it timed out.
+func interruptedEnvelope(request data.CommandRequest, err error) data.CommandResponseEnvelope {
+	if errors.Is(err, context.Canceled) {
+		return data.NewCommandResponseEnvelope(
+			request,
+			data.WithError("Command cancelled", ErrCancelled, ExitCancelled),
+		)
+	}
+
+	return data.NewCommandResponseEnvelope(
+		request,
+		data.WithError(err.Error(), err, ExitTimeout),
+	)
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+)
+
+func TestCancel(t *testing.T) {
+	request := data.CommandRequest{RequestID: 1234, UserName: "someone"}
+
+	ctx := inFlight.add(context.Background(), request)
+
+	r, ok := InFlight(1234)
+	require.True(t, ok)
+	assert.Equal(t, "someone", r.UserName)
+
+	require.NoError(t, Cancel(1234))
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	envelope := interruptedEnvelope(request, ctx.Err())
+	assert.Equal(t, int16(ExitCancelled), envelope.Data.ExitCode)
+	assert.Equal(t, "Command cancelled", envelope.Response.Title)
+
+	inFlight.remove(1234)
+
+	_, ok = InFlight(1234)
+	assert.False(t, ok)
+	assert.ErrorIs(t, Cancel(1234), ErrNoSuchRequest)
+}
+
+func TestInterruptedEnvelopeTimeout(t *testing.T) {
+	envelope := interruptedEnvelope(data.CommandRequest{}, context.DeadlineExceeded)
+	assert.Equal(t, int16(ExitTimeout), envelope.Data.ExitCode)
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/getgort/gort/data"
+)
+
+var (
+	// ErrConcurrencyLimit is returned when a request waited longer than its
+	// command timeout for a bundle or command concurrency limit to free up.
+	ErrConcurrencyLimit = errors.New("concurrency limit reached")
+)
+
+// slots holds one semaphore for each bundle and command that has a
+// concurrency limit.
+var slots = &semaphores{m: map[string]chan struct{}{}}
+
+type semaphores struct {
+	mutex sync.Mutex
+	m     map[string]chan struct{}
+}
+
+// get returns the semaphore for key, with capacity max. If the limit has
+// changed, such as when a new bundle version is installed, a new semaphore is
+// created; invocations holding the old one release it as usual.
+func (s *semaphores) get(key string, max int) chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sem, ok := s.m[key]
+	if !ok || cap(sem) != max {
+		sem = make(chan struct{}, max)
+		s.m[key] = sem
+	}
+
+	return sem
+}
+
+// acquireSlots waits until the request's command and bundle are both below
+// their concurrency limits (if any) and claims a slot in each. Requests that
+// can't be started within the command's timeout are rejected with
+// ErrConcurrencyLimit; if ctx is cancelled first, its error is returned. The
+// returned function must be called to release the slots once the command
+// has completed.
+func acquireSlots(ctx context.Context, request data.CommandRequest) (func(), error) {
+	type limit struct {
+		key string
+		max int
+	}
+
+	// The command slot is always claimed before the bundle slot, so waiting
+	// requests never hold a bundle slot that another command could use.
+	limits := []limit{
+		{"command:" + request.Bundle.Name + ":" + request.Command.Name, request.Command.MaxConcurrent},
+		{"bundle:" + request.Bundle.Name, request.Bundle.MaxConcurrent},
+	}
+
+	if timeout := commandTimeout(request); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var held []chan struct{}
+	release := func() {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+
+	for _, l := range limits {
+		if l.max <= 0 {
+			continue
+		}
+
+		sem := slots.get(l.key, l.max)
+
+		select {
+		case sem <- struct{}{}:
+			held = append(held, sem)
+		case <-ctx.Done():
+			release()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, ErrConcurrencyLimit
+			}
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
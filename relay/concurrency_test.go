@@ -0,0 +1,74 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+)
+
+func TestAcquireSlots(t *testing.T) {
+	ctx := context.Background()
+
+	request := data.CommandRequest{
+		CommandEntry: data.CommandEntry{
+			Bundle: data.Bundle{Name: "limited", Limits: data.Limits{MaxConcurrent: 2}},
+			Command: data.BundleCommand{
+				Name:   "slow",
+				Limits: data.Limits{Timeout: 20 * time.Millisecond, MaxConcurrent: 1},
+			},
+		},
+	}
+
+	other := request
+	other.Command = data.BundleCommand{
+		Name:   "other",
+		Limits: data.Limits{Timeout: 20 * time.Millisecond},
+	}
+
+	release, err := acquireSlots(ctx, request)
+	require.NoError(t, err)
+
+	// The command is at its limit, but the bundle isn't.
+	_, err = acquireSlots(ctx, request)
+	assert.ErrorIs(t, err, ErrConcurrencyLimit)
+
+	releaseOther, err := acquireSlots(ctx, other)
+	require.NoError(t, err)
+
+	// Now the bundle is at its limit too.
+	_, err = acquireSlots(ctx, other)
+	assert.ErrorIs(t, err, ErrConcurrencyLimit)
+
+	// A queued request starts as soon as a slot is released.
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+
+	release, err = acquireSlots(ctx, request)
+	require.NoError(t, err)
+
+	release()
+	releaseOther()
+}
@@ -63,4 +63,9 @@ const (
 
 	// ExitCommandNotFound represents that the command can't be found.
 	ExitCommandNotFound = 127
+
+	// ExitCancelled represents that the command was cancelled before it
+	// completed. By convention, this is the status of a process terminated
+	// by an interrupt (128 + SIGINT).
+	ExitCancelled = 130
 )
@@ -0,0 +1,338 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/auth"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess"
+	"github.com/getgort/gort/telemetry"
+)
+
+var (
+	// ErrNoSuchHelpTopic is returned by gort:help when the requested bundle
+	// or command doesn't exist, or the user isn't permitted to see it.
+	ErrNoSuchHelpTopic = errors.New("no such bundle or command")
+)
+
+// HelpBundle describes an enabled bundle, and the commands in it that a user
+// is permitted to execute. A []HelpBundle is the payload of the gort:help
+// command, for use by the "help" template.
+type HelpBundle struct {
+	Name            string
+	Version         string
+	Description     string
+	LongDescription string
+	Commands        []HelpCommand
+}
+
+// HelpCommand describes a single command in a HelpBundle.
+type HelpCommand struct {
+	Bundle          string
+	Name            string
+	Description     string
+	LongDescription string
+	Triggers        []string
+	Rules           []string
+	Options         []string
+	Usage           string
+}
+
+// runHelp implements gort:help, which describes the commands that the
+// requesting user is permitted to execute. With no parameters it lists every
+// enabled bundle and its commands. Given a bundle name it describes that
+// bundle's commands in more detail, and given a "bundle:command" (or just a
+// command name) it describes that command in full.
+func runHelp(ctx context.Context, request data.CommandRequest) data.CommandResponseEnvelope {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "relay.runHelp")
+	defer sp.End()
+
+	if len(request.Parameters) > 1 {
+		err := errors.New("usage: gort:help [bundle[:command]]")
+		return data.NewCommandResponseEnvelope(request, data.WithError("Invalid Command Usage", err, ExitGeneral))
+	}
+
+	bundles, err := helpBundles(ctx, request.UserName)
+	if err != nil {
+		return data.NewCommandResponseEnvelope(request, data.WithError("Failed to load bundles", err, ExitIoErr))
+	}
+
+	var title string
+	var lines []string
+
+	if len(request.Parameters) == 0 {
+		title = "Available Commands"
+		lines = helpListLines(bundles)
+	} else {
+		topic := request.Parameters[0]
+
+		if b, ok := findHelpBundle(bundles, topic); ok {
+			title = "Bundle " + b.Name
+			bundles = []HelpBundle{b}
+			lines = helpBundleLines(b)
+		} else if bundles = findHelpCommands(bundles, topic); len(bundles) > 0 {
+			title = "Command " + topic
+			lines = helpCommandLines(bundles)
+		} else {
+			err := fmt.Errorf("%w: %s", ErrNoSuchHelpTopic, topic)
+			return data.NewCommandResponseEnvelope(request, data.WithError("No Such Command", err, ExitGeneral))
+		}
+	}
+
+	envelope := data.NewCommandResponseEnvelope(request, data.WithResponseLines(lines))
+	envelope.Response.Title = title
+	envelope.Payload = bundles
+	envelope.Data.Template = data.Help
+
+	return envelope
+}
+
+// helpBundles returns all enabled bundles, sorted by name, each containing
+// only those commands that the named user may execute. Bundles with no such
+// commands are omitted.
+func helpBundles(ctx context.Context, username string) ([]HelpBundle, error) {
+	da, err := dataaccess.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	perms, err := da.UserPermissionList(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	bundles, err := da.BundleList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var hbs []HelpBundle
+
+	for _, b := range bundles {
+		if !b.Enabled {
+			continue
+		}
+
+		hb := HelpBundle{
+			Name:            b.Name,
+			Version:         b.Version,
+			Description:     b.Description,
+			LongDescription: b.LongDescription,
+		}
+
+		for name, c := range b.Commands {
+			cmd := *c
+			cmd.Name = name
+			entry := data.CommandEntry{Bundle: b, Command: cmd}
+
+			// Commands whose rules can't be evaluated are treated like
+			// commands the user isn't permitted to execute.
+			if ok, err := auth.MayExecute(perms.Strings(), entry); err != nil || !ok {
+				continue
+			}
+
+			hb.Commands = append(hb.Commands, newHelpCommand(entry))
+		}
+
+		if len(hb.Commands) == 0 {
+			continue
+		}
+
+		sort.Slice(hb.Commands, func(i, j int) bool {
+			return hb.Commands[i].Name < hb.Commands[j].Name
+		})
+
+		hbs = append(hbs, hb)
+	}
+
+	sort.Slice(hbs, func(i, j int) bool { return hbs[i].Name < hbs[j].Name })
+
+	return hbs, nil
+}
+
+func newHelpCommand(entry data.CommandEntry) HelpCommand {
+	hc := HelpCommand{
+		Bundle:          entry.Bundle.Name,
+		Name:            entry.Command.Name,
+		Description:     entry.Command.Description,
+		LongDescription: entry.Command.LongDescription,
+		Rules:           entry.Command.Rules,
+	}
+
+	for _, t := range entry.Command.Triggers {
+		hc.Triggers = append(hc.Triggers, t.Match)
+	}
+
+	for name, o := range entry.Command.Options {
+		flags := []string{helpOptionFlag(name)}
+		for _, a := range o.Aliases {
+			flags = append(flags, helpOptionFlag(a))
+		}
+		hc.Options = append(hc.Options, strings.Join(flags, ", "))
+	}
+	sort.Strings(hc.Options)
+
+	if entry.Command.HasSchema() {
+		hc.Usage = entry.Usage()
+	}
+
+	return hc
+}
+
+// findHelpBundle returns the bundle with the given name, if there is one.
+func findHelpBundle(bundles []HelpBundle, name string) (HelpBundle, bool) {
+	for _, b := range bundles {
+		if b.Name == name {
+			return b, true
+		}
+	}
+
+	return HelpBundle{}, false
+}
+
+// findHelpCommands returns the bundles that contain a command matching topic,
+// which is either "bundle:command" or an unqualified command name, each
+// containing only the matching command.
+func findHelpCommands(bundles []HelpBundle, topic string) []HelpBundle {
+	bundleName, commandName := "", topic
+	if i := strings.Index(topic, ":"); i >= 0 {
+		bundleName, commandName = topic[:i], topic[i+1:]
+	}
+
+	var found []HelpBundle
+
+	for _, b := range bundles {
+		if bundleName != "" && bundleName != b.Name {
+			continue
+		}
+
+		for _, c := range b.Commands {
+			if c.Name == commandName {
+				b.Commands = []HelpCommand{c}
+				found = append(found, b)
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+func helpListLines(bundles []HelpBundle) []string {
+	var lines []string
+
+	for _, b := range bundles {
+		lines = append(lines, helpBundleHeading(b))
+
+		for _, c := range b.Commands {
+			lines = append(lines, fmt.Sprintf("  %s:%s - %s", c.Bundle, c.Name, c.Description))
+		}
+
+		lines = append(lines, "")
+	}
+
+	return append(lines, `Use "gort:help <bundle>" or "gort:help <bundle>:<command>" for more information.`)
+}
+
+func helpBundleLines(b HelpBundle) []string {
+	lines := []string{helpBundleHeading(b)}
+
+	if b.LongDescription != "" {
+		lines = append(lines, "", b.LongDescription)
+	}
+
+	lines = append(lines, "", "Commands:")
+
+	for _, c := range b.Commands {
+		lines = append(lines, fmt.Sprintf("  %s:%s - %s", c.Bundle, c.Name, c.Description))
+
+		if len(c.Triggers) > 0 {
+			lines = append(lines, "    Triggers: "+strings.Join(c.Triggers, "; "))
+		}
+		if len(c.Rules) > 0 {
+			lines = append(lines, "    Rules: "+strings.Join(c.Rules, "; "))
+		}
+		if len(c.Options) > 0 {
+			lines = append(lines, "    Options: "+strings.Join(c.Options, "; "))
+		}
+	}
+
+	return lines
+}
+
+func helpCommandLines(bundles []HelpBundle) []string {
+	var lines []string
+
+	for i, b := range bundles {
+		c := b.Commands[0]
+
+		if i > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, fmt.Sprintf("%s:%s - %s", c.Bundle, c.Name, c.Description))
+
+		if c.Usage != "" {
+			lines = append(lines, "", c.Usage)
+		} else if c.LongDescription != "" {
+			lines = append(lines, "", c.LongDescription)
+		}
+
+		if len(c.Triggers) > 0 {
+			lines = append(lines, "", "Triggers:")
+			for _, t := range c.Triggers {
+				lines = append(lines, "  "+t)
+			}
+		}
+
+		if len(c.Rules) > 0 {
+			lines = append(lines, "", "Rules:")
+			for _, r := range c.Rules {
+				lines = append(lines, "  "+r)
+			}
+		}
+	}
+
+	return lines
+}
+
+func helpBundleHeading(b HelpBundle) string {
+	heading := b.Name
+	if b.Version != "" {
+		heading += " " + b.Version
+	}
+	if b.Description != "" {
+		heading += ": " + b.Description
+	}
+	return heading
+}
+
+func helpOptionFlag(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/config"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	"github.com/getgort/gort/dataaccess/memory"
+)
+
+func TestRunHelp(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, config.Initialize("../testing/config/no-database.yml"))
+	memory.Reset()
+
+	da, err := dataaccess.Get()
+	require.NoError(t, err)
+	require.NoError(t, da.Initialize(ctx))
+	require.NoError(t, da.UserCreate(ctx, rest.User{Username: "user"}))
+
+	require.NoError(t, da.BundleCreate(ctx, data.Bundle{
+		GortBundleVersion: 1,
+		Name:              "test",
+		Version:           "1.0.0",
+		Description:       "a test bundle",
+		Enabled:           true,
+		Commands: map[string]*data.BundleCommand{
+			"cmd": {
+				Description: "a test command",
+				Triggers:    []data.Trigger{{Match: "com+and"}},
+				Rules:       []string{"allow"},
+				Options:     map[string]*data.BundleCommandOption{"verbose": {Aliases: []string{"v"}}},
+			},
+			"secret": {
+				Description: "a secret command",
+				Rules:       []string{"must have test:secret"},
+			},
+		},
+	}))
+
+	require.NoError(t, da.BundleCreate(ctx, data.Bundle{
+		GortBundleVersion: 1,
+		Name:              "disabled",
+		Version:           "1.0.0",
+		Description:       "a disabled bundle",
+		Commands: map[string]*data.BundleCommand{
+			"cmd": {Rules: []string{"allow"}},
+		},
+	}))
+
+	help := func(params ...string) data.CommandResponseEnvelope {
+		return runHelp(ctx, data.CommandRequest{
+			CommandEntry: data.CommandEntry{
+				Bundle:  data.Bundle{Name: "gort"},
+				Command: data.BundleCommand{Name: "help"},
+			},
+			Parameters: params,
+			UserName:   "user",
+		})
+	}
+
+	// Disabled bundles, and commands the user may not execute, are omitted.
+	e := help()
+	require.Equal(t, int16(ExitOK), e.Data.ExitCode)
+	assert.Equal(t, data.Help, e.Data.Template)
+	assert.Contains(t, e.Response.Out, "test:cmd - a test command")
+	assert.NotContains(t, e.Response.Out, "secret")
+	assert.NotContains(t, e.Response.Out, "disabled")
+
+	bundles := e.Payload.([]HelpBundle)
+	require.Len(t, bundles, 1)
+	require.Len(t, bundles[0].Commands, 1)
+	assert.Equal(t, "cmd", bundles[0].Commands[0].Name)
+	assert.Equal(t, []string{"com+and"}, bundles[0].Commands[0].Triggers)
+	assert.Equal(t, []string{"--verbose, -v"}, bundles[0].Commands[0].Options)
+
+	e = help("test")
+	require.Equal(t, int16(ExitOK), e.Data.ExitCode)
+	assert.Contains(t, e.Response.Out, "Triggers: com+and")
+	assert.Contains(t, e.Response.Out, "Rules: allow")
+	assert.Contains(t, e.Response.Out, "Options: --verbose, -v")
+
+	for _, topic := range []string{"test:cmd", "cmd"} {
+		e = help(topic)
+		require.Equal(t, int16(ExitOK), e.Data.ExitCode, topic)
+		assert.Contains(t, e.Response.Out, "Usage: test:cmd [options]", topic)
+	}
+
+	for _, topic := range []string{"test:secret", "disabled", "test:missing"} {
+		e = help(topic)
+		assert.Equal(t, int16(ExitGeneral), e.Data.ExitCode, topic)
+		assert.ErrorIs(t, e.Data.Error, ErrNoSuchHelpTopic, topic)
+	}
+
+	e = help("test", "cmd")
+	assert.Equal(t, int16(ExitGeneral), e.Data.ExitCode)
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+
+	"github.com/getgort/gort/data"
+)
+
+// nativeCommand is a command that's implemented by the controller itself,
+// rather than by an executable in a worker container.
+type nativeCommand func(ctx context.Context, request data.CommandRequest) data.CommandResponseEnvelope
+
+// nativeCommands contains the native commands, keyed by "bundle:command".
+var nativeCommands = map[string]nativeCommand{
+	"gort:help": runHelp,
+}
+
+// getNativeCommand returns the native implementation of the requested
+// command, or false if it doesn't have one.
+func getNativeCommand(request data.CommandRequest) (nativeCommand, bool) {
+	c, ok := nativeCommands[request.Bundle.Name+":"+request.Command.Name]
+	return c, ok
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package relay
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/getgort/gort/config"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess"
+)
+
+const (
+	// defaultOutputMaxSize is the most output, in bytes, that's stored for a
+	// request if the config doesn't set a limit.
+	defaultOutputMaxSize = 64 * 1024
+
+	redactedText  = "[REDACTED]"
+	truncatedText = "\n[output truncated]"
+)
+
+// captureOutput stores the output of a completed request if the "audit"
+// config section enables it. Secret dynamic configuration values, and any
+// text matching the configured redaction patterns, are removed first. If the
+// secrets can't be loaded nothing is stored.
+func captureOutput(ctx context.Context, da dataaccess.DataAccess, envelope data.CommandResponseEnvelope) {
+	c := config.GetAuditConfigs().Output
+	if !c.Enabled || envelope.Request.RequestID == 0 {
+		return
+	}
+
+	le := log.WithField("request.id", envelope.Request.RequestID)
+
+	var secrets []string
+	for stage := &envelope.Request; stage != nil; stage = stage.Next {
+		dc, err := loadDynamicConfigurations(ctx, *stage)
+		if err != nil {
+			le.WithError(err).Error("Failed to load secrets; command output not stored")
+			return
+		}
+
+		for _, d := range dc {
+			if d.Secret && d.Value != "" {
+				secrets = append(secrets, d.Value)
+			}
+		}
+	}
+
+	var patterns []*regexp.Regexp
+	for _, p := range c.Redact {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			le.WithError(err).WithField("pattern", p).Error("Invalid redaction pattern; command output not stored")
+			return
+		}
+		patterns = append(patterns, re)
+	}
+
+	maxSize := c.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultOutputMaxSize
+	}
+
+	output := truncateOutput(redactOutput(envelope.Response.Out, secrets, patterns), maxSize)
+
+	if err := da.RequestOutputSet(ctx, envelope.Request.RequestID, output); err != nil {
+		le.WithError(err).Error("Failed to store command output")
+	}
+}
+
+// redactOutput replaces each occurrence of a secret, and each match of a
+// pattern, with redactedText. Longer secrets are replaced first so that a
+// secret that contains another is removed entirely.
+func redactOutput(out string, secrets []string, patterns []*regexp.Regexp) string {
+	sorted := append([]string(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	for _, s := range sorted {
+		out = strings.ReplaceAll(out, s, redactedText)
+	}
+
+	for _, re := range patterns {
+		out = re.ReplaceAllLiteralString(out, redactedText)
+	}
+
+	return out
+}
+
+// truncateOutput cuts out down to at most maxSize bytes, without splitting a
+// UTF-8 character, and notes that it's done so.
+func truncateOutput(out string, maxSize int) string {
+	if len(out) <= maxSize {
+		return out
+	}
+
+	cut := maxSize
+	for cut > 0 && !utf8.RuneStart(out[cut]) {
+		cut--
+	}
+
+	return out[:cut] + truncatedText
+}
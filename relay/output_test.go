@@ -0,0 +1,40 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package relay
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactOutput(t *testing.T) {
+	patterns := []*regexp.Regexp{regexp.MustCompile(`token=\S+`)}
+
+	out := redactOutput("user hunter2 pass hunter22 token=abc123 done",
+		[]string{"hunter2", "hunter22"}, patterns)
+
+	assert.Equal(t, "user [REDACTED] pass [REDACTED] [REDACTED] done", out)
+}
+
+func TestTruncateOutput(t *testing.T) {
+	assert.Equal(t, "short", truncateOutput("short", 10))
+	assert.Equal(t, "abcd"+truncatedText, truncateOutput("abcdefgh", 4))
+
+	// "é" is two bytes, and mustn't be split.
+	assert.Equal(t, "ab"+truncatedText, truncateOutput("abé", 3))
+}
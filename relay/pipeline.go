@@ -0,0 +1,153 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/telemetry"
+)
+
+// MaxPipelineInputSize is the largest output, in bytes, that may be passed
+// from one stage of a pipeline to the next. Workers receive it in the
+// GORT_PIPELINE_INPUT environment variable, and Linux won't start a process
+// with a single variable larger than 128 KiB.
+const MaxPipelineInputSize = 64 * 1024
+
+// ErrPipelineInputTooLarge is returned when the output of a pipeline stage
+// is larger than MaxPipelineInputSize.
+var ErrPipelineInputTooLarge = errors.New("pipeline input too large")
+
+// runPipeline executes each stage of a request's pipeline in turn, passing
+// the output of each stage to the next as its Input. It stops at the first
+// stage that fails, and returns the envelope of the last stage executed. A
+// request that isn't a pipeline is simply a pipeline with one stage.
+//
+// If the final stage is a streaming command, its output is sent to partials
+// as partial envelopes while it runs. Earlier stages never stream, since
+// their output is consumed by the next stage rather than shown to the user.
+func runPipeline(ctx context.Context, request data.CommandRequest, partials chan<- data.CommandResponseEnvelope) data.CommandResponseEnvelope {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "relay.runPipeline")
+	defer sp.End()
+
+	var envelope data.CommandResponseEnvelope
+
+	for stage, i := &request, 0; stage != nil; stage, i = stage.Next, i+1 {
+		current := *stage
+		current.Next = nil
+
+		if i > 0 {
+			input, err := pipelineInput(envelope)
+			if err != nil {
+				return data.NewCommandResponseEnvelope(
+					current,
+					data.WithError("Pipeline Input Too Large", err, ExitIoErr),
+				)
+			}
+
+			current.Input = input
+			current.Parameters = bindVariables(current.Parameters, envelope.Payload)
+		}
+
+		dc, err := loadDynamicConfigurations(ctx, current)
+		if err != nil {
+			return data.NewCommandResponseEnvelope(
+				current,
+				data.WithError("Failed to load dynamic configurations", err, ExitSystemErr),
+			)
+		}
+
+		var progress progressFunc
+		if stage.Next == nil && current.Command.Streaming && partials != nil {
+			progress = func(lines []string) {
+				partials <- data.NewCommandResponseEnvelope(
+					current,
+					data.WithResponseLines(lines),
+					data.WithPartial(),
+				)
+			}
+		}
+
+		envelope = dispatch(ctx, current, dc, progress)
+
+		if envelope.Data.ExitCode != ExitOK {
+			break
+		}
+	}
+
+	return envelope
+}
+
+// pipelineInput returns the output of a pipeline stage, to be passed as
+// input to the next, or an error if it's too large.
+func pipelineInput(envelope data.CommandResponseEnvelope) (string, error) {
+	out := envelope.Response.Out
+	if len(out) > MaxPipelineInputSize {
+		return "", fmt.Errorf("%w: the output of %s:%s is %d bytes, but at most %d may be passed to the next command",
+			ErrPipelineInputTooLarge, envelope.Request.Bundle.Name, envelope.Request.Command.Name, len(out), MaxPipelineInputSize)
+	}
+
+	return out, nil
+}
+
+// bindVariables replaces any parameter of the form "$name" with the value
+// of the "name" field of payload, which is the structured output of the
+// previous pipeline stage. Parameters are left untouched if the payload
+// isn't a JSON object or has no such field. Field values that aren't
+// strings are bound as JSON.
+func bindVariables(params data.CommandParameters, payload interface{}) data.CommandParameters {
+	fields, ok := payload.(map[string]interface{})
+	if !ok {
+		return params
+	}
+
+	bound := make(data.CommandParameters, len(params))
+
+	for i, p := range params {
+		bound[i] = p
+
+		if len(p) < 2 || !strings.HasPrefix(p, "$") {
+			continue
+		}
+
+		v, ok := fields[p[1:]]
+		if !ok {
+			continue
+		}
+
+		switch v := v.(type) {
+		case string:
+			bound[i] = v
+		default:
+			if b, err := json.Marshal(v); err == nil {
+				bound[i] = string(b)
+			} else {
+				bound[i] = fmt.Sprint(v)
+			}
+		}
+	}
+
+	return bound
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getgort/gort/data"
+)
+
+func TestBindVariables(t *testing.T) {
+	payload := map[string]interface{}{
+		"value": "foo",
+		"count": float64(5),
+		"list":  []interface{}{"a", "b"},
+	}
+
+	params := data.CommandParameters{"$value", "$count", "$list", "$missing", "$", "value"}
+	bound := bindVariables(params, payload)
+
+	assert.Equal(t, data.CommandParameters{"foo", "5", `["a","b"]`, "$missing", "$", "value"}, bound)
+
+	// The original parameters are untouched.
+	assert.Equal(t, "$value", params[0])
+
+	// Unstructured output binds nothing.
+	assert.Equal(t, params, bindVariables(params, "just some text"))
+	assert.Equal(t, params, bindVariables(params, []interface{}{"a"}))
+}
+
+func TestPipelineInput(t *testing.T) {
+	request := data.CommandRequest{
+		CommandEntry: data.CommandEntry{
+			Bundle:  data.Bundle{Name: "test"},
+			Command: data.BundleCommand{Name: "dump"},
+		},
+	}
+
+	envelope := data.NewCommandResponseEnvelope(request, data.WithResponseLines([]string{"foo", "bar"}))
+	input, err := pipelineInput(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo\nbar", input)
+
+	envelope = data.NewCommandResponseEnvelope(request, data.WithResponseLines([]string{strings.Repeat("x", MaxPipelineInputSize+1)}))
+	_, err = pipelineInput(envelope)
+	assert.ErrorIs(t, err, ErrPipelineInputTooLarge)
+	assert.Contains(t, err.Error(), "test:dump")
+}
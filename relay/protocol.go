@@ -0,0 +1,158 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+)
+
+// HeartbeatInterval is how often a remote relay tells the controller that
+// it's still alive. A relay that's silent for three intervals is considered
+// to be gone.
+const HeartbeatInterval = 10 * time.Second
+
+// MessageType describes the contents of a Message.
+type MessageType string
+
+const (
+	// MessageRegister is sent once by a relay immediately after it connects.
+	MessageRegister MessageType = "register"
+
+	// MessageHeartbeat is sent periodically by a relay to indicate liveness.
+	MessageHeartbeat MessageType = "heartbeat"
+
+	// MessageRequest is sent by the controller to ask a relay to execute a
+	// command.
+	MessageRequest MessageType = "request"
+
+	// MessageResponse is sent by a relay when a command has completed.
+	MessageResponse MessageType = "response"
+
+	// MessageProgress is sent by a relay with partial output from a
+	// streaming command. Its Response contains only the new lines.
+	MessageProgress MessageType = "progress"
+
+	// MessageCancel is sent by the controller to ask a relay to stop
+	// executing a request. Only the ID of its Request is set.
+	MessageCancel MessageType = "cancel"
+)
+
+// Message is the unit of communication between the controller and a remote
+// relay. Exactly one of the pointer fields is set, depending on Type.
+type Message struct {
+	Type     MessageType     `json:"type"`
+	Register *Registration   `json:"register,omitempty"`
+	Request  *RemoteRequest  `json:"request,omitempty"`
+	Response *RemoteResponse `json:"response,omitempty"`
+}
+
+// Registration is sent by a remote relay to identify itself.
+type Registration struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// RemoteRequest wraps a CommandRequest with everything a remote relay needs
+// to execute it without access to the controller's data store.
+type RemoteRequest struct {
+	ID          int64                       `json:"id"`
+	Request     data.CommandRequest         `json:"request"`
+	CommandName string                      `json:"command_name"`
+	Configs     []data.DynamicConfiguration `json:"configs,omitempty"`
+	Stream      bool                        `json:"stream,omitempty"`
+	Token       rest.Token                  `json:"token"`
+}
+
+// CommandRequest returns the wrapped request with the fields that don't
+// survive serialization restored.
+func (r RemoteRequest) CommandRequest() data.CommandRequest {
+	request := r.Request
+	request.Command.Name = r.CommandName
+	return request
+}
+
+// RemoteResponse is the result of a RemoteRequest, as reported by the relay
+// that executed it.
+type RemoteResponse struct {
+	ID       int64    `json:"id"`
+	Lines    []string `json:"lines"`
+	ExitCode int16    `json:"exit_code"`
+	Error    string   `json:"error,omitempty"`
+	Title    string   `json:"title,omitempty"`
+}
+
+// NewRemoteResponse builds a RemoteResponse from a locally-generated
+// envelope.
+func NewRemoteResponse(id int64, envelope data.CommandResponseEnvelope) RemoteResponse {
+	response := RemoteResponse{
+		ID:       id,
+		Lines:    envelope.Response.Lines,
+		ExitCode: envelope.Data.ExitCode,
+		Title:    envelope.Response.Title,
+	}
+
+	if envelope.Data.Error != nil {
+		response.Error = envelope.Data.Error.Error()
+	}
+
+	return response
+}
+
+// Envelope reconstructs the response envelope for the original request.
+func (r RemoteResponse) Envelope(request data.CommandRequest) data.CommandResponseEnvelope {
+	var opts []data.CommandResponseEnvelopeOption
+
+	if r.Error != "" {
+		opts = append(opts, data.WithError(r.Title, errors.New(r.Error), r.ExitCode))
+	} else {
+		opts = append(opts, data.WithExitCode(r.ExitCode))
+	}
+
+	if r.Lines != nil {
+		opts = append(opts, data.WithResponseLines(r.Lines))
+	}
+
+	return data.NewCommandResponseEnvelope(request, opts...)
+}
+
+// Conn is the transport used to exchange messages between the controller
+// and a relay. It's satisfied by *websocket.Conn.
+type Conn interface {
+	ReadJSON(v interface{}) error
+	WriteJSON(v interface{}) error
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+// syncConn serializes writes to an underlying Conn, which generally support
+// only one concurrent writer.
+type syncConn struct {
+	Conn
+	mutex sync.Mutex
+}
+
+func (c *syncConn) WriteJSON(v interface{}) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.Conn.WriteJSON(v)
+}
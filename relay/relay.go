@@ -26,6 +26,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
 
+	"github.com/getgort/gort/audit"
 	"github.com/getgort/gort/config"
 	"github.com/getgort/gort/data"
 	"github.com/getgort/gort/data/rest"
@@ -36,9 +37,9 @@ import (
 	"github.com/getgort/gort/worker"
 )
 
-// AuthorizeUser is not yet implemented, and will not be until remote relays
-// become a thing. For now, all authentication is done by the command execution
-// framework (which, in turn, invokes a/the relay).
+// AuthorizeUser is not yet implemented. All authorization is done by the
+// command execution framework before a request reaches the relay, and remote
+// relays only ever receive requests that the controller has already vetted.
 func AuthorizeUser(commandRequest data.CommandRequest, user rest.User) (bool, error) {
 	// TODO
 	return true, nil
@@ -52,7 +53,7 @@ func StartListening() (chan<- data.CommandRequest, <-chan data.CommandResponseEn
 	go func() {
 		for commandRequest := range commandRequests {
 			go func(request data.CommandRequest) {
-				commandResponses <- handleRequest(request.Context, request)
+				commandResponses <- handleRequest(request.Context, request, commandResponses)
 			}(commandRequest)
 		}
 	}()
@@ -60,6 +61,14 @@ func StartListening() (chan<- data.CommandRequest, <-chan data.CommandResponseEn
 	return commandRequests, commandResponses
 }
 
+// Execute executes a command request synchronously and returns its result.
+// The request is handled exactly as if it had been received through
+// StartListening, except that any partial output from a streaming command is
+// discarded.
+func Execute(ctx context.Context, request data.CommandRequest) data.CommandResponseEnvelope {
+	return handleRequest(ctx, request, nil)
+}
+
 // SpawnWorker receives a CommandEntry and a slice of command parameters
 // strings, and constructs a new worker.Worker.
 func SpawnWorker(ctx context.Context, command data.CommandRequest) (worker.Worker, error) {
@@ -100,8 +109,10 @@ func getUser(ctx context.Context, username string) (rest.User, error) {
 // handleRequest does the work of spawning, starting, stopping, and cleaning
 // up after worker processes. It receives incoming command requests from the
 // StartListening() CommandRequest channel, returning a CommandResponse which
-// in turn gets forwarded to that function's CommandRequest channel.
-func handleRequest(ctx context.Context, request data.CommandRequest) data.CommandResponseEnvelope {
+// in turn gets forwarded to that function's CommandRequest channel. If the
+// request is a pipeline, its stages are executed in order. Any partial output
+// from a streaming command is sent to partials before the result is returned.
+func handleRequest(ctx context.Context, request data.CommandRequest, partials chan<- data.CommandResponseEnvelope) data.CommandResponseEnvelope {
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	ctx, sp := tr.Start(ctx, "relay.handleRequest")
 	defer sp.End()
@@ -120,7 +131,14 @@ func handleRequest(ctx context.Context, request data.CommandRequest) data.Comman
 
 	defer func() {
 		envelope.Data.Duration = time.Since(envelope.Request.Timestamp)
-		da.RequestClose(ctx, envelope)
+
+		// The audit record describes the request as a whole, including every
+		// stage of its pipeline, rather than the stage that produced the result.
+		record := envelope
+		record.Request = request
+		da.RequestClose(ctx, record)
+		captureOutput(ctx, da, record)
+		audit.Write(ctx, record)
 	}()
 
 	user, err := getUser(ctx, request.UserName)
@@ -148,6 +166,10 @@ func handleRequest(ctx context.Context, request data.CommandRequest) data.Comman
 		return envelope
 	}
 
+	// From here on the request can be cancelled.
+	ctx = inFlight.add(ctx, request)
+	defer inFlight.remove(request.RequestID)
+
 	if authorized, err := AuthorizeUser(request, user); err != nil {
 		envelope = data.NewCommandResponseEnvelope(
 			request,
@@ -162,30 +184,122 @@ func handleRequest(ctx context.Context, request data.CommandRequest) data.Comman
 		return envelope
 	}
 
-	worker, err := SpawnWorker(ctx, request)
+	envelope = runPipeline(ctx, request, partials)
+
+	return envelope
+}
+
+// commandTimeout returns the maximum time that a command is allowed to run:
+// the timeout set on the command or its bundle, if any, or the global
+// command timeout otherwise. Zero (or less) is no timeout.
+func commandTimeout(request data.CommandRequest) time.Duration {
+	if timeout := request.EffectiveLimits().Timeout; timeout > 0 {
+		return timeout
+	}
+
+	return config.GetGlobalConfigs().CommandTimeout
+}
+
+// dispatch decides where a request should be executed. If the request's
+// bundle is assigned to one or more relay groups, it's sent to a healthy
+// member of those groups. Otherwise it's executed by a local worker, unless
+// relay_unassigned_bundles is set and a remote relay is connected, in which
+// case it's sent to one of those. Remote relays are selected round-robin. If
+// progress is non-nil, the command's output is streamed to it as it's
+// produced.
+//
+// If the command or its bundle has a concurrency limit that's been reached,
+// the request waits for a running invocation to complete first. Native
+// commands are executed by the controller directly.
+func dispatch(ctx context.Context, request data.CommandRequest, dc []data.DynamicConfiguration, progress progressFunc) data.CommandResponseEnvelope {
+	if native, ok := getNativeCommand(request); ok {
+		return native(ctx, request)
+	}
+
+	release, err := acquireSlots(ctx, request)
+	if err != nil && ctx.Err() != nil {
+		return interruptedEnvelope(request, ctx.Err())
+	} else if err != nil {
+		return data.NewCommandResponseEnvelope(
+			request,
+			data.WithError("Too many concurrent invocations of "+request.Bundle.Name+":"+request.Command.Name, err, ExitUnavailable),
+		)
+	}
+	defer release()
+
+	members, assigned, err := relayGroupMembers(ctx, request.Bundle.Name)
 	if err != nil {
-		envelope = data.NewCommandResponseEnvelope(
+		return data.NewCommandResponseEnvelope(
 			request,
-			data.WithError("Failed to spawn worker", err, ExitSystemErr),
+			data.WithError("Failed to load relay groups", err, ExitIoErr),
 		)
-		return envelope
 	}
 
-	dc, err := loadDynamicConfigurations(ctx, request)
+	if assigned {
+		if len(members) == 0 {
+			return data.NewCommandResponseEnvelope(
+				request,
+				data.WithError("No relays in relay group for bundle "+request.Bundle.Name, ErrNoRelay, ExitNoRelay),
+			)
+		}
+
+		remote := relays.next(members)
+		if remote == nil {
+			return data.NewCommandResponseEnvelope(
+				request,
+				data.WithError("No relay available for bundle "+request.Bundle.Name, ErrRelayUnavailable, ExitUnavailable),
+			)
+		}
+
+		return runRemote(ctx, remote, request, dc, progress)
+	}
+
+	if config.GetGortServerConfigs().RelayUnassignedBundles {
+		if remote := relays.next(nil); remote != nil {
+			return runRemote(ctx, remote, request, dc, progress)
+		}
+	}
 
+	if !worker.Defined() {
+		return data.NewCommandResponseEnvelope(
+			request,
+			data.WithError("No relay available", ErrNoRelay, ExitNoRelay),
+		)
+	}
+
+	worker, err := SpawnWorker(ctx, request)
 	if err != nil {
-		envelope = data.NewCommandResponseEnvelope(
+		return data.NewCommandResponseEnvelope(
 			request,
-			data.WithError("Failed to load dynamic configurations", err, ExitSystemErr),
+			data.WithError("Failed to spawn worker", err, ExitSystemErr),
 		)
-		return envelope
 	}
 
 	worker.Initialize(dc)
 
-	envelope = runWorker(ctx, worker, request)
+	return runWorker(ctx, worker, request, progress)
+}
 
-	return envelope
+// relayGroupMembers returns the names of all relays in any relay group that
+// the bundle is assigned to. The assigned return value is false if the
+// bundle isn't assigned to any relay group.
+func relayGroupMembers(ctx context.Context, bundleName string) (members []string, assigned bool, err error) {
+	da, err := dataaccess.Get()
+	if err != nil {
+		return nil, false, err
+	}
+
+	groups, err := da.RelayGroupListByBundle(ctx, bundleName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	members = []string{}
+	for _, g := range groups {
+		members = append(members, g.Relays...)
+	}
+
+	return members, len(groups) > 0, nil
 }
 
 func loadDynamicConfigurations(ctx context.Context, command data.CommandRequest) ([]data.DynamicConfiguration, error) {
@@ -277,8 +391,9 @@ func loadDynamicConfigurations(ctx context.Context, command data.CommandRequest)
 }
 
 // runWorker is called by handleRequest to do the work of starting an
-// individual worker, capturing its output, and cleaning up after it.
-func runWorker(ctx context.Context, worker worker.Worker, request data.CommandRequest) data.CommandResponseEnvelope {
+// individual worker, capturing its output, and cleaning up after it. If
+// progress is non-nil, output is also passed to it in batches as it's read.
+func runWorker(ctx context.Context, worker worker.Worker, request data.CommandRequest, progress progressFunc) data.CommandResponseEnvelope {
 	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
 	_, sp := tr.Start(ctx, "relay.runWorker")
 	defer sp.End()
@@ -290,7 +405,7 @@ func runWorker(ctx context.Context, worker worker.Worker, request data.CommandRe
 
 	// Get configured timeout. Zero (or less) is no timeout.
 	var cancel context.CancelFunc
-	if timeout := config.GetGlobalConfigs().CommandTimeout; timeout > 0 {
+	if timeout := commandTimeout(request); timeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, timeout)
 	} else {
 		ctx, cancel = context.WithCancel(ctx)
@@ -308,8 +423,12 @@ func runWorker(ctx context.Context, worker worker.Worker, request data.CommandRe
 
 	// Read input from the worker until the stream closes
 	var lines []string
-	for line := range stdoutChan {
-		lines = append(lines, line)
+	if progress == nil {
+		for line := range stdoutChan {
+			lines = append(lines, line)
+		}
+	} else {
+		lines = streamLines(stdoutChan, progress)
 	}
 
 	var exitCode int64
@@ -339,22 +458,24 @@ func runWorker(ctx context.Context, worker worker.Worker, request data.CommandRe
 			Info("Command exited")
 
 	case <-ctx.Done():
-		err := ctx.Err()
+	}
 
-		envelope = data.NewCommandResponseEnvelope(
-			request,
-			data.WithError(err.Error(), err, ExitTimeout),
-		)
+	// If the command timed out or was cancelled the worker may also report
+	// an exit status, so the context takes precedence.
+	if err := ctx.Err(); err != nil {
+		envelope = interruptedEnvelope(request, err)
 
 		log.
 			WithError(err).
 			WithField("request.id", request.RequestID).
-			WithField("status", ExitTimeout).
+			WithField("status", envelope.Data.ExitCode).
 			Info("Command exited with error")
 	}
 
+	// The request's context may already be done, so it can't be used to stop
+	// the worker.
 	forceTerm := time.Second * 10
-	worker.Stop(ctx, &forceTerm)
+	worker.Stop(context.Background(), &forceTerm)
 
 	return envelope
 }
@@ -0,0 +1,407 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	gerrs "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/telemetry"
+)
+
+var (
+	// ErrNoRelay is returned when no relay is able to service a request.
+	ErrNoRelay = errors.New("no relay available")
+
+	// ErrRelayUnavailable is returned when the relay servicing a request
+	// disconnects before returning a response.
+	ErrRelayUnavailable = errors.New("relay unavailable")
+
+	// ErrProtocol is returned when a relay sends an unexpected message.
+	ErrProtocol = errors.New("relay protocol error")
+)
+
+// remoteGracePeriod is how much longer than the command timeout the
+// controller will wait for a remote relay to respond, to allow for the
+// relay's own cleanup and network latency.
+const remoteGracePeriod = 30 * time.Second
+
+var (
+	relays          = &registry{relays: map[string]*remoteRelay{}}
+	remoteRequestID int64
+)
+
+// ConnectedRelays returns descriptions of all currently connected remote
+// relays, sorted by name.
+func ConnectedRelays() []rest.Relay {
+	relays.mutex.RLock()
+	defer relays.mutex.RUnlock()
+
+	infos := []rest.Relay{}
+	for _, r := range relays.relays {
+		infos = append(infos, r.info())
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos
+}
+
+// Serve handles a single remote relay connection for its entire lifetime.
+// The first message on the connection must be a registration; after that
+// the relay is eligible to receive command requests until the connection
+// is lost or ctx is cancelled. It blocks until then.
+func Serve(ctx context.Context, conn Conn) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(3 * HeartbeatInterval))
+
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		return err
+	}
+
+	if msg.Type != MessageRegister || msg.Register == nil || msg.Register.Name == "" {
+		return ErrProtocol
+	}
+
+	remote := newRemoteRelay(*msg.Register, conn)
+	relays.add(remote)
+	defer remote.close()
+	defer relays.remove(remote)
+
+	le := log.WithField("relay.name", remote.Name).WithField("relay.tags", remote.Tags)
+	le.Info("Relay connected")
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(3 * HeartbeatInterval))
+
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			le.WithError(err).Info("Relay disconnected")
+			return err
+		}
+
+		remote.touch()
+
+		switch msg.Type {
+		case MessageHeartbeat:
+		case MessageResponse:
+			if msg.Response != nil {
+				remote.deliver(*msg.Response)
+			}
+		case MessageProgress:
+			if msg.Response != nil {
+				remote.progress(*msg.Response)
+			}
+		default:
+			le.WithField("message.type", msg.Type).Warn("Unexpected message from relay")
+		}
+	}
+}
+
+// runRemote sends a request to a remote relay and waits for its response.
+// If progress is non-nil, the relay is asked to stream the command's output,
+// which is passed to progress as it arrives.
+func runRemote(ctx context.Context, remote *remoteRelay, request data.CommandRequest, configs []data.DynamicConfiguration, progress progressFunc) data.CommandResponseEnvelope {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "relay.runRemote")
+	defer sp.End()
+
+	var envelope data.CommandResponseEnvelope
+	defer func() {
+		envelope.Data.Duration = time.Since(envelope.Request.Timestamp)
+	}()
+
+	// The worker authenticates with a token generated by the controller,
+	// since the remote relay has no access to the data store.
+	dal, err := dataaccess.Get()
+	if err != nil {
+		envelope = data.NewCommandResponseEnvelope(
+			request,
+			data.WithError("Failed to access data access layer", err, ExitIoErr),
+		)
+		return envelope
+	}
+
+	token, err := dal.TokenGenerate(ctx, request.UserName, 10*time.Second)
+	if err != nil {
+		envelope = data.NewCommandResponseEnvelope(
+			request,
+			data.WithError("Failed to generate worker token", err, ExitSystemErr),
+		)
+		return envelope
+	}
+
+	// The relay enforces the command timeout itself. This just keeps us
+	// from waiting forever on a relay that's gone quiet.
+	var cancel context.CancelFunc
+	if timeout := commandTimeout(request); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout+remoteGracePeriod)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	rr := RemoteRequest{
+		ID:          atomic.AddInt64(&remoteRequestID, 1),
+		Request:     request,
+		CommandName: request.Command.Name,
+		Configs:     configs,
+		Stream:      progress != nil,
+		Token:       token,
+	}
+
+	response, err := remote.execute(ctx, rr, progress)
+
+	switch {
+	case err == nil:
+		envelope = response.Envelope(request)
+	case gerrs.Is(err, ErrRelayUnavailable):
+		envelope = data.NewCommandResponseEnvelope(
+			request,
+			data.WithError("Relay unavailable: "+remote.Name, err, ExitUnavailable),
+		)
+	default:
+		envelope = interruptedEnvelope(request, err)
+	}
+
+	log.WithField("request.id", request.RequestID).
+		WithField("relay.name", remote.Name).
+		WithField("status", envelope.Data.ExitCode).
+		Info("Remote command exited")
+
+	return envelope
+}
+
+// registry tracks the currently connected remote relays.
+type registry struct {
+	cursor uint64
+	mutex  sync.RWMutex
+	relays map[string]*remoteRelay
+}
+
+// add registers a relay. A relay connecting with the same name as an
+// existing one replaces it; the old connection is closed.
+func (g *registry) add(r *remoteRelay) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if old, ok := g.relays[r.Name]; ok {
+		old.conn.Close()
+	}
+
+	g.relays[r.Name] = r
+}
+
+// next selects a healthy connected relay, round-robin. If names is non-nil,
+// only relays with those names are candidates. It returns nil if there are
+// no candidates.
+func (g *registry) next(names []string) *remoteRelay {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	var candidates []*remoteRelay
+
+	if names == nil {
+		for _, r := range g.relays {
+			candidates = append(candidates, r)
+		}
+	} else {
+		for _, n := range names {
+			if r, ok := g.relays[n]; ok {
+				candidates = append(candidates, r)
+			}
+		}
+	}
+
+	healthy := candidates[:0]
+	for _, r := range candidates {
+		if r.healthy() {
+			healthy = append(healthy, r)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	sort.Slice(healthy, func(i, j int) bool { return healthy[i].Name < healthy[j].Name })
+
+	i := atomic.AddUint64(&g.cursor, 1)
+	return healthy[i%uint64(len(healthy))]
+}
+
+// remove unregisters a relay, unless it's already been replaced.
+func (g *registry) remove(r *remoteRelay) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.relays[r.Name] == r {
+		delete(g.relays, r.Name)
+	}
+}
+
+// remoteRelay is the controller's view of a single connected relay.
+type remoteRelay struct {
+	Registration
+
+	conn      *syncConn
+	connected time.Time
+	done      chan struct{}
+	lastSeen  time.Time
+	mutex     sync.Mutex
+	pending   map[int64]*pendingRequest
+}
+
+// pendingRequest is a request that's been sent to a remote relay and is
+// awaiting its response.
+type pendingRequest struct {
+	progress progressFunc
+	response chan RemoteResponse
+}
+
+func newRemoteRelay(reg Registration, conn Conn) *remoteRelay {
+	return &remoteRelay{
+		Registration: reg,
+		conn:         &syncConn{Conn: conn},
+		connected:    time.Now(),
+		done:         make(chan struct{}),
+		lastSeen:     time.Now(),
+		pending:      map[int64]*pendingRequest{},
+	}
+}
+
+// close marks the relay as gone, which fails any requests still waiting
+// on it.
+func (r *remoteRelay) close() {
+	close(r.done)
+}
+
+// deliver routes a response to the request waiting on it, if any.
+func (r *remoteRelay) deliver(response RemoteResponse) {
+	r.mutex.Lock()
+	p, ok := r.pending[response.ID]
+	delete(r.pending, response.ID)
+	r.mutex.Unlock()
+
+	if !ok {
+		log.WithField("relay.name", r.Name).
+			WithField("remote.id", response.ID).
+			Warn("Received response for unknown request")
+		return
+	}
+
+	p.response <- response
+}
+
+// progress routes partial output to the request waiting on it, if any. It's
+// called from the connection's read loop, so partial output is always
+// passed on before the final response is delivered.
+func (r *remoteRelay) progress(response RemoteResponse) {
+	r.mutex.Lock()
+	p, ok := r.pending[response.ID]
+	r.mutex.Unlock()
+
+	if !ok || p.progress == nil {
+		return
+	}
+
+	p.progress(response.Lines)
+}
+
+// execute sends a request to the relay and blocks until it responds, the
+// relay disconnects, or ctx is done. Any partial output received in the
+// meantime is passed to progress, if it's non-nil. If ctx is done first, the
+// relay is told to stop executing the request.
+func (r *remoteRelay) execute(ctx context.Context, request RemoteRequest, progress progressFunc) (RemoteResponse, error) {
+	ch := make(chan RemoteResponse, 1)
+
+	r.mutex.Lock()
+	r.pending[request.ID] = &pendingRequest{progress: progress, response: ch}
+	r.mutex.Unlock()
+
+	defer func() {
+		r.mutex.Lock()
+		delete(r.pending, request.ID)
+		r.mutex.Unlock()
+	}()
+
+	if err := r.conn.WriteJSON(Message{Type: MessageRequest, Request: &request}); err != nil {
+		return RemoteResponse{}, gerrs.Wrap(ErrRelayUnavailable, err)
+	}
+
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-r.done:
+		return RemoteResponse{}, ErrRelayUnavailable
+	case <-ctx.Done():
+		cancel := RemoteRequest{ID: request.ID}
+		if err := r.conn.WriteJSON(Message{Type: MessageCancel, Request: &cancel}); err != nil {
+			log.WithError(err).
+				WithField("relay.name", r.Name).
+				WithField("remote.id", request.ID).
+				Warn("Failed to cancel remote request")
+		}
+		return RemoteResponse{}, ctx.Err()
+	}
+}
+
+// healthy returns true if the relay has been heard from recently.
+func (r *remoteRelay) healthy() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return time.Since(r.lastSeen) < 2*HeartbeatInterval
+}
+
+func (r *remoteRelay) info() rest.Relay {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return rest.Relay{
+		Name:      r.Name,
+		Tags:      r.Tags,
+		Connected: r.connected,
+		LastSeen:  r.lastSeen,
+	}
+}
+
+func (r *remoteRelay) touch() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastSeen = time.Now()
+}
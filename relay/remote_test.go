@@ -0,0 +1,307 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+)
+
+// pipeConn is one end of an in-memory Conn pair.
+type pipeConn struct {
+	in     <-chan []byte
+	out    chan<- []byte
+	closed chan struct{}
+	once   *sync.Once
+}
+
+func newPipe() (*pipeConn, *pipeConn) {
+	a, b := make(chan []byte, 16), make(chan []byte, 16)
+	closed := make(chan struct{})
+	once := &sync.Once{}
+
+	return &pipeConn{in: a, out: b, closed: closed, once: once},
+		&pipeConn{in: b, out: a, closed: closed, once: once}
+}
+
+func (p *pipeConn) ReadJSON(v interface{}) error {
+	select {
+	case b := <-p.in:
+		return json.Unmarshal(b, v)
+	case <-p.closed:
+		return errors.New("closed")
+	}
+}
+
+func (p *pipeConn) WriteJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case p.out <- b:
+		return nil
+	case <-p.closed:
+		return errors.New("closed")
+	}
+}
+
+func (p *pipeConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (p *pipeConn) Close() error {
+	p.once.Do(func() { close(p.closed) })
+	return nil
+}
+
+func waitForRelay(t *testing.T, name string) *remoteRelay {
+	for i := 0; i < 100; i++ {
+		relays.mutex.RLock()
+		r := relays.relays[name]
+		relays.mutex.RUnlock()
+
+		if r != nil {
+			return r
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("relay %q never registered", name)
+	return nil
+}
+
+func TestRemoteResponseEnvelope(t *testing.T) {
+	request := data.CommandRequest{RequestID: 42}
+
+	envelope := data.NewCommandResponseEnvelope(
+		request,
+		data.WithError("Command Error", errors.New("boom"), 3),
+	)
+
+	rr := NewRemoteResponse(7, envelope)
+	assert.Equal(t, int64(7), rr.ID)
+	assert.Equal(t, "boom", rr.Error)
+
+	e := rr.Envelope(request)
+	assert.Equal(t, int16(3), e.Data.ExitCode)
+	assert.Equal(t, "Command Error", e.Response.Title)
+	assert.Equal(t, "boom", e.Data.Error.Error())
+	assert.Equal(t, int64(42), e.Request.RequestID)
+
+	rr = RemoteResponse{ID: 8, Lines: []string{"foo", "bar"}}
+	e = rr.Envelope(request)
+	assert.Equal(t, int16(0), e.Data.ExitCode)
+	assert.Nil(t, e.Data.Error)
+	assert.Equal(t, "foo\nbar", e.Response.Out)
+}
+
+func TestServeRegisterAndExecute(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	controller, relay := newPipe()
+
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, controller) }()
+
+	require.NoError(t, relay.WriteJSON(Message{
+		Type:     MessageRegister,
+		Register: &Registration{Name: "test-relay", Tags: []string{"a"}},
+	}))
+
+	remote := waitForRelay(t, "test-relay")
+	assert.Equal(t, []string{"a"}, remote.Tags)
+
+	// Play the part of the relay: answer one request.
+	go func() {
+		var msg Message
+		if err := relay.ReadJSON(&msg); err != nil || msg.Request == nil {
+			return
+		}
+
+		relay.WriteJSON(Message{
+			Type: MessageResponse,
+			Response: &RemoteResponse{
+				ID:    msg.Request.ID,
+				Lines: []string{msg.Request.CommandName},
+			},
+		})
+	}()
+
+	response, err := remote.execute(ctx, RemoteRequest{ID: 1, CommandName: "echo"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo"}, response.Lines)
+
+	// A disconnected relay fails outstanding requests.
+	relay.Close()
+	require.Error(t, <-done)
+
+	_, err = remote.execute(ctx, RemoteRequest{ID: 2}, nil)
+	assert.Error(t, err)
+	assert.Nil(t, relays.next(nil))
+}
+
+func TestServeProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	controller, relay := newPipe()
+	defer relay.Close()
+
+	go Serve(ctx, controller)
+
+	require.NoError(t, relay.WriteJSON(Message{
+		Type:     MessageRegister,
+		Register: &Registration{Name: "streaming-relay"},
+	}))
+
+	remote := waitForRelay(t, "streaming-relay")
+
+	// Play the part of the relay: stream two batches, then respond.
+	go func() {
+		var msg Message
+		if err := relay.ReadJSON(&msg); err != nil || msg.Request == nil || !msg.Request.Stream {
+			return
+		}
+
+		id := msg.Request.ID
+		relay.WriteJSON(Message{Type: MessageProgress, Response: &RemoteResponse{ID: id, Lines: []string{"a"}}})
+		relay.WriteJSON(Message{Type: MessageProgress, Response: &RemoteResponse{ID: id, Lines: []string{"b"}}})
+		relay.WriteJSON(Message{Type: MessageResponse, Response: &RemoteResponse{ID: id, Lines: []string{"a", "b"}}})
+	}()
+
+	var streamed []string
+	progress := func(lines []string) { streamed = append(streamed, lines...) }
+
+	response, err := remote.execute(ctx, RemoteRequest{ID: 1, Stream: true}, progress)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, response.Lines)
+	assert.Equal(t, []string{"a", "b"}, streamed)
+}
+
+func TestServeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	controller, relay := newPipe()
+	defer relay.Close()
+
+	go Serve(ctx, controller)
+
+	require.NoError(t, relay.WriteJSON(Message{
+		Type:     MessageRegister,
+		Register: &Registration{Name: "cancel-relay"},
+	}))
+
+	remote := waitForRelay(t, "cancel-relay")
+
+	reqCtx, cancelRequest := context.WithCancel(ctx)
+
+	// Play the part of the relay: never respond, but report any cancellation.
+	cancelled := make(chan int64, 1)
+	go func() {
+		for {
+			var msg Message
+			if err := relay.ReadJSON(&msg); err != nil {
+				return
+			}
+
+			switch msg.Type {
+			case MessageRequest:
+				cancelRequest()
+			case MessageCancel:
+				cancelled <- msg.Request.ID
+				return
+			}
+		}
+	}()
+
+	_, err := remote.execute(reqCtx, RemoteRequest{ID: 5}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(5), <-cancelled)
+}
+
+func TestServeRejectsMissingRegistration(t *testing.T) {
+	controller, relay := newPipe()
+	defer relay.Close()
+
+	require.NoError(t, relay.WriteJSON(Message{Type: MessageHeartbeat}))
+
+	err := Serve(context.Background(), controller)
+	assert.ErrorIs(t, err, ErrProtocol)
+}
+
+func TestRegistryNextRoundRobin(t *testing.T) {
+	g := &registry{relays: map[string]*remoteRelay{}}
+
+	for _, name := range []string{"a", "b", "c"} {
+		conn, _ := newPipe()
+		g.add(newRemoteRelay(Registration{Name: name}, conn))
+	}
+
+	// A relay that hasn't been heard from recently is skipped.
+	g.relays["c"].lastSeen = time.Now().Add(-time.Hour)
+
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[g.next(nil).Name]++
+	}
+	assert.Equal(t, map[string]int{"a": 2, "b": 2}, seen)
+
+	// Only named relays are candidates.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "b", g.next([]string{"b", "c", "d"}).Name)
+	}
+
+	assert.Nil(t, g.next([]string{"c", "d"}))
+	assert.Nil(t, g.next([]string{}))
+}
+
+func TestDispatchUnassignedBundleStaysLocal(t *testing.T) {
+	conn, relayEnd := newPipe()
+	remote := newRemoteRelay(Registration{Name: "unassigned-test"}, conn)
+	relays.add(remote)
+	defer relays.remove(remote)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The bundle isn't assigned to a relay group and relay_unassigned_bundles
+	// isn't set, so the request mustn't be sent to the connected relay.
+	request := data.CommandRequest{
+		CommandEntry: data.CommandEntry{
+			Bundle:  data.Bundle{Name: "unassigned"},
+			Command: data.BundleCommand{Name: "echo"},
+		},
+		RequestID: 1,
+	}
+
+	dispatch(ctx, request, nil, nil)
+	assert.Empty(t, relayEnd.in)
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"time"
+)
+
+// streamInterval is how often the accumulated output of a streaming command
+// is flushed. Chat providers rate limit message posts and edits, so output
+// is batched rather than sent line by line.
+var streamInterval = 2 * time.Second
+
+// progressFunc receives batches of output lines from a streaming command as
+// they're produced.
+type progressFunc func(lines []string)
+
+// streamLines reads lines from ch until it closes, passing them to progress
+// at most once per streamInterval, and returns every line read. Any lines
+// not yet flushed when ch closes are passed to progress before it returns.
+func streamLines(ch <-chan string, progress progressFunc) []string {
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+
+	var lines, batch []string
+
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				if len(batch) > 0 {
+					progress(batch)
+				}
+				return lines
+			}
+
+			lines = append(lines, line)
+			batch = append(batch, line)
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				progress(batch)
+				batch = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamLines(t *testing.T) {
+	defer func(d time.Duration) { streamInterval = d }(streamInterval)
+	streamInterval = 10 * time.Millisecond
+
+	ch := make(chan string)
+	var batches [][]string
+
+	go func() {
+		ch <- "one"
+		ch <- "two"
+		time.Sleep(5 * streamInterval)
+		ch <- "three"
+		close(ch)
+	}()
+
+	lines := streamLines(ch, func(batch []string) {
+		batches = append(batches, batch)
+	})
+
+	assert.Equal(t, []string{"one", "two", "three"}, lines)
+	assert.Equal(t, [][]string{{"one", "two"}, {"three"}}, batches)
+}
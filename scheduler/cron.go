@@ -0,0 +1,211 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpression is returned by Parse if a cron expression can't be
+// parsed.
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// searchLimit bounds how far ahead Next will look for a matching time, so
+// that an expression that can never match (such as "0 0 30 2 *") doesn't
+// loop forever.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Expression is a parsed standard five-field cron expression: minute, hour,
+// day of month, month, and day of week. Each field is stored as a bit set of
+// the values it matches.
+type Expression struct {
+	minute, hour, dom, month, dow uint64
+
+	// As in Vixie cron, if both day fields are restricted a day matches if
+	// either of them does.
+	domAny, dowAny bool
+}
+
+// Parse parses a cron expression. In addition to the five standard fields,
+// which accept "*", lists, ranges, steps, and (for months and days of the
+// week) three-letter names, the macros @yearly, @monthly, @weekly, @daily,
+// and @hourly are accepted.
+func Parse(spec string) (Expression, error) {
+	spec = strings.TrimSpace(spec)
+	if m, ok := macros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return Expression{}, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidExpression, len(fields))
+	}
+
+	var e Expression
+	var err error
+
+	if e.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return Expression{}, err
+	}
+	if e.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return Expression{}, err
+	}
+	if e.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return Expression{}, err
+	}
+	if e.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return Expression{}, err
+	}
+	if e.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return Expression{}, err
+	}
+
+	// Both 0 and 7 are Sunday.
+	if e.dow&(1<<7) != 0 {
+		e.dow |= 1
+	}
+
+	e.domAny = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	e.dowAny = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+
+	return e, nil
+}
+
+// Next returns the first time after t that matches the expression, in t's
+// location. The zero time is returned if there's no such time in the next
+// five years.
+func (e Expression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(searchLimit)
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		switch {
+		case e.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !e.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case e.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case e.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (e Expression) matchDay(t time.Time) bool {
+	dom := e.dom&(1<<uint(t.Day())) != 0
+	dow := e.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case e.domAny && e.dowAny:
+		return true
+	case e.domAny:
+		return dow
+	case e.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// parseField parses one comma-separated field into a bit set.
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		lo, hi, step := min, max, 1
+
+		rng := part
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("%w: bad step in %q", ErrInvalidExpression, part)
+			}
+			step, rng = s, part[:i]
+		}
+
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+
+			var err error
+			if lo, err = parseValue(bounds[0], names); err != nil {
+				return 0, err
+			}
+
+			switch {
+			case len(bounds) == 2:
+				if hi, err = parseValue(bounds[1], names); err != nil {
+					return 0, err
+				}
+			case step == 1:
+				hi = lo
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%w: %q out of range %d-%d", ErrInvalidExpression, part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad value %q", ErrInvalidExpression, s)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data/rest"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@fortnightly",
+	} {
+		_, err := Parse(spec)
+		assert.ErrorIs(t, err, ErrInvalidExpression, spec)
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Wednesday.
+	start := time.Date(2021, time.September, 15, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2021, 9, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 9, 15, 10, 45, 0, 0, time.UTC)},
+		{"0,30 * * * *", time.Date(2021, 9, 15, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2021, 9, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 9, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2021, 9, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2021, 9, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2021, 9, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 9, 19, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2021, 9, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2021, 10, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+
+		// If both day fields are restricted, either may match.
+		{"0 0 1 * fri", time.Date(2021, 9, 17, 0, 0, 0, 0, time.UTC)},
+
+		// February 30th never happens.
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		expr, err := Parse(test.spec)
+		require.NoError(t, err, test.spec)
+		assert.Equal(t, test.expected, expr.Next(start), test.spec)
+	}
+}
+
+func TestNextRunTimezone(t *testing.T) {
+	s := rest.Schedule{Cron: "0 9 * * *", Timezone: "America/New_York"}
+
+	next, err := NextRun(s, time.Date(2021, 9, 15, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	// 9am EDT is 1pm UTC.
+	assert.True(t, next.Equal(time.Date(2021, 9, 15, 13, 0, 0, 0, time.UTC)), next.String())
+}
+
+func TestIsDue(t *testing.T) {
+	created := time.Date(2021, 9, 15, 10, 2, 0, 0, time.UTC)
+	s := rest.Schedule{Cron: "*/5 * * * *", Created: created}
+
+	assert.False(t, isDue(s, created.Add(2*time.Minute)))
+	assert.True(t, isDue(s, created.Add(3*time.Minute)))
+
+	// Missed runs are collapsed into one.
+	s.LastRun = created.Add(time.Hour)
+	assert.False(t, isDue(s, created.Add(time.Hour+2*time.Minute)))
+	assert.True(t, isDue(s, created.Add(time.Hour+3*time.Minute)))
+}
+
+func TestValidate(t *testing.T) {
+	s := rest.Schedule{
+		Cron:      "@daily",
+		Command:   "echo hello",
+		Adapter:   "slack",
+		ChannelID: "C0123",
+		UserName:  "admin",
+	}
+	assert.NoError(t, Validate(s))
+
+	bad := s
+	bad.Cron = "every day"
+	assert.Error(t, Validate(bad))
+
+	bad = s
+	bad.Timezone = "Nowhere/Special"
+	assert.Error(t, Validate(bad))
+
+	bad = s
+	bad.UserName = ""
+	assert.Error(t, Validate(bad))
+}
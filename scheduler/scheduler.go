@@ -0,0 +1,174 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	gerrs "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/telemetry"
+)
+
+// ErrInvalidSchedule is returned by Validate if a schedule is incomplete, or
+// has an invalid cron expression or time zone.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// pollInterval is how often the data store is checked for due schedules.
+// Cron expressions have a resolution of one minute, so a run will never be
+// more than this late.
+const pollInterval = 15 * time.Second
+
+// Validate checks that a schedule has everything it needs to run, and that
+// its cron expression and time zone are valid.
+func Validate(s rest.Schedule) error {
+	switch {
+	case s.Cron == "":
+		return gerrs.Wrap(ErrInvalidSchedule, errors.New("cron expression is required"))
+	case s.Command == "":
+		return gerrs.Wrap(ErrInvalidSchedule, errors.New("command is required"))
+	case s.Adapter == "":
+		return gerrs.Wrap(ErrInvalidSchedule, errors.New("adapter is required"))
+	case s.ChannelID == "":
+		return gerrs.Wrap(ErrInvalidSchedule, errors.New("channel is required"))
+	case s.UserName == "":
+		return gerrs.Wrap(ErrInvalidSchedule, errors.New("user is required"))
+	}
+
+	if _, err := Parse(s.Cron); err != nil {
+		return gerrs.Wrap(ErrInvalidSchedule, err)
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return gerrs.Wrap(ErrInvalidSchedule, err)
+	}
+
+	return nil
+}
+
+// NextRun returns the first time after t at which the schedule is due,
+// evaluating its cron expression in the schedule's time zone. An empty time
+// zone is treated as UTC.
+func NextRun(s rest.Schedule, t time.Time) (time.Time, error) {
+	expr, err := Parse(s.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return expr.Next(t.In(loc)), nil
+}
+
+// Start periodically checks for schedules that are due, and sends each one
+// on the returned channel. A due schedule's LastRun is set before it's sent,
+// so any runs that were missed (for example, while Gort wasn't running) are
+// collapsed into one. The channel is closed when ctx is done.
+func Start(ctx context.Context) <-chan rest.Schedule {
+	due := make(chan rest.Schedule)
+
+	go func() {
+		defer close(due)
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				for _, s := range dueSchedules(ctx, now) {
+					select {
+					case due <- s:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return due
+}
+
+// dueSchedules returns all unpaused schedules that were due to run at or
+// before now, and records now as their last run time.
+func dueSchedules(ctx context.Context, now time.Time) []rest.Schedule {
+	tr := otel.GetTracerProvider().Tracer(telemetry.ServiceName)
+	ctx, sp := tr.Start(ctx, "scheduler.dueSchedules")
+	defer sp.End()
+
+	da, err := dataaccess.Get()
+	if err != nil {
+		// The data store may not be available yet. Try again next time.
+		log.WithError(err).Debug("Can't check schedules")
+		return nil
+	}
+
+	schedules, err := da.ScheduleList(ctx)
+	if err != nil {
+		telemetry.Errors().WithError(err).Commit(ctx)
+		log.WithError(err).Error("Failed to list schedules")
+		return nil
+	}
+
+	var due []rest.Schedule
+
+	for _, s := range schedules {
+		if s.Paused || !isDue(s, now) {
+			continue
+		}
+
+		s.LastRun = now
+		if err := da.ScheduleUpdate(ctx, s); err != nil {
+			telemetry.Errors().WithError(err).Commit(ctx)
+			log.WithError(err).WithField("schedule.id", s.ID).Error("Failed to update schedule")
+			continue
+		}
+
+		due = append(due, s)
+	}
+
+	return due
+}
+
+// isDue returns true if the schedule should have run at some point between
+// its last run (or its creation, if it's never run) and now.
+func isDue(s rest.Schedule, now time.Time) bool {
+	last := s.LastRun
+	if last.IsZero() {
+		last = s.Created
+	}
+
+	next, err := NextRun(s, last)
+	if err != nil {
+		log.WithError(err).WithField("schedule.id", s.ID).Warn("Invalid schedule")
+		return false
+	}
+
+	return !next.IsZero() && !next.After(now)
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	gerrs "github.com/getgort/gort/errors"
+)
+
+var (
+	adapterHandlersMutex sync.RWMutex
+
+	// adapterHandlers are the handlers of adapters that receive their
+	// messages through the REST API, keyed by adapter name.
+	adapterHandlers = map[string]http.Handler{}
+)
+
+// RegisterAdapterHandler makes an adapter's handler available at
+// "POST /v2/adapters/{name}/messages". The handler is responsible for
+// authenticating its callers.
+func RegisterAdapterHandler(name string, handler http.Handler) {
+	adapterHandlersMutex.Lock()
+	defer adapterHandlersMutex.Unlock()
+
+	adapterHandlers[name] = handler
+}
+
+// handlePostAdapterMessage handles "POST /v2/adapters/{adapter}/messages"
+func handlePostAdapterMessage(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["adapter"]
+
+	adapterHandlersMutex.RLock()
+	handler, ok := adapterHandlers[name]
+	adapterHandlersMutex.RUnlock()
+
+	if !ok {
+		respondAndLogError(r.Context(), w, gerrs.Wrap(ErrNoSuchAdapter, fmt.Errorf("%q", name)))
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+func addAdapterMethodsToRouter(router *mux.Router) {
+	// Adapters authenticate their callers themselves.
+	router.Handle("/v2/adapters/{adapter}/messages", otelhttp.NewHandler(http.HandlerFunc(handlePostAdapterMessage), "handlePostAdapterMessage")).Methods("POST")
+}
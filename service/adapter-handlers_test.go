@@ -0,0 +1,85 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAdapterMessage(t *testing.T) {
+	router := createTestRouter()
+
+	var received string
+	RegisterAdapterHandler("portal", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = string(b)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	body := map[string]string{"user_id": "alice", "text": "whoami"}
+
+	NewResponseTester("POST", "http://example.com/v2/adapters/portal/messages").WithBody(body).
+		WithStatus(http.StatusAccepted).Test(t, router)
+	assert.JSONEq(t, `{"user_id": "alice", "text": "whoami"}`, received)
+
+	NewResponseTester("POST", "http://example.com/v2/adapters/nope/messages").WithBody(body).
+		WithStatus(http.StatusNotFound).Test(t, router)
+	NewResponseTester("GET", "http://example.com/v2/adapters/portal/messages").
+		WithStatus(http.StatusMethodNotAllowed).Test(t, router)
+}
+
+func TestPostAdapterMessageWithoutSessionToken(t *testing.T) {
+	createTestRouter()
+
+	RegisterAdapterHandler("portal", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	// Go through the full middleware chain, which normally requires a
+	// session token.
+	server := BuildRESTServer(context.Background(), ":0")
+	go func() {
+		for range server.Requests() {
+		}
+	}()
+
+	tests := []struct {
+		method string
+		target string
+		status int
+	}{
+		{"POST", "/v2/adapters/portal/messages", http.StatusAccepted},
+		{"GET", "/v2/users", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(`{"text": "whoami"}`))
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+
+		assert.Equal(t, test.status, w.Result().StatusCode, test.target)
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/getgort/gort/auth"
+	"github.com/getgort/gort/command"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	gerrs "github.com/getgort/gort/errors"
+)
+
+// manageAliasesPermission allows a user to manage site aliases, and the
+// aliases of other users.
+const manageAliasesPermission = "gort:manage_aliases"
+
+// handleDeleteAlias handles "DELETE /v2/aliases/{scope}/{name}"
+func handleDeleteAlias(w http.ResponseWriter, r *http.Request) {
+	owner, err := aliasOwner(r, true)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.AliasDelete(r.Context(), owner, mux.Vars(r)["name"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handleGetAlias handles "GET /v2/aliases/{scope}/{name}"
+func handleGetAlias(w http.ResponseWriter, r *http.Request) {
+	owner, err := aliasOwner(r, false)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	alias, err := dataAccessLayer.AliasGet(r.Context(), owner, mux.Vars(r)["name"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(alias)
+}
+
+// handleGetAliases handles "GET /v2/aliases"
+func handleGetAliases(w http.ResponseWriter, r *http.Request) {
+	user, err := getUserByRequest(r)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	manager, err := auth.UserHasPermission(r.Context(), user.Username, manageAliasesPermission)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	aliases, err := dataAccessLayer.AliasList(r.Context())
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	// Users only see site aliases and their own, unless they manage aliases.
+	visible := []rest.Alias{}
+	for _, a := range aliases {
+		if manager || a.Owner == "" || a.Owner == user.Username {
+			visible = append(visible, a)
+		}
+	}
+
+	json.NewEncoder(w).Encode(visible)
+}
+
+// handlePutAlias handles "PUT /v2/aliases/{scope}/{name}"
+func handlePutAlias(w http.ResponseWriter, r *http.Request) {
+	var alias rest.Alias
+
+	err := json.NewDecoder(r.Body).Decode(&alias)
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.ErrUnmarshal)
+		return
+	}
+
+	owner, err := aliasOwner(r, true)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	alias.Name = mux.Vars(r)["name"]
+	alias.Scope = rest.AliasScope(mux.Vars(r)["scope"])
+	alias.Owner = owner
+
+	if err := validateAlias(r.Context(), alias); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	if owner != "" {
+		if _, err := dataAccessLayer.UserGet(r.Context(), owner); err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+	}
+
+	err = dataAccessLayer.AliasCreate(r.Context(), alias)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// aliasOwner returns the owner of the alias described by a request's path:
+// empty for a site alias, or for a user alias the user named by the "user"
+// query parameter, which defaults to the requesting user. An ErrUnauthorized
+// error is returned if the requesting user may not access the alias. Only
+// users with the gort:manage_aliases permission may access other users'
+// aliases, or (if modify is true) modify site aliases.
+func aliasOwner(r *http.Request, modify bool) (string, error) {
+	user, err := getUserByRequest(r)
+	if err != nil {
+		return "", err
+	}
+
+	owner := ""
+	if rest.AliasScope(mux.Vars(r)["scope"]) == rest.AliasScopeUser {
+		if owner = r.FormValue("user"); owner == "" {
+			owner = user.Username
+		}
+	}
+
+	if owner == user.Username || (owner == "" && !modify) {
+		return owner, nil
+	}
+
+	permitted, err := auth.UserHasPermission(r.Context(), user.Username, manageAliasesPermission)
+	if err != nil {
+		return "", err
+	} else if !permitted {
+		return "", ErrUnauthorized
+	}
+
+	return owner, nil
+}
+
+// validateAlias checks that an alias's name can be typed as a command name,
+// and isn't already used by an installed command, which would always take
+// precedence over it, and that its command line can be parsed.
+func validateAlias(ctx context.Context, alias rest.Alias) error {
+	if strings.ContainsRune(alias.Name, ':') || strings.IndexFunc(alias.Name, unicode.IsSpace) >= 0 {
+		return gerrs.Wrap(ErrInvalidAliasName, fmt.Errorf("alias names can't contain colons or spaces: %q", alias.Name))
+	}
+
+	tokens, err := command.Tokenize(alias.Command)
+	if err != nil {
+		return gerrs.Wrap(ErrInvalidCommand, err)
+	} else if len(tokens) == 0 {
+		return gerrs.Wrap(ErrInvalidCommand, fmt.Errorf("alias %q has no command", alias.Name))
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return err
+	}
+
+	entries, err := dataAccessLayer.FindCommandEntry(ctx, "", alias.Name)
+	if err != nil {
+		return err
+	} else if len(entries) > 0 {
+		return gerrs.Wrap(ErrAliasCollision, fmt.Errorf("%s:%s", entries[0].Bundle.Name, entries[0].Command.Name))
+	}
+
+	return nil
+}
+
+func addAliasMethodsToRouter(router *mux.Router) {
+	router.Handle("/v2/aliases", otelhttp.NewHandler(authCommand(handleGetAliases, "alias", "list"), "handleGetAliases")).Methods("GET")
+	router.Handle("/v2/aliases/{scope:user|site}/{name}", otelhttp.NewHandler(authCommand(handleGetAlias, "alias", "info"), "handleGetAlias")).Methods("GET")
+	router.Handle("/v2/aliases/{scope:user|site}/{name}", otelhttp.NewHandler(authCommand(handlePutAlias, "alias", "create"), "handlePutAlias")).Methods("PUT")
+	router.Handle("/v2/aliases/{scope:user|site}/{name}", otelhttp.NewHandler(authCommand(handleDeleteAlias, "alias", "delete"), "handleDeleteAlias")).Methods("DELETE")
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+)
+
+func TestAliases(t *testing.T) {
+	router := createTestRouter()
+
+	const base = "http://example.com/v2/aliases/"
+
+	NewResponseTester("PUT", base+"site/deploy").WithBody(rest.Alias{Command: "echo deploy | echo done"}).WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("PUT", base+"site/deploy").WithBody(rest.Alias{Command: "echo again"}).WithStatus(http.StatusConflict).Test(t, router)
+	NewResponseTester("PUT", base+"user/deploy").WithBody(rest.Alias{Command: "echo mine"}).WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("PUT", base+"user/deploy?user=nobody").WithBody(rest.Alias{Command: "echo mine"}).WithStatus(http.StatusNotFound).Test(t, router)
+
+	var alias rest.Alias
+	NewResponseTester("GET", base+"user/deploy").WithStatus(http.StatusOK).WithOutput(&alias).Test(t, router)
+	assert.Equal(t, rest.Alias{Name: "deploy", Command: "echo mine", Scope: rest.AliasScopeUser, Owner: "admin"}, alias)
+
+	// Names of installed commands, and bundle-qualified names, can't be used.
+	NewResponseTester("PUT", base+"user/whoami").WithBody(rest.Alias{Command: "echo mine"}).WithStatus(http.StatusConflict).Test(t, router)
+	NewResponseTester("PUT", base+"user/gort:deploy").WithBody(rest.Alias{Command: "echo mine"}).WithStatus(http.StatusExpectationFailed).Test(t, router)
+	NewResponseTester("PUT", base+"user/empty").WithBody(rest.Alias{}).WithStatus(http.StatusExpectationFailed).Test(t, router)
+
+	// Other users may only see, and manage, their own and site aliases.
+	ctx := context.Background()
+	dataAccessLayer, err := dataaccess.Get()
+	require.NoError(t, err)
+	require.NoError(t, dataAccessLayer.UserCreate(ctx, rest.User{Username: "other", Email: "other@getgort.io"}))
+	token, err := dataAccessLayer.TokenGenerate(ctx, "other", time.Minute)
+	require.NoError(t, err)
+
+	other := func(method, target string) ResponseTester {
+		return NewResponseTester(method, target).WithHeader("X-Session-Token", token.Token)
+	}
+
+	other("PUT", base+"user/deploy").WithBody(rest.Alias{Command: "echo theirs"}).WithStatus(http.StatusOK).Test(t, router)
+	other("PUT", base+"site/other").WithBody(rest.Alias{Command: "echo theirs"}).WithStatus(http.StatusUnauthorized).Test(t, router)
+	other("DELETE", base+"site/deploy").WithStatus(http.StatusUnauthorized).Test(t, router)
+	other("GET", base+"user/deploy?user=admin").WithStatus(http.StatusUnauthorized).Test(t, router)
+	other("GET", base+"site/deploy").WithStatus(http.StatusOK).Test(t, router)
+
+	var aliases []rest.Alias
+	other("GET", "http://example.com/v2/aliases").WithStatus(http.StatusOK).WithOutput(&aliases).Test(t, router)
+	require.Len(t, aliases, 2)
+	assert.Equal(t, rest.AliasScopeSite, aliases[0].Scope)
+	assert.Equal(t, "other", aliases[1].Owner)
+
+	aliases = nil
+	NewResponseTester("GET", "http://example.com/v2/aliases").WithStatus(http.StatusOK).WithOutput(&aliases).Test(t, router)
+	require.Len(t, aliases, 3)
+
+	NewResponseTester("DELETE", base+"user/deploy?user=other").WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("DELETE", base+"user/deploy?user=other").WithStatus(http.StatusNotFound).Test(t, router)
+	NewResponseTester("DELETE", base+"site/deploy").WithStatus(http.StatusOK).Test(t, router)
+}
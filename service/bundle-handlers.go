@@ -51,6 +51,10 @@ func handleGetBundles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range bundles {
+		bundles[i] = redactBundle(bundles[i])
+	}
+
 	json.NewEncoder(w).Encode(bundles)
 }
 
@@ -97,6 +101,10 @@ func handleGetBundleVersions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range bundles {
+		bundles[i] = redactBundle(bundles[i])
+	}
+
 	json.NewEncoder(w).Encode(bundles)
 }
 
@@ -137,7 +145,7 @@ func handleGetBundleVersion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(bundle)
+	json.NewEncoder(w).Encode(redactBundle(bundle))
 }
 
 // handleHeadBundleVersion handles "HEAD /v2/bundles/{name}/versions/{version}"
@@ -248,6 +256,31 @@ func handlePutBundleVersion(w http.ResponseWriter, r *http.Request) {
 	bundle.Name = params["name"]
 	bundle.Version = params["version"]
 
+	for name, cmd := range bundle.Commands {
+		cmd.Name = name
+		if err := cmd.ValidateSchema(); err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+		if err := cmd.ValidateTriggers(); err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+	}
+
+	if err := bundle.ValidateLimits(); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	// Installing a bundle mustn't let a user run commands as someone else.
+	for _, webhook := range bundle.Webhooks {
+		if err := authorizeRunAs(r, webhook.User); err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+	}
+
 	dataAccessLayer, err := dataaccess.Get()
 	if err != nil {
 		respondAndLogError(r.Context(), w, err)
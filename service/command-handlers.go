@@ -0,0 +1,62 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/getgort/gort/data/rest"
+	gerrs "github.com/getgort/gort/errors"
+)
+
+// handlePostCommandExecute handles "POST /v2/commands/execute"
+func handlePostCommandExecute(w http.ResponseWriter, r *http.Request) {
+	var execution rest.CommandExecution
+
+	err := json.NewDecoder(r.Body).Decode(&execution)
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.ErrUnmarshal)
+		return
+	}
+
+	user, err := getUserByRequest(r)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	envelope, err := executeCommandLine(r.Context(), execution.Command, user)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	// Errors don't survive JSON encoding, but WithError has already copied
+	// the message into the response.
+	envelope.Data.Error = nil
+
+	json.NewEncoder(w).Encode(envelope)
+}
+
+func addCommandMethodsToRouter(router *mux.Router) {
+	// Permissions are checked against the rules of the command being executed.
+	router.Handle("/v2/commands/execute", otelhttp.NewHandler(http.HandlerFunc(handlePostCommandExecute), "handlePostCommandExecute")).Methods("POST")
+}
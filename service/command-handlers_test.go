@@ -0,0 +1,68 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+)
+
+func TestPostCommandExecute(t *testing.T) {
+	router := createTestRouter()
+
+	const url = "http://example.com/v2/commands/execute"
+
+	var envelope data.CommandResponseEnvelope
+	NewResponseTester("POST", url).
+		WithBody(rest.CommandExecution{Command: "gort:whoami"}).
+		WithStatus(http.StatusOK).
+		WithOutput(&envelope).
+		Test(t, router)
+
+	assert.NotZero(t, envelope.Request.RequestID)
+	assert.Equal(t, "gort", envelope.Request.Bundle.Name)
+	assert.Equal(t, "admin", envelope.Request.UserName)
+
+	envelope = data.CommandResponseEnvelope{}
+	NewResponseTester("POST", url).
+		WithBody(rest.CommandExecution{Command: "whoami | gort:whoami"}).
+		WithStatus(http.StatusOK).
+		WithOutput(&envelope).
+		Test(t, router)
+
+	assert.NotZero(t, envelope.Request.RequestID)
+
+	tests := map[string]int{
+		"nosuch:command":     http.StatusNotFound,
+		`gort:whoami "oops`:  http.StatusExpectationFailed,
+		"gort:whoami > me":   http.StatusExpectationFailed,
+		"gort:whoami |":      http.StatusExpectationFailed,
+		"gort:whoami | nope": http.StatusNotFound,
+	}
+
+	for line, status := range tests {
+		NewResponseTester("POST", url).
+			WithBody(rest.CommandExecution{Command: line}).
+			WithStatus(status).
+			Test(t, router, line)
+	}
+}
@@ -0,0 +1,264 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getgort/gort/auth"
+	"github.com/getgort/gort/command"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	gerrs "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/relay"
+	"github.com/getgort/gort/rules"
+)
+
+// commandQueueSize is the number of asynchronous command requests that can
+// be waiting to be picked up from CommandRequests.
+const commandQueueSize = 64
+
+// commandRequests carries requests for commands that were started through
+// the API and whose output is to be sent to a chat channel.
+var commandRequests = make(chan data.CommandRequest, commandQueueSize)
+
+// CommandRequests returns the channel on which requests for commands that
+// were started through the API, and whose output is to be sent to a chat
+// channel, are emitted. They should be forwarded to the relay along with the
+// requests that come from the chat adapters, so that their responses are
+// routed back to the right adapter and channel.
+func CommandRequests() <-chan data.CommandRequest {
+	return commandRequests
+}
+
+// executeCommand executes a bundle command on behalf of a user, after
+// checking that the user is permitted to do so. If channelID is empty the
+// command is executed synchronously and its result is returned. Otherwise
+// the request is queued, its output will be sent to the channel through the
+// named adapter, and the returned result has only a request ID.
+func executeCommand(ctx context.Context, bundleName, commandName string, params []string, username, adapter, channelID string) (rest.CommandResult, error) {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return rest.CommandResult{}, err
+	}
+
+	entry, err := getCommandEntry(ctx, bundleName, commandName)
+	if err != nil {
+		return rest.CommandResult{}, err
+	}
+
+	user, err := dataAccessLayer.UserGet(ctx, username)
+	if err != nil {
+		return rest.CommandResult{}, err
+	}
+
+	cmdInput, err := entry.Parse(append([]string{entry.Bundle.Name + ":" + entry.Command.Name}, params...))
+	if err != nil {
+		return rest.CommandResult{}, err
+	}
+
+	if err := checkCommandPermission(ctx, user, entry, cmdInput); err != nil {
+		return rest.CommandResult{}, err
+	}
+
+	// Asynchronous requests outlive the API call that started them.
+	if channelID != "" {
+		ctx = context.Background()
+	}
+
+	request := newCommandRequest(ctx, entry, entry.Parameters(cmdInput), user, adapter, channelID)
+
+	if err := dataAccessLayer.RequestBegin(ctx, &request); err != nil {
+		return rest.CommandResult{}, err
+	}
+
+	result := rest.CommandResult{RequestID: request.RequestID}
+
+	if channelID != "" {
+		select {
+		case commandRequests <- request:
+			return result, nil
+		default:
+			dataAccessLayer.RequestError(ctx, request, ErrCommandQueueFull)
+			return rest.CommandResult{}, ErrCommandQueueFull
+		}
+	}
+
+	envelope := relay.Execute(ctx, request)
+
+	result.ExitCode = &envelope.Data.ExitCode
+	result.Duration = envelope.Data.Duration
+	result.Output = envelope.Response.Lines
+	if envelope.Data.Error != nil {
+		result.Error = envelope.Data.Error.Error()
+	}
+
+	return result, nil
+}
+
+// executeCommandLine executes a command line, which may be a pipeline, on
+// behalf of a user and waits for it to complete. Every command in the
+// pipeline is checked against the user's permissions before any of them is
+// executed.
+func executeCommandLine(ctx context.Context, line string, user rest.User) (data.CommandResponseEnvelope, error) {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return data.CommandResponseEnvelope{}, err
+	}
+
+	tokens, err := command.Tokenize(line)
+	if err != nil {
+		return data.CommandResponseEnvelope{}, gerrs.Wrap(ErrInvalidCommand, err)
+	}
+
+	tokens, targets, err := command.SplitRedirect(tokens)
+	if err != nil {
+		return data.CommandResponseEnvelope{}, gerrs.Wrap(ErrInvalidCommand, err)
+	}
+	if len(targets) > 0 {
+		return data.CommandResponseEnvelope{}, gerrs.Wrap(ErrInvalidCommand, fmt.Errorf("output can't be redirected"))
+	}
+
+	stages, err := command.SplitPipeline(tokens)
+	if err != nil {
+		return data.CommandResponseEnvelope{}, gerrs.Wrap(ErrInvalidCommand, err)
+	}
+
+	var request data.CommandRequest
+	last := &request
+
+	for i, stage := range stages {
+		cmdInput, err := command.Parse(stage)
+		if err != nil {
+			return data.CommandResponseEnvelope{}, gerrs.Wrap(ErrInvalidCommand, err)
+		}
+
+		entry, err := getCommandEntry(ctx, cmdInput.Bundle, cmdInput.Command)
+		if err != nil {
+			return data.CommandResponseEnvelope{}, err
+		}
+
+		stage[0] = entry.Bundle.Name + ":" + entry.Command.Name
+		cmdInput, err = entry.Parse(stage)
+		if err != nil {
+			return data.CommandResponseEnvelope{}, err
+		}
+
+		if err := checkCommandPermission(ctx, user, entry, cmdInput); err != nil {
+			return data.CommandResponseEnvelope{}, err
+		}
+
+		params := entry.Parameters(cmdInput)
+
+		if i == 0 {
+			request = newCommandRequest(ctx, entry, params, user, "", "")
+			continue
+		}
+
+		next := newCommandRequest(ctx, entry, params, user, "", "")
+		last.Next = &next
+		last = &next
+	}
+
+	if err := dataAccessLayer.RequestBegin(ctx, &request); err != nil {
+		return data.CommandResponseEnvelope{}, err
+	}
+
+	// All stages share the first stage's request ID.
+	for next := request.Next; next != nil; next = next.Next {
+		next.RequestID = request.RequestID
+	}
+
+	return relay.Execute(ctx, request), nil
+}
+
+// getCommandEntry returns the entry for a command in an enabled bundle. The
+// bundle name may be empty, in which case the command must be unambiguous.
+func getCommandEntry(ctx context.Context, bundleName, commandName string) (data.CommandEntry, error) {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return data.CommandEntry{}, err
+	}
+
+	cmd := commandName
+	if bundleName != "" {
+		cmd = bundleName + ":" + commandName
+	}
+
+	entries, err := dataAccessLayer.FindCommandEntry(ctx, bundleName, commandName)
+	switch {
+	case err != nil:
+		return data.CommandEntry{}, err
+	case len(entries) == 0:
+		return data.CommandEntry{}, gerrs.Wrap(ErrNoSuchCommand, fmt.Errorf("%s", cmd))
+	case len(entries) > 1:
+		return data.CommandEntry{}, gerrs.Wrap(ErrMultipleCommands, fmt.Errorf("%s", cmd))
+	}
+
+	return entries[0], nil
+}
+
+// newCommandRequest builds a request to execute a command on behalf of a
+// user. If adapter is set, the user's ID for that adapter is included.
+func newCommandRequest(ctx context.Context, entry data.CommandEntry, params []string, user rest.User, adapter, channelID string) data.CommandRequest {
+	return data.CommandRequest{
+		CommandEntry: entry,
+		Adapter:      adapter,
+		ChannelID:    channelID,
+		Context:      ctx,
+		Parameters:   params,
+		Timestamp:    time.Now(),
+		UserEmail:    user.Email,
+		UserID:       user.Mappings[adapter],
+		UserName:     user.Username,
+	}
+}
+
+// checkCommandPermission returns an ErrUnauthorized error if the user isn't
+// permitted to execute the command as parsed. Rules are evaluated just as
+// they are for commands typed in chat.
+func checkCommandPermission(ctx context.Context, user rest.User, entry data.CommandEntry, cmdInput command.Command) error {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return err
+	}
+
+	perms, err := dataAccessLayer.UserPermissionList(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := auth.EvaluateCommandEntry(
+		perms.Strings(),
+		entry,
+		rules.EvaluationEnvironment{
+			"option": cmdInput.OptionsValues(),
+			"arg":    cmdInput.Parameters,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return gerrs.Wrap(ErrUnauthorized, fmt.Errorf("user %s may not execute %s:%s", user.Username, entry.Bundle.Name, entry.Command.Name))
+	}
+
+	return nil
+}
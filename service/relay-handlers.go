@@ -0,0 +1,228 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	"github.com/getgort/gort/relay"
+)
+
+// manageRelaysPermission is required to connect as a remote relay.
+const manageRelaysPermission = "gort:manage_relays"
+
+var relayUpgrader = websocket.Upgrader{}
+
+// handleDeleteRelayGroup handles "DELETE /v2/relay-groups/{groupname}"
+func handleDeleteRelayGroup(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.RelayGroupDelete(r.Context(), params["groupname"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handleDeleteRelayGroupBundle handles "DELETE /v2/relay-groups/{groupname}/bundles/{bundlename}"
+func handleDeleteRelayGroupBundle(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.RelayGroupBundleDelete(r.Context(), params["groupname"], params["bundlename"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handleDeleteRelayGroupRelay handles "DELETE /v2/relay-groups/{groupname}/relays/{relayname}"
+func handleDeleteRelayGroupRelay(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.RelayGroupRelayDelete(r.Context(), params["groupname"], params["relayname"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handleGetRelayGroup handles "GET /v2/relay-groups/{groupname}"
+func handleGetRelayGroup(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	group, err := dataAccessLayer.RelayGroupGet(r.Context(), params["groupname"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(group)
+}
+
+// handleGetRelayGroups handles "GET /v2/relay-groups"
+func handleGetRelayGroups(w http.ResponseWriter, r *http.Request) {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	groups, err := dataAccessLayer.RelayGroupList(r.Context())
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(groups)
+}
+
+// handleGetRelays handles "GET /v2/relays"
+func handleGetRelays(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(relay.ConnectedRelays())
+}
+
+// handlePutRelayGroup handles "PUT /v2/relay-groups/{groupname}". Relay
+// groups have no mutable fields, so this is a no-op if the group exists.
+func handlePutRelayGroup(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	group := rest.RelayGroup{Name: params["groupname"]}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	exists, err := dataAccessLayer.RelayGroupExists(r.Context(), group.Name)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+	if exists {
+		return
+	}
+
+	err = dataAccessLayer.RelayGroupCreate(r.Context(), group)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handlePutRelayGroupBundle handles "PUT /v2/relay-groups/{groupname}/bundles/{bundlename}"
+func handlePutRelayGroupBundle(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.RelayGroupBundleAdd(r.Context(), params["groupname"], params["bundlename"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handlePutRelayGroupRelay handles "PUT /v2/relay-groups/{groupname}/relays/{relayname}"
+func handlePutRelayGroupRelay(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.RelayGroupRelayAdd(r.Context(), params["groupname"], params["relayname"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handleRelayConnect handles "GET /v2/relays/connect". The connection is
+// upgraded to a websocket and held open for as long as the relay remains
+// connected.
+func handleRelayConnect(w http.ResponseWriter, r *http.Request) {
+	conn, err := relayUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response.
+		log.WithError(err).Warn("Failed to upgrade relay connection")
+		return
+	}
+
+	if err := relay.Serve(r.Context(), conn); err != nil {
+		log.WithError(err).
+			WithField("addr", r.RemoteAddr).
+			Debug("Relay connection closed")
+	}
+}
+
+func addRelayMethodsToRouter(router *mux.Router) {
+	// Relay connections
+	router.Handle("/v2/relays", otelhttp.NewHandler(authCommand(handleGetRelays, "relay-group", "info"), "handleGetRelays")).Methods("GET")
+	router.Handle("/v2/relays/connect", otelhttp.NewHandler(authPermission(handleRelayConnect, manageRelaysPermission), "handleRelayConnect")).Methods("GET")
+
+	// Basic relay group methods
+	router.Handle("/v2/relay-groups", otelhttp.NewHandler(authCommand(handleGetRelayGroups, "relay-group", "list"), "handleGetRelayGroups")).Methods("GET")
+	router.Handle("/v2/relay-groups/{groupname}", otelhttp.NewHandler(authCommand(handleGetRelayGroup, "relay-group", "info"), "handleGetRelayGroup")).Methods("GET")
+	router.Handle("/v2/relay-groups/{groupname}", otelhttp.NewHandler(authCommand(handlePutRelayGroup, "relay-group", "create"), "handlePutRelayGroup")).Methods("PUT")
+	router.Handle("/v2/relay-groups/{groupname}", otelhttp.NewHandler(authCommand(handleDeleteRelayGroup, "relay-group", "delete"), "handleDeleteRelayGroup")).Methods("DELETE")
+
+	// Relay group membership
+	router.Handle("/v2/relay-groups/{groupname}/relays/{relayname}", otelhttp.NewHandler(authCommand(handlePutRelayGroupRelay, "relay-group", "add"), "handlePutRelayGroupRelay")).Methods("PUT")
+	router.Handle("/v2/relay-groups/{groupname}/relays/{relayname}", otelhttp.NewHandler(authCommand(handleDeleteRelayGroupRelay, "relay-group", "remove"), "handleDeleteRelayGroupRelay")).Methods("DELETE")
+
+	// Relay group bundle assignment
+	router.Handle("/v2/relay-groups/{groupname}/bundles/{bundlename}", otelhttp.NewHandler(authCommand(handlePutRelayGroupBundle, "relay-group", "assign"), "handlePutRelayGroupBundle")).Methods("PUT")
+	router.Handle("/v2/relay-groups/{groupname}/bundles/{bundlename}", otelhttp.NewHandler(authCommand(handleDeleteRelayGroupBundle, "relay-group", "unassign"), "handleDeleteRelayGroupBundle")).Methods("DELETE")
+}
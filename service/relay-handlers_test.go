@@ -0,0 +1,95 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+)
+
+func TestRelayGroupLifecycle(t *testing.T) {
+	router := createTestRouter()
+
+	// Create relay group
+	NewResponseTester("PUT", "http://example.com/v2/relay-groups/relayGroupTestLifecycle").WithStatus(http.StatusOK).Test(t, router)
+
+	// Add relays
+	NewResponseTester("PUT", "http://example.com/v2/relay-groups/relayGroupTestLifecycle/relays/relay-b").WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("PUT", "http://example.com/v2/relay-groups/relayGroupTestLifecycle/relays/relay-a").WithStatus(http.StatusOK).Test(t, router)
+
+	// Assign the default bundle
+	NewResponseTester("PUT", "http://example.com/v2/relay-groups/relayGroupTestLifecycle/bundles/gort").WithStatus(http.StatusOK).Test(t, router)
+
+	group := rest.RelayGroup{}
+	NewResponseTester("GET", "http://example.com/v2/relay-groups/relayGroupTestLifecycle").WithOutput(&group).WithStatus(http.StatusOK).Test(t, router)
+	assert.Equal(t, []string{"relay-a", "relay-b"}, group.Relays)
+	assert.Equal(t, []string{"gort"}, group.Bundles)
+
+	// Remove a relay and unassign the bundle
+	NewResponseTester("DELETE", "http://example.com/v2/relay-groups/relayGroupTestLifecycle/relays/relay-a").WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("DELETE", "http://example.com/v2/relay-groups/relayGroupTestLifecycle/bundles/gort").WithStatus(http.StatusOK).Test(t, router)
+
+	groups := []rest.RelayGroup{}
+	NewResponseTester("GET", "http://example.com/v2/relay-groups").WithOutput(&groups).WithStatus(http.StatusOK).Test(t, router)
+	assert.Equal(t, []rest.RelayGroup{{Name: "relayGroupTestLifecycle", Relays: []string{"relay-b"}}}, groups)
+
+	// Delete relay group
+	NewResponseTester("DELETE", "http://example.com/v2/relay-groups/relayGroupTestLifecycle").WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("GET", "http://example.com/v2/relay-groups/relayGroupTestLifecycle").WithStatus(http.StatusNotFound).Test(t, router)
+}
+
+func TestRelayGroupInvalid(t *testing.T) {
+	router := createTestRouter()
+
+	// No such relay group
+	NewResponseTester("PUT", "http://example.com/v2/relay-groups/relayGroupTestInvalid/relays/relay-a").WithStatus(http.StatusNotFound).Test(t, router)
+
+	NewResponseTester("PUT", "http://example.com/v2/relay-groups/relayGroupTestInvalid").WithStatus(http.StatusOK).Test(t, router)
+
+	// No such bundle
+	NewResponseTester("PUT", "http://example.com/v2/relay-groups/relayGroupTestInvalid/bundles/nope").WithStatus(http.StatusNotFound).Test(t, router)
+
+	// No such member
+	NewResponseTester("DELETE", "http://example.com/v2/relay-groups/relayGroupTestInvalid/relays/relay-a").WithStatus(http.StatusNotFound).Test(t, router)
+}
+
+func TestRelayConnectRequiresPermission(t *testing.T) {
+	router := createTestRouter()
+
+	ctx := context.Background()
+	dataAccessLayer, err := dataaccess.Get()
+	require.NoError(t, err)
+	require.NoError(t, dataAccessLayer.UserCreate(ctx, rest.User{Username: "other", Email: "other@getgort.io"}))
+	token, err := dataAccessLayer.TokenGenerate(ctx, "other", time.Minute)
+	require.NoError(t, err)
+
+	NewResponseTester("GET", "http://example.com/v2/relays/connect").
+		WithHeader("X-Session-Token", token.Token).
+		WithStatus(http.StatusUnauthorized).Test(t, router)
+
+	// The admin is permitted, but this isn't a websocket request.
+	NewResponseTester("GET", "http://example.com/v2/relays/connect").
+		WithStatus(http.StatusBadRequest).Test(t, router)
+}
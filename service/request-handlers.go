@@ -0,0 +1,321 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/getgort/gort/auth"
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	"github.com/getgort/gort/dataaccess/errs"
+	gerrs "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/relay"
+	"github.com/getgort/gort/templates"
+)
+
+// cancelAnyPermission allows a user to cancel requests made by other users.
+const cancelAnyPermission = "gort:cancel_any"
+
+// viewAuditPermission allows a user to view the audit log, including the
+// output of requests made by other users.
+const viewAuditPermission = "gort:view_audit"
+
+// defaultAuditLimit is the number of records returned by "GET /v2/requests"
+// if the caller doesn't specify a limit.
+const defaultAuditLimit = 100
+
+// ErrInvalidFilter is returned when an audit log query parameter can't be
+// parsed.
+var ErrInvalidFilter = errors.New("invalid filter value")
+
+// handleDeleteRequest handles "DELETE /v2/requests/{id}"
+func handleDeleteRequest(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	id, err := strconv.ParseInt(params["id"], 10, 64)
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.Wrap(relay.ErrNoSuchRequest, err))
+		return
+	}
+
+	// Resolve the user first, so that the existence of a request isn't
+	// revealed to a caller who couldn't otherwise act on it.
+	user, err := getUserByRequest(r)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	request, ok := relay.InFlight(id)
+	if !ok {
+		respondAndLogError(r.Context(), w, relay.ErrNoSuchRequest)
+		return
+	}
+
+	// Users may always cancel their own requests.
+	if request.UserName != user.Username {
+		permitted, err := auth.UserHasPermission(r.Context(), user.Username, cancelAnyPermission)
+		if err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+
+		if !permitted {
+			respondAndLogError(r.Context(), w, ErrUnauthorized)
+			return
+		}
+	}
+
+	if err := relay.Cancel(id); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handleGetRequest handles "GET /v2/requests/{id}"
+func handleGetRequest(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	id, err := strconv.ParseInt(params["id"], 10, 64)
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.Wrap(errs.ErrNoSuchRequest, err))
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	record, err := dataAccessLayer.RequestGet(r.Context(), id)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(record)
+}
+
+// handleGetRequestOutput handles "GET /v2/requests/{id}/output"
+func handleGetRequestOutput(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	id, err := strconv.ParseInt(params["id"], 10, 64)
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.Wrap(errs.ErrNoSuchRequest, err))
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	record, err := dataAccessLayer.RequestGet(r.Context(), id)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	user, err := getUserByRequest(r)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	// Users may always view the output of their own requests.
+	if record.UserName != user.Username {
+		permitted, err := auth.UserHasPermission(r.Context(), user.Username, viewAuditPermission)
+		if err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+
+		if !permitted {
+			respondAndLogError(r.Context(), w, ErrUnauthorized)
+			return
+		}
+	}
+
+	output, err := dataAccessLayer.RequestOutputGet(r.Context(), id)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	rendered, err := renderOutput(r.Context(), record, output)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rest.RequestOutput{
+		RequestID: id,
+		Output:    output,
+		Rendered:  rendered,
+	})
+}
+
+// handleGetRequests handles "GET /v2/requests"
+func handleGetRequests(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	records, err := dataAccessLayer.RequestList(r.Context(), filter)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(records)
+}
+
+// parseAuditFilter builds an audit log filter from the request's query
+// parameters: user, bundle, command, adapter, channel, status, since, until,
+// limit, and offset. Times are in RFC 3339 format.
+func parseAuditFilter(r *http.Request) (rest.AuditFilter, error) {
+	q := r.URL.Query()
+
+	filter := rest.AuditFilter{
+		UserName:    q.Get("user"),
+		BundleName:  q.Get("bundle"),
+		CommandName: q.Get("command"),
+		Adapter:     q.Get("adapter"),
+		ChannelID:   q.Get("channel"),
+		Limit:       defaultAuditLimit,
+	}
+
+	if v := q.Get("status"); v != "" {
+		code, err := strconv.ParseInt(v, 10, 16)
+		if err != nil {
+			return filter, gerrs.Wrap(ErrInvalidFilter, err)
+		}
+		c := int16(code)
+		filter.ExitCode = &c
+	}
+
+	for key, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(key); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, gerrs.Wrap(ErrInvalidFilter, err)
+			}
+			*t = parsed
+		}
+	}
+
+	for key, n := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if v := q.Get(key); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 0 {
+				return filter, gerrs.Wrap(ErrInvalidFilter, fmt.Errorf("%s: %q", key, v))
+			}
+			*n = parsed
+		}
+	}
+
+	return filter, nil
+}
+
+// renderOutput transforms stored output using the templates of the command
+// that produced it, exactly as if it had just been executed. If the command's
+// bundle has since been uninstalled the configured or default templates are
+// used instead.
+func renderOutput(ctx context.Context, record rest.AuditRecord, output string) (string, error) {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return "", err
+	}
+
+	bundle, err := dataAccessLayer.BundleGet(ctx, record.BundleName, record.BundleVersion)
+	if err != nil {
+		bundle = data.Bundle{Name: record.BundleName, Version: record.BundleVersion}
+	}
+
+	command := data.BundleCommand{Name: record.CommandName}
+	if c, ok := bundle.Commands[record.CommandName]; ok {
+		command = *c
+	}
+
+	// Records written before the parameter list was stored only have the
+	// joined parameters, which is the best that can be done for them.
+	params := record.ParameterList
+	if len(params) == 0 {
+		params = strings.Fields(record.Parameters)
+	}
+
+	request := data.CommandRequest{
+		CommandEntry: data.CommandEntry{Bundle: bundle, Command: command},
+		Adapter:      record.Adapter,
+		ChannelID:    record.ChannelID,
+		Parameters:   params,
+		RequestID:    record.RequestID,
+		Timestamp:    record.Timestamp,
+		UserID:       record.UserID,
+		UserEmail:    record.UserEmail,
+		UserName:     record.UserName,
+	}
+
+	lines := strings.Split(output, "\n")
+	opts := []data.CommandResponseEnvelopeOption{}
+	tt := data.Command
+
+	if record.ExitCode != nil && *record.ExitCode != 0 {
+		opts = append(opts, data.WithError("Command Error", errors.New(strings.Join(lines, " ")), *record.ExitCode))
+		tt = data.CommandError
+	}
+	opts = append(opts, data.WithResponseLines(lines))
+
+	envelope := data.NewCommandResponseEnvelope(request, opts...)
+
+	template, err := templates.Get(command, bundle, tt)
+	if err != nil {
+		return "", err
+	}
+
+	return templates.Transform(template, envelope)
+}
+
+func addRequestMethodsToRouter(router *mux.Router) {
+	router.Handle("/v2/requests", otelhttp.NewHandler(authCommand(handleGetRequests, "audit", "list"), "handleGetRequests")).Methods("GET")
+	router.Handle("/v2/requests/{id}", otelhttp.NewHandler(authCommand(handleGetRequest, "audit", "info"), "handleGetRequest")).Methods("GET")
+	router.Handle("/v2/requests/{id}/output", otelhttp.NewHandler(authCommand(handleGetRequestOutput, "output"), "handleGetRequestOutput")).Methods("GET")
+	router.Handle("/v2/requests/{id}", otelhttp.NewHandler(authCommand(handleDeleteRequest, "cancel"), "handleDeleteRequest")).Methods("DELETE")
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+)
+
+func TestDeleteRequestNotFound(t *testing.T) {
+	router := createTestRouter()
+
+	NewResponseTester("DELETE", "http://example.com/v2/requests/999").WithStatus(http.StatusNotFound).Test(t, router)
+	NewResponseTester("DELETE", "http://example.com/v2/requests/nope").WithStatus(http.StatusNotFound).Test(t, router)
+
+	// Without a valid session the request's existence isn't checked.
+	NewResponseTester("DELETE", "http://example.com/v2/requests/999").WithHeader("X-Session-Token", "bogus").WithStatus(http.StatusUnauthorized).Test(t, router)
+}
+
+func TestGetRequests(t *testing.T) {
+	router := createTestRouter()
+
+	ctx := context.Background()
+	da, err := dataaccess.Get()
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, user := range []string{"alice", "bob", "alice"} {
+		req := data.CommandRequest{
+			CommandEntry: data.CommandEntry{
+				Bundle:  data.Bundle{Name: "test", Version: "0.0.1"},
+				Command: data.BundleCommand{Name: "echo"},
+			},
+			Adapter:   "testAdapter",
+			Timestamp: time.Now(),
+			UserName:  user,
+		}
+		require.NoError(t, da.RequestBegin(ctx, &req))
+		ids = append(ids, req.RequestID)
+	}
+
+	var records []rest.AuditRecord
+	NewResponseTester("GET", "http://example.com/v2/requests?user=alice").WithStatus(http.StatusOK).WithOutput(&records).Test(t, router)
+	require.Len(t, records, 2)
+	assert.Equal(t, ids[2], records[0].RequestID)
+	assert.Equal(t, ids[0], records[1].RequestID)
+
+	records = nil
+	NewResponseTester("GET", "http://example.com/v2/requests?limit=1&offset=1").WithStatus(http.StatusOK).WithOutput(&records).Test(t, router)
+	require.Len(t, records, 1)
+	assert.Equal(t, ids[1], records[0].RequestID)
+
+	var record rest.AuditRecord
+	url := fmt.Sprintf("http://example.com/v2/requests/%d", ids[1])
+	NewResponseTester("GET", url).WithStatus(http.StatusOK).WithOutput(&record).Test(t, router)
+	assert.Equal(t, "bob", record.UserName)
+	assert.Equal(t, "echo", record.CommandName)
+
+	NewResponseTester("GET", "http://example.com/v2/requests/999").WithStatus(http.StatusNotFound).Test(t, router)
+	NewResponseTester("GET", "http://example.com/v2/requests?status=x").WithStatus(http.StatusExpectationFailed).Test(t, router)
+	NewResponseTester("GET", "http://example.com/v2/requests?since=yesterday").WithStatus(http.StatusExpectationFailed).Test(t, router)
+}
+
+func TestGetRequestOutput(t *testing.T) {
+	router := createTestRouter()
+
+	ctx := context.Background()
+	da, err := dataaccess.Get()
+	require.NoError(t, err)
+
+	req := data.CommandRequest{
+		CommandEntry: data.CommandEntry{
+			Bundle:  data.Bundle{Name: "test", Version: "0.0.1"},
+			Command: data.BundleCommand{Name: "echo"},
+		},
+		Timestamp: time.Now(),
+		UserName:  "admin",
+	}
+	require.NoError(t, da.RequestBegin(ctx, &req))
+
+	url := fmt.Sprintf("http://example.com/v2/requests/%d/output", req.RequestID)
+
+	// Nothing has been stored yet.
+	NewResponseTester("GET", url).WithStatus(http.StatusNotFound).Test(t, router)
+
+	require.NoError(t, da.RequestOutputSet(ctx, req.RequestID, "hello"))
+
+	var output rest.RequestOutput
+	NewResponseTester("GET", url).WithStatus(http.StatusOK).WithOutput(&output).Test(t, router)
+	assert.Equal(t, req.RequestID, output.RequestID)
+	assert.Equal(t, "hello", output.Output)
+	assert.Contains(t, output.Rendered, "hello")
+
+	NewResponseTester("GET", "http://example.com/v2/requests/999/output").WithStatus(http.StatusNotFound).Test(t, router)
+}
+
+func TestGetRequestOutputParameters(t *testing.T) {
+	router := createTestRouter()
+
+	ctx := context.Background()
+	da, err := dataaccess.Get()
+	require.NoError(t, err)
+
+	command := data.BundleCommand{
+		Name:      "echo",
+		Rules:     []string{"allow"},
+		Templates: data.Templates{Command: `{{ text }}{{ range .Request.Parameters }}<{{ . }}>{{ end }}{{ endtext }}`},
+	}
+	bundle := data.Bundle{
+		GortBundleVersion: 1,
+		Name:              "test",
+		Version:           "0.0.1",
+		Description:       "a test bundle",
+		Commands:          map[string]*data.BundleCommand{"echo": &command},
+	}
+	require.NoError(t, da.BundleCreate(ctx, bundle))
+
+	req := data.CommandRequest{
+		CommandEntry: data.CommandEntry{Bundle: bundle, Command: command},
+		Parameters:   []string{"foo bar", "baz"},
+		Timestamp:    time.Now(),
+		UserName:     "admin",
+	}
+	require.NoError(t, da.RequestBegin(ctx, &req))
+	require.NoError(t, da.RequestOutputSet(ctx, req.RequestID, "hello"))
+
+	// Parameters containing spaces are passed to the template intact.
+	var output rest.RequestOutput
+	url := fmt.Sprintf("http://example.com/v2/requests/%d/output", req.RequestID)
+	NewResponseTester("GET", url).WithStatus(http.StatusOK).WithOutput(&output).Test(t, router)
+	assert.Contains(t, output.Rendered, "<foo bar><baz>")
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+	"github.com/getgort/gort/dataaccess/errs"
+	gerrs "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/scheduler"
+)
+
+// handleDeleteSchedule handles "DELETE /v2/schedules/{id}"
+func handleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := scheduleID(r)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.ScheduleDelete(r.Context(), id)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handleGetSchedules handles "GET /v2/schedules"
+func handleGetSchedules(w http.ResponseWriter, r *http.Request) {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	schedules, err := dataAccessLayer.ScheduleList(r.Context())
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	for i := range schedules {
+		setNextRun(&schedules[i])
+	}
+
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// handlePatchSchedule handles "PATCH /v2/schedules/{id}"
+func handlePatchSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := scheduleID(r)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	paused, err := strconv.ParseBool(r.FormValue("paused"))
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.Wrap(ErrMissingValue, err))
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	schedule, err := dataAccessLayer.ScheduleGet(r.Context(), id)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	// Runs that were missed while the schedule was paused are skipped,
+	// rather than being run as soon as it's resumed.
+	if schedule.Paused && !paused {
+		schedule.LastRun = time.Now()
+	}
+	schedule.Paused = paused
+
+	err = dataAccessLayer.ScheduleUpdate(r.Context(), schedule)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handlePostSchedule handles "POST /v2/schedules"
+func handlePostSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule rest.Schedule
+
+	err := json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.ErrUnmarshal)
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	// Schedules run as the user that created them, unless told otherwise by
+	// someone allowed to act on the other user's behalf.
+	if schedule.UserName == "" {
+		user, err := getUserByRequest(r)
+		if err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+		schedule.UserName = user.Username
+	} else if err := authorizeRunAs(r, schedule.UserName); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	if err := scheduler.Validate(schedule); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	if _, err := dataAccessLayer.UserGet(r.Context(), schedule.UserName); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	schedule.LastRun = time.Time{}
+
+	err = dataAccessLayer.ScheduleCreate(r.Context(), &schedule)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	setNextRun(&schedule)
+
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// scheduleID extracts the schedule ID from a request's path.
+func scheduleID(r *http.Request) (int64, error) {
+	params := mux.Vars(r)
+
+	id, err := strconv.ParseInt(params["id"], 10, 64)
+	if err != nil {
+		return 0, gerrs.Wrap(errs.ErrNoSuchSchedule, err)
+	}
+
+	return id, nil
+}
+
+// setNextRun sets the time that the schedule will next run, if it's not
+// paused.
+func setNextRun(s *rest.Schedule) {
+	if s.Paused {
+		return
+	}
+
+	last := s.LastRun
+	if last.IsZero() {
+		last = s.Created
+	}
+
+	if next, err := scheduler.NextRun(*s, last); err == nil {
+		s.NextRun = next
+	}
+}
+
+func addScheduleMethodsToRouter(router *mux.Router) {
+	router.Handle("/v2/schedules", otelhttp.NewHandler(authCommand(handleGetSchedules, "schedule", "list"), "handleGetSchedules")).Methods("GET")
+	router.Handle("/v2/schedules", otelhttp.NewHandler(authCommand(handlePostSchedule, "schedule", "create"), "handlePostSchedule")).Methods("POST")
+	router.Handle("/v2/schedules/{id}", otelhttp.NewHandler(authCommand(handleDeleteSchedule, "schedule", "delete"), "handleDeleteSchedule")).Methods("DELETE")
+	router.Handle("/v2/schedules/{id}", otelhttp.NewHandler(authCommand(handlePatchSchedule, "schedule", "pause"), "handlePatchSchedule")).Methods("PATCH")
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data/rest"
+)
+
+func TestSchedules(t *testing.T) {
+	router := createTestRouter()
+
+	var created rest.Schedule
+	NewResponseTester("POST", "http://example.com/v2/schedules").
+		WithBody(rest.Schedule{
+			Cron:      "0 9 * * mon-fri",
+			Timezone:  "America/New_York",
+			Command:   "echo hello",
+			Adapter:   "testAdapter",
+			ChannelID: "mychannel",
+		}).
+		WithStatus(http.StatusOK).
+		WithOutput(&created).
+		Test(t, router)
+
+	require.NotZero(t, created.ID)
+	assert.Equal(t, "admin", created.UserName)
+	assert.False(t, created.NextRun.IsZero())
+
+	var schedules []rest.Schedule
+	NewResponseTester("GET", "http://example.com/v2/schedules").WithStatus(http.StatusOK).WithOutput(&schedules).Test(t, router)
+	require.Len(t, schedules, 1)
+	assert.Equal(t, created.ID, schedules[0].ID)
+	assert.Equal(t, "echo hello", schedules[0].Command)
+
+	url := fmt.Sprintf("http://example.com/v2/schedules/%d", created.ID)
+
+	schedules = nil
+	NewResponseTester("PATCH", url+"?paused=true").WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("GET", "http://example.com/v2/schedules").WithStatus(http.StatusOK).WithOutput(&schedules).Test(t, router)
+	require.Len(t, schedules, 1)
+	assert.True(t, schedules[0].Paused)
+	assert.True(t, schedules[0].NextRun.IsZero())
+
+	NewResponseTester("PATCH", url).WithStatus(http.StatusExpectationFailed).Test(t, router)
+
+	NewResponseTester("DELETE", url).WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("DELETE", url).WithStatus(http.StatusNotFound).Test(t, router)
+	NewResponseTester("PATCH", url+"?paused=false").WithStatus(http.StatusNotFound).Test(t, router)
+}
+
+func TestPostScheduleInvalid(t *testing.T) {
+	router := createTestRouter()
+
+	valid := rest.Schedule{
+		Cron:      "@hourly",
+		Command:   "echo hello",
+		Adapter:   "testAdapter",
+		ChannelID: "mychannel",
+	}
+
+	badCron := valid
+	badCron.Cron = "61 * * * *"
+
+	badZone := valid
+	badZone.Timezone = "Mars/Olympus_Mons"
+
+	noCommand := valid
+	noCommand.Command = ""
+
+	noSuchUser := valid
+	noSuchUser.UserName = "nobody"
+
+	for _, s := range []rest.Schedule{badCron, badZone, noCommand} {
+		NewResponseTester("POST", "http://example.com/v2/schedules").WithBody(s).WithStatus(http.StatusExpectationFailed).Test(t, router)
+	}
+
+	NewResponseTester("POST", "http://example.com/v2/schedules").WithBody(noSuchUser).WithStatus(http.StatusNotFound).Test(t, router)
+}
+
+func TestPostScheduleAsOtherUser(t *testing.T) {
+	router := createTestRouter()
+
+	token := createTestUser(t, "scheduler", "gort:manage_schedules")
+
+	schedule := rest.Schedule{
+		Cron:      "@hourly",
+		Command:   "echo hello",
+		Adapter:   "testAdapter",
+		ChannelID: "mychannel",
+		UserName:  "admin",
+	}
+
+	// Only users allowed to manage users may schedule commands as others.
+	NewResponseTester("POST", "http://example.com/v2/schedules").WithBody(schedule).
+		WithHeader("X-Session-Token", token.Token).
+		WithStatus(http.StatusUnauthorized).Test(t, router)
+
+	var created rest.Schedule
+	schedule.UserName = "scheduler"
+	NewResponseTester("POST", "http://example.com/v2/schedules").WithBody(schedule).
+		WithHeader("X-Session-Token", token.Token).
+		WithStatus(http.StatusOK).WithOutput(&created).Test(t, router)
+	assert.Equal(t, "scheduler", created.UserName)
+
+	// The administrator may.
+	NewResponseTester("POST", "http://example.com/v2/schedules").WithBody(schedule).
+		WithStatus(http.StatusOK).Test(t, router)
+}
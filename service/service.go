@@ -17,10 +17,12 @@
 package service
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -37,7 +39,9 @@ import (
 	"github.com/getgort/gort/dataaccess"
 	"github.com/getgort/gort/dataaccess/errs"
 	gerrs "github.com/getgort/gort/errors"
+	"github.com/getgort/gort/relay"
 	"github.com/getgort/gort/rules"
+	"github.com/getgort/gort/scheduler"
 	"github.com/getgort/gort/telemetry"
 	"github.com/getgort/gort/types"
 )
@@ -47,7 +51,21 @@ var (
 
 	ErrNoSuchCommand = errors.New("no such command")
 
+	ErrMultipleCommands = errors.New("multiple commands match")
+
+	ErrInvalidCommand = errors.New("invalid command")
+
 	ErrGortBundleDisabled = errors.New("gort bundle disabled")
+
+	ErrCommandQueueFull = errors.New("command queue is full")
+
+	ErrInvalidWebhookParameter = errors.New("invalid webhook parameter")
+
+	ErrInvalidAliasName = errors.New("invalid alias name")
+
+	ErrAliasCollision = errors.New("alias name is used by a command")
+
+	ErrNoSuchAdapter = errors.New("no such adapter")
 )
 
 // RequestEvent represents a request of a service endpoint.
@@ -100,6 +118,18 @@ func (w StatusCaptureWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Hijack lets the caller take over the connection, if the underlying
+// ResponseWriter supports it. It's required for websocket upgrades.
+func (w StatusCaptureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+
+	*w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
 // RESTServer represents a Gort REST API service.
 type RESTServer struct {
 	*http.Server
@@ -135,11 +165,18 @@ func BuildRESTServer(ctx context.Context, addr string) *RESTServer {
 
 func addAllMethodsToRouter(router *mux.Router) {
 	addHealthzMethodToRouter(router)
+	addAdapterMethodsToRouter(router)
+	addAliasMethodsToRouter(router)
 	addBundleMethodsToRouter(router)
+	addCommandMethodsToRouter(router)
 	addConfigMethodsToRouter(router)
 	addGroupMethodsToRouter(router)
+	addRelayMethodsToRouter(router)
+	addRequestMethodsToRouter(router)
 	addRoleMethodsToRouter(router)
+	addScheduleMethodsToRouter(router)
 	addUserMethodsToRouter(router)
+	addWebhookMethodsToRouter(router)
 	addManagementMethodsToRouter(router)
 }
 
@@ -308,11 +345,17 @@ func DoBootstrap(ctx context.Context, user rest.User) (rest.User, error) {
 	const adminGroup = "admin"
 	const adminRole = "admin"
 	var adminPermissions = []string{
+		"cancel_any",
+		"manage_aliases",
 		"manage_commands",
 		"manage_configs",
 		"manage_groups",
+		"manage_relays",
 		"manage_roles",
+		"manage_schedules",
 		"manage_users",
+		"redirect_any",
+		"view_audit",
 	}
 
 	dataAccessLayer, err := dataaccess.Get()
@@ -467,6 +510,10 @@ func respondAndLogError(ctx context.Context, w http.ResponseWriter, err error) {
 
 	switch {
 	// A required field is empty or missing
+	case gerrs.Is(err, errs.ErrEmptyAliasName):
+		fallthrough
+	case gerrs.Is(err, ErrInvalidAliasName):
+		fallthrough
 	case gerrs.Is(err, errs.ErrEmptyBundleName):
 		fallthrough
 	case gerrs.Is(err, errs.ErrEmptyBundleVersion):
@@ -481,10 +528,34 @@ func respondAndLogError(ctx context.Context, w http.ResponseWriter, err error) {
 		fallthrough
 	case gerrs.Is(err, errs.ErrEmptyGroupName):
 		fallthrough
+	case gerrs.Is(err, errs.ErrEmptyRelayGroupName):
+		fallthrough
+	case gerrs.Is(err, errs.ErrEmptyRelayName):
+		fallthrough
 	case gerrs.Is(err, errs.ErrEmptyUserName):
 		fallthrough
+	case gerrs.Is(err, errs.ErrEmptyWebhookName):
+		fallthrough
+	case gerrs.Is(err, ErrInvalidWebhookParameter):
+		fallthrough
+	case gerrs.Is(err, ErrInvalidCommand):
+		fallthrough
+	case gerrs.Is(err, data.ErrInvalidInvocation):
+		fallthrough
+	case gerrs.Is(err, data.ErrInvalidSchema):
+		fallthrough
+	case gerrs.Is(err, data.ErrInvalidTrigger):
+		fallthrough
+	case gerrs.Is(err, data.ErrInvalidLimits):
+		fallthrough
+	case gerrs.Is(err, ErrMultipleCommands):
+		fallthrough
 	case gerrs.Is(err, ErrMissingValue):
 		fallthrough
+	case gerrs.Is(err, ErrInvalidFilter):
+		fallthrough
+	case gerrs.Is(err, scheduler.ErrInvalidSchedule):
+		fallthrough
 	case gerrs.Is(err, errs.ErrFieldRequired):
 		fallthrough
 	case strings.HasPrefix(err.Error(), "dynamic configuration layers must be one of:"):
@@ -492,17 +563,37 @@ func respondAndLogError(ctx context.Context, w http.ResponseWriter, err error) {
 		log.WithError(err).WithField("status", status).Info(msg)
 
 	// Requested resource doesn't exist
+	case gerrs.Is(err, ErrNoSuchAdapter):
+		fallthrough
+	case gerrs.Is(err, errs.ErrNoSuchAlias):
+		fallthrough
 	case gerrs.Is(err, errs.ErrNoSuchBundle):
 		fallthrough
 	case gerrs.Is(err, errs.ErrNoSuchConfig):
 		fallthrough
 	case gerrs.Is(err, errs.ErrNoSuchGroup):
 		fallthrough
+	case gerrs.Is(err, errs.ErrNoSuchRelay):
+		fallthrough
+	case gerrs.Is(err, errs.ErrNoSuchRelayGroup):
+		fallthrough
 	case gerrs.Is(err, errs.ErrNoSuchRole):
 		fallthrough
 	case gerrs.Is(err, errs.ErrNoSuchToken):
 		fallthrough
 	case gerrs.Is(err, errs.ErrNoSuchUser):
+		fallthrough
+	case gerrs.Is(err, errs.ErrNoSuchRequest):
+		fallthrough
+	case gerrs.Is(err, errs.ErrNoSuchOutput):
+		fallthrough
+	case gerrs.Is(err, errs.ErrNoSuchSchedule):
+		fallthrough
+	case gerrs.Is(err, errs.ErrNoSuchWebhook):
+		fallthrough
+	case gerrs.Is(err, ErrNoSuchCommand):
+		fallthrough
+	case gerrs.Is(err, relay.ErrNoSuchRequest):
 		status = http.StatusNotFound
 		log.WithError(err).WithField("status", status).Info(msg)
 
@@ -514,13 +605,21 @@ func respondAndLogError(ctx context.Context, w http.ResponseWriter, err error) {
 		log.WithError(err).WithField("status", status).Warn(msg)
 
 	// Can't insert over something that already exists
+	case gerrs.Is(err, errs.ErrAliasExists):
+		fallthrough
+	case gerrs.Is(err, ErrAliasCollision):
+		fallthrough
 	case gerrs.Is(err, errs.ErrBundleExists):
 		fallthrough
 	case gerrs.Is(err, errs.ErrConfigExists):
 		fallthrough
 	case gerrs.Is(err, errs.ErrGroupExists):
 		fallthrough
+	case gerrs.Is(err, errs.ErrRelayGroupExists):
+		fallthrough
 	case gerrs.Is(err, errs.ErrUserExists):
+		fallthrough
+	case gerrs.Is(err, errs.ErrWebhookExists):
 		status = http.StatusConflict
 		log.WithError(err).WithField("status", status).Info(msg)
 
@@ -529,6 +628,11 @@ func respondAndLogError(ctx context.Context, w http.ResponseWriter, err error) {
 		status = http.StatusNotImplemented
 		log.WithError(err).WithField("status", status).Info(msg)
 
+	// Try again later
+	case gerrs.Is(err, ErrCommandQueueFull):
+		status = http.StatusServiceUnavailable
+		log.WithError(err).WithField("status", status).Warn(msg)
+
 	// Data access errors
 	case gerrs.Is(err, errs.ErrDataAccessNotInitialized):
 		fallthrough
@@ -582,7 +686,10 @@ func tokenObservingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestURI := strings.Split(r.RequestURI, "?")[0]
 
-		if exemptEndpoints[requestURI] {
+		// Webhooks and adapters authenticate their own callers.
+		if exemptEndpoints[requestURI] ||
+			strings.HasPrefix(requestURI, "/v2/hooks/") ||
+			strings.HasPrefix(requestURI, "/v2/adapters/") {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -624,6 +731,63 @@ func authCommand(handler func(w http.ResponseWriter, r *http.Request), cmd strin
 	return http.HandlerFunc(inner)
 }
 
+// authPermission wraps a handler so that it's only called if the requesting
+// user has been granted the named permission, such as "gort:manage_relays".
+// It's used for endpoints that have no equivalent chat command whose rules
+// could be evaluated by authCommand.
+func authPermission(handler func(w http.ResponseWriter, r *http.Request), permission string) http.HandlerFunc {
+	inner := func(w http.ResponseWriter, r *http.Request) {
+		user, err := getUserByRequest(r)
+		if err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+
+		permitted, err := auth.UserHasPermission(r.Context(), user.Username, permission)
+		if err != nil {
+			respondAndLogError(r.Context(), w, err)
+			return
+		}
+
+		if !permitted {
+			respondAndLogError(r.Context(), w, ErrUnauthorized)
+			return
+		}
+
+		handler(w, r)
+	}
+
+	return http.HandlerFunc(inner)
+}
+
+// runAsAnyPermission allows a user to create schedules and webhooks that run
+// as other users.
+const runAsAnyPermission = "gort:manage_users"
+
+// authorizeRunAs returns ErrUnauthorized unless the requesting user is the
+// named user, or has been granted permission to act on behalf of any user.
+func authorizeRunAs(r *http.Request, username string) error {
+	user, err := getUserByRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if user.Username == username {
+		return nil
+	}
+
+	permitted, err := auth.UserHasPermission(r.Context(), user.Username, runAsAnyPermission)
+	if err != nil {
+		return err
+	}
+
+	if !permitted {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
 // authenticateUser is used to authenticate service actions by evaluating them
 // against the default Gort command bundle. For example, `authenticateUser(r, "users")`
 // is evaluated exactly as if the requesting user executed "gort users" on the
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,6 +41,7 @@ var adminToken rest.Token
 // result to be verified.
 type ResponseTester struct {
 	body           interface{}
+	headers        map[string]string
 	out            interface{}
 	method         string
 	target         string
@@ -61,6 +63,16 @@ func (r ResponseTester) WithBody(body interface{}) ResponseTester {
 	return r
 }
 
+// WithHeader adds a header to a request to be tested.
+func (r ResponseTester) WithHeader(key, value string) ResponseTester {
+	headers := map[string]string{key: value}
+	for k, v := range r.headers {
+		headers[k] = v
+	}
+	r.headers = headers
+	return r
+}
+
 // WithStatus adds an expected HTTP status to a ResponseTester.
 // If the response does not have the specified code, the test fails.
 func (r ResponseTester) WithStatus(status int) ResponseTester {
@@ -92,6 +104,9 @@ func (r ResponseTester) Test(t *testing.T, router *mux.Router, msgAndArgs ...int
 
 	req := httptest.NewRequest(r.method, r.target, bodyReader)
 	req.Header.Add("X-Session-Token", adminToken.Token)
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -136,3 +151,32 @@ func createTestRouter() *mux.Router {
 
 	return router
 }
+
+// createTestUser creates a user that has been granted the given permissions,
+// such as "gort:manage_schedules", and returns a session token for them.
+func createTestUser(t *testing.T, username string, permissions ...string) rest.Token {
+	t.Helper()
+
+	ctx := context.Background()
+	dataAccessLayer, err := dataaccess.Get()
+	require.NoError(t, err)
+
+	require.NoError(t, dataAccessLayer.UserCreate(ctx, rest.User{Username: username, Email: username + "@getgort.io"}))
+
+	if len(permissions) > 0 {
+		require.NoError(t, dataAccessLayer.RoleCreate(ctx, username))
+		require.NoError(t, dataAccessLayer.GroupCreate(ctx, rest.Group{Name: username}))
+		require.NoError(t, dataAccessLayer.GroupUserAdd(ctx, username, username))
+		require.NoError(t, dataAccessLayer.GroupRoleAdd(ctx, username, username))
+
+		for _, p := range permissions {
+			parts := strings.SplitN(p, ":", 2)
+			require.NoError(t, dataAccessLayer.RolePermissionAdd(ctx, username, parts[0], parts[1]))
+		}
+	}
+
+	token, err := dataAccessLayer.TokenGenerate(ctx, username, time.Minute)
+	require.NoError(t, err)
+
+	return token
+}
@@ -0,0 +1,406 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/dataaccess"
+	"github.com/getgort/gort/dataaccess/errs"
+	gerrs "github.com/getgort/gort/errors"
+)
+
+// maxWebhookBodySize is the largest request body a webhook will accept.
+const maxWebhookBodySize = 1 << 20
+
+// handleDeleteWebhook handles "DELETE /v2/webhooks/{bundle}/{hook}"
+func handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.WebhookDelete(r.Context(), params["bundle"], params["hook"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// handleGetWebhook handles "GET /v2/webhooks/{bundle}/{hook}"
+func handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	webhook, err := getWebhook(r.Context(), params["bundle"], params["hook"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(redactWebhook(webhook))
+}
+
+// handleGetWebhooks handles "GET /v2/webhooks"
+func handleGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	webhooks, err := dataAccessLayer.WebhookList(r.Context())
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	bundles, err := dataAccessLayer.BundleList(r.Context())
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	for _, b := range bundles {
+		if !b.Enabled {
+			continue
+		}
+
+		for name, webhook := range b.Webhooks {
+			wh := *webhook
+			wh.Bundle, wh.Name = b.Name, name
+			webhooks = append(webhooks, wh)
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		if webhooks[i].Bundle != webhooks[j].Bundle {
+			return webhooks[i].Bundle < webhooks[j].Bundle
+		}
+		return webhooks[i].Name < webhooks[j].Name
+	})
+
+	for i := range webhooks {
+		webhooks[i] = redactWebhook(webhooks[i])
+	}
+
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// handlePostHook handles "POST /v2/hooks/{bundle}/{hook}"
+func handlePostHook(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.Wrap(ErrInvalidWebhookParameter, err))
+		return
+	}
+
+	webhook, err := getWebhook(r.Context(), params["bundle"], params["hook"])
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	if err := authenticateWebhook(r, body, webhook); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	cmdParams, err := webhookParameters(webhook, body)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	result, err := executeCommand(r.Context(), webhook.Bundle, webhook.Command, cmdParams, webhook.User, webhook.Adapter, webhook.ChannelID)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	if webhook.ChannelID != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// handlePutWebhook handles "PUT /v2/webhooks/{bundle}/{hook}"
+func handlePutWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook data.Webhook
+
+	err := json.NewDecoder(r.Body).Decode(&webhook)
+	if err != nil {
+		respondAndLogError(r.Context(), w, gerrs.ErrUnmarshal)
+		return
+	}
+
+	params := mux.Vars(r)
+	webhook.Bundle = params["bundle"]
+	webhook.Name = params["hook"]
+
+	if err := validateWebhook(r.Context(), webhook); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	if err := authorizeRunAs(r, webhook.User); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	// A webhook declared by the enabled bundle would shadow this one.
+	if _, found, err := getBundleWebhook(r.Context(), webhook.Bundle, webhook.Name); err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	} else if found {
+		respondAndLogError(r.Context(), w, errs.ErrWebhookExists)
+		return
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+
+	err = dataAccessLayer.WebhookCreate(r.Context(), webhook)
+	if err != nil {
+		respondAndLogError(r.Context(), w, err)
+		return
+	}
+}
+
+// authenticateWebhook checks a webhook request's signature and token against
+// those configured for the webhook, returning an ErrUnauthorized error if
+// either is missing or wrong. A webhook with neither a secret nor a token
+// can't be called at all.
+func authenticateWebhook(r *http.Request, body []byte, webhook data.Webhook) error {
+	if webhook.Secret == "" && webhook.Token == "" {
+		return gerrs.Wrap(ErrUnauthorized, fmt.Errorf("webhook %s:%s has no secret or token", webhook.Bundle, webhook.Name))
+	}
+
+	if webhook.Secret != "" {
+		signature := r.Header.Get(data.WebhookSignatureHeader)
+		if signature == "" {
+			// GitHub's header, so that its webhooks can be used as-is.
+			signature = r.Header.Get("X-Hub-Signature-256")
+		}
+
+		got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+		if err != nil || signature == "" {
+			return gerrs.Wrap(ErrUnauthorized, fmt.Errorf("missing or malformed webhook signature"))
+		}
+
+		mac := hmac.New(sha256.New, []byte(webhook.Secret))
+		mac.Write(body)
+		if !hmac.Equal(got, mac.Sum(nil)) {
+			return gerrs.Wrap(ErrUnauthorized, fmt.Errorf("invalid webhook signature"))
+		}
+	}
+
+	if webhook.Token != "" {
+		// Only accepted as a header: query strings end up in access logs.
+		token := r.Header.Get(data.WebhookTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(webhook.Token)) != 1 {
+			return gerrs.Wrap(ErrUnauthorized, fmt.Errorf("invalid webhook token"))
+		}
+	}
+
+	return nil
+}
+
+// getBundleWebhook returns a webhook declared by the enabled version of a
+// bundle. The bool is false if the bundle isn't installed or enabled, or if
+// it doesn't declare the webhook.
+func getBundleWebhook(ctx context.Context, bundlename, name string) (data.Webhook, bool, error) {
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return data.Webhook{}, false, err
+	}
+
+	version, err := dataAccessLayer.BundleEnabledVersion(ctx, bundlename)
+	switch {
+	case gerrs.Is(err, errs.ErrNoSuchBundle):
+		return data.Webhook{}, false, nil
+	case err != nil:
+		return data.Webhook{}, false, err
+	case version == "":
+		return data.Webhook{}, false, nil
+	}
+
+	bundle, err := dataAccessLayer.BundleGet(ctx, bundlename, version)
+	if err != nil {
+		return data.Webhook{}, false, err
+	}
+
+	webhook, exists := bundle.Webhooks[name]
+	if !exists {
+		return data.Webhook{}, false, nil
+	}
+
+	wh := *webhook
+	wh.Bundle, wh.Name = bundlename, name
+
+	return wh, true, nil
+}
+
+// getWebhook returns the named webhook, looking first at the enabled version
+// of the bundle and then at the webhooks created through the API.
+func getWebhook(ctx context.Context, bundlename, name string) (data.Webhook, error) {
+	webhook, found, err := getBundleWebhook(ctx, bundlename, name)
+	if err != nil {
+		return data.Webhook{}, err
+	}
+	if found {
+		return webhook, nil
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return data.Webhook{}, err
+	}
+
+	return dataAccessLayer.WebhookGet(ctx, bundlename, name)
+}
+
+// redactWebhook blanks a webhook's credentials so that it can be returned by
+// the API.
+func redactWebhook(webhook data.Webhook) data.Webhook {
+	if webhook.Secret != "" {
+		webhook.Secret = "********"
+	}
+	if webhook.Token != "" {
+		webhook.Token = "********"
+	}
+
+	return webhook
+}
+
+// redactBundle blanks the credentials of the webhooks declared by a bundle so
+// that it can be returned by the API. The bundle's own webhooks are left
+// untouched.
+func redactBundle(bundle data.Bundle) data.Bundle {
+	if len(bundle.Webhooks) == 0 {
+		return bundle
+	}
+
+	webhooks := make(map[string]*data.Webhook, len(bundle.Webhooks))
+	for name, webhook := range bundle.Webhooks {
+		wh := redactWebhook(*webhook)
+		webhooks[name] = &wh
+	}
+	bundle.Webhooks = webhooks
+
+	return bundle
+}
+
+// validateWebhook checks that a webhook created through the API can be
+// called and executed.
+func validateWebhook(ctx context.Context, webhook data.Webhook) error {
+	switch {
+	case webhook.Command == "":
+		return gerrs.Wrap(errs.ErrFieldRequired, fmt.Errorf("command is required"))
+	case webhook.User == "":
+		return gerrs.Wrap(errs.ErrFieldRequired, fmt.Errorf("user is required"))
+	case webhook.Secret == "" && webhook.Token == "":
+		return gerrs.Wrap(errs.ErrFieldRequired, fmt.Errorf("secret or token is required"))
+	case webhook.ChannelID != "" && webhook.Adapter == "":
+		return gerrs.Wrap(errs.ErrFieldRequired, fmt.Errorf("adapter is required if channel_id is set"))
+	}
+
+	for _, p := range webhook.Parameters {
+		if _, err := template.New("parameter").Parse(p); err != nil {
+			return gerrs.Wrap(ErrInvalidWebhookParameter, err)
+		}
+	}
+
+	dataAccessLayer, err := dataaccess.Get()
+	if err != nil {
+		return err
+	}
+
+	_, err = dataAccessLayer.UserGet(ctx, webhook.User)
+	return err
+}
+
+// webhookParameters renders a webhook's parameter templates against a
+// request body. JSON object bodies have their fields available by name;
+// the raw body is always available as "Body".
+func webhookParameters(webhook data.Webhook, body []byte) ([]string, error) {
+	if len(webhook.Parameters) == 0 {
+		if len(body) == 0 {
+			return nil, nil
+		}
+		return []string{string(body)}, nil
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		values = map[string]interface{}{}
+	}
+	values["Body"] = string(body)
+
+	params := make([]string, 0, len(webhook.Parameters))
+
+	for _, p := range webhook.Parameters {
+		tmpl, err := template.New("parameter").Option("missingkey=error").Parse(p)
+		if err != nil {
+			return nil, gerrs.Wrap(ErrInvalidWebhookParameter, err)
+		}
+
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, values); err != nil {
+			return nil, gerrs.Wrap(ErrInvalidWebhookParameter, err)
+		}
+
+		params = append(params, b.String())
+	}
+
+	return params, nil
+}
+
+func addWebhookMethodsToRouter(router *mux.Router) {
+	// Webhooks authenticate their callers themselves.
+	router.Handle("/v2/hooks/{bundle}/{hook}", otelhttp.NewHandler(http.HandlerFunc(handlePostHook), "handlePostHook")).Methods("POST")
+
+	router.Handle("/v2/webhooks", otelhttp.NewHandler(authCommand(handleGetWebhooks, "bundle", "webhook", "list"), "handleGetWebhooks")).Methods("GET")
+	router.Handle("/v2/webhooks/{bundle}/{hook}", otelhttp.NewHandler(authCommand(handleGetWebhook, "bundle", "webhook", "info"), "handleGetWebhook")).Methods("GET")
+	router.Handle("/v2/webhooks/{bundle}/{hook}", otelhttp.NewHandler(authCommand(handlePutWebhook, "bundle", "webhook", "create"), "handlePutWebhook")).Methods("PUT")
+	router.Handle("/v2/webhooks/{bundle}/{hook}", otelhttp.NewHandler(authCommand(handleDeleteWebhook, "bundle", "webhook", "delete"), "handleDeleteWebhook")).Methods("DELETE")
+}
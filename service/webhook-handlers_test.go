@@ -0,0 +1,230 @@
+/*
+ * Copyright 2021 The Gort Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getgort/gort/data"
+	"github.com/getgort/gort/data/rest"
+	"github.com/getgort/gort/dataaccess"
+)
+
+func sign(t *testing.T, secret string, body interface{}) string {
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(b)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhooks(t *testing.T) {
+	router := createTestRouter()
+
+	const base = "http://example.com/v2/webhooks/gort/"
+
+	signed := data.Webhook{Command: "whoami", User: "admin", Secret: "s3cr3t"}
+	tokened := data.Webhook{Command: "whoami", User: "admin", Token: "t0k3n"}
+	async := data.Webhook{Command: "whoami", User: "admin", Token: "t0k3n", Adapter: "testAdapter", ChannelID: "mychannel"}
+
+	NewResponseTester("PUT", base+"signed").WithBody(signed).WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("PUT", base+"tokened").WithBody(tokened).WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("PUT", base+"async").WithBody(async).WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("PUT", base+"async").WithBody(async).WithStatus(http.StatusConflict).Test(t, router)
+
+	var webhooks []data.Webhook
+	NewResponseTester("GET", "http://example.com/v2/webhooks").WithStatus(http.StatusOK).WithOutput(&webhooks).Test(t, router)
+	require.Len(t, webhooks, 3)
+	assert.Equal(t, "async", webhooks[0].Name)
+	assert.Equal(t, "gort", webhooks[0].Bundle)
+	assert.NotEqual(t, "t0k3n", webhooks[0].Token)
+	assert.NotEqual(t, "s3cr3t", webhooks[1].Secret)
+
+	const hooks = "http://example.com/v2/hooks/gort/"
+	body := map[string]string{"event": "deploy"}
+
+	// Signed with the webhook's secret
+	var result rest.CommandResult
+	NewResponseTester("POST", hooks+"signed").WithBody(body).
+		WithHeader(data.WebhookSignatureHeader, sign(t, "s3cr3t", body)).
+		WithStatus(http.StatusOK).WithOutput(&result).Test(t, router)
+	assert.NotZero(t, result.RequestID)
+	assert.NotNil(t, result.ExitCode)
+
+	NewResponseTester("POST", hooks+"signed").WithBody(body).
+		WithStatus(http.StatusUnauthorized).Test(t, router)
+	NewResponseTester("POST", hooks+"signed").WithBody(body).
+		WithHeader(data.WebhookSignatureHeader, sign(t, "wrong", body)).
+		WithStatus(http.StatusUnauthorized).Test(t, router)
+
+	// With the webhook's token
+	NewResponseTester("POST", hooks+"tokened").WithBody(body).
+		WithHeader(data.WebhookTokenHeader, "t0k3n").
+		WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("POST", hooks+"tokened").WithBody(body).
+		WithHeader(data.WebhookTokenHeader, "wrong").
+		WithStatus(http.StatusUnauthorized).Test(t, router)
+
+	// Tokens aren't accepted in the query string.
+	NewResponseTester("POST", hooks+"tokened?token=t0k3n").WithBody(body).
+		WithStatus(http.StatusUnauthorized).Test(t, router)
+
+	// Output sent to a channel
+	result = rest.CommandResult{}
+	NewResponseTester("POST", hooks+"async").WithBody(body).
+		WithHeader(data.WebhookTokenHeader, "t0k3n").
+		WithStatus(http.StatusAccepted).WithOutput(&result).Test(t, router)
+	require.NotZero(t, result.RequestID)
+	assert.Nil(t, result.ExitCode)
+
+	request := <-CommandRequests()
+	assert.Equal(t, result.RequestID, request.RequestID)
+	assert.Equal(t, "mychannel", request.ChannelID)
+	assert.Equal(t, "whoami", request.Command.Name)
+
+	NewResponseTester("DELETE", base+"tokened").WithStatus(http.StatusOK).Test(t, router)
+	NewResponseTester("DELETE", base+"tokened").WithStatus(http.StatusNotFound).Test(t, router)
+	NewResponseTester("POST", hooks+"tokened").WithBody(body).
+		WithHeader(data.WebhookTokenHeader, "t0k3n").
+		WithStatus(http.StatusNotFound).Test(t, router)
+}
+
+func TestPutWebhookInvalid(t *testing.T) {
+	router := createTestRouter()
+
+	const url = "http://example.com/v2/webhooks/gort/hook"
+
+	tests := map[string]data.Webhook{
+		"no command":     {User: "admin", Token: "t"},
+		"no user":        {Command: "whoami", Token: "t"},
+		"no credentials": {Command: "whoami", User: "admin"},
+		"no adapter":     {Command: "whoami", User: "admin", Token: "t", ChannelID: "c"},
+		"bad parameter":  {Command: "whoami", User: "admin", Token: "t", Parameters: []string{"{{ .x"}},
+	}
+
+	for name, webhook := range tests {
+		NewResponseTester("PUT", url).WithBody(webhook).WithStatus(http.StatusExpectationFailed).Test(t, router, name)
+	}
+
+	NewResponseTester("PUT", url).WithBody(data.Webhook{Command: "whoami", User: "nobody", Token: "t"}).
+		WithStatus(http.StatusNotFound).Test(t, router)
+}
+
+func TestPutWebhookAsOtherUser(t *testing.T) {
+	router := createTestRouter()
+
+	token := createTestUser(t, "hooker", "gort:manage_commands")
+
+	const url = "http://example.com/v2/webhooks/gort/hook"
+	webhook := data.Webhook{Command: "whoami", User: "admin", Token: "t"}
+
+	// Only users allowed to manage users may create webhooks run as others.
+	NewResponseTester("PUT", url).WithBody(webhook).
+		WithHeader("X-Session-Token", token.Token).
+		WithStatus(http.StatusUnauthorized).Test(t, router)
+
+	webhook.User = "hooker"
+	NewResponseTester("PUT", url).WithBody(webhook).
+		WithHeader("X-Session-Token", token.Token).
+		WithStatus(http.StatusOK).Test(t, router)
+
+	// The same goes for webhooks declared by bundles.
+	bundle := data.Bundle{
+		GortBundleVersion: 1,
+		Description:       "A bundle with a webhook",
+		Webhooks: map[string]*data.Webhook{
+			"hook": {Command: "whoami", User: "admin", Token: "t"},
+		},
+	}
+
+	NewResponseTester("PUT", "http://example.com/v2/bundles/hooked/versions/0.0.1").WithBody(bundle).
+		WithHeader("X-Session-Token", token.Token).
+		WithStatus(http.StatusUnauthorized).Test(t, router)
+
+	bundle.Webhooks["hook"].User = "hooker"
+	NewResponseTester("PUT", "http://example.com/v2/bundles/hooked/versions/0.0.1").WithBody(bundle).
+		WithHeader("X-Session-Token", token.Token).
+		WithStatus(http.StatusOK).Test(t, router)
+}
+
+func TestGetBundleRedactsWebhooks(t *testing.T) {
+	router := createTestRouter()
+
+	bundle := data.Bundle{
+		GortBundleVersion: 1,
+		Description:       "A bundle with a webhook",
+		Webhooks: map[string]*data.Webhook{
+			"hook": {Command: "whoami", User: "admin", Secret: "s3cr3t", Token: "t0k3n"},
+		},
+	}
+
+	NewResponseTester("PUT", "http://example.com/v2/bundles/hooked/versions/0.0.1").WithBody(bundle).
+		WithStatus(http.StatusOK).Test(t, router)
+
+	var got data.Bundle
+	NewResponseTester("GET", "http://example.com/v2/bundles/hooked/versions/0.0.1").
+		WithStatus(http.StatusOK).WithOutput(&got).Test(t, router)
+	require.Contains(t, got.Webhooks, "hook")
+	assert.Equal(t, "whoami", got.Webhooks["hook"].Command)
+	assert.NotEqual(t, "s3cr3t", got.Webhooks["hook"].Secret)
+	assert.NotEqual(t, "t0k3n", got.Webhooks["hook"].Token)
+
+	for _, url := range []string{"http://example.com/v2/bundles", "http://example.com/v2/bundles/hooked/versions"} {
+		var bundles []data.Bundle
+		NewResponseTester("GET", url).WithStatus(http.StatusOK).WithOutput(&bundles).Test(t, router, url)
+
+		for _, b := range bundles {
+			for _, webhook := range b.Webhooks {
+				assert.NotEqual(t, "s3cr3t", webhook.Secret, url)
+				assert.NotEqual(t, "t0k3n", webhook.Token, url)
+			}
+		}
+	}
+
+	// The stored webhook keeps its credentials.
+	dataAccessLayer, err := dataaccess.Get()
+	require.NoError(t, err)
+	stored, err := dataAccessLayer.BundleGet(context.Background(), "hooked", "0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", stored.Webhooks["hook"].Secret)
+}
+
+func TestWebhookParameters(t *testing.T) {
+	webhook := data.Webhook{Parameters: []string{"deployed", "{{ .repository.name }}", "{{ .Body }}"}}
+	body := []byte(`{"repository":{"name":"gort"}}`)
+
+	params, err := webhookParameters(webhook, body)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deployed", "gort", string(body)}, params)
+
+	_, err = webhookParameters(data.Webhook{Parameters: []string{"{{ .missing }}"}}, body)
+	assert.Error(t, err)
+
+	params, err = webhookParameters(data.Webhook{}, []byte("raw text"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"raw text"}, params)
+}
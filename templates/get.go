@@ -44,6 +44,12 @@ const (
 	// Gort system (not commands).
 	DefaultMessageError = `{{ header | color "#FF0000" | title .Response.Title }}
 {{ text }}{{ .Response.Out }}{{ endtext }}`
+
+	// DefaultHelp is a template used to format the output of the native help
+	// command. The envelope's Payload is a []relay.HelpBundle describing the
+	// bundles and commands shown.
+	DefaultHelp = `{{ header | title .Response.Title }}
+{{ text | monospace true }}{{ .Response.Out }}{{ endtext }}`
 )
 
 var templateDefaults = data.Templates{
@@ -51,6 +57,7 @@ var templateDefaults = data.Templates{
 	MessageError: DefaultMessageError,
 	Command:      DefaultCommand,
 	CommandError: DefaultCommandError,
+	Help:         DefaultHelp,
 }
 
 // Get returns the first defined template found in the following sequence:
@@ -88,3 +88,9 @@ func TestLoadBundleFromFile(t *testing.T) {
 	assert.Equal(t, DefaultMessageError, template)
 	assert.NoError(t, err)
 }
+
+func TestGetHelpDefault(t *testing.T) {
+	template, err := Get(data.BundleCommand{}, data.Bundle{}, data.Help)
+	assert.Equal(t, DefaultHelp, template)
+	assert.NoError(t, err)
+}
@@ -33,6 +33,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Worker represents a container executor. It has a lifetime of a single command execution.
@@ -78,6 +79,32 @@ func New(command data.CommandRequest, token rest.Token) (*ContainerWorker, error
 	}, nil
 }
 
+// resources translates the command's memory and CPU limits into container
+// resource constraints.
+func (w *ContainerWorker) resources() (container.Resources, error) {
+	var r container.Resources
+
+	limits := w.command.EffectiveLimits()
+
+	if limits.Memory != "" {
+		q, err := resource.ParseQuantity(limits.Memory)
+		if err != nil {
+			return r, fmt.Errorf("invalid memory limit %q: %w", limits.Memory, err)
+		}
+		r.Memory = q.Value()
+	}
+
+	if limits.CPU != "" {
+		q, err := resource.ParseQuantity(limits.CPU)
+		if err != nil {
+			return r, fmt.Errorf("invalid cpu limit %q: %w", limits.CPU, err)
+		}
+		r.NanoCPUs = q.MilliValue() * 1e6
+	}
+
+	return r, nil
+}
+
 func (w *ContainerWorker) Initialize(dc []data.DynamicConfiguration) {
 	for _, c := range dc {
 		w.configs[c.Key] = c.Value
@@ -134,10 +161,16 @@ func (w *ContainerWorker) Start(ctx context.Context) (<-chan string, error) {
 		ctx, sp := tr.Start(ctx, "worker.docker.ContainerCreate")
 		defer sp.End()
 
+		resources, err := w.resources()
+		if err != nil {
+			return container.ContainerCreateCreatedBody{}, err
+		}
+
+		hc := &container.HostConfig{Resources: resources}
+
 		// If a host network is defined, set it here.
-		var hc *container.HostConfig
 		if network := config.GetDockerConfigs().Network; network != "" {
-			hc = &container.HostConfig{NetworkMode: container.NetworkMode(network)}
+			hc.NetworkMode = container.NetworkMode(network)
 		}
 
 		return cli.ContainerCreate(ctx, &cfg, hc, nil, nil, "")
@@ -250,6 +283,16 @@ func (w *ContainerWorker) envVars() []string {
 		`GORT_USER`:          w.command.UserName,
 	}
 
+	// The output of the previous stage of a pipeline, if any.
+	if w.command.Input != "" {
+		vars[`GORT_PIPELINE_INPUT`] = w.command.Input
+	}
+
+	// Named groups captured by the trigger that invoked the command, if any.
+	for k, v := range w.command.Captures.Environment() {
+		vars[k] = v
+	}
+
 	for k, v := range vars {
 		env = append(env, fmt.Sprintf("%s=%s", k, v))
 	}
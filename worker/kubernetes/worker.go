@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"time"
 
@@ -33,6 +34,7 @@ import (
 	"go.opentelemetry.io/otel"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	k8srest "k8s.io/client-go/rest"
@@ -208,6 +210,11 @@ func (w *KubernetesWorker) buildJobData(ctx context.Context) (*batchv1.Job, erro
 		return nil, err
 	}
 
+	resources, err := w.resources()
+	if err != nil {
+		return nil, err
+	}
+
 	secretEnv := []corev1.EnvFromSource{}
 
 	if w.command.Bundle.Kubernetes.EnvSecret != "" {
@@ -238,12 +245,13 @@ func (w *KubernetesWorker) buildJobData(ctx context.Context) (*batchv1.Job, erro
 					ServiceAccountName: w.command.Bundle.Kubernetes.ServiceAccountName,
 					Containers: []corev1.Container{
 						{
-							Name:    "command",
-							Image:   w.imageName,
-							Command: w.entryPoint,
-							Args:    w.commandParameters,
-							Env:     envVars,
-							EnvFrom: secretEnv,
+							Name:      "command",
+							Image:     w.imageName,
+							Command:   w.entryPoint,
+							Args:      w.commandParameters,
+							Env:       envVars,
+							EnvFrom:   secretEnv,
+							Resources: resources,
 						},
 					},
 					RestartPolicy: corev1.RestartPolicyNever,
@@ -256,9 +264,51 @@ func (w *KubernetesWorker) buildJobData(ctx context.Context) (*batchv1.Job, erro
 		job.Spec.Template.Spec.Containers[0].Command = w.entryPoint
 	}
 
+	// Let Kubernetes terminate the job if it outlives the command timeout,
+	// even if the relay that started it isn't around to do so.
+	timeout := w.command.EffectiveLimits().Timeout
+	if timeout <= 0 {
+		timeout = config.GetGlobalConfigs().CommandTimeout
+	}
+	if timeout > 0 {
+		deadline := int64(math.Ceil(timeout.Seconds()))
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+
 	return job, nil
 }
 
+// resources builds the container resource limits for the command from its
+// memory and CPU limits.
+func (w *KubernetesWorker) resources() (corev1.ResourceRequirements, error) {
+	var r corev1.ResourceRequirements
+
+	limits := w.command.EffectiveLimits()
+	if limits.Memory == "" && limits.CPU == "" {
+		return r, nil
+	}
+
+	r.Limits = corev1.ResourceList{}
+
+	if limits.Memory != "" {
+		q, err := resource.ParseQuantity(limits.Memory)
+		if err != nil {
+			return r, fmt.Errorf("invalid memory limit %q: %w", limits.Memory, err)
+		}
+		r.Limits[corev1.ResourceMemory] = q
+	}
+
+	if limits.CPU != "" {
+		q, err := resource.ParseQuantity(limits.CPU)
+		if err != nil {
+			return r, fmt.Errorf("invalid cpu limit %q: %w", limits.CPU, err)
+		}
+		r.Limits[corev1.ResourceCPU] = q
+	}
+
+	return r, nil
+}
+
 // envVars builds the default environment variables that get injected into
 // the command pod.
 func (w *KubernetesWorker) envVars(ctx context.Context) ([]corev1.EnvVar, error) {
@@ -285,6 +335,16 @@ func (w *KubernetesWorker) envVars(ctx context.Context) ([]corev1.EnvVar, error)
 		`GORT_USER`:          w.command.UserName,
 	}
 
+	// Every pipeline stage after the first gets the previous stage's output.
+	if w.command.Input != "" {
+		vars[`GORT_PIPELINE_INPUT`] = w.command.Input
+	}
+
+	// Named groups captured by the trigger that invoked the command, if any.
+	for k, v := range w.command.Captures.Environment() {
+		vars[k] = v
+	}
+
 	for k, v := range vars {
 		env = append(env, corev1.EnvVar{Name: k, Value: v})
 	}
@@ -36,6 +36,13 @@ type Worker interface {
 	Stopped() <-chan int64
 }
 
+// Defined returns true if a local worker backend (docker or kubernetes) is
+// configured.
+func Defined() bool {
+	return !config.Undefined(config.GetDockerConfigs()) ||
+		!config.Undefined(config.GetKubernetesConfigs())
+}
+
 // New will build and return a new Worker for a single command execution.
 func New(command data.CommandRequest, token rest.Token) (Worker, error) {
 	dockerDefined := !config.Undefined(config.GetDockerConfigs())